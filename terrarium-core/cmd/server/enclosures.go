@@ -0,0 +1,564 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"terrarium-core/internal/api"
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/energy"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/storage"
+)
+
+// enclosureConfig описывает аппаратуру одного террариума (элемент JSON-массива ENCLOSURES).
+type enclosureConfig struct {
+	ID      string                 `json:"id"`
+	Name    string                 `json:"name"`
+	WarmPin int                    `json:"warm_pin"`
+	ColdPin int                    `json:"cold_pin"`
+	Relays  map[string]relayConfig `json:"relays"`  // heat_mat, fogger, light обязательны; spare — по желанию
+	Sensors []sensorConfig         `json:"sensors"` // дополнительные датчики (к датчикам зон warm и cold)
+
+	Reservoir *reservoirConfig `json:"reservoir"` // поплавковый выключатель резервуара фоггера (по желанию)
+	Inputs    []inputConfig    `json:"inputs"`    // концевики крышки и кнопки аварийного стопа (по желанию)
+}
+
+// inputWiring описывает подключение цифрового входа (контакт между пином и GND или 3.3 В).
+type inputWiring struct {
+	Driver     string `json:"driver"`      // cdev (по умолчанию: символьное устройство GPIO с прерываниями), gpio (опрос через /dev/gpiomem), mock
+	Chip       string `json:"chip"`        // контроллер для cdev (по умолчанию /dev/gpiochip0)
+	Pin        int    `json:"pin"`         // BCM-пин (для cdev — номер линии контроллера)
+	Pull       string `json:"pull"`        // подтяжка: up (по умолчанию), down, none
+	DebounceMs int    `json:"debounce_ms"` // подавление дребезга, мс (по умолчанию зависит от назначения входа)
+}
+
+// reservoirConfig описывает поплавковый выключатель резервуара фоггера.
+type reservoirConfig struct {
+	inputWiring
+	EmptyWhen string `json:"empty_when"` // open (по умолчанию: поплавок опустился и разомкнул контакт) или closed
+}
+
+// inputConfig описывает цифровой вход террариума: концевик крышки или кнопку аварийного стопа.
+type inputConfig struct {
+	inputWiring
+	ID         string `json:"id"`
+	Role       string `json:"role"`        // lid или panic
+	ActiveWhen string `json:"active_when"` // когда вход активен: open или closed (по умолчанию lid — open, panic — closed)
+}
+
+// Подавление дребезга по умолчанию: поплавок качается от волн, крышка — от хлопка, кнопка нажимается коротко
+var defaultDebounce = map[string]time.Duration{
+	"reservoir":           gpio.DefaultDebounce,
+	automation.InputLid:   500 * time.Millisecond,
+	automation.InputPanic: 50 * time.Millisecond,
+}
+
+// relayConfig описывает реле террариума: номер GPIO (число) или сетевую розетку (объект с type).
+type relayConfig struct {
+	Type string `json:"type"` // gpio (по умолчанию), shelly, shelly_rpc, tasmota, mqtt; диммеры: pwm, shelly_dimmer, tasmota_dimmer
+	Pin  int    `json:"pin"`  // BCM-пин для gpio
+
+	// Аппаратный ШИМ: контроллер pwmchipN (канал — в channel) и частота, Гц (по умолчанию 1000)
+	Chip      int `json:"chip"`
+	Frequency int `json:"frequency"`
+
+	// HTTP-розетки и диммеры: адрес устройства, номер канала (с 0) и учетные данные, если включена авторизация
+	URL      string `json:"url"`
+	Channel  int    `json:"channel"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// MQTT-розетки: брокер (по умолчанию MQTT_URL), топики команд и состояния, значения ON/OFF
+	Broker       string `json:"broker"`
+	CommandTopic string `json:"command_topic"`
+	StateTopic   string `json:"state_topic"`
+	PayloadOn    string `json:"payload_on"`
+	PayloadOff   string `json:"payload_off"`
+	QoS          byte   `json:"qos"`
+}
+
+// relayTypes — поддерживаемые типы реле
+var relayTypes = []string{"gpio", gpio.HTTPShelly, gpio.HTTPShellyRPC, gpio.HTTPTasmota, "mqtt", "pwm", gpio.HTTPShellyDimmer, gpio.HTTPTasmotaDimmer}
+
+// UnmarshalJSON принимает как число (пин GPIO, исходный формат), так и объект.
+func (rc *relayConfig) UnmarshalJSON(data []byte) error {
+	var pin int
+	if err := json.Unmarshal(data, &pin); err == nil {
+		*rc = relayConfig{Type: "gpio", Pin: pin}
+		return nil
+	}
+	type plain relayConfig
+	return json.Unmarshal(data, (*plain)(rc))
+}
+
+// String используется в стартовом логе (без паролей).
+func (rc relayConfig) String() string {
+	switch rc.Type {
+	case "gpio":
+		return fmt.Sprintf("GPIO %d", rc.Pin)
+	case "mqtt":
+		return "mqtt " + rc.CommandTopic
+	case "pwm":
+		return fmt.Sprintf("pwm %s", rc.device())
+	}
+	return fmt.Sprintf("%s %s#%d", rc.Type, rc.URL, rc.Channel)
+}
+
+// sensorConfig описывает датчик террариума. Датчики warm и cold создаются из warm_pin/cold_pin.
+type sensorConfig struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Zone   string `json:"zone"`   // по умолчанию совпадает с id
+	Driver string `json:"driver"` // dht22 (по умолчанию), ds18b20, bme280, bmp280, sht3x
+	Pin    int    `json:"pin"`    // GPIO для dht22
+	Device string `json:"device"` // ID 1-wire для ds18b20 (28-xxxxxxxxxxxx, см. GET /api/v1/hardware/w1)
+
+	// Для датчиков I2C: шина (по умолчанию /dev/i2c-1) и адрес ("0x76"; по умолчанию — основной адрес драйвера)
+	Bus     string `json:"bus"`
+	Address string `json:"address"`
+	addr    uint16
+}
+
+// sensorDrivers — поддерживаемые драйверы датчиков
+var sensorDrivers = []string{"dht22", "ds18b20", "bme280", "bmp280", "sht3x"}
+
+// i2cDefaultAddr — основной адрес датчиков I2C по драйверу
+var i2cDefaultAddr = map[string]uint16{
+	"bme280": gpio.BME280AddrPrimary,
+	"bmp280": gpio.BME280AddrPrimary,
+	"sht3x":  gpio.SHT3xAddrPrimary,
+}
+
+// defaultEnclosure — распиновка исходной установки с одним террариумом.
+// Может быть переопределена элементом ENCLOSURES с id "default".
+var defaultEnclosure = enclosureConfig{
+	ID:      storage.DefaultEnclosure,
+	Name:    "Террариум",
+	WarmPin: 5, // GPIO 5 (D5)
+	ColdPin: 6, // GPIO 6 (D6)
+	Relays: map[string]relayConfig{
+		"heat_mat": {Type: "gpio", Pin: 22}, // Оранжевый
+		"fogger":   {Type: "gpio", Pin: 27}, // Серый (из питона BCM 27)
+		"light":    {Type: "gpio", Pin: 17}, // Белый (из питона BCM 17)
+		"spare":    {Type: "gpio", Pin: 23}, // Фиолетовый (BCM 23)
+	},
+}
+
+// idPattern — допустимые ID террариумов, датчиков и зон
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// parseEnclosures разбирает ENCLOSURES вида
+// [{"id": "gecko", "name": "Геккон", "warm_pin": 12, "cold_pin": 13, "relays": {"heat_mat": 24, "fogger": 25, "light": 26}}]
+// (дополнительные датчики — в "sensors": [{"id": "basking", "zone": "basking", "driver": "dht22", "pin": 16}],
+// сетевые розетки — объектом вместо пина: "light": {"type": "shelly", "url": "http://192.168.0.50"},
+// поплавковый выключатель резервуара — "reservoir": {"pin": 16},
+// крышка и кнопка аварийного стопа — "inputs": [{"id": "lid", "role": "lid", "pin": 20}, {"id": "panic", "role": "panic", "pin": 21}])
+// и возвращает список террариумов, начиная с террариума по умолчанию. Пины не должны пересекаться.
+func parseEnclosures(raw string) ([]enclosureConfig, error) {
+	result := []enclosureConfig{defaultEnclosure}
+	if raw != "" {
+		var parsed []enclosureConfig
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			return nil, fmt.Errorf("ошибка разбора ENCLOSURES: %w", err)
+		}
+		for _, enc := range parsed {
+			if enc.ID == storage.DefaultEnclosure {
+				result[0] = enc
+				continue
+			}
+			result = append(result, enc)
+		}
+	}
+
+	seenIDs := make(map[string]bool)
+	usedPins := make(map[int]string)
+	usedDevices := make(map[string]string)
+	for i := range result {
+		enc := &result[i]
+		if !idPattern.MatchString(enc.ID) {
+			return nil, fmt.Errorf("ENCLOSURES: недопустимый id %q (строчные латинские буквы, цифры, - и _)", enc.ID)
+		}
+		if seenIDs[enc.ID] {
+			return nil, fmt.Errorf("ENCLOSURES: террариум %q описан дважды", enc.ID)
+		}
+		seenIDs[enc.ID] = true
+
+		for _, relay := range []string{"heat_mat", "fogger", "light"} {
+			if _, ok := enc.Relays[relay]; !ok {
+				return nil, fmt.Errorf("ENCLOSURES: у террариума %q не задано реле %s", enc.ID, relay)
+			}
+		}
+		if err := normalizeRelays(enc); err != nil {
+			return nil, err
+		}
+		if err := normalizeSensors(enc); err != nil {
+			return nil, err
+		}
+		if err := normalizeReservoir(enc); err != nil {
+			return nil, err
+		}
+		if err := normalizeInputs(enc); err != nil {
+			return nil, err
+		}
+
+		pins := map[string]int{"warm_pin": enc.WarmPin, "cold_pin": enc.ColdPin}
+		if rc := enc.Reservoir; rc != nil && rc.Driver != "mock" {
+			pins["reservoir"] = rc.Pin
+		}
+		for _, ic := range enc.Inputs {
+			if ic.Driver != "mock" {
+				pins["input:"+ic.ID] = ic.Pin
+			}
+		}
+		for relay, rc := range enc.Relays {
+			switch rc.Type {
+			case "gpio":
+				pins[relay] = rc.Pin
+			case "pwm":
+				if owner, ok := usedDevices[rc.device()]; ok {
+					return nil, fmt.Errorf("ENCLOSURES: канал ШИМ %s (%s/%s) уже используется (%s)", rc.device(), enc.ID, relay, owner)
+				}
+				usedDevices[rc.device()] = enc.ID + "/" + relay
+			}
+		}
+		for _, sc := range enc.Sensors {
+			if sc.Driver == "dht22" {
+				pins["sensor:"+sc.ID] = sc.Pin
+				continue
+			}
+			if owner, ok := usedDevices[sc.Device]; ok {
+				return nil, fmt.Errorf("ENCLOSURES: устройство %s (%s/%s) уже используется (%s)", sc.Device, enc.ID, sc.ID, owner)
+			}
+			usedDevices[sc.Device] = enc.ID + "/" + sc.ID
+		}
+		for what, pin := range pins {
+			if owner, ok := usedPins[pin]; ok {
+				return nil, fmt.Errorf("ENCLOSURES: GPIO %d (%s/%s) уже занят (%s)", pin, enc.ID, what, owner)
+			}
+			usedPins[pin] = enc.ID + "/" + what
+		}
+	}
+	return result, nil
+}
+
+// device возвращает канал ШИМ в виде pwmchipN/pwmM.
+func (rc relayConfig) device() string {
+	return fmt.Sprintf("pwmchip%d/pwm%d", rc.Chip, rc.Channel)
+}
+
+// normalizeRelays проверяет реле террариума и заполняет тип по умолчанию.
+func normalizeRelays(enc *enclosureConfig) error {
+	for name, rc := range enc.Relays {
+		if rc.Type == "" {
+			rc.Type = "gpio"
+		}
+		switch rc.Type {
+		case "gpio", "pwm":
+		case gpio.HTTPShelly, gpio.HTTPShellyRPC, gpio.HTTPTasmota, gpio.HTTPShellyDimmer, gpio.HTTPTasmotaDimmer:
+			if rc.URL == "" {
+				return fmt.Errorf("ENCLOSURES: у реле %s/%s (%s) не задан url устройства", enc.ID, name, rc.Type)
+			}
+		case "mqtt":
+			if rc.CommandTopic == "" || rc.StateTopic == "" {
+				return fmt.Errorf("ENCLOSURES: у реле %s/%s (mqtt) должны быть заданы command_topic и state_topic", enc.ID, name)
+			}
+		default:
+			return fmt.Errorf("ENCLOSURES: у реле %s/%s неизвестный тип %q (допустимо: %v)", enc.ID, name, rc.Type, relayTypes)
+		}
+		enc.Relays[name] = rc
+	}
+	return nil
+}
+
+// newRelay создает драйвер реле.
+func newRelay(name string, rc relayConfig, hw api.Hardware) (gpio.RelayController, error) {
+	switch rc.Type {
+	case "gpio":
+		return gpio.NewRealRelay(name, rc.Pin)
+	case gpio.HTTPShelly, gpio.HTTPShellyRPC, gpio.HTTPTasmota:
+		return gpio.NewHTTPRelay(name, rc.Type, rc.URL, rc.Channel, rc.Username, rc.Password)
+	case gpio.HTTPShellyDimmer, gpio.HTTPTasmotaDimmer:
+		return gpio.NewHTTPDimmer(name, rc.Type, rc.URL, rc.Channel, rc.Username, rc.Password)
+	case "pwm":
+		return gpio.NewPWMDimmer(name, hw.PWMRoot, rc.Chip, rc.Channel, rc.Frequency)
+	case "mqtt":
+		brokerURL := rc.Broker
+		if brokerURL == "" {
+			brokerURL = hw.MQTTURL
+		}
+		if brokerURL == "" {
+			return nil, fmt.Errorf("не задан брокер MQTT (поле broker или MQTT_URL)")
+		}
+		broker, err := gpio.OpenMQTT(brokerURL)
+		if err != nil {
+			return nil, err
+		}
+		return gpio.NewMQTTRelay(name, broker, gpio.MQTTRelayConfig{
+			CommandTopic: rc.CommandTopic,
+			StateTopic:   rc.StateTopic,
+			PayloadOn:    rc.PayloadOn,
+			PayloadOff:   rc.PayloadOff,
+			QoS:          rc.QoS,
+		})
+	}
+	return nil, fmt.Errorf("неизвестный тип реле %q", rc.Type)
+}
+
+// normalizeSensors проверяет дополнительные датчики террариума и заполняет значения по умолчанию.
+func normalizeSensors(enc *enclosureConfig) error {
+	seen := map[string]bool{"warm": true, "cold": true}
+	for i := range enc.Sensors {
+		sc := &enc.Sensors[i]
+		if !idPattern.MatchString(sc.ID) {
+			return fmt.Errorf("ENCLOSURES: у террариума %q недопустимый id датчика %q (строчные латинские буквы, цифры, - и _)", enc.ID, sc.ID)
+		}
+		if seen[sc.ID] {
+			return fmt.Errorf("ENCLOSURES: датчик %s/%s описан дважды (warm и cold задаются через warm_pin/cold_pin)", enc.ID, sc.ID)
+		}
+		seen[sc.ID] = true
+
+		if sc.Zone == "" {
+			sc.Zone = sc.ID
+		}
+		if !idPattern.MatchString(sc.Zone) {
+			return fmt.Errorf("ENCLOSURES: у датчика %s/%s недопустимая зона %q", enc.ID, sc.ID, sc.Zone)
+		}
+		if sc.Driver == "" {
+			sc.Driver = "dht22"
+		}
+		if !slices.Contains(sensorDrivers, sc.Driver) {
+			return fmt.Errorf("ENCLOSURES: у датчика %s/%s неизвестный драйвер %q (допустимо: %v)", enc.ID, sc.ID, sc.Driver, sensorDrivers)
+		}
+		if sc.Driver == "ds18b20" && sc.Device == "" {
+			return fmt.Errorf("ENCLOSURES: у датчика %s/%s (ds18b20) не задан device — ID 1-wire вида 28-xxxxxxxxxxxx", enc.ID, sc.ID)
+		}
+		if addr, ok := i2cDefaultAddr[sc.Driver]; ok {
+			if sc.Address != "" {
+				parsed, err := strconv.ParseUint(sc.Address, 0, 7)
+				if err != nil {
+					return fmt.Errorf("ENCLOSURES: у датчика %s/%s неверный адрес I2C %q (например \"0x76\")", enc.ID, sc.ID, sc.Address)
+				}
+				addr = uint16(parsed)
+			}
+			if sc.Bus == "" {
+				sc.Bus = gpio.DefaultI2CBus
+			}
+			sc.addr = addr
+			sc.Device = fmt.Sprintf("%s@0x%02x", sc.Bus, addr)
+		}
+		if sc.Name == "" {
+			sc.Name = sc.ID
+		}
+	}
+	return nil
+}
+
+// normalizeReservoir проверяет поплавковый выключатель террариума и заполняет значения по умолчанию.
+func normalizeReservoir(enc *enclosureConfig) error {
+	rc := enc.Reservoir
+	if rc == nil {
+		return nil
+	}
+	if err := rc.normalize(enc.ID + "/reservoir"); err != nil {
+		return err
+	}
+	if rc.EmptyWhen == "" {
+		rc.EmptyWhen = "open"
+	}
+	if rc.EmptyWhen != "open" && rc.EmptyWhen != "closed" {
+		return fmt.Errorf("ENCLOSURES: у резервуара %s неверное empty_when %q (допустимо: open, closed)", enc.ID, rc.EmptyWhen)
+	}
+	return nil
+}
+
+// normalizeInputs проверяет цифровые входы террариума и заполняет значения по умолчанию.
+func normalizeInputs(enc *enclosureConfig) error {
+	seen := make(map[string]bool)
+	for i := range enc.Inputs {
+		ic := &enc.Inputs[i]
+		if !idPattern.MatchString(ic.ID) {
+			return fmt.Errorf("ENCLOSURES: недопустимый id входа %q у террариума %s", ic.ID, enc.ID)
+		}
+		if seen[ic.ID] {
+			return fmt.Errorf("ENCLOSURES: вход %s/%s описан дважды", enc.ID, ic.ID)
+		}
+		seen[ic.ID] = true
+
+		switch ic.Role {
+		case automation.InputLid:
+			if ic.ActiveWhen == "" {
+				ic.ActiveWhen = "open" // геркон на закрытой крышке замкнут
+			}
+		case automation.InputPanic:
+			if ic.ActiveWhen == "" {
+				ic.ActiveWhen = "closed" // нормально разомкнутая кнопка
+			}
+		default:
+			return fmt.Errorf("ENCLOSURES: у входа %s/%s неизвестное назначение %q (допустимо: lid, panic)", enc.ID, ic.ID, ic.Role)
+		}
+		if ic.ActiveWhen != "open" && ic.ActiveWhen != "closed" {
+			return fmt.Errorf("ENCLOSURES: у входа %s/%s неверное active_when %q (допустимо: open, closed)", enc.ID, ic.ID, ic.ActiveWhen)
+		}
+		if err := ic.normalize(enc.ID + "/" + ic.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalize проверяет подключение входа и заполняет значения по умолчанию.
+func (w *inputWiring) normalize(name string) error {
+	if w.Driver == "" {
+		w.Driver = "cdev"
+	}
+	if w.Driver != "cdev" && w.Driver != "gpio" && w.Driver != "mock" {
+		return fmt.Errorf("ENCLOSURES: у входа %s неизвестный драйвер %q (допустимо: cdev, gpio, mock)", name, w.Driver)
+	}
+	if w.Pull == "" {
+		w.Pull = gpio.PullUp
+	}
+	if w.Pull != gpio.PullUp && w.Pull != gpio.PullDown && w.Pull != gpio.PullNone {
+		return fmt.Errorf("ENCLOSURES: у входа %s неизвестная подтяжка %q (допустимо: up, down, none)", name, w.Pull)
+	}
+	if w.DebounceMs < 0 {
+		return fmt.Errorf("ENCLOSURES: у входа %s отрицательный debounce_ms", name)
+	}
+	return nil
+}
+
+// newInput создает цифровой вход с подавлением дребезга (debounce — значение по умолчанию для назначения входа).
+func newInput(name string, w inputWiring, debounce time.Duration) (*gpio.DebouncedInput, error) {
+	var in gpio.DigitalInput
+	switch w.Driver {
+	case "gpio":
+		polled, err := gpio.NewRealInput(name, w.Pin, w.Pull)
+		if err != nil {
+			return nil, err
+		}
+		in = polled
+	case "mock":
+		in = gpio.NewMockInput(name)
+	default:
+		cdev, err := gpio.NewCdevInput(name, w.Chip, w.Pin, w.Pull)
+		if err != nil {
+			return nil, err
+		}
+		in = cdev
+	}
+	if w.DebounceMs > 0 {
+		debounce = time.Duration(w.DebounceMs) * time.Millisecond
+	}
+	return gpio.NewDebouncedInput(in, debounce), nil
+}
+
+// sensors возвращает все датчики террариума: зоны warm и cold, затем дополнительные.
+func (cfg enclosureConfig) sensors() []sensorConfig {
+	return append([]sensorConfig{
+		{ID: "warm", Name: "WarmZone", Zone: "warm", Driver: "dht22", Pin: cfg.WarmPin},
+		{ID: "cold", Name: "ColdZone", Zone: "cold", Driver: "dht22", Pin: cfg.ColdPin},
+	}, cfg.Sensors...)
+}
+
+// newSensor создает драйвер датчика.
+func newSensor(sc sensorConfig, hw api.Hardware) (gpio.Sensor, error) {
+	switch sc.Driver {
+	case "dht22":
+		reader, err := gpio.NewRealDHT22(sc.Name, sc.Pin)
+		if err != nil {
+			return nil, err
+		}
+		return gpio.FromReader(reader), nil
+	case "ds18b20":
+		return gpio.NewDS18B20(sc.Name, sc.Device, hw.W1Root)
+	case "bme280", "bmp280", "sht3x":
+		bus, err := gpio.OpenI2C(sc.Bus)
+		if err != nil {
+			return nil, err
+		}
+		switch sc.Driver {
+		case "bme280":
+			return gpio.NewBME280(sc.Name, bus, sc.addr), nil
+		case "bmp280":
+			return gpio.NewBMP280(sc.Name, bus, sc.addr), nil
+		}
+		return gpio.NewSHT3x(sc.Name, bus, sc.addr), nil
+	}
+	return nil, fmt.Errorf("неизвестный драйвер датчика %q", sc.Driver)
+}
+
+// setupEnclosure инициализирует аппаратуру террариума, регистрирует его в БД и создает движок автоматизации.
+func setupEnclosure(ctx context.Context, repo *storage.Repository, cfg enclosureConfig, hw api.Hardware, limits map[string]gpio.RelayLimits, wattage energy.Wattage, tracePersist string) (*api.Enclosure, error) {
+	logger.Info("Инициализация террариума", logging.KeyEnclosure, cfg.ID, "name", cfg.Name,
+		"warm_pin", cfg.WarmPin, "cold_pin", cfg.ColdPin, "extra_sensors", len(cfg.Sensors), "relays", cfg.Relays)
+
+	relays := make(map[string]gpio.RelayController)
+	drivers := make(map[string]string)
+	for name, rc := range cfg.Relays {
+		drivers[name] = rc.Type
+		relay, err := newRelay(name, rc, hw)
+		if err != nil {
+			return nil, fmt.Errorf("реле %s/%s: %w", cfg.ID, name, err)
+		}
+		// Оборачиваем реле защитой от дребезга (мин. время вкл/выкл, лимит переключений, макс. время работы)
+		relays[name] = gpio.NewProtectedRelay(relay, limits[name])
+	}
+
+	scoped := repo.ForEnclosure(cfg.ID)
+	if err := scoped.EnsureEnclosure(ctx, cfg.Name); err != nil {
+		return nil, err
+	}
+
+	engine := automation.NewEngine(
+		scoped,
+		relays["heat_mat"],
+		relays["fogger"],
+		relays["light"],
+	)
+	for name, relay := range relays {
+		if name != "heat_mat" && name != "fogger" && name != "light" {
+			engine.AddRelay(relay) // Правила автоматизации могут управлять и дополнительными реле (spare)
+		}
+	}
+	for _, sc := range cfg.sensors() {
+		sensor, err := newSensor(sc, hw)
+		if err != nil {
+			// Программа не должна падать, если датчик временно отвалился
+			logger.Warn("Ошибка инициализации датчика", logging.KeyEnclosure, cfg.ID, logging.KeySensor, sc.ID, "driver", sc.Driver, logging.Err(err))
+			continue
+		}
+		engine.AddSensor(models.SensorInfo{ID: sc.ID, Name: sc.Name, Zone: sc.Zone, Driver: sc.Driver, Device: sc.Device}, sensor)
+	}
+	if rc := cfg.Reservoir; rc != nil {
+		input, err := newInput(cfg.ID+"/reservoir", rc.inputWiring, defaultDebounce["reservoir"])
+		if err != nil {
+			return nil, fmt.Errorf("резервуар %s: %w", cfg.ID, err)
+		}
+		engine.SetReservoir(input, rc.EmptyWhen == "closed")
+	}
+	for _, ic := range cfg.Inputs {
+		input, err := newInput(cfg.ID+"/"+ic.ID, ic.inputWiring, defaultDebounce[ic.Role])
+		if err != nil {
+			return nil, fmt.Errorf("вход %s/%s: %w", cfg.ID, ic.ID, err)
+		}
+		engine.AddInput(ic.ID, ic.Role, input, ic.ActiveWhen == "open")
+	}
+	engine.SetTracePersistence(tracePersist)
+	engine.SetWattage(wattage)
+
+	return &api.Enclosure{
+		ID:      cfg.ID,
+		Name:    cfg.Name,
+		Repo:    scoped,
+		Relays:  relays,
+		Drivers: drivers,
+		Engine:  engine,
+	}, nil
+}
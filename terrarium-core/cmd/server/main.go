@@ -2,28 +2,49 @@ package main
 
 import (
 	"context"
-	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"terrarium-core/internal/api"
-	"terrarium-core/internal/automation"
+	"terrarium-core/internal/digest"
+	"terrarium-core/internal/energy"
 	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/mqttbridge"
+	"terrarium-core/internal/notify"
 	"terrarium-core/internal/storage"
+	"terrarium-core/internal/webhooks"
 
 	"github.com/joho/godotenv"
 )
 
+// defaultRelayLimits — защитные ограничения реле по умолчанию (переопределяются через RELAY_PROTECTION).
+var defaultRelayLimits = map[string]gpio.RelayLimits{
+	"heat_mat": {MinOnTime: time.Minute, MinOffTime: time.Minute, MaxSwitchesPerHour: 20},
+	"fogger":   {MinOnTime: 10 * time.Second, MinOffTime: 30 * time.Second, MaxSwitchesPerHour: 30, MaxOnTime: 30 * time.Minute},
+	"light":    {MinOnTime: time.Minute, MinOffTime: time.Minute, MaxSwitchesPerHour: 6, MaxOnTime: 16 * time.Hour},
+	"spare":    {},
+}
+
 // @title API Платформы Климат-Контроля Террариума (Terrarium Climate)
 // @version 1.0.0
 // @description Экстенсивная (excessive) документация API системы управления микроклиматом террариума на базе Raspberry Pi 5.
 // @host localhost:8080
 // @BasePath /
 
-func main() {
-	log.Println("Запуск ядра автоматизации климата (Terrarium Core)...")
+// logger — журнал запуска сервиса
+var logger = logging.Component("main")
 
-	// 1. Загрузка конфигурации окружения
+func main() {
+	// 1. Загрузка конфигурации окружения и журнала
 	_ = godotenv.Load("../.env")
+	bufferSize, _ := strconv.Atoi(os.Getenv("LOG_BUFFER_SIZE"))
+	if err := logging.Setup(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"), bufferSize); err != nil {
+		fatal("Ошибка конфигурации журнала", err)
+	}
+	logger.Info("Запуск ядра автоматизации климата (Terrarium Core)...", "version", api.Version)
 
 	// 2. Инициализация глобального контекста
 	ctx := context.Background()
@@ -31,73 +52,142 @@ func main() {
 	// 3. Подключение к БД
 	db, err := storage.Connect(ctx)
 	if err != nil {
-		log.Fatalf("Критическая ошибка инициализации БД: %v", err)
+		fatal("Критическая ошибка инициализации БД", err)
 	}
 	defer db.Close()
+	// Схема применяется при каждом запуске: обновляет базы, созданные прежними версиями
+	if err := db.Migrate(ctx); err != nil {
+		fatal("Ошибка миграции схемы БД", err)
+	}
 	repo := storage.NewRepository(db)
 
 	// 4. Инициализация Аппаратуры (GPIO) - ТОЛЬКО БЕЗ МОКОВ
-	log.Println("[СТАРТ] Инициализация БОЕВОГО оборудования Raspberry Pi 5 (libgpiod)...")
+	logger.Info("Инициализация БОЕВОГО оборудования Raspberry Pi 5 (libgpiod)...")
 
-	var warmSensor, coldSensor gpio.SensorReader
-	var relays map[string]gpio.RelayController
-	// В будущем брать пины из .env GPIO_MAPPING, пока хардкод для стабильности
-	warmSensor, err = gpio.NewRealDHT22("WarmZone", 5) // GPIO 5 (D5)
+	// Террариумы: по умолчанию один с исходной распиновкой, дополнительные — из ENCLOSURES
+	enclosureCfgs, err := parseEnclosures(os.Getenv("ENCLOSURES"))
 	if err != nil {
-		log.Printf("[ВНИМАНИЕ] Ошибка инициализации DHT22 (Warm): %v\n", err)
-		// Программа не должна падать, если датчик временно отвалился
+		fatal("Ошибка конфигурации террариумов", err)
 	}
 
-	coldSensor, err = gpio.NewRealDHT22("ColdZone", 6) // GPIO 6 (D6)
+	limits, err := gpio.ParseRelayLimits(os.Getenv("RELAY_PROTECTION"), defaultRelayLimits)
 	if err != nil {
-		log.Printf("[ВНИМАНИЕ] Ошибка инициализации DHT22 (Cold): %v\n", err)
+		fatal("Ошибка конфигурации защиты реле", err)
 	}
 
-	relays = make(map[string]gpio.RelayController)
-
-	relays["heat_mat"], err = gpio.NewRealRelay("heat_mat", 22) // Оранжевый
+	wattage, err := energy.ParseWattage(os.Getenv("WATTAGE_MAPPING"))
 	if err != nil {
-		log.Fatalf("Ошибка Реле heat_mat: %v", err)
+		fatal("Ошибка конфигурации мощности нагрузок", err)
 	}
 
-	relays["fogger"], err = gpio.NewRealRelay("fogger", 27) // Серый (из питона BCM 27)
-	if err != nil {
-		log.Fatalf("Ошибка Реле fogger: %v", err)
+	// Общие шины датчиков (1-wire)
+	hw := api.Hardware{
+		W1Root:  os.Getenv("W1_SYSFS_ROOT"),
+		PWMRoot: os.Getenv("PWM_SYSFS_ROOT"),
+		MQTTURL: os.Getenv("MQTT_URL"),
 	}
 
-	relays["light"], err = gpio.NewRealRelay("light", 17) // Белый (из питона BCM 17)
-	if err != nil {
-		log.Fatalf("Ошибка Реле light: %v", err)
+	// Каналы доставки уведомлений (аварии, неисправности, алерты)
+	notifier := notify.NewCenter()
+	notifier.Register("log", notify.LogNotifier{})
+	if token, chatID := os.Getenv("TELEGRAM_TOKEN"), os.Getenv("TELEGRAM_CHAT_ID"); token != "" && chatID != "" {
+		notifier.Register("telegram", notify.NewTelegram(token, chatID))
 	}
-
-	relays["spare"], err = gpio.NewRealRelay("spare", 23) // Фиолетовый (BCM 23)
-	if err != nil {
-		log.Fatalf("Ошибка Реле spare: %v", err)
+	var email *notify.Email
+	if host, to := os.Getenv("SMTP_HOST"), splitList(os.Getenv("SMTP_TO")); host != "" && len(to) > 0 {
+		port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		email = notify.NewEmail(notify.SMTPConfig{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+			To:       to,
+			TLS:      os.Getenv("SMTP_TLS"),
+		})
+		notifier.Register("email", email)
+	}
+	logger.Info("Каналы уведомлений", "channels", notifier.Channels())
+
+	// 5. Запуск фонового движка автоматизации (Конечного Автомата) — по одному на террариум
+	enclosures := make([]*api.Enclosure, 0, len(enclosureCfgs))
+	for _, cfg := range enclosureCfgs {
+		enc, err := setupEnclosure(ctx, repo, cfg, hw, limits, wattage, os.Getenv("DECISION_TRACE_PERSIST"))
+		if err != nil {
+			fatal("Ошибка инициализации террариума", err, logging.KeyEnclosure, cfg.ID)
+		}
+		enc.Engine.SetNotifier(notifier)
+		enclosures = append(enclosures, enc)
+
+		// Горутина автоматизации начинает работу в фоне
+		go enc.Engine.Start(ctx)
 	}
 
-	// 5. Запуск фонового движка автоматизации (Конечного Автомата)
-	engine := automation.NewEngine(
-		repo,
-		warmSensor,
-		coldSensor,
-		relays["heat_mat"],
-		relays["fogger"],
-		relays["light"],
-	)
+	// Доставка исходящих webhooks (очередь общая для всех террариумов)
+	webhooks.NewDispatcher(repo).Start(ctx)
+
+	// Мост MQTT: показания, реле, режим и аварийное состояние в топиках MQTT_TOPIC_PREFIX, команды — из .../set
+	if brokerURL := os.Getenv("MQTT_BRIDGE_URL"); brokerURL != "" {
+		bridged := make([]mqttbridge.Enclosure, 0, len(enclosures))
+		for _, enc := range enclosures {
+			bridged = append(bridged, mqttbridge.Enclosure{ID: enc.ID, Name: enc.Name, Relays: enc.Relays, Sensors: enc.Engine.Sensors(), Control: enc.Engine})
+		}
+		bridge, err := mqttbridge.Connect(brokerURL, os.Getenv("MQTT_TOPIC_PREFIX"), bridged)
+		if err != nil {
+			fatal("Ошибка подключения моста MQTT", err)
+		}
+		// Discovery Home Assistant: террариумы появляются в HA как устройства с датчиками, реле, режимом и аварией
+		if discovery, _ := strconv.ParseBool(os.Getenv("MQTT_HA_DISCOVERY")); discovery {
+			bridge.EnableDiscovery(os.Getenv("MQTT_HA_DISCOVERY_PREFIX"), api.Version)
+		}
+		bridge.Start(ctx)
+	}
 
-	// Горутина автоматизации начинает работу в фоне
-	go engine.Start(ctx)
+	// Ежедневная сводка на почту (DIGEST_TIME, пусто — только отправка по запросу API)
+	var digests *digest.Scheduler
+	if email != nil {
+		sources := make([]digest.Source, 0, len(enclosures))
+		for _, enc := range enclosures {
+			sources = append(sources, digest.Source{ID: enc.ID, Name: enc.Name, Engine: enc.Engine})
+		}
+		digests = digest.NewScheduler(sources, email)
+		if at := os.Getenv("DIGEST_TIME"); at != "" {
+			hour, minute, err := digest.ParseTime(at)
+			if err != nil {
+				fatal("Ошибка конфигурации ежедневной сводки", err)
+			}
+			digests.Start(ctx, hour, minute)
+			logger.Info("Ежедневная сводка включена", "time", at)
+		}
+	}
 
 	// 6. Настройка HTTP Роутинга и Swagger
-	router := api.SetupRouter(repo, relays, engine)
+	router := api.SetupRouter(enclosures, hw, digests)
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("HTTP Сервер запущен. Swagger: http://localhost:%s/swagger/index.html\n", port)
+	logger.Info("HTTP Сервер запущен", "port", port, "swagger", "http://localhost:"+port+"/swagger/index.html")
 	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Ошибка запуска HTTP сервера: %v", err)
+		fatal("Ошибка запуска HTTP сервера", err)
+	}
+}
+
+// fatal записывает критическую ошибку запуска и завершает процесс.
+func fatal(msg string, err error, args ...any) {
+	logger.Error(msg, append(args, logging.Err(err))...)
+	os.Exit(1)
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы.
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
 	}
+	return result
 }
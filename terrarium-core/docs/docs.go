@@ -15,74 +15,48 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/config": {
+        "/api/v1/alerts": {
             "get": {
-                "description": "Возвращает текущие настройки террариума: полярные целевые значения температуры, влажности, гистерезиса и пороги аварийных отключений. Настройки подтягиваются из Postgres.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "System",
-                    "Configuration"
-                ],
-                "summary": "Получить текущую конфигурацию (Настройки Автоматизации)",
-                "responses": {
-                    "200": {
-                        "description": "Успешное получение настроек",
-                        "schema": {
-                            "$ref": "#/definitions/models.ConfigPayload"
-                        }
-                    },
-                    "500": {
-                        "description": "Внутренняя ошибка (например, сбой соединения с БД)",
-                        "schema": {
-                            "$ref": "#/definitions/models.HTTPError"
-                        }
-                    }
-                }
-            },
-            "put": {
-                "description": "Принимает новые пороговые значения (Payload) и валидирует их. В случае успеха, новые пороги сохраняются в БД.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Активные (FIRING) и решенные (RESOLVED) алерты террариума, начиная с последних.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System",
-                    "Configuration"
+                    "Alerts"
                 ],
-                "summary": "Обновить границы климатического контроля",
+                "summary": "Получить алерты",
                 "parameters": [
                     {
-                        "description": "Объект новых настроек климата",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.ConfigPayload"
-                        }
+                        "type": "string",
+                        "description": "Состояние: FIRING или RESOLVED (по умолчанию все)",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, макс 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Конфигурация успешно обновлена",
+                        "description": "Список алертов",
                         "schema": {
-                            "$ref": "#/definitions/models.ConfigPayload"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Alert"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Невалидный Payload",
+                        "description": "Неизвестное состояние",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
                     },
                     "500": {
-                        "description": "Ошибка записи в базу данных postgres",
+                        "description": "Ошибка чтения из БД",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
@@ -90,95 +64,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/metrics/energy": {
+        "/api/v1/alerts/channels": {
             "get": {
-                "description": "Возвращает агрегированные отчёты расхода электроэнергии по каждому реле (кВт⋅ч). Данные берутся из таблицы energy_reports. Если отчёты ещё не генерировались — массив будет пуст.",
+                "description": "Имена каналов, которые можно указать в channels правила оповещения (log — системный журнал, telegram — если заданы TELEGRAM_TOKEN и TELEGRAM_CHAT_ID, email — если заданы SMTP_HOST и SMTP_TO).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Metrics"
-                ],
-                "summary": "Получить отчёты энергопотребления",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Начало периода (формат YYYY-MM-DD)",
-                        "name": "from",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Конец периода (формат YYYY-MM-DD)",
-                        "name": "to",
-                        "in": "query"
-                    }
+                    "Alerts"
                 ],
+                "summary": "Получить каналы доставки уведомлений",
                 "responses": {
                     "200": {
-                        "description": "Отчёты энергопотребления",
+                        "description": "Каналы доставки",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.EnergyReport"
+                                "type": "string"
                             }
                         }
-                    },
-                    "500": {
-                        "description": "Ошибка чтения из БД",
-                        "schema": {
-                            "$ref": "#/definitions/models.HTTPError"
-                        }
                     }
                 }
             }
         },
-        "/api/v1/metrics/sensors": {
+        "/api/v1/alerts/rules": {
             "get": {
-                "description": "Возвращает исторические данные температуры и влажности из sensor_logs. Поддерживает фильтрацию по дате и ограничение выборки. Если система подключена — данные реальные из БД; если нет — массив будет пуст.",
+                "description": "Возвращает все правила оповещения террариума (по имени).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Metrics"
-                ],
-                "summary": "Получить историю показаний датчиков",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Начало периода (RFC3339, например 2026-02-26T00:00:00Z)",
-                        "name": "from",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Конец периода (RFC3339, например 2026-02-26T23:59:59Z)",
-                        "name": "to",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Максимальное количество записей (по умолчанию 100, макс 1000)",
-                        "name": "limit",
-                        "in": "query"
-                    }
+                    "Alerts"
                 ],
+                "summary": "Получить правила оповещения",
                 "responses": {
                     "200": {
-                        "description": "Историческая справка",
+                        "description": "Список правил",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.SensorDataHistory"
+                                "$ref": "#/definitions/models.AlertRule"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Неверный формат параметров",
-                        "schema": {
-                            "$ref": "#/definitions/models.HTTPError"
-                        }
-                    },
                     "500": {
                         "description": "Ошибка чтения из БД",
                         "schema": {
@@ -186,77 +114,61 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/relay-logs": {
-            "get": {
-                "description": "Возвращает аудит-лог всех событий включения/выключения реле с причиной и временной меткой. Поддерживает пагинацию.",
+            },
+            "post": {
+                "description": "Создает правило: condition — условия (И) над показаниями, временем и реле, как у правил автоматизации (например cold_temp \u003c 22); sensor_offline — датчик не отвечает. Алерт срабатывает, когда условие держится for_sec секунд, и решается, когда оно перестает выполняться.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Logs"
+                    "Alerts"
                 ],
-                "summary": "Получить журнал переключений реле",
+                "summary": "Создать правило оповещения",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Количество записей (по умолчанию 50, макс 500)",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Смещение для пагинации (по умолчанию 0)",
-                        "name": "offset",
-                        "in": "query"
+                        "description": "Правило оповещения",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertRuleRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Журнал переключений",
+                    "201": {
+                        "description": "Правило создано",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.RelayLogEntry"
-                            }
+                            "$ref": "#/definitions/models.AlertRule"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка чтения из БД",
+                    "400": {
+                        "description": "Невалидное правило",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
-                    }
-                }
-            }
-        },
-        "/api/v1/relays": {
-            "get": {
-                "description": "Выполняет прямой опрос состояния реле из памяти/оборудования.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Hardware Control (Manual Mode)"
-                ],
-                "summary": "Получить фактическое состояние пинов GPIO (всех 4 реле)",
-                "responses": {
-                    "200": {
-                        "description": "Состояние реле",
+                    },
+                    "409": {
+                        "description": "Правило с таким именем уже существует",
                         "schema": {
-                            "$ref": "#/definitions/models.RelayState"
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка записи в БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
                         }
                     }
                 }
             }
         },
-        "/api/v1/relays/{id}/toggle": {
-            "post": {
-                "description": "Сигнализирует Raspberry Pi переключить уровень GPIO на конкретном пине. Работает только в MANUAL.",
+        "/api/v1/alerts/rules/{id}": {
+            "put": {
+                "description": "Полностью обновляет правило оповещения. Отключение правила решает его активный алерт без уведомления.",
                 "consumes": [
                     "application/json"
                 ],
@@ -264,48 +176,81 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Hardware Control (Manual Mode)"
+                    "Alerts"
                 ],
-                "summary": "Переключить конкретное реле [Требует MANUAL режим]",
+                "summary": "Обновить правило оповещения",
                 "parameters": [
                     {
-                        "enum": [
-                            "heat_mat",
-                            "fogger",
-                            "light",
-                            "spare"
-                        ],
                         "type": "string",
-                        "description": "ID реле для переключения",
+                        "description": "ID правила",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Телеметрия с запросом на активацию/деактивацию",
+                        "description": "Правило оповещения",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.RelayToggleRequest"
+                            "$ref": "#/definitions/models.AlertRuleRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Реле успешно переключено",
+                        "description": "Правило обновлено",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Неизвестный ID реле",
+                        "description": "Невалидное правило",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
                     },
-                    "403": {
-                        "description": "Система находится в режиме AUTO (ручное управление запрещено)",
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Правило с таким именем уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет правило оповещения. История его алертов сохраняется, активный алерт решается без уведомления.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Удалить правило оповещения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Правило удалено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
@@ -313,23 +258,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/schedules": {
+        "/api/v1/alerts/silences": {
             "get": {
-                "description": "Возвращает список всех расписаний автоматического включения/выключения реле по времени.",
+                "description": "Действующие и запланированные окна тишины (закончившиеся не возвращаются).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Schedules"
+                    "Alerts"
                 ],
-                "summary": "Получить все расписания реле",
+                "summary": "Получить окна тишины",
                 "responses": {
                     "200": {
-                        "description": "Список расписаний",
+                        "description": "Окна тишины",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Schedule"
+                                "$ref": "#/definitions/models.AlertSilence"
                             }
                         }
                     },
@@ -342,7 +287,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Создаёт запись расписания для автоматического включения/выключения реле по времени суток.",
+                "description": "На время окна алерты правила (или всех правил, если rule_id пуст) срабатывают и сохраняются, но уведомления не рассылаются — например, на время чистки террариума. Окно не длиннее 7 суток.",
                 "consumes": [
                     "application/json"
                 ],
@@ -350,35 +295,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Schedules"
+                    "Alerts"
                 ],
-                "summary": "Создать новое расписание реле",
+                "summary": "Создать окно тишины",
                 "parameters": [
                     {
-                        "description": "Данные расписания",
+                        "description": "Окно тишины",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ScheduleRequest"
+                            "$ref": "#/definitions/models.AlertSilenceRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Расписание успешно создано",
+                        "description": "Окно создано",
                         "schema": {
-                            "$ref": "#/definitions/models.Schedule"
+                            "$ref": "#/definitions/models.AlertSilence"
                         }
                     },
                     "400": {
-                        "description": "Невалидный Payload",
+                        "description": "Невалидное окно",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка записи в БД",
+                    "404": {
+                        "description": "Правило не найдено",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
@@ -386,42 +331,145 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/schedules/{id}": {
-            "put": {
-                "description": "Изменяет параметры существующего расписания реле по его UUID.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/v1/alerts/silences/{id}": {
+            "delete": {
+                "description": "Досрочно завершает (удаляет) окно тишины.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Schedules"
+                    "Alerts"
                 ],
-                "summary": "Обновить существующее расписание",
+                "summary": "Удалить окно тишины",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "UUID расписания",
+                        "description": "ID окна тишины",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Окно удалено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Окно не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/alerts/{id}/ack": {
+            "post": {
+                "description": "Отмечает алерт как принятый оператором: повторные уведомления (repeat_min) о нем прекращаются. Алерт остается активным, пока условие выполняется.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Alerts"
+                ],
+                "summary": "Подтвердить алерт",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID алерта",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Обновлённые данные",
+                        "description": "Кто подтверждает",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertAckRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Алерт подтвержден",
+                        "schema": {
+                            "$ref": "#/definitions/models.Alert"
+                        }
+                    },
+                    "404": {
+                        "description": "Алерт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/config": {
+            "get": {
+                "description": "Возвращает текущие настройки террариума: полярные целевые значения температуры, влажности, гистерезиса и пороги аварийных отключений. Настройки подтягиваются из Postgres.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System",
+                    "Configuration"
+                ],
+                "summary": "Получить текущую конфигурацию (Настройки Автоматизации)",
+                "responses": {
+                    "200": {
+                        "description": "Успешное получение настроек",
+                        "schema": {
+                            "$ref": "#/definitions/models.ConfigPayload"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка (например, сбой соединения с БД)",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Принимает новые пороговые значения (Payload) и валидирует их. В случае успеха, новые пороги сохраняются в БД.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System",
+                    "Configuration"
+                ],
+                "summary": "Обновить границы климатического контроля",
+                "parameters": [
+                    {
+                        "description": "Объект новых настроек климата",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ScheduleRequest"
+                            "$ref": "#/definitions/models.ConfigPayload"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Расписание обновлено",
+                        "description": "Конфигурация успешно обновлена",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.ConfigPayload"
                         }
                     },
                     "400": {
@@ -430,47 +478,95 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.HTTPError"
                         }
                     },
-                    "404": {
-                        "description": "Расписание не найдено",
+                    "500": {
+                        "description": "Ошибка записи в базу данных postgres",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/config/dry-run": {
+            "post": {
+                "description": "Воспроизводит сохраненные показания sensor_readings за интервал (не более 7 суток) через логику решений движка с кандидатной конфигурацией, ничего не переключая и не сохраняя. Возвращает смоделированные переключения, скважность и оценку энергопотребления (по WATTAGE_MAPPING) в сравнении с фактической работой реле. Защита реле от дребезга, импульсный режим тумана и ручные переопределения не моделируются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System",
+                    "Configuration"
+                ],
+                "summary": "Проверить кандидатную конфигурацию на истории датчиков",
+                "parameters": [
+                    {
+                        "description": "Интервал истории и кандидатная конфигурация",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.DryRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат симуляции",
+                        "schema": {
+                            "$ref": "#/definitions/models.DryRunResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверная конфигурация или интервал",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
                     },
                     "500": {
-                        "description": "Ошибка обновления",
+                        "description": "Ошибка чтения истории из БД",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет запись расписания по UUID.",
+            }
+        },
+        "/api/v1/digest": {
+            "get": {
+                "description": "Сводка за сутки (местное время сервера): минимум, максимум и среднее показаний по зонам, время работы и скважность реле, энергопотребление по WATTAGE_MAPPING и события безопасности (алерты, отключения по правилам безопасности, аварийные стопы, опустошение резервуара). Та же сводка ежедневно отправляется письмом в DIGEST_TIME.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Schedules"
+                    "Digest"
                 ],
-                "summary": "Удалить расписание реле",
+                "summary": "Получить суточную сводку",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "UUID расписания",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Дата ГГГГ-ММ-ДД (по умолчанию вчера)",
+                        "name": "date",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Расписание удалено",
+                        "description": "Сводка",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.DailyDigest"
                         }
                     },
-                    "404": {
-                        "description": "Расписание не найдено",
+                    "400": {
+                        "description": "Неверная или будущая дата",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
@@ -478,59 +574,88 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/sensors/current": {
+        "/api/v1/enclosures": {
             "get": {
-                "description": "Возвращает последние мгновенные показания с DHT22. Данные кэшируются в Engine (обновляются каждые 5 сек). При отсутствии подключения к оборудованию возвращаются mock-значения.",
+                "description": "Возвращает террариумы, обслуживаемые ядром. Все маршруты API доступны для каждого террариума с префиксом /api/v1/enclosures/{id}/... (например /api/v1/enclosures/gecko/config); маршруты без префикса относятся к террариуму \"default\".",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Sensors"
+                    "Enclosures"
                 ],
-                "summary": "Получить текущие показания датчиков (температура + влажность, обе зоны)",
+                "summary": "Получить список террариумов",
                 "responses": {
                     "200": {
-                        "description": "Текущие показания",
+                        "description": "Список террариумов",
                         "schema": {
-                            "$ref": "#/definitions/models.SensorCurrent"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EnclosureInfo"
+                            }
                         }
                     }
                 }
             }
         },
-        "/api/v1/system/mode": {
-            "post": {
-                "description": "Позволяет пользователю полностью перехватить контроль над реле.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/v1/engine/decisions": {
+            "get": {
+                "description": "Возвращает структурированные записи решений последних циклов (входные данные, эффективная конфигурация, оценка каждого правила, команды реле) из кольцевого буфера в памяти, от новых к старым.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Engine"
                 ],
-                "summary": "Изменить глобальный режим системы (AUTO или MANUAL)",
+                "summary": "Получить трассировку последних циклов движка",
                 "parameters": [
                     {
-                        "description": "Целевой режим: 'AUTO' или 'MANUAL'",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Количество циклов (по умолчанию 20, макс 240)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только циклы с командами указанного реле",
+                        "name": "relay",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Записи решений",
                         "schema": {
-                            "$ref": "#/definitions/models.ModeRequest"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DecisionRecord"
+                            }
                         }
                     }
+                }
+            }
+        },
+        "/api/v1/hardware/w1": {
+            "get": {
+                "description": "Перечисляет датчики DS18B20, обнаруженные ядром Linux на шине 1-wire (/sys/bus/w1/devices/28-*), с текущей температурой и привязкой к датчику реестра. ID устройства указывается в поле \"device\" датчика с driver \"ds18b20\" в ENCLOSURES. Опрос каждого датчика занимает ~750 мс.",
+                "produces": [
+                    "application/json"
                 ],
+                "tags": [
+                    "Sensors"
+                ],
+                "summary": "Найти датчики DS18B20 на шине 1-wire",
                 "responses": {
                     "200": {
-                        "description": "Режим успешно изменен",
+                        "description": "Найденные датчики",
                         "schema": {
-                            "$ref": "#/definitions/models.ModeRequest"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DiscoveredProbe"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Неверно заданный режим",
+                    "503": {
+                        "description": "Шина 1-wire недоступна (не включен dtoverlay=w1-gpio)",
                         "schema": {
                             "$ref": "#/definitions/models.HTTPError"
                         }
@@ -538,170 +663,3402 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/system/status": {
+        "/api/v1/inputs": {
             "get": {
-                "description": "Предоставляет uptime приложения и текущий режим работы автомата (AUTO/MANUAL) из БД.",
-                "consumes": [
+                "description": "Концевики крышки (role=lid) и кнопки аварийного стопа (role=panic) с подавлением дребезга. Пока крышка открыта, фоггер на паузе. Вход, который не отвечает, считается неактивным и содержит поле error.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Inputs"
+                ],
+                "summary": "Получить состояние цифровых входов",
+                "responses": {
+                    "200": {
+                        "description": "Состояние входов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.InputStatus"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/inputs/events": {
+            "get": {
+                "description": "Возвращает открытия (OPEN) и закрытия (CLOSE) крышки, включения (PANIC_STOP) и сбросы (PANIC_RESET) аварийного стопа, начиная с последних. Для стопа input_id — ID кнопки или \"api\".",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "System"
+                    "Inputs"
+                ],
+                "summary": "Получить журнал цифровых входов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, макс 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Получить статус и общую \"проверку здоровья\" (Health check) системы",
                 "responses": {
                     "200": {
-                        "description": "Системный статус успешно получен",
+                        "description": "Журнал входов",
                         "schema": {
-                            "$ref": "#/definitions/models.SystemStatus"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.InputEvent"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.ConfigPayload": {
+        },
+        "/api/v1/metrics/energy": {
+            "get": {
+                "description": "Возвращает агрегированные отчёты расхода электроэнергии по каждому реле (кВт⋅ч). Данные берутся из таблицы energy_reports. Если отчёты ещё не генерировались — массив будет пуст.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Получить отчёты энергопотребления",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода (формат YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (формат YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчёты энергопотребления",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EnergyReport"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metrics/readings": {
+            "get": {
+                "description": "Возвращает историю показаний из sensor_readings, сгруппированную по ID датчика (от новых к старым). Можно отфильтровать датчики, величину и период.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Получить историю показаний по датчикам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID датчиков через запятую (по умолчанию — все)",
+                        "name": "sensor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Величина (temperature, humidity, ...)",
+                        "name": "quantity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339, например 2026-02-26T00:00:00Z)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339, например 2026-02-26T23:59:59Z)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество значений (по умолчанию 1000, макс 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "История по датчикам",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/models.SensorReading"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат параметров",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metrics/sensors": {
+            "get": {
+                "description": "Возвращает исторические данные температуры и влажности датчиков warm и cold из sensor_readings (история по всем датчикам — /metrics/readings). Поддерживает фильтрацию по дате и ограничение выборки. Если система подключена — данные реальные из БД; если нет — массив будет пуст.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Получить историю показаний датчиков",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339, например 2026-02-26T00:00:00Z)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339, например 2026-02-26T23:59:59Z)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальное количество записей (по умолчанию 100, макс 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Историческая справка",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SensorDataHistory"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат параметров",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/mist/events": {
+            "get": {
+                "description": "Возвращает список запланированных событий дождя (принудительных импульсов фоггера по времени суток).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mist"
+                ],
+                "summary": "Получить расписание дождя",
+                "responses": {
+                    "200": {
+                        "description": "Список событий дождя",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RainEvent"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Планирует ежедневный импульс фоггера на заданное время. Дождь учитывается в суточном лимите, но игнорирует паузу между импульсами.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mist"
+                ],
+                "summary": "Создать событие дождя",
+                "parameters": [
+                    {
+                        "description": "Данные события дождя",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RainEventRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Событие создано",
+                        "schema": {
+                            "$ref": "#/definitions/models.RainEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный Payload",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка записи в БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/mist/events/{id}": {
+            "put": {
+                "description": "Изменяет время, длительность или активность события дождя по UUID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mist"
+                ],
+                "summary": "Обновить событие дождя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UUID события дождя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Обновлённые данные",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RainEventRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Событие обновлено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный Payload",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Событие не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет запланированное событие дождя по UUID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mist"
+                ],
+                "summary": "Удалить событие дождя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UUID события дождя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Событие удалено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Событие не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/mist/settings": {
+            "get": {
+                "description": "Возвращает длительность импульса, паузу между импульсами и суточный лимит работы фоггера.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mist"
+                ],
+                "summary": "Получить настройки импульсного режима фоггера",
+                "responses": {
+                    "200": {
+                        "description": "Настройки импульсного режима",
+                        "schema": {
+                            "$ref": "#/definitions/models.MistSettings"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Включает/выключает режим misting и задает параметры импульсов. Изменения применяются со следующего цикла движка.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mist"
+                ],
+                "summary": "Обновить настройки импульсного режима фоггера",
+                "parameters": [
+                    {
+                        "description": "Настройки импульсного режима",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MistSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Настройки обновлены",
+                        "schema": {
+                            "$ref": "#/definitions/models.MistSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный Payload",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка записи в БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/mist/status": {
+            "get": {
+                "description": "Показывает, идет ли импульс сейчас, когда был последний импульс и сколько суточного лимита израсходовано.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mist"
+                ],
+                "summary": "Получить состояние импульсного режима фоггера",
+                "responses": {
+                    "200": {
+                        "description": "Состояние импульсного режима",
+                        "schema": {
+                            "$ref": "#/definitions/models.MistStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/overrides": {
+            "get": {
+                "description": "Возвращает список временных ручных переопределений с временем окончания. Пока переопределение действует, автоматика не управляет реле.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Overrides"
+                ],
+                "summary": "Получить активные переопределения реле",
+                "responses": {
+                    "200": {
+                        "description": "Активные переопределения",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RelayOverride"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/relay-logs": {
+            "get": {
+                "description": "Возвращает аудит-лог всех событий включения/выключения реле с причиной и временной меткой. Поддерживает пагинацию.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Logs"
+                ],
+                "summary": "Получить журнал переключений реле",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, макс 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение для пагинации (по умолчанию 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Журнал переключений",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RelayLogEntry"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/relays": {
+            "get": {
+                "description": "Возвращает желаемое состояние реле (последняя подтвержденная команда), яркость диммируемых выходов (brightness) и в поле sync — результат последней сверки с оборудованием: фактическое состояние (уровень пина или ответ сетевой розетки), признак расхождения и последнюю ошибку. Движок сверяет состояние каждые 30 секунд и восстанавливает желаемое при расхождении.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hardware Control (Manual Mode)"
+                ],
+                "summary": "Получить состояние реле",
+                "responses": {
+                    "200": {
+                        "description": "Состояние реле",
+                        "schema": {
+                            "$ref": "#/definitions/models.RelayState"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/relays/{id}/explain": {
+            "get": {
+                "description": "Отвечает на вопрос \"почему это реле включено/выключено\": последнее переключение и правило, которое к нему привело, активные переопределения и текущая оценка правил.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Engine"
+                ],
+                "summary": "Объяснить текущее состояние реле",
+                "parameters": [
+                    {
+                        "enum": [
+                            "heat_mat",
+                            "fogger",
+                            "light"
+                        ],
+                        "type": "string",
+                        "description": "ID реле",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Объяснение состояния реле",
+                        "schema": {
+                            "$ref": "#/definitions/models.RelayExplanation"
+                        }
+                    },
+                    "404": {
+                        "description": "Реле не управляется автоматикой",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/relays/{id}/override": {
+            "post": {
+                "description": "Включает/выключает реле на заданное время (duration_min) или до указанного времени суток (until, HH:MM), не переводя систему в MANUAL. По окончании реле возвращается в исходное состояние и автоматика возобновляет управление. Аварийный контур имеет приоритет над переопределением.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Overrides"
+                ],
+                "summary": "Временно переопределить реле (работает в AUTO)",
+                "parameters": [
+                    {
+                        "enum": [
+                            "heat_mat",
+                            "fogger",
+                            "light"
+                        ],
+                        "type": "string",
+                        "description": "ID реле",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Состояние и срок переопределения",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RelayOverrideRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Переопределение создано",
+                        "schema": {
+                            "$ref": "#/definitions/models.RelayOverride"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный Payload или реле не управляется автоматикой",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Активно аварийное отключение или аварийный стоп, резервуар фоггера пуст, крышка открыта или команда отклонена защитой реле",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Аппаратная ошибка переключения реле",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Досрочно снимает переопределение: реле возвращается в исходное состояние, автоматика возобновляет управление.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Overrides"
+                ],
+                "summary": "Отменить переопределение реле",
+                "parameters": [
+                    {
+                        "enum": [
+                            "heat_mat",
+                            "fogger",
+                            "light"
+                        ],
+                        "type": "string",
+                        "description": "ID реле",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Переопределение отменено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Активного переопределения нет",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/relays/{id}/toggle": {
+            "post": {
+                "description": "Сигнализирует Raspberry Pi переключить уровень GPIO на конкретном пине. Работает только в MANUAL. Для диммируемого выхода можно указать brightness — яркость при включении.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Hardware Control (Manual Mode)"
+                ],
+                "summary": "Переключить конкретное реле [Требует MANUAL режим]",
+                "parameters": [
+                    {
+                        "enum": [
+                            "heat_mat",
+                            "fogger",
+                            "light",
+                            "spare"
+                        ],
+                        "type": "string",
+                        "description": "ID реле для переключения",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Телеметрия с запросом на активацию/деактивацию",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RelayToggleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Реле успешно переключено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестный ID реле",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Система находится в режиме AUTO (ручное управление запрещено)",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Команда отклонена защитой реле (минимальное время вкл/выкл или лимит переключений), аварийным стопом, пустым резервуаром или открытой крышкой",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Аппаратная ошибка переключения реле",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/reservoir": {
+            "get": {
+                "description": "Уровень воды по поплавковому выключателю (с подавлением дребезга), время последнего опустошения и пополнения. Пока резервуар пуст или датчик не отвечает, фоггер выключен и не включается ни автоматикой, ни вручную.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reservoir"
+                ],
+                "summary": "Получить состояние резервуара фоггера",
+                "responses": {
+                    "200": {
+                        "description": "Состояние резервуара",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReservoirStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/reservoir/events": {
+            "get": {
+                "description": "Возвращает события опустошения (EMPTY) и пополнения (REFILL) резервуара, начиная с последних. Для пополнения указано, сколько резервуар простоял пустым.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reservoir"
+                ],
+                "summary": "Получить журнал резервуара фоггера",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, макс 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Журнал резервуара",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ReservoirEvent"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rules": {
+            "get": {
+                "description": "Возвращает все правила (встроенные и пользовательские) в порядке оценки: по приоритету (меньше — раньше), затем по имени. Правила безопасности оцениваются до остальных независимо от приоритета.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rules"
+                ],
+                "summary": "Получить правила автоматизации",
+                "responses": {
+                    "200": {
+                        "description": "Список правил",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AutomationRule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает пользовательское правило: условия (И) над показаниями датчиков, временем суток и состоянием/длительностью реле, и действие над любым реле. Первое сработавшее правило захватывает реле на цикл. Правило проверяется при сохранении.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rules"
+                ],
+                "summary": "Создать правило автоматизации",
+                "parameters": [
+                    {
+                        "description": "Правило",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AutomationRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Правило создано",
+                        "schema": {
+                            "$ref": "#/definitions/models.AutomationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное правило",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Правило с таким именем уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка записи в БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rules/{id}": {
+            "put": {
+                "description": "Полностью обновляет пользовательское правило. У встроенных правил учитывается только is_active; правила безопасности отключить нельзя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rules"
+                ],
+                "summary": "Обновить правило автоматизации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Правило",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AutomationRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Правило обновлено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидное правило",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "403": {
+                        "description": "Встроенное правило безопасности нельзя отключить",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "409": {
+                        "description": "Правило с таким именем уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет пользовательское правило. Встроенные правила удалить нельзя (их можно отключить, кроме правил безопасности).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Rules"
+                ],
+                "summary": "Удалить правило автоматизации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID правила",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Правило удалено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Встроенное правило",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Правило не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/schedules": {
+            "get": {
+                "description": "Возвращает список всех расписаний автоматического включения/выключения реле по времени.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Получить все расписания реле",
+                "responses": {
+                    "200": {
+                        "description": "Список расписаний",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Schedule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создаёт запись расписания для автоматического включения/выключения реле по времени суток. Для диммируемых выходов движок выставляет яркость brightness с плавным рассветом после start_time и закатом перед end_time длительностью ramp_min минут.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Создать новое расписание реле",
+                "parameters": [
+                    {
+                        "description": "Данные расписания",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Расписание успешно создано",
+                        "schema": {
+                            "$ref": "#/definitions/models.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный Payload",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка записи в БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/schedules/{id}": {
+            "put": {
+                "description": "Изменяет параметры существующего расписания реле по его UUID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Обновить существующее расписание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UUID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Обновлённые данные",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Расписание обновлено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный Payload",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Расписание не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка обновления",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет запись расписания по UUID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Schedules"
+                ],
+                "summary": "Удалить расписание реле",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "UUID расписания",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Расписание удалено",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Расписание не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/sensors": {
+            "get": {
+                "description": "Возвращает датчики террариума: ID, зону, драйвер и измеряемые величины. Датчики warm и cold задаются пинами warm_pin/cold_pin, дополнительные — списком sensors в ENCLOSURES. В условиях правил показание датчика указывается как \"\u003cдатчик\u003e.\u003cвеличина\u003e\" (например basking.temperature), показание зоны — как \"\u003cзона\u003e_temp\" / \"\u003cзона\u003e_hum\".",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sensors"
+                ],
+                "summary": "Получить реестр датчиков",
+                "responses": {
+                    "200": {
+                        "description": "Реестр датчиков",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SensorInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/sensors/current": {
+            "get": {
+                "description": "Возвращает последние мгновенные показания теплой и холодной зон (показания всех датчиков — /sensors/readings). Данные кэшируются в Engine (обновляются каждые 5 сек). При отсутствии подключения к оборудованию возвращаются mock-значения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sensors"
+                ],
+                "summary": "Получить текущие показания датчиков (температура + влажность, обе зоны)",
+                "responses": {
+                    "200": {
+                        "description": "Текущие показания",
+                        "schema": {
+                            "$ref": "#/definitions/models.SensorCurrent"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/sensors/readings": {
+            "get": {
+                "description": "Возвращает последние показания каждого датчика реестра (ключ — ID датчика), включая ошибку последнего опроса. Данные кэшируются в Engine (обновляются каждые 5 сек).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sensors"
+                ],
+                "summary": "Получить текущие показания всех датчиков",
+                "responses": {
+                    "200": {
+                        "description": "Текущие показания по датчикам",
+                        "schema": {
+                            "$ref": "#/definitions/models.SensorReadings"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/system/digest/send": {
+            "post": {
+                "description": "Собирает сводку за сутки по всем террариумам и сразу отправляет ее на SMTP_TO (для проверки настроек SMTP). Доступно, если заданы SMTP_HOST и SMTP_TO.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Digest"
+                ],
+                "summary": "Отправить сводку письмом",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата ГГГГ-ММ-ДД (по умолчанию вчера)",
+                        "name": "date",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Сводка отправлена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверная или будущая дата",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "502": {
+                        "description": "Ошибка сборки или отправки письма",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "503": {
+                        "description": "SMTP не настроен",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/system/logs": {
+            "get": {
+                "description": "Последние записи журнала из кольцевого буфера в памяти (от новых к старым) с фильтром по уровню, компоненту и террариуму. Размер буфера задается LOG_BUFFER_SIZE; журнал не сохраняется между перезапусками.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Получить журнал сервиса",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Минимальный уровень: debug, info (по умолчанию), warn, error",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Компонент (engine, sensors, gpio, storage, ...)",
+                        "name": "component",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID террариума",
+                        "name": "enclosure",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Только записи новее указанной",
+                        "name": "after_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 200, макс 2000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Записи журнала",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.LogEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный уровень или after_id",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/system/logs/stream": {
+            "get": {
+                "description": "Поток text/event-stream: сначала последние tail записей (по умолчанию 50), затем каждая новая запись событием \"log\" с JSON models.LogEntry. Фильтры — как у /system/logs. Если клиент не успевает читать, часть записей пропускается (их можно дочитать через /system/logs?after_id=...).",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Живой хвост журнала (Server-Sent Events)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Минимальный уровень: debug, info (по умолчанию), warn, error",
+                        "name": "level",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Компонент (engine, sensors, gpio, storage, ...)",
+                        "name": "component",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID террариума",
+                        "name": "enclosure",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сколько последних записей отправить сразу (по умолчанию 50, макс 2000)",
+                        "name": "tail",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий log",
+                        "schema": {
+                            "$ref": "#/definitions/models.LogEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный уровень",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/system/mode": {
+            "post": {
+                "description": "Позволяет пользователю полностью перехватить контроль над реле. Для MANUAL можно указать duration_min — по истечении срока движок сам вернет систему в AUTO.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Изменить глобальный режим системы (AUTO или MANUAL)",
+                "parameters": [
+                    {
+                        "description": "Целевой режим: 'AUTO' или 'MANUAL'",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ModeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Режим успешно изменен",
+                        "schema": {
+                            "$ref": "#/definitions/models.ModeRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверно заданный режим",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сохранения режима",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/system/panic": {
+            "post": {
+                "description": "То же, что нажатие физической кнопки: active=true сразу выключает все реле (в обход защиты от дребезга), снимает переопределения и останавливает автоматику; active=false сбрасывает стоп. Пока стоп активен, включить реле нельзя (409).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Включить или сбросить аварийный стоп",
+                "parameters": [
+                    {
+                        "description": "Включить (true) или сбросить (false) стоп",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PanicStopRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Состояние системы после команды",
+                        "schema": {
+                            "$ref": "#/definitions/models.SystemStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/system/status": {
+            "get": {
+                "description": "Предоставляет uptime и версию приложения, текущий режим работы автомата (AUTO/MANUAL) из БД, результаты самодиагностики нагревателя и датчиков, последний цикл движка, последний успешный опрос каждого датчика, связь с драйверами реле, а также задержку и загрузку PostgreSQL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Получить статус и общую \"проверку здоровья\" (Health check) системы",
+                "responses": {
+                    "200": {
+                        "description": "Системный статус успешно получен",
+                        "schema": {
+                            "$ref": "#/definitions/models.SystemStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks": {
+            "get": {
+                "description": "Подписки террариума на события. Секреты подписи не возвращаются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Получить подписки webhook",
+                "responses": {
+                    "200": {
+                        "description": "Список подписок",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Подписывает URL на события террариума: relay.switched, mode.changed, alert.fired, alert.resolved, alert.acknowledged или \"*\" (все). Каждое событие отправляется POST-запросом с JSON models.WebhookEvent и заголовками X-Terrarium-Event, X-Terrarium-Delivery и X-Terrarium-Signature (sha256=\u003chex HMAC-SHA256 тела на секрете\u003e). Неудачная доставка (не 2xx) повторяется с экспоненциальной задержкой от 10 с до 1 ч; после 10 попыток доставка получает статус DEAD. События алертов отправляются и во время окон тишины. Если secret не задан, он генерируется и возвращается только в этом ответе.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Создать подписку webhook",
+                "parameters": [
+                    {
+                        "description": "Подписка",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Подписка создана (с секретом)",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидная подписка",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка записи в БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/deliveries": {
+            "get": {
+                "description": "Доставки событий подписчикам террариума, начиная с последних: состояние, число попыток, код ответа и ошибка последней попытки. Успешные доставки хранятся 7 суток.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Журнал доставок webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки (по умолчанию все)",
+                        "name": "webhook_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Состояние: PENDING, DELIVERED или DEAD (по умолчанию все)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей (по умолчанию 50, макс 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Журнал доставок",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неизвестное состояние",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка чтения из БД",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/deliveries/{id}/retry": {
+            "post": {
+                "description": "Возвращает доставку в состоянии DEAD в очередь с новым счетчиком попыток.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Повторить доставку webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID доставки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Доставка снова в очереди",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDelivery"
+                        }
+                    },
+                    "404": {
+                        "description": "Доставка в состоянии DEAD не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}": {
+            "put": {
+                "description": "Полностью обновляет подписку. Пустой secret сохраняет прежний. Доставки отключенной подписки остаются в очереди до ее включения.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Обновить подписку webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Подписка",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка обновлена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидная подписка",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет подписку вместе с ее очередью и журналом доставок.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Удалить подписку webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Подписка удалена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/test": {
+            "post": {
+                "description": "Ставит в очередь подписки событие ping. Результат доставки виден в журнале /webhooks/deliveries.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Проверить подписку webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID подписки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "ID доставки",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Отвечает 200, пока процесс работает и циклы движков автоматизации завершаются. 503 — движок завис (цикл не завершался дольше 15 секунд): процесс нужно перезапустить. Не обращается к БД.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Проверка живости (liveness)",
+                "responses": {
+                    "200": {
+                        "description": "Сервис жив",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Движок автоматизации завис",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Отвечает 200, когда PostgreSQL отвечает на ping и каждый движок автоматизации выполнил хотя бы один цикл и не завис. Иначе 503 со списком непройденных проверок.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Проверка готовности (readiness)",
+                "responses": {
+                    "200": {
+                        "description": "Сервис готов",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервис не готов",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthStatus"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.Alert": {
+            "description": "Алерт: срабатывание правила оповещения с подтверждением оператором.",
+            "type": "object",
+            "properties": {
+                "acknowledged_at": {
+                    "description": "Когда алерт подтвержден оператором (null — не подтвержден)\nExample: \"2026-02-26T14:20:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:20:00Z"
+                },
+                "acknowledged_by": {
+                    "description": "Кто подтвердил алерт\nExample: anna",
+                    "type": "string",
+                    "example": "anna"
+                },
+                "fired_at": {
+                    "description": "Когда алерт сработал (started_at + for_sec)\nExample: \"2026-02-26T14:15:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:15:00Z"
+                },
+                "id": {
+                    "description": "Идентификатор алерта (UUID)\nExample: \"6f1d2e3c-4b5a-4978-8c6d-5e4f3a2b1c0d\"",
+                    "type": "string",
+                    "example": "6f1d2e3c-4b5a-4978-8c6d-5e4f3a2b1c0d"
+                },
+                "message": {
+                    "description": "Объяснение: выполненные условия на момент срабатывания\nExample: \"cold_temp 21.4 \u003c 22.0\"",
+                    "type": "string",
+                    "example": "cold_temp 21.4 \u003c 22.0"
+                },
+                "resolved_at": {
+                    "description": "Когда алерт решен (null — активен)\nExample: \"2026-02-26T15:02:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:02:00Z"
+                },
+                "rule_id": {
+                    "description": "Правило оповещения\nExample: \"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30\"",
+                    "type": "string",
+                    "example": "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+                },
+                "rule_name": {
+                    "description": "Имя правила на момент срабатывания\nExample: COLD_ZONE_TOO_COLD",
+                    "type": "string",
+                    "example": "COLD_ZONE_TOO_COLD"
+                },
+                "severity": {
+                    "description": "Важность\nExample: WARNING",
+                    "type": "string",
+                    "example": "WARNING"
+                },
+                "started_at": {
+                    "description": "Когда условие начало выполняться\nExample: \"2026-02-26T14:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:00:00Z"
+                },
+                "state": {
+                    "description": "Состояние: FIRING (активен) или RESOLVED (условие больше не выполняется)\nExample: FIRING",
+                    "type": "string",
+                    "enum": [
+                        "FIRING",
+                        "RESOLVED"
+                    ],
+                    "example": "FIRING"
+                }
+            }
+        },
+        "models.AlertAckRequest": {
+            "description": "Payload подтверждения алерта.",
+            "type": "object",
+            "properties": {
+                "by": {
+                    "description": "Кто подтверждает\nExample: anna",
+                    "type": "string",
+                    "example": "anna"
+                }
+            }
+        },
+        "models.AlertRule": {
+            "description": "Правило оповещения над показаниями, временем и реле (condition) или доступностью датчика (sensor_offline).",
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Каналы доставки (GET /alerts/channels); пусто — все каналы\nExample: [\"telegram\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "telegram"
+                    ]
+                },
+                "conditions": {
+                    "description": "Условия — для condition (все должны выполниться)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RuleCondition"
+                    }
+                },
+                "created_at": {
+                    "description": "Example: \"2026-02-26T12:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:00:00Z"
+                },
+                "description": {
+                    "description": "Описание правила\nExample: \"Холодная зона ниже 22 °C дольше 15 минут\"",
+                    "type": "string",
+                    "example": "Холодная зона ниже 22 °C дольше 15 минут"
+                },
+                "for_sec": {
+                    "description": "Сколько секунд условие должно держаться непрерывно, прежде чем алерт сработает\nExample: 900",
+                    "type": "integer",
+                    "example": 900
+                },
+                "id": {
+                    "description": "Идентификатор правила (UUID)\nExample: \"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30\"",
+                    "type": "string",
+                    "example": "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+                },
+                "is_active": {
+                    "description": "Активно ли правило\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "description": "Уникальное имя правила (заголовок уведомления)\nExample: COLD_ZONE_TOO_COLD",
+                    "type": "string",
+                    "example": "COLD_ZONE_TOO_COLD"
+                },
+                "repeat_min": {
+                    "description": "Повторять уведомление, пока алерт активен и не подтвержден, каждые N минут (0 — не повторять)\nExample: 60",
+                    "type": "integer",
+                    "example": 60
+                },
+                "sensor": {
+                    "description": "ID датчика — для sensor_offline (\"*\" — любой датчик террариума)\nExample: basking",
+                    "type": "string",
+                    "example": "basking"
+                },
+                "severity": {
+                    "description": "Важность: INFO, WARNING, CRITICAL\nExample: WARNING",
+                    "type": "string",
+                    "enum": [
+                        "INFO",
+                        "WARNING",
+                        "CRITICAL"
+                    ],
+                    "example": "WARNING"
+                },
+                "type": {
+                    "description": "Тип правила: condition — условия (И) как у правил автоматизации, sensor_offline — датчик не отвечает\nExample: condition",
+                    "type": "string",
+                    "enum": [
+                        "condition",
+                        "sensor_offline"
+                    ],
+                    "example": "condition"
+                },
+                "updated_at": {
+                    "description": "Example: \"2026-02-26T12:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:00:00Z"
+                }
+            }
+        },
+        "models.AlertRuleRequest": {
+            "description": "Payload для создания/обновления правила оповещения.",
+            "type": "object",
+            "required": [
+                "name",
+                "type"
+            ],
+            "properties": {
+                "channels": {
+                    "description": "Example: [\"telegram\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "telegram"
+                    ]
+                },
+                "conditions": {
+                    "description": "Условия — для condition (от 1 до 10)",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/models.RuleCondition"
+                    }
+                },
+                "description": {
+                    "description": "Example: \"Холодная зона ниже 22 °C дольше 15 минут\"",
+                    "type": "string",
+                    "example": "Холодная зона ниже 22 °C дольше 15 минут"
+                },
+                "for_sec": {
+                    "description": "Example: 900",
+                    "type": "integer",
+                    "example": 900
+                },
+                "is_active": {
+                    "description": "Активно ли правило (по умолчанию true)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "description": "Example: COLD_ZONE_TOO_COLD",
+                    "type": "string",
+                    "example": "COLD_ZONE_TOO_COLD"
+                },
+                "repeat_min": {
+                    "description": "Example: 60",
+                    "type": "integer",
+                    "example": 60
+                },
+                "sensor": {
+                    "description": "ID датчика или \"*\" — для sensor_offline\nExample: basking",
+                    "type": "string",
+                    "example": "basking"
+                },
+                "severity": {
+                    "description": "Важность (по умолчанию WARNING)\nExample: WARNING",
+                    "type": "string",
+                    "enum": [
+                        "INFO",
+                        "WARNING",
+                        "CRITICAL"
+                    ],
+                    "example": "WARNING"
+                },
+                "type": {
+                    "description": "Тип правила: condition или sensor_offline\nExample: condition",
+                    "type": "string",
+                    "enum": [
+                        "condition",
+                        "sensor_offline"
+                    ],
+                    "example": "condition"
+                }
+            }
+        },
+        "models.AlertSilence": {
+            "description": "Окно тишины для одного правила оповещения или для всех правил террариума.",
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "description": "Комментарий (например, \"чистка террариума\")\nExample: \"Чистка террариума\"",
+                    "type": "string",
+                    "example": "Чистка террариума"
+                },
+                "created_at": {
+                    "description": "Example: \"2026-02-26T08:55:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T08:55:00Z"
+                },
+                "ends_at": {
+                    "description": "Конец окна\nExample: \"2026-02-26T11:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T11:00:00Z"
+                },
+                "id": {
+                    "description": "Идентификатор окна (UUID)\nExample: \"9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d\"",
+                    "type": "string",
+                    "example": "9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"
+                },
+                "rule_id": {
+                    "description": "Правило оповещения (пусто — все правила террариума)\nExample: \"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30\"",
+                    "type": "string",
+                    "example": "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+                },
+                "starts_at": {
+                    "description": "Начало окна\nExample: \"2026-02-26T09:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T09:00:00Z"
+                }
+            }
+        },
+        "models.AlertSilenceRequest": {
+            "description": "Payload окна тишины: ends_at или duration_min.",
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "description": "Example: \"Чистка террариума\"",
+                    "type": "string",
+                    "example": "Чистка террариума"
+                },
+                "duration_min": {
+                    "description": "Длительность окна в минутах от starts_at (1..10080)\nExample: 120",
+                    "type": "integer",
+                    "example": 120
+                },
+                "ends_at": {
+                    "description": "Конец окна (взаимоисключающе с duration_min)\nExample: \"2026-02-26T11:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T11:00:00Z"
+                },
+                "rule_id": {
+                    "description": "Правило оповещения (пусто — все правила террариума)\nExample: \"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30\"",
+                    "type": "string",
+                    "example": "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+                },
+                "starts_at": {
+                    "description": "Начало окна (по умолчанию — сейчас)\nExample: \"2026-02-26T09:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T09:00:00Z"
+                }
+            }
+        },
+        "models.AutomationRule": {
+            "description": "Правило автоматизации с приоритетом, условиями и действием.",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Действие при выполнении условий",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RuleAction"
+                        }
+                    ]
+                },
+                "builtin": {
+                    "description": "Встроенное правило (нельзя удалить или изменить условия)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "conditions": {
+                    "description": "Условия правила (все должны выполниться)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RuleCondition"
+                    }
+                },
+                "created_at": {
+                    "description": "Example: \"2026-02-26T12:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:00:00Z"
+                },
+                "description": {
+                    "description": "Описание правила\nExample: \"Включить обогрев, когда теплая зона остыла ниже нижней границы гистерезиса\"",
+                    "type": "string",
+                    "example": "Включить обогрев, когда теплая зона остыла ниже нижней границы гистерезиса"
+                },
+                "id": {
+                    "description": "Идентификатор правила (UUID или \"builtin-...\" для встроенных)\nExample: \"builtin-heat-on\"",
+                    "type": "string",
+                    "example": "builtin-heat-on"
+                },
+                "is_active": {
+                    "description": "Активно ли правило\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "description": "Уникальное имя правила (отображается в трассировке решений)\nExample: HEAT_HYSTERESIS_ON",
+                    "type": "string",
+                    "example": "HEAT_HYSTERESIS_ON"
+                },
+                "priority": {
+                    "description": "Приоритет: меньшее значение оценивается раньше. Правила безопасности всегда оцениваются до остальных.\nExample: 100",
+                    "type": "integer",
+                    "example": 100
+                },
+                "safety": {
+                    "description": "Правило безопасности: работает и в режиме MANUAL, выключает реле в обход защиты от дребезга\nи снимает ручные переопределения. Допускает только действие \"выключить\".\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "updated_at": {
+                    "description": "Example: \"2026-02-26T12:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:00:00Z"
+                }
+            }
+        },
+        "models.AutomationRuleRequest": {
+            "description": "Payload для создания/обновления правила автоматизации.",
+            "type": "object",
+            "required": [
+                "action",
+                "conditions",
+                "name"
+            ],
+            "properties": {
+                "action": {
+                    "description": "Действие при выполнении условий",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RuleAction"
+                        }
+                    ]
+                },
+                "conditions": {
+                    "description": "Условия правила (от 1 до 10, все должны выполниться)",
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.RuleCondition"
+                    }
+                },
+                "description": {
+                    "description": "Example: \"Включить вентилятор, если холодная зона перегрелась днем\"",
+                    "type": "string",
+                    "example": "Включить вентилятор, если холодная зона перегрелась днем"
+                },
+                "is_active": {
+                    "description": "Активно ли правило (по умолчанию true)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "description": "Example: SPARE_FAN_HOT",
+                    "type": "string",
+                    "example": "SPARE_FAN_HOT"
+                },
+                "priority": {
+                    "description": "Example: 50",
+                    "type": "integer",
+                    "example": 50
+                },
+                "safety": {
+                    "description": "Правило безопасности (только выключение реле, работает и в MANUAL)\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.ComponentHealth": {
+            "description": "Здоровье компонента по данным диагностики теплового отклика и показаний датчиков.",
+            "type": "object",
+            "properties": {
+                "component": {
+                    "description": "Компонент (\"heater\", \"sensor:WarmZone\", ...)\nExample: heater",
+                    "type": "string",
+                    "example": "heater"
+                },
+                "faults": {
+                    "description": "Активные неисправности",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FaultInfo"
+                    }
+                },
+                "learned_runs": {
+                    "description": "Количество завершенных фаз, учтенных при обучении\nExample: 12",
+                    "type": "integer",
+                    "example": 12
+                },
+                "off_rate_c_per_min": {
+                    "description": "Выученная скорость изменения температуры при выключенном нагревателе (°C/мин)\nExample: -0.05",
+                    "type": "number",
+                    "example": -0.05
+                },
+                "on_rate_c_per_min": {
+                    "description": "Выученная скорость нагрева при включенном нагревателе (°C/мин)\nExample: 0.12",
+                    "type": "number",
+                    "example": 0.12
+                },
+                "status": {
+                    "description": "Статус: OK, LEARNING (набор статистики) или FAULT\nExample: OK",
+                    "type": "string",
+                    "example": "OK"
+                }
+            }
+        },
+        "models.ConfigPayload": {
             "description": "Payload конфигурации для управления поведением механизма климат-контроля",
             "type": "object",
-            "required": [
-                "cold_max_threshold",
-                "emergency_max_threshold",
-                "humidity_max",
-                "humidity_min",
-                "hysteresis_hum",
-                "hysteresis_temp",
-                "warm_target_max",
-                "warm_target_min"
-            ],
+            "required": [
+                "cold_max_threshold",
+                "emergency_max_threshold",
+                "humidity_max",
+                "humidity_min",
+                "hysteresis_hum",
+                "hysteresis_temp",
+                "warm_target_max",
+                "warm_target_min"
+            ],
+            "properties": {
+                "cold_max_threshold": {
+                    "description": "Максимально допустимая температура в холодной зоне (°C). Если превышена, обогрев принудительно отключается.\nExample: 26.5",
+                    "type": "number",
+                    "maximum": 35,
+                    "minimum": 20,
+                    "example": 26.5
+                },
+                "emergency_max_threshold": {
+                    "description": "Температурный порог теплой зоны (°C), при котором система аварийно отключает всё и шлёт Alert в Telegram.\nExample: 35.0",
+                    "type": "number",
+                    "maximum": 45,
+                    "minimum": 30,
+                    "example": 35
+                },
+                "humidity_max": {
+                    "description": "Максимальная влажность (%), при достижении которой фоггер отключается.\nExample: 65.0",
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 65
+                },
+                "humidity_min": {
+                    "description": "Минимальная влажность (%), при которой включается фоггер (генератор тумана).\nExample: 50.0",
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 50
+                },
+                "hysteresis_hum": {
+                    "description": "Гистерезис влажности (%), для предотвращения частого срабатывания фоггера.\nExample: 2.0",
+                    "type": "number",
+                    "maximum": 10,
+                    "minimum": 0.5,
+                    "example": 2
+                },
+                "hysteresis_temp": {
+                    "description": "Температурный гистерезис (°C), чтобы избежать \"дребезга\" реле около целевого значения.\nExample: 0.5",
+                    "type": "number",
+                    "maximum": 5,
+                    "minimum": 0.1,
+                    "example": 0.5
+                },
+                "warm_target_max": {
+                    "description": "Максимальная целевая температура в теплой зоне (°C), при достижении которой обогрев отключается.\nОграничения: от WarmTargetMin до 40.0.\nExample: 33.0",
+                    "type": "number",
+                    "maximum": 40,
+                    "minimum": 20,
+                    "example": 33
+                },
+                "warm_target_min": {
+                    "description": "Минимальная целевая температура в теплой зоне (°C), при которой включается обогрев.\nОграничения: от 20.0 до 40.0.\nExample: 31.5",
+                    "type": "number",
+                    "maximum": 40,
+                    "minimum": 20,
+                    "example": 31.5
+                }
+            }
+        },
+        "models.DailyDigest": {
+            "description": "Суточная сводка: климат по зонам, работа реле, энергопотребление и события безопасности.",
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Сутки сводки (местное время сервера)\nExample: \"2026-02-25\"",
+                    "type": "string",
+                    "example": "2026-02-25"
+                },
+                "enclosure": {
+                    "description": "Террариум\nExample: default",
+                    "type": "string",
+                    "example": "default"
+                },
+                "events": {
+                    "description": "События безопасности (в хронологическом порядке)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DigestEvent"
+                    }
+                },
+                "from": {
+                    "description": "Начало суток\nExample: \"2026-02-25T00:00:00+03:00\"",
+                    "type": "string",
+                    "example": "2026-02-25T00:00:00+03:00"
+                },
+                "relays": {
+                    "description": "Работа реле",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DigestRelay"
+                    }
+                },
+                "to": {
+                    "description": "Конец суток\nExample: \"2026-02-26T00:00:00+03:00\"",
+                    "type": "string",
+                    "example": "2026-02-26T00:00:00+03:00"
+                },
+                "total_kwh": {
+                    "description": "Суммарное энергопотребление (кВт⋅ч, по WATTAGE_MAPPING)\nExample: 0.41",
+                    "type": "number",
+                    "example": 0.41
+                },
+                "zones": {
+                    "description": "Показания по зонам и величинам",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DigestZoneStat"
+                    }
+                }
+            }
+        },
+        "models.DecisionInputs": {
+            "description": "Входные данные цикла движка.",
+            "type": "object",
+            "properties": {
+                "cold_hum": {
+                    "description": "Example: 65.2",
+                    "type": "number",
+                    "example": 65.2
+                },
+                "cold_temp": {
+                    "description": "Example: 24.8",
+                    "type": "number",
+                    "example": 24.8
+                },
+                "readings": {
+                    "description": "Все показания цикла (\"\u003cдатчик\u003e.\u003cвеличина\u003e\" -\u003e значение)\nExample: {\"warm.temperature\": 32.3, \"basking.temperature\": 41.5}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "warm_hum": {
+                    "description": "Example: 58.5",
+                    "type": "number",
+                    "example": 58.5
+                },
+                "warm_temp": {
+                    "description": "Example: 32.3",
+                    "type": "number",
+                    "example": 32.3
+                }
+            }
+        },
+        "models.DecisionRecord": {
+            "description": "Структурированная запись решения: входные данные, эффективная конфигурация, оценка каждого правила и итоговые действия с реле.",
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "description": "Команды реле, выданные в цикле",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RelayAction"
+                    }
+                },
+                "config": {
+                    "description": "Эффективная конфигурация климата",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ConfigPayload"
+                        }
+                    ]
+                },
+                "cycle_id": {
+                    "description": "Порядковый номер цикла с момента запуска\nExample: 1542",
+                    "type": "integer",
+                    "example": 1542
+                },
+                "duration_ms": {
+                    "description": "Длительность цикла (мс)\nExample: 215.4",
+                    "type": "number",
+                    "example": 215.4
+                },
+                "inputs": {
+                    "description": "Входные показания датчиков",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DecisionInputs"
+                        }
+                    ]
+                },
+                "mist": {
+                    "description": "Эффективные настройки импульсного режима фоггера",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MistSettings"
+                        }
+                    ]
+                },
+                "mode": {
+                    "description": "Режим системы в момент цикла (AUTO / MANUAL)\nExample: AUTO",
+                    "type": "string",
+                    "example": "AUTO"
+                },
+                "rules": {
+                    "description": "Результаты оценки правил в порядке приоритета",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RuleEvaluation"
+                    }
+                },
+                "skipped": {
+                    "description": "Причина досрочного прерывания цикла (SENSOR_ERROR, CONFIG_ERROR)\nExample: \"\"",
+                    "type": "string",
+                    "example": ""
+                },
+                "started_at": {
+                    "description": "Время начала цикла\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
+                }
+            }
+        },
+        "models.DigestEvent": {
+            "description": "Срабатывание алерта, аварийное отключение по правилу безопасности, аварийный стоп или опустошение резервуара.",
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Подробности: сообщение алерта, отключенные реле, вход\nExample: \"fogger, heat_mat, light\"",
+                    "type": "string",
+                    "example": "fogger, heat_mat, light"
+                },
+                "event": {
+                    "description": "Событие: имя правила оповещения, причина отключения, PANIC_STOP, EMPTY, ...\nExample: EMERGENCY_CUTOFF",
+                    "type": "string",
+                    "example": "EMERGENCY_CUTOFF"
+                },
+                "source": {
+                    "description": "Источник: alert, safety, input, reservoir\nExample: safety",
+                    "type": "string",
+                    "example": "safety"
+                },
+                "time": {
+                    "description": "Example: \"2026-02-25T14:02:11+03:00\"",
+                    "type": "string",
+                    "example": "2026-02-25T14:02:11+03:00"
+                }
+            }
+        },
+        "models.DigestRelay": {
+            "description": "Время работы, скважность, переключения и энергопотребление реле.",
+            "type": "object",
+            "properties": {
+                "duty_pct": {
+                    "description": "Скважность (% времени суток во включенном состоянии; для текущих суток — по текущий момент)\nExample: 25.8",
+                    "type": "number",
+                    "example": 25.8
+                },
+                "kwh": {
+                    "description": "Энергопотребление (кВт⋅ч)\nExample: 0.279",
+                    "type": "number",
+                    "example": 0.279
+                },
+                "on_sec": {
+                    "description": "Время во включенном состоянии (секунды)\nExample: 22320",
+                    "type": "integer",
+                    "example": 22320
+                },
+                "relay_id": {
+                    "description": "Example: heat_mat",
+                    "type": "string",
+                    "example": "heat_mat"
+                },
+                "switches": {
+                    "description": "Example: 14",
+                    "type": "integer",
+                    "example": 14
+                }
+            }
+        },
+        "models.DigestZoneStat": {
+            "description": "Минимум, максимум и среднее величины в зоне.",
+            "type": "object",
+            "properties": {
+                "avg": {
+                    "description": "Example: 29.04",
+                    "type": "number",
+                    "example": 29.04
+                },
+                "max": {
+                    "description": "Example: 31.2",
+                    "type": "number",
+                    "example": 31.2
+                },
+                "min": {
+                    "description": "Example: 26.1",
+                    "type": "number",
+                    "example": 26.1
+                },
+                "quantity": {
+                    "description": "Example: temperature",
+                    "type": "string",
+                    "example": "temperature"
+                },
+                "samples": {
+                    "description": "Количество измерений\nExample: 8640",
+                    "type": "integer",
+                    "example": 8640
+                },
+                "zone": {
+                    "description": "Example: warm",
+                    "type": "string",
+                    "example": "warm"
+                }
+            }
+        },
+        "models.DiscoveredProbe": {
+            "description": "Датчик на шине 1-wire и его текущее показание.",
+            "type": "object",
+            "properties": {
+                "assigned_to": {
+                    "description": "Датчик реестра, к которому привязано устройство (\"\u003cтеррариум\u003e/\u003cдатчик\u003e\"; пусто — не используется)\nExample: default/basking",
+                    "type": "string",
+                    "example": "default/basking"
+                },
+                "device": {
+                    "description": "ID устройства на шине\nExample: 28-0316a2799cff",
+                    "type": "string",
+                    "example": "28-0316a2799cff"
+                },
+                "driver": {
+                    "description": "Драйвер\nExample: ds18b20",
+                    "type": "string",
+                    "example": "ds18b20"
+                },
+                "error": {
+                    "description": "Ошибка опроса\nExample: \"ds18b20 28-0316a2799cff: ошибка CRC: вычислено 57, получено 58\"",
+                    "type": "string",
+                    "example": "ds18b20 28-0316a2799cff: ошибка CRC: вычислено 57, получено 58"
+                },
+                "temperature": {
+                    "description": "Текущая температура (°C), если датчик ответил\nExample: 23.125",
+                    "type": "number",
+                    "example": 23.125
+                }
+            }
+        },
+        "models.DryRunRelaySummary": {
+            "description": "Сводка симуляции по реле.",
+            "type": "object",
+            "properties": {
+                "actual_duty_pct": {
+                    "description": "Фактическая скважность (%)\nExample: 36.1",
+                    "type": "number",
+                    "example": 36.1
+                },
+                "actual_kwh": {
+                    "description": "Фактическое энергопотребление (кВт⋅ч)\nExample: 0.39",
+                    "type": "number",
+                    "example": 0.39
+                },
+                "actual_on_sec": {
+                    "description": "Фактическое время работы (секунды)\nExample: 31200",
+                    "type": "integer",
+                    "example": 31200
+                },
+                "actual_switches": {
+                    "description": "Фактическое количество переключений по relay_logs\nExample: 22",
+                    "type": "integer",
+                    "example": 22
+                },
+                "relay_id": {
+                    "description": "Example: heat_mat",
+                    "type": "string",
+                    "example": "heat_mat"
+                },
+                "simulated_duty_pct": {
+                    "description": "Скважность в симуляции (% времени во включенном состоянии)\nExample: 33.3",
+                    "type": "number",
+                    "example": 33.3
+                },
+                "simulated_kwh": {
+                    "description": "Оценка энергопотребления в симуляции (кВт⋅ч, по WATTAGE_MAPPING)\nExample: 0.36",
+                    "type": "number",
+                    "example": 0.36
+                },
+                "simulated_on_sec": {
+                    "description": "Время работы в симуляции (секунды)\nExample: 28800",
+                    "type": "integer",
+                    "example": 28800
+                },
+                "simulated_switches": {
+                    "description": "Количество переключений в симуляции\nExample: 14",
+                    "type": "integer",
+                    "example": 14
+                }
+            }
+        },
+        "models.DryRunRequest": {
+            "description": "Кандидатная конфигурация и интервал истории sensor_readings для симуляции (не более 7 суток).",
+            "type": "object",
+            "required": [
+                "config",
+                "from",
+                "to"
+            ],
+            "properties": {
+                "config": {
+                    "description": "Кандидатная конфигурация климата",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ConfigPayload"
+                        }
+                    ]
+                },
+                "from": {
+                    "description": "Начало интервала (RFC3339)\nExample: \"2026-02-25T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-25T00:00:00Z"
+                },
+                "to": {
+                    "description": "Конец интервала (RFC3339)\nExample: \"2026-02-26T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T00:00:00Z"
+                }
+            }
+        },
+        "models.DryRunResult": {
+            "description": "Смоделированные переключения реле, скважность и энергопотребление против фактических.",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "Начало интервала\nExample: \"2026-02-25T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-25T00:00:00Z"
+                },
+                "relays": {
+                    "description": "Сводка по каждому реле",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DryRunRelaySummary"
+                    }
+                },
+                "samples": {
+                    "description": "Количество воспроизведенных показаний\nExample: 17280",
+                    "type": "integer",
+                    "example": 17280
+                },
+                "to": {
+                    "description": "Конец интервала\nExample: \"2026-02-26T00:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T00:00:00Z"
+                },
+                "transitions": {
+                    "description": "Смоделированные переключения в хронологическом порядке",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SimulatedTransition"
+                    }
+                }
+            }
+        },
+        "models.EnclosureInfo": {
+            "description": "Террариум: собственные датчики, реле, конфигурация, расписания и журналы. Маршруты террариума: /api/v1/enclosures/{id}/...",
+            "type": "object",
+            "properties": {
+                "base_path": {
+                    "description": "Префикс маршрутов API террариума\nExample: \"/api/v1/enclosures/default\"",
+                    "type": "string",
+                    "example": "/api/v1/enclosures/default"
+                },
+                "id": {
+                    "description": "Идентификатор террариума (\"default\" — террариум маршрутов /api/v1/... без префикса)\nExample: default",
+                    "type": "string",
+                    "example": "default"
+                },
+                "mode": {
+                    "description": "Текущий режим автоматики (AUTO / MANUAL)\nExample: AUTO",
+                    "type": "string",
+                    "example": "AUTO"
+                },
+                "name": {
+                    "description": "Название террариума\nExample: \"Королевский питон\"",
+                    "type": "string",
+                    "example": "Королевский питон"
+                },
+                "relays": {
+                    "description": "Реле террариума\nExample: [\"fogger\",\"heat_mat\",\"light\",\"spare\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "fogger",
+                        "heat_mat",
+                        "light",
+                        "spare"
+                    ]
+                },
+                "sensors": {
+                    "description": "Датчики террариума\nExample: [\"warm\",\"cold\",\"basking\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "warm",
+                        "cold",
+                        "basking"
+                    ]
+                }
+            }
+        },
+        "models.EnergyReport": {
+            "description": "Общие затраты энергопотребления террариумом (рассчитываются из времени работы и заявленной мощности реле).",
+            "type": "object",
+            "properties": {
+                "fogger_kwh": {
+                    "description": "КВт⋅ч, затраченные генератором тумана\nExample: 0.0300",
+                    "type": "number",
+                    "example": 0.03
+                },
+                "heat_mat_kwh": {
+                    "description": "КВт⋅ч, затраченные нагревателем (Heat Mat)\nExample: 0.1500",
+                    "type": "number",
+                    "example": 0.15
+                },
+                "light_kwh": {
+                    "description": "КВт⋅ч, затраченные освещением\nExample: 0.2400",
+                    "type": "number",
+                    "example": 0.24
+                },
+                "report_date": {
+                    "description": "Дата генерации отчета\nExample: \"2026-02-26\"",
+                    "type": "string",
+                    "example": "2026-02-26"
+                },
+                "spare_kwh": {
+                    "description": "КВт⋅ч, затраченные запасным портом\nExample: 0.0000",
+                    "type": "number",
+                    "example": 0
+                },
+                "total_kwh": {
+                    "description": "Суммарные затраты по всем розеткам (КВт⋅ч)\nExample: 0.4200",
+                    "type": "number",
+                    "example": 0.42
+                }
+            }
+        },
+        "models.EngineStatus": {
+            "description": "Последний цикл движка. Цикл выполняется каждые 5 секунд; если он не завершался дольше 15 секунд, движок считается зависшим.",
+            "type": "object",
             "properties": {
-                "cold_max_threshold": {
-                    "description": "Максимально допустимая температура в холодной зоне (°C). Если превышена, обогрев принудительно отключается.\nExample: 26.5",
-                    "type": "number",
-                    "maximum": 35,
-                    "minimum": 20,
-                    "example": 26.5
+                "last_cycle_at": {
+                    "description": "Время начала последнего завершенного цикла\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
                 },
-                "emergency_max_threshold": {
-                    "description": "Температурный порог теплой зоны (°C), при котором система аварийно отключает всё и шлёт Alert в Telegram.\nExample: 35.0",
+                "last_cycle_ms": {
+                    "description": "Длительность последнего цикла (мс)\nExample: 215.4",
                     "type": "number",
-                    "maximum": 45,
+                    "example": 215.4
+                },
+                "last_skipped": {
+                    "description": "Причина прерывания последнего цикла (SENSOR_ERROR, CONFIG_ERROR)\nExample: \"\"",
+                    "type": "string",
+                    "example": ""
+                },
+                "running": {
+                    "description": "Запущен ли движок\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "stale": {
+                    "description": "Движок завис: цикл давно не завершался\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.FaultInfo": {
+            "description": "Активная неисправность, выявленная диагностикой.",
+            "type": "object",
+            "properties": {
+                "fault": {
+                    "description": "Тип неисправности (HEATER_NOT_HEATING, TEMP_RISING_WHILE_OFF, SENSOR_FROZEN)\nExample: HEATER_NOT_HEATING",
+                    "type": "string",
+                    "example": "HEATER_NOT_HEATING"
+                },
+                "message": {
+                    "description": "Подробности\nExample: \"нагрев включен 20m0s, прирост 0.05°C при ожидаемых ≥0.30°C\"",
+                    "type": "string",
+                    "example": "нагрев включен 20m0s, прирост 0.05°C при ожидаемых ≥0.30°C"
+                },
+                "since": {
+                    "description": "Время обнаружения\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
+                }
+            }
+        },
+        "models.HTTPError": {
+            "description": "Стандартный формат возвращаемой ошибки при нештатных или невалидных запросах",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "HTTP код статуса ошибки\nExample: 400",
+                    "type": "integer",
+                    "example": 400
+                },
+                "message": {
+                    "description": "Текстовое описание ошибки или причина отказа\nExample: \"Invalid request payload\"",
+                    "type": "string",
+                    "example": "Параметры выходят за допустимые пределы"
+                }
+            }
+        },
+        "models.HealthCheck": {
+            "description": "Проверка готовности сервиса.",
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Подробности\nExample: ping 1.8 мс",
+                    "type": "string",
+                    "example": "ping 1.8 мс"
+                },
+                "name": {
+                    "description": "Что проверялось (\"database\", \"engine:default\", ...)\nExample: database",
+                    "type": "string",
+                    "example": "database"
+                },
+                "ok": {
+                    "description": "Пройдена ли проверка\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.HealthStatus": {
+            "description": "Живость (liveness) или готовность (readiness) сервиса.",
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Результаты проверок",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HealthCheck"
+                    }
+                },
+                "status": {
+                    "description": "ok или fail\nExample: ok",
+                    "type": "string",
+                    "example": "ok"
+                },
+                "uptime": {
+                    "description": "Время работы сервиса (в секундах)\nExample: 3600",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "version": {
+                    "description": "Версия сборки\nExample: 1.4.0",
+                    "type": "string",
+                    "example": "1.4.0"
+                }
+            }
+        },
+        "models.InputEvent": {
+            "description": "Открытие/закрытие крышки, включение или сброс аварийного стопа.",
+            "type": "object",
+            "properties": {
+                "event": {
+                    "description": "Событие: OPEN, CLOSE, PANIC_STOP или PANIC_RESET\nExample: OPEN",
+                    "type": "string",
+                    "example": "OPEN"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор события (UUID)\nExample: \"d4e5f6a7-b8c9-0123-def0-234567890123\"",
+                    "type": "string",
+                    "example": "d4e5f6a7-b8c9-0123-def0-234567890123"
+                },
+                "input_id": {
+                    "description": "Вход-источник (ID входа или \"api\" для команд через API)\nExample: lid",
+                    "type": "string",
+                    "example": "lid"
+                },
+                "occurred_at": {
+                    "description": "Время события\nExample: \"2026-02-26T14:02:11Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:02:11Z"
+                }
+            }
+        },
+        "models.InputStatus": {
+            "description": "Состояние цифрового входа после подавления дребезга.",
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Активен ли вход (крышка открыта, кнопка нажата)\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "error": {
+                    "description": "Ошибка чтения входа (если есть)\nExample: \"\"",
+                    "type": "string",
+                    "example": ""
+                },
+                "id": {
+                    "description": "Идентификатор входа\nExample: lid",
+                    "type": "string",
+                    "example": "lid"
+                },
+                "role": {
+                    "description": "Назначение: lid (крышка — пока открыта, фоггер на паузе) или panic (кнопка аварийного стопа)\nExample: lid",
+                    "type": "string",
+                    "example": "lid"
+                },
+                "since": {
+                    "description": "С какого момента вход в текущем состоянии\nExample: \"2026-02-26T14:02:11Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:02:11Z"
+                }
+            }
+        },
+        "models.LogEntry": {
+            "description": "Структурированная запись журнала (slog) с уровнем, компонентом и полями.",
+            "type": "object",
+            "properties": {
+                "component": {
+                    "description": "Компонент (engine, sensors, gpio, storage, ...)\nExample: sensors",
+                    "type": "string",
+                    "example": "sensors"
+                },
+                "enclosure": {
+                    "description": "Террариум, к которому относится запись\nExample: default",
+                    "type": "string",
+                    "example": "default"
+                },
+                "fields": {
+                    "description": "Поля записи (sensor, relay, err, ...)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "Порядковый номер записи с момента запуска (для продолжения хвоста: after_id)\nExample: 10542",
+                    "type": "integer",
+                    "example": 10542
+                },
+                "level": {
+                    "description": "Уровень: DEBUG, INFO, WARN, ERROR\nExample: WARN",
+                    "type": "string",
+                    "example": "WARN"
+                },
+                "message": {
+                    "description": "Сообщение\nExample: Ошибка опроса датчика",
+                    "type": "string",
+                    "example": "Ошибка опроса датчика"
+                },
+                "time": {
+                    "description": "Время записи\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
+                }
+            }
+        },
+        "models.MistSettings": {
+            "description": "В режиме misting фоггер включается короткими импульсами с паузой между ними и суточным лимитом вместо термостатного управления.",
+            "type": "object",
+            "required": [
+                "cooldown_sec",
+                "daily_max_min",
+                "pulse_sec"
+            ],
+            "properties": {
+                "cooldown_sec": {
+                    "description": "Минимальная пауза между импульсами по влажности (секунды)\nExample: 900",
+                    "type": "integer",
+                    "maximum": 86400,
                     "minimum": 30,
-                    "example": 35
+                    "example": 900
                 },
-                "humidity_max": {
-                    "description": "Максимальная влажность (%), при достижении которой фоггер отключается.\nExample: 65.0",
-                    "type": "number",
-                    "maximum": 100,
-                    "minimum": 0,
-                    "example": 65
+                "daily_max_min": {
+                    "description": "Суточный лимит суммарной работы фоггера (минуты)\nExample: 20",
+                    "type": "integer",
+                    "maximum": 720,
+                    "minimum": 1,
+                    "example": 20
                 },
-                "humidity_min": {
-                    "description": "Минимальная влажность (%), при которой включается фоггер (генератор тумана).\nExample: 50.0",
-                    "type": "number",
-                    "maximum": 100,
-                    "minimum": 0,
-                    "example": 50
+                "enabled": {
+                    "description": "Включен ли импульсный режим (false = классический гистерезис по влажности)\nExample: true",
+                    "type": "boolean",
+                    "example": true
                 },
-                "hysteresis_hum": {
-                    "description": "Гистерезис влажности (%), для предотвращения частого срабатывания фоггера.\nExample: 2.0",
-                    "type": "number",
-                    "maximum": 10,
-                    "minimum": 0.5,
-                    "example": 2
+                "pulse_sec": {
+                    "description": "Длительность одного импульса (секунды)\nExample: 30",
+                    "type": "integer",
+                    "maximum": 600,
+                    "minimum": 10,
+                    "example": 30
+                }
+            }
+        },
+        "models.MistStatus": {
+            "description": "Состояние импульсов тумана и расход суточного лимита.",
+            "type": "object",
+            "properties": {
+                "daily_budget_sec": {
+                    "description": "Суточный лимит работы фоггера (секунды)\nExample: 1200",
+                    "type": "integer",
+                    "example": 1200
                 },
-                "hysteresis_temp": {
-                    "description": "Температурный гистерезис (°C), чтобы избежать \"дребезга\" реле около целевого значения.\nExample: 0.5",
-                    "type": "number",
-                    "maximum": 5,
-                    "minimum": 0.1,
-                    "example": 0.5
+                "enabled": {
+                    "description": "Включен ли импульсный режим\nExample: true",
+                    "type": "boolean",
+                    "example": true
                 },
-                "warm_target_max": {
-                    "description": "Максимальная целевая температура в теплой зоне (°C), при достижении которой обогрев отключается.\nОграничения: от WarmTargetMin до 40.0.\nExample: 33.0",
-                    "type": "number",
-                    "maximum": 40,
-                    "minimum": 20,
-                    "example": 33
+                "last_pulse_at": {
+                    "description": "Время окончания последнего импульса\nExample: \"2026-02-26T15:00:30Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:00:30Z"
+                },
+                "pulse_active": {
+                    "description": "Идет ли импульс прямо сейчас\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "pulse_ends_at": {
+                    "description": "Время окончания текущего импульса (если идет)\nExample: \"2026-02-26T15:30:30Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:30Z"
+                },
+                "used_today_sec": {
+                    "description": "Израсходовано работы фоггера за сутки (секунды)\nExample: 360",
+                    "type": "integer",
+                    "example": 360
+                }
+            }
+        },
+        "models.ModeRequest": {
+            "description": "Запрос для переключения между АВТОМАТИЧЕСКОЙ и РУЧНОЙ работой механизмов.",
+            "type": "object",
+            "required": [
+                "mode"
+            ],
+            "properties": {
+                "duration_min": {
+                    "description": "Срок аренды режима MANUAL в минутах (1..1440). По истечении система сама вернется в AUTO.\nЕсли не указан, MANUAL действует до ручного переключения.\nExample: 30",
+                    "type": "integer",
+                    "maximum": 1440,
+                    "minimum": 1,
+                    "example": 30
+                },
+                "mode": {
+                    "description": "Целевой режим работы системы. Допускается 'AUTO' (автоматика) или 'MANUAL' (ручное управление).\nExample: MANUAL",
+                    "type": "string",
+                    "enum": [
+                        "AUTO",
+                        "MANUAL"
+                    ],
+                    "example": "MANUAL"
+                }
+            }
+        },
+        "models.PanicStopRequest": {
+            "description": "Payload аварийного стопа (то же, что нажатие физической кнопки).",
+            "type": "object",
+            "required": [
+                "active"
+            ],
+            "properties": {
+                "active": {
+                    "description": "true — выключить все реле и остановить автоматику, false — сбросить стоп\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.RainEvent": {
+            "description": "Запланированное событие дождя (принудительный импульс фоггера по времени).",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Дата создания записи\nExample: \"2026-02-26T12:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:00:00Z"
+                },
+                "duration_sec": {
+                    "description": "Длительность дождя (секунды)\nExample: 120",
+                    "type": "integer",
+                    "example": 120
+                },
+                "id": {
+                    "description": "Уникальный идентификатор события (UUID)\nExample: \"b2c3d4e5-f6a7-8901-bcde-f12345678901\"",
+                    "type": "string",
+                    "example": "b2c3d4e5-f6a7-8901-bcde-f12345678901"
+                },
+                "is_active": {
+                    "description": "Активно ли событие\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "start_time": {
+                    "description": "Время начала дождя (формат HH:MM)\nExample: \"07:30\"",
+                    "type": "string",
+                    "example": "07:30"
+                }
+            }
+        },
+        "models.RainEventRequest": {
+            "description": "Payload для создания/обновления запланированного дождя.",
+            "type": "object",
+            "required": [
+                "duration_sec",
+                "start_time"
+            ],
+            "properties": {
+                "duration_sec": {
+                    "description": "Длительность дождя (секунды, от 10 до 1800)\nExample: 120",
+                    "type": "integer",
+                    "maximum": 1800,
+                    "minimum": 10,
+                    "example": 120
+                },
+                "is_active": {
+                    "description": "Активно ли событие (по умолчанию true)\nExample: true",
+                    "type": "boolean",
+                    "example": true
                 },
-                "warm_target_min": {
-                    "description": "Минимальная целевая температура в теплой зоне (°C), при которой включается обогрев.\nОграничения: от 20.0 до 40.0.\nExample: 31.5",
-                    "type": "number",
-                    "maximum": 40,
-                    "minimum": 20,
-                    "example": 31.5
+                "start_time": {
+                    "description": "Время начала дождя (формат HH:MM)\nExample: \"07:30\"",
+                    "type": "string",
+                    "example": "07:30"
                 }
             }
         },
-        "models.EnergyReport": {
-            "description": "Общие затраты энергопотребления террариумом (рассчитываются из времени работы и заявленной мощности реле).",
+        "models.RelayAction": {
+            "description": "Команда реле и результат ее выполнения.",
             "type": "object",
             "properties": {
-                "fogger_kwh": {
-                    "description": "КВт⋅ч, затраченные генератором тумана\nExample: 0.0300",
-                    "type": "number",
-                    "example": 0.03
+                "applied": {
+                    "description": "Выполнена ли команда (false — отклонена защитой реле или аппаратной ошибкой)\nExample: true",
+                    "type": "boolean",
+                    "example": true
                 },
-                "heat_mat_kwh": {
-                    "description": "КВт⋅ч, затраченные нагревателем (Heat Mat)\nExample: 0.1500",
-                    "type": "number",
-                    "example": 0.15
+                "at": {
+                    "description": "Время команды\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
                 },
-                "light_kwh": {
-                    "description": "КВт⋅ч, затраченные освещением\nExample: 0.2400",
+                "brightness": {
+                    "description": "Запрошенная яркость диммируемого выхода, %\nExample: 42.5",
                     "type": "number",
-                    "example": 0.24
+                    "example": 42.5
                 },
-                "report_date": {
-                    "description": "Дата генерации отчета\nExample: \"2026-02-26\"",
+                "error": {
+                    "description": "Ошибка выполнения (если команда не выполнена)\nExample: \"\"",
                     "type": "string",
-                    "example": "2026-02-26"
+                    "example": ""
                 },
-                "spare_kwh": {
-                    "description": "КВт⋅ч, затраченные запасным портом\nExample: 0.0000",
-                    "type": "number",
-                    "example": 0
+                "reason": {
+                    "description": "Причина (как в relay_logs)\nExample: AUTO_TEMP_TRIGGER",
+                    "type": "string",
+                    "example": "AUTO_TEMP_TRIGGER"
                 },
-                "total_kwh": {
-                    "description": "Суммарные затраты по всем розеткам (КВт⋅ч)\nExample: 0.4200",
-                    "type": "number",
-                    "example": 0.42
+                "relay": {
+                    "description": "ID реле\nExample: heat_mat",
+                    "type": "string",
+                    "example": "heat_mat"
+                },
+                "state": {
+                    "description": "Запрошенное состояние\nExample: true",
+                    "type": "boolean",
+                    "example": true
                 }
             }
         },
-        "models.HTTPError": {
-            "description": "Стандартный формат возвращаемой ошибки при нештатных или невалидных запросах",
+        "models.RelayExplanation": {
+            "description": "Объяснение состояния реле на основе трассировки решений движка.",
             "type": "object",
             "properties": {
-                "code": {
-                    "description": "HTTP код статуса ошибки\nExample: 400",
+                "current_rules": {
+                    "description": "Оценка правил для реле в последнем цикле",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RuleEvaluation"
+                    }
+                },
+                "last_change": {
+                    "description": "Последнее переключение реле",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RelayAction"
+                        }
+                    ]
+                },
+                "last_change_rules": {
+                    "description": "Правила, которые привели к последнему переключению",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RuleEvaluation"
+                    }
+                },
+                "mode": {
+                    "description": "Режим системы\nExample: AUTO",
+                    "type": "string",
+                    "example": "AUTO"
+                },
+                "on_for_sec": {
+                    "description": "Время непрерывной работы (секунды, если реле включено и известно)\nExample: 320",
                     "type": "integer",
-                    "example": 400
+                    "example": 320
                 },
-                "message": {
-                    "description": "Текстовое описание ошибки или причина отказа\nExample: \"Invalid request payload\"",
+                "override": {
+                    "description": "Активное ручное переопределение (если есть)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RelayOverride"
+                        }
+                    ]
+                },
+                "relay_id": {
+                    "description": "ID реле\nExample: heat_mat",
                     "type": "string",
-                    "example": "Параметры выходят за допустимые пределы"
+                    "example": "heat_mat"
+                },
+                "state": {
+                    "description": "Текущее состояние реле\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "summary": {
+                    "description": "Итоговое объяснение\nExample: \"Реле heat_mat ВКЛЮЧЕНО. Включено в 15:03:10 (AUTO_TEMP_TRIGGER): warm_temp 30.2 \u003c= 30.5.\"",
+                    "type": "string",
+                    "example": "Реле heat_mat ВКЛЮЧЕНО. Включено в 15:03:10 (AUTO_TEMP_TRIGGER): warm_temp 30.2 \u003c= 30.5."
                 }
             }
         },
-        "models.ModeRequest": {
-            "description": "Запрос для переключения между АВТОМАТИЧЕСКОЙ и РУЧНОЙ работой механизмов.",
+        "models.RelayHealth": {
+            "description": "Состояние драйвера реле по данным сверки желаемого и фактического состояния.",
             "type": "object",
-            "required": [
-                "mode"
-            ],
             "properties": {
-                "mode": {
-                    "description": "Целевой режим работы системы. Допускается 'AUTO' (автоматика) или 'MANUAL' (ручное управление).\nExample: MANUAL",
+                "driver": {
+                    "description": "Драйвер реле (gpio, pwm, shelly, shelly_rpc, shelly_dimmer, tasmota, tasmota_dimmer, mqtt)\nExample: shelly",
                     "type": "string",
-                    "enum": [
-                        "AUTO",
-                        "MANUAL"
-                    ],
-                    "example": "MANUAL"
+                    "example": "shelly"
+                },
+                "id": {
+                    "description": "ID реле\nExample: light",
+                    "type": "string",
+                    "example": "light"
+                },
+                "last_error": {
+                    "description": "Последняя ошибка чтения или переключения реле\nExample: реле light недоступно: connection refused",
+                    "type": "string",
+                    "example": "реле light недоступно: connection refused"
+                },
+                "last_error_at": {
+                    "description": "Время последней ошибки\nExample: \"2026-02-26T13:29:30Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T13:29:30Z"
+                },
+                "status": {
+                    "description": "OK, DESYNC (фактическое состояние расходится с желаемым) или UNREACHABLE (состояние не читается)\nExample: OK",
+                    "type": "string",
+                    "example": "OK"
                 }
             }
         },
@@ -709,6 +4066,11 @@ const docTemplate = `{
             "description": "Запись журнала переключений реле с причиной и временной меткой.",
             "type": "object",
             "properties": {
+                "brightness": {
+                    "description": "Яркость диммируемого выхода после события, % (только для диммеров)\nExample: 80",
+                    "type": "number",
+                    "example": 80
+                },
                 "id": {
                     "description": "Уникальный идентификатор записи (UUID)\nExample: \"f1e2d3c4-b5a6-7890-cdef-1234567890ab\"",
                     "type": "string",
@@ -736,40 +4098,353 @@ const docTemplate = `{
                 }
             }
         },
+        "models.RelayOverride": {
+            "description": "Активное переопределение: пока оно действует, автоматика не управляет реле.",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Время создания\nExample: \"2026-02-26T15:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:00:00Z"
+                },
+                "expires_at": {
+                    "description": "Время окончания, после которого автоматика возобновит управление\nExample: \"2026-02-26T15:10:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:10:00Z"
+                },
+                "prev_state": {
+                    "description": "Состояние реле до переопределения (восстанавливается по окончании)\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "relay_id": {
+                    "description": "ID реле\nExample: \"fogger\"",
+                    "type": "string",
+                    "example": "fogger"
+                },
+                "remaining_sec": {
+                    "description": "Осталось секунд до окончания\nExample: 540",
+                    "type": "integer",
+                    "example": 540
+                },
+                "state": {
+                    "description": "Удерживаемое состояние реле\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.RelayOverrideRequest": {
+            "description": "Временное переопределение реле (\"туман на 10 минут\", \"свет выключен до 18:00\").",
+            "type": "object",
+            "properties": {
+                "duration_min": {
+                    "description": "Длительность переопределения в минутах (1..1440)\nExample: 10",
+                    "type": "integer",
+                    "maximum": 1440,
+                    "minimum": 1,
+                    "example": 10
+                },
+                "state": {
+                    "description": "Желаемое состояние реле на время переопределения\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "until": {
+                    "description": "Время окончания переопределения (формат HH:MM, ближайшее наступление)\nExample: \"18:00\"",
+                    "type": "string",
+                    "example": "18:00"
+                }
+            }
+        },
         "models.RelayState": {
             "description": "Фактическое (электрическое) состояние исполнительных устройств.",
             "type": "object",
             "properties": {
+                "brightness": {
+                    "description": "Яркость диммируемых выходов, % (ID реле -\u003e яркость; обычные реле не включаются)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
                 "fogger": {
                     "description": "Состояние реле генератора тумана\nExample: false",
                     "type": "boolean",
                     "example": false
                 },
-                "heat_mat": {
-                    "description": "Состояние реле термоковрика (true = ВКЛ, false = ВЫКЛ)\nExample: true",
-                    "type": "boolean",
-                    "example": true
+                "heat_mat": {
+                    "description": "Состояние реле термоковрика (true = ВКЛ, false = ВЫКЛ)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "light": {
+                    "description": "Состояние освещения террариума\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "spare": {
+                    "description": "Состояние запасной розетки\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "sync": {
+                    "description": "Сверка желаемого и фактического состояния каждого реле (ID реле -\u003e сверка)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.RelaySync"
+                    }
+                }
+            }
+        },
+        "models.RelaySync": {
+            "description": "Желаемое и фактическое состояние реле. Движок сверяет их каждые 30 секунд и восстанавливает желаемое при расхождении.",
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "description": "Фактическое состояние при последней сверке (отсутствует, если прочитать не удалось или сверки еще не было)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "checked_at": {
+                    "description": "Время последней сверки\nExample: \"2026-02-26T13:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T13:30:00Z"
+                },
+                "desired": {
+                    "description": "Желаемое состояние (последняя подтвержденная команда)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "desyncs": {
+                    "description": "Сколько раз с момента запуска обнаруживалось расхождение\nExample: 0",
+                    "type": "integer",
+                    "example": 0
+                },
+                "in_sync": {
+                    "description": "Совпадало ли фактическое состояние с желаемым при последней сверке\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "last_error": {
+                    "description": "Последняя ошибка чтения или переключения реле\nExample: реле light недоступно: connection refused",
+                    "type": "string",
+                    "example": "реле light недоступно: connection refused"
+                },
+                "last_error_at": {
+                    "description": "Время последней ошибки\nExample: \"2026-02-26T13:29:30Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T13:29:30Z"
+                }
+            }
+        },
+        "models.RelayToggleRequest": {
+            "description": "Запрос на принудительное переключение состояния реле",
+            "type": "object",
+            "properties": {
+                "brightness": {
+                    "description": "Яркость диммируемого выхода при включении, % (по умолчанию — последняя ненулевая)\nExample: 60",
+                    "type": "number",
+                    "maximum": 100,
+                    "example": 60
+                },
+                "state": {
+                    "description": "Желаемое состояние реле (true для включения, false для выключения)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.ReservoirEvent": {
+            "description": "Опустошение или пополнение резервуара фоггера (по фронту поплавкового выключателя).",
+            "type": "object",
+            "properties": {
+                "empty_sec": {
+                    "description": "Сколько резервуар простоял пустым до пополнения (секунды, только для REFILL)\nExample: 41100",
+                    "type": "integer",
+                    "example": 41100
+                },
+                "event": {
+                    "description": "Событие: EMPTY (резервуар опустел) или REFILL (пополнен)\nExample: REFILL",
+                    "type": "string",
+                    "example": "REFILL"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор события (UUID)\nExample: \"c3d4e5f6-a7b8-9012-cdef-123456789012\"",
+                    "type": "string",
+                    "example": "c3d4e5f6-a7b8-9012-cdef-123456789012"
+                },
+                "occurred_at": {
+                    "description": "Время события\nExample: \"2026-02-26T09:15:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T09:15:00Z"
+                }
+            }
+        },
+        "models.ReservoirStatus": {
+            "description": "Состояние резервуара фоггера. При пустом резервуаре (или недоступном датчике) фоггер заблокирован в любом режиме.",
+            "type": "object",
+            "properties": {
+                "configured": {
+                    "description": "Установлен ли поплавковый выключатель\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "error": {
+                    "description": "Ошибка чтения датчика (если есть)\nExample: \"\"",
+                    "type": "string",
+                    "example": ""
+                },
+                "fogger_blocked": {
+                    "description": "Заблокировано ли включение фоггера\nExample: false",
+                    "type": "boolean",
+                    "example": false
+                },
+                "last_empty_at": {
+                    "description": "Когда резервуар последний раз опустел\nExample: \"2026-02-25T21:40:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-25T21:40:00Z"
+                },
+                "last_refill_at": {
+                    "description": "Когда резервуар последний раз пополнили\nExample: \"2026-02-26T09:15:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T09:15:00Z"
+                },
+                "level": {
+                    "description": "Уровень: OK, EMPTY или UNKNOWN (датчик не отвечает)\nExample: OK",
+                    "type": "string",
+                    "example": "OK"
+                },
+                "since": {
+                    "description": "С какого момента резервуар в текущем состоянии\nExample: \"2026-02-26T09:15:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T09:15:00Z"
+                }
+            }
+        },
+        "models.RuleAction": {
+            "description": "Действие правила: перевести реле в заданное состояние.",
+            "type": "object",
+            "required": [
+                "relay"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Причина для журнала relay_logs (по умолчанию RULE:\u003cимя правила\u003e)\nExample: AUTO_TEMP_TRIGGER",
+                    "type": "string",
+                    "example": "AUTO_TEMP_TRIGGER"
+                },
+                "relay": {
+                    "description": "ID реле (\"*\" — все реле, только для правил безопасности)\nExample: heat_mat",
+                    "type": "string",
+                    "example": "heat_mat"
+                },
+                "state": {
+                    "description": "Требуемое состояние реле\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.RuleCondition": {
+            "description": "Условие правила автоматизации.",
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "for_sec": {
+                    "description": "Минимальное время в этом состоянии (секунды) — для relay\nExample: 600",
+                    "type": "integer",
+                    "example": 600
+                },
+                "from": {
+                    "description": "Начало окна (HH:MM) — для time\nExample: \"08:00\"",
+                    "type": "string",
+                    "example": "08:00"
+                },
+                "input": {
+                    "description": "Показание датчика — для sensor: \"\u003cдатчик\u003e.\u003cвеличина\u003e\" (например basking.temperature)\nили показание зоны \"\u003cзона\u003e_temp\" / \"\u003cзона\u003e_hum\" (warm_temp, cold_hum, ...)\nExample: warm_temp",
+                    "type": "string",
+                    "example": "warm_temp"
+                },
+                "op": {
+                    "description": "Оператор сравнения (\u003c, \u003c=, \u003e, \u003e=) — для sensor\nExample: \"\u003c=\"",
+                    "type": "string",
+                    "enum": [
+                        "\u003c",
+                        "\u003c=",
+                        "\u003e",
+                        "\u003e="
+                    ],
+                    "example": "\u003c="
+                },
+                "ref": {
+                    "description": "Порог из конфигурации — для sensor (взаимоисключающе с value)\nExample: \"warm_target_min-hysteresis_temp\"",
+                    "type": "string",
+                    "example": "warm_target_min-hysteresis_temp"
+                },
+                "relay": {
+                    "description": "ID реле — для relay\nExample: light",
+                    "type": "string",
+                    "example": "light"
                 },
-                "light": {
-                    "description": "Состояние освещения террариума\nExample: true",
+                "state": {
+                    "description": "Ожидаемое состояние реле — для relay\nExample: true",
                     "type": "boolean",
                     "example": true
                 },
-                "spare": {
-                    "description": "Состояние запасной розетки\nExample: false",
-                    "type": "boolean",
-                    "example": false
+                "to": {
+                    "description": "Конец окна (HH:MM, не включительно) — для time\nExample: \"20:00\"",
+                    "type": "string",
+                    "example": "20:00"
+                },
+                "type": {
+                    "description": "Тип условия\nExample: sensor",
+                    "type": "string",
+                    "enum": [
+                        "sensor",
+                        "time",
+                        "relay"
+                    ],
+                    "example": "sensor"
+                },
+                "value": {
+                    "description": "Порог-число — для sensor (взаимоисключающе с ref)\nExample: 30.5",
+                    "type": "number",
+                    "example": 30.5
                 }
             }
         },
-        "models.RelayToggleRequest": {
-            "description": "Запрос на принудительное переключение состояния реле",
+        "models.RuleEvaluation": {
+            "description": "Оценка правила: сработало ли оно, почему, и какое решение приняло.",
             "type": "object",
             "properties": {
-                "state": {
-                    "description": "Желаемое состояние реле (true для включения, false для выключения)\nExample: true",
+                "decision": {
+                    "description": "Решение правила: ON, OFF, HOLD (оставить как есть) или SKIP (правило не применялось)\nExample: ON",
+                    "type": "string",
+                    "example": "ON"
+                },
+                "detail": {
+                    "description": "Человекочитаемое объяснение с фактическими значениями\nExample: \"warm_temp 30.2 \u003c= 30.5 (warm_target_min-hysteresis_temp)\"",
+                    "type": "string",
+                    "example": "warm_temp 30.2 \u003c= 30.5 (warm_target_min-hysteresis_temp)"
+                },
+                "matched": {
+                    "description": "Выполнилось ли условие правила\nExample: true",
                     "type": "boolean",
                     "example": true
+                },
+                "relay": {
+                    "description": "Реле, к которому относится правило (\"*\" — ко всем)\nExample: heat_mat",
+                    "type": "string",
+                    "example": "heat_mat"
+                },
+                "rule": {
+                    "description": "Имя правила автоматизации (EMERGENCY_CUTOFF, HEAT_HYSTERESIS_ON, пользовательские) или служебного контура (MIST_PULSE, OVERRIDE, MAX_ON_TIME, ...)\nExample: HEAT_HYSTERESIS_ON",
+                    "type": "string",
+                    "example": "HEAT_HYSTERESIS_ON"
                 }
             }
         },
@@ -777,6 +4452,11 @@ const docTemplate = `{
             "description": "Расписание автоматического управления реле по времени суток.",
             "type": "object",
             "properties": {
+                "brightness": {
+                    "description": "Яркость диммируемого выхода, %\nExample: 80",
+                    "type": "number",
+                    "example": 80
+                },
                 "created_at": {
                     "description": "Дата создания записи\nExample: \"2026-02-26T12:00:00Z\"",
                     "type": "string",
@@ -797,6 +4477,11 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": true
                 },
+                "ramp_min": {
+                    "description": "Длительность рассвета после start_time и заката перед end_time, мин (0 — без плавного перехода)\nExample: 30",
+                    "type": "integer",
+                    "example": 30
+                },
                 "relay_id": {
                     "description": "ID реле, к которому привязано расписание\nExample: \"light\"",
                     "type": "string",
@@ -818,6 +4503,12 @@ const docTemplate = `{
                 "start_time"
             ],
             "properties": {
+                "brightness": {
+                    "description": "Яркость диммируемого выхода, % (по умолчанию 100)\nExample: 80",
+                    "type": "number",
+                    "maximum": 100,
+                    "example": 80
+                },
                 "end_time": {
                     "description": "Время выключения (формат HH:MM)\nExample: \"20:00\"",
                     "type": "string",
@@ -828,6 +4519,13 @@ const docTemplate = `{
                     "type": "boolean",
                     "example": true
                 },
+                "ramp_min": {
+                    "description": "Длительность рассвета и заката, мин (по умолчанию 0, не более 720)\nExample: 30",
+                    "type": "integer",
+                    "maximum": 720,
+                    "minimum": 0,
+                    "example": 30
+                },
                 "relay_id": {
                     "description": "ID реле (heat_mat, fogger, light, spare)\nExample: \"light\"",
                     "type": "string",
@@ -907,6 +4605,245 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SensorHealth": {
+            "description": "Последний успешный опрос датчика и ошибка последнего опроса.",
+            "type": "object",
+            "properties": {
+                "driver": {
+                    "description": "Драйвер датчика\nExample: dht22",
+                    "type": "string",
+                    "example": "dht22"
+                },
+                "error": {
+                    "description": "Ошибка последнего опроса (пусто — датчик отвечает)\nExample: \"\"",
+                    "type": "string",
+                    "example": ""
+                },
+                "id": {
+                    "description": "ID датчика\nExample: warm",
+                    "type": "string",
+                    "example": "warm"
+                },
+                "last_success_at": {
+                    "description": "Время последнего успешного опроса (отсутствует, если датчик еще ни разу не ответил)\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
+                }
+            }
+        },
+        "models.SensorInfo": {
+            "description": "Датчик террариума: ID, зона и измеряемые величины.",
+            "type": "object",
+            "properties": {
+                "device": {
+                    "description": "Адрес устройства на шине (ID 1-wire для ds18b20, \"\u003cшина\u003e@\u003cадрес\u003e\" для датчиков I2C)\nExample: 28-0316a2799cff",
+                    "type": "string",
+                    "example": "28-0316a2799cff"
+                },
+                "driver": {
+                    "description": "Драйвер датчика (dht22, ds18b20, bme280, bmp280, sht3x)\nExample: dht22",
+                    "type": "string",
+                    "example": "dht22"
+                },
+                "id": {
+                    "description": "Уникальный (в пределах террариума) ID датчика\nExample: basking",
+                    "type": "string",
+                    "example": "basking"
+                },
+                "name": {
+                    "description": "Отображаемое имя\nExample: \"Точка прогрева\"",
+                    "type": "string",
+                    "example": "Точка прогрева"
+                },
+                "quantities": {
+                    "description": "Измеряемые величины (temperature, humidity, pressure)\nExample: [\"temperature\",\"humidity\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "temperature",
+                        "humidity"
+                    ]
+                },
+                "zone": {
+                    "description": "Зона террариума (warm, cold, basking, ...)\nExample: warm",
+                    "type": "string",
+                    "example": "warm"
+                }
+            }
+        },
+        "models.SensorReading": {
+            "description": "Показание датчика в длинном формате.",
+            "type": "object",
+            "properties": {
+                "quantity": {
+                    "description": "Величина\nExample: temperature",
+                    "type": "string",
+                    "example": "temperature"
+                },
+                "sensor_id": {
+                    "description": "ID датчика\nExample: basking",
+                    "type": "string",
+                    "example": "basking"
+                },
+                "timestamp": {
+                    "description": "Время измерения\nExample: \"2026-02-26T13:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T13:30:00Z"
+                },
+                "value": {
+                    "description": "Значение\nExample: 34.8",
+                    "type": "number",
+                    "example": 34.8
+                }
+            }
+        },
+        "models.SensorReadings": {
+            "description": "Текущие (live) показания всех датчиков террариума.",
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "Текущий режим системы (AUTO / MANUAL)\nExample: AUTO",
+                    "type": "string",
+                    "example": "AUTO"
+                },
+                "sensors": {
+                    "description": "Показания по ID датчика",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.SensorSnapshot"
+                    }
+                },
+                "timestamp": {
+                    "description": "Время последнего цикла опроса\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
+                }
+            }
+        },
+        "models.SensorSnapshot": {
+            "description": "Последние показания датчика из реестра.",
+            "type": "object",
+            "properties": {
+                "device": {
+                    "description": "Адрес устройства на шине (ID 1-wire для ds18b20, \"\u003cшина\u003e@\u003cадрес\u003e\" для датчиков I2C)\nExample: 28-0316a2799cff",
+                    "type": "string",
+                    "example": "28-0316a2799cff"
+                },
+                "driver": {
+                    "description": "Драйвер датчика (dht22, ds18b20, bme280, bmp280, sht3x)\nExample: dht22",
+                    "type": "string",
+                    "example": "dht22"
+                },
+                "error": {
+                    "description": "Ошибка последнего опроса\nExample: \"dht error: checksum did not validate\"",
+                    "type": "string",
+                    "example": "dht error: checksum did not validate"
+                },
+                "id": {
+                    "description": "Уникальный (в пределах террариума) ID датчика\nExample: basking",
+                    "type": "string",
+                    "example": "basking"
+                },
+                "name": {
+                    "description": "Отображаемое имя\nExample: \"Точка прогрева\"",
+                    "type": "string",
+                    "example": "Точка прогрева"
+                },
+                "quantities": {
+                    "description": "Измеряемые величины (temperature, humidity, pressure)\nExample: [\"temperature\",\"humidity\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "temperature",
+                        "humidity"
+                    ]
+                },
+                "read_at": {
+                    "description": "Время последнего успешного опроса\nExample: \"2026-02-26T15:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T15:30:00Z"
+                },
+                "values": {
+                    "description": "Значения по величинам (пусто, если датчик еще не опрашивался или не ответил)\nExample: {\"temperature\": 34.8, \"humidity\": 41.2}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "zone": {
+                    "description": "Зона террариума (warm, cold, basking, ...)\nExample: warm",
+                    "type": "string",
+                    "example": "warm"
+                }
+            }
+        },
+        "models.SimulatedTransition": {
+            "description": "Смоделированное переключение реле и правило, которое его вызвало.",
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "Example: \"2026-02-25T03:15:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-25T03:15:00Z"
+                },
+                "detail": {
+                    "description": "Example: \"warm_temp 30.4 \u003c= 30.5 (warm_target_min-hysteresis_temp)\"",
+                    "type": "string",
+                    "example": "warm_temp 30.4 \u003c= 30.5 (warm_target_min-hysteresis_temp)"
+                },
+                "relay": {
+                    "description": "Example: heat_mat",
+                    "type": "string",
+                    "example": "heat_mat"
+                },
+                "rule": {
+                    "description": "Example: HEAT_HYSTERESIS_ON",
+                    "type": "string",
+                    "example": "HEAT_HYSTERESIS_ON"
+                },
+                "state": {
+                    "description": "Example: true",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.StorageStatus": {
+            "description": "Задержка PostgreSQL и пул соединений. Записи выполняются синхронно, поэтому очередь хранилища — это запросы, занявшие соединения пула.",
+            "type": "object",
+            "properties": {
+                "acquired_conns": {
+                    "description": "Занятые соединения пула (запросы в работе)\nExample: 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "idle_conns": {
+                    "description": "Свободные соединения пула\nExample: 2",
+                    "type": "integer",
+                    "example": 2
+                },
+                "latency_ms": {
+                    "description": "Задержка ping базы данных (мс)\nExample: 1.8",
+                    "type": "number",
+                    "example": 1.8
+                },
+                "max_conns": {
+                    "description": "Максимальный размер пула\nExample: 10",
+                    "type": "integer",
+                    "example": 10
+                },
+                "total_conns": {
+                    "description": "Открытые соединения пула (занятые, свободные и устанавливаемые)\nExample: 3",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
         "models.SystemStatus": {
             "description": "Состояние системы, режим и аптайм",
             "type": "object",
@@ -916,15 +4853,236 @@ const docTemplate = `{
                     "type": "string",
                     "example": "OK"
                 },
+                "engine": {
+                    "description": "Состояние цикла движка автоматизации",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EngineStatus"
+                        }
+                    ]
+                },
+                "health": {
+                    "description": "Диагностика оборудования (нагреватель, датчики)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ComponentHealth"
+                    }
+                },
+                "manual_remaining_sec": {
+                    "description": "Осталось секунд до автоматического возврата в AUTO\nExample: 1200",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "manual_until": {
+                    "description": "Время автоматического возврата из MANUAL в AUTO (если задана аренда)\nExample: \"2026-02-26T16:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T16:00:00Z"
+                },
                 "mode": {
                     "description": "Текущий активный режим автоматизации (AUTO или MANUAL).\nExample: AUTO",
                     "type": "string",
                     "example": "AUTO"
                 },
+                "panic_stop_since": {
+                    "description": "Время включения аварийного стопа (кнопкой или через API), если он активен\nExample: \"2026-02-26T14:05:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:05:00Z"
+                },
+                "relays": {
+                    "description": "Связь с драйвером каждого реле",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RelayHealth"
+                    }
+                },
+                "sensors": {
+                    "description": "Последний успешный опрос каждого датчика",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SensorHealth"
+                    }
+                },
+                "started_at": {
+                    "description": "Время запуска сервиса\nExample: \"2026-02-26T12:30:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:30:00Z"
+                },
+                "storage": {
+                    "description": "Задержка и загрузка пула соединений PostgreSQL (отсутствует, если БД не ответила)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.StorageStatus"
+                        }
+                    ]
+                },
                 "uptime": {
                     "description": "Время работы сервиса с момента старта (в секундах).\nExample: 3600",
                     "type": "integer",
                     "example": 3600
+                },
+                "version": {
+                    "description": "Версия сборки\nExample: 1.4.0",
+                    "type": "string",
+                    "example": "1.4.0"
+                }
+            }
+        },
+        "models.Webhook": {
+            "description": "Исходящий webhook: URL, типы событий и секрет подписи HMAC-SHA256.",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Example: \"2026-02-26T12:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:00:00Z"
+                },
+                "description": {
+                    "description": "Описание подписки\nExample: \"Home Assistant\"",
+                    "type": "string",
+                    "example": "Home Assistant"
+                },
+                "events": {
+                    "description": "Типы событий (\"*\" — все): relay.switched, mode.changed, alert.fired, alert.resolved, alert.acknowledged\nExample: [\"relay.switched\",\"alert.fired\"]",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "relay.switched",
+                        "alert.fired"
+                    ]
+                },
+                "id": {
+                    "description": "Идентификатор подписки (UUID)\nExample: \"3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4\"",
+                    "type": "string",
+                    "example": "3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4"
+                },
+                "is_active": {
+                    "description": "Активна ли подписка (неактивной события не ставятся в очередь)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "secret": {
+                    "description": "Секрет подписи (возвращается только при создании)\nExample: \"4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a\"",
+                    "type": "string",
+                    "example": "4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a"
+                },
+                "updated_at": {
+                    "description": "Example: \"2026-02-26T12:00:00Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T12:00:00Z"
+                },
+                "url": {
+                    "description": "URL получателя (http или https)\nExample: \"http://192.168.0.20:9000/terrarium\"",
+                    "type": "string",
+                    "example": "http://192.168.0.20:9000/terrarium"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "description": "Доставка webhook: состояние, число попыток и результат последней попытки.",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Сделано попыток\nExample: 2",
+                    "type": "integer",
+                    "example": 2
+                },
+                "created_at": {
+                    "description": "Example: \"2026-02-26T14:02:11Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:02:11Z"
+                },
+                "delivered_at": {
+                    "description": "Когда доставлено (null — не доставлено)\nExample: \"2026-02-26T14:02:12Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:02:12Z"
+                },
+                "event": {
+                    "description": "Тип события\nExample: relay.switched",
+                    "type": "string",
+                    "example": "relay.switched"
+                },
+                "id": {
+                    "description": "Идентификатор доставки (UUID, заголовок X-Terrarium-Delivery)\nExample: \"7e6d5c4b-3a29-4180-9f8e-7d6c5b4a3928\"",
+                    "type": "string",
+                    "example": "7e6d5c4b-3a29-4180-9f8e-7d6c5b4a3928"
+                },
+                "last_error": {
+                    "description": "Ошибка последней попытки\nExample: \"получатель ответил 503\"",
+                    "type": "string",
+                    "example": "получатель ответил 503"
+                },
+                "last_status_code": {
+                    "description": "HTTP-код последней попытки (null — ответа не было)\nExample: 503",
+                    "type": "integer",
+                    "example": 503
+                },
+                "next_attempt_at": {
+                    "description": "Время следующей попытки (для PENDING)\nExample: \"2026-02-26T14:02:51Z\"",
+                    "type": "string",
+                    "example": "2026-02-26T14:02:51Z"
+                },
+                "payload": {
+                    "description": "Тело запроса (models.WebhookEvent)",
+                    "type": "object"
+                },
+                "status": {
+                    "description": "Состояние: PENDING (в очереди), DELIVERED (доставлено), DEAD (попытки исчерпаны)\nExample: PENDING",
+                    "type": "string",
+                    "enum": [
+                        "PENDING",
+                        "DELIVERED",
+                        "DEAD"
+                    ],
+                    "example": "PENDING"
+                },
+                "webhook_id": {
+                    "description": "Подписка\nExample: \"3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4\"",
+                    "type": "string",
+                    "example": "3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4"
+                }
+            }
+        },
+        "models.WebhookRequest": {
+            "description": "Payload подписки webhook. Пустой secret: при создании генерируется, при обновлении сохраняется прежний.",
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "description": {
+                    "description": "Example: \"Home Assistant\"",
+                    "type": "string",
+                    "example": "Home Assistant"
+                },
+                "events": {
+                    "description": "Example: [\"relay.switched\",\"alert.fired\"]",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "relay.switched",
+                        "alert.fired"
+                    ]
+                },
+                "is_active": {
+                    "description": "Активна ли подписка (по умолчанию true)\nExample: true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "secret": {
+                    "description": "Example: \"4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a\"",
+                    "type": "string",
+                    "example": "4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a"
+                },
+                "url": {
+                    "description": "Example: \"http://192.168.0.20:9000/terrarium\"",
+                    "type": "string",
+                    "example": "http://192.168.0.20:9000/terrarium"
                 }
             }
         }
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// ALERTS (ОПОВЕЩЕНИЯ)
+// ==========================================
+
+// GetAlerts godoc
+// @Summary Получить алерты
+// @Description Активные (FIRING) и решенные (RESOLVED) алерты террариума, начиная с последних.
+// @Tags Alerts
+// @Produce json
+// @Param state query string false "Состояние: FIRING или RESOLVED (по умолчанию все)"
+// @Param limit query int false "Количество записей (по умолчанию 50, макс 500)"
+// @Success 200 {array} models.Alert "Список алертов"
+// @Failure 400 {object} models.HTTPError "Неизвестное состояние"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/alerts [get]
+func (a *API) GetAlerts(c *gin.Context) {
+	state := c.Query("state")
+	if state != "" && state != storage.AlertFiring && state != storage.AlertResolved {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "state должен быть FIRING или RESOLVED"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	alerts, err := a.Repo.GetAlerts(c.Request.Context(), state, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения алертов: " + err.Error()})
+		return
+	}
+	if alerts == nil {
+		alerts = []models.Alert{}
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// AcknowledgeAlert godoc
+// @Summary Подтвердить алерт
+// @Description Отмечает алерт как принятый оператором: повторные уведомления (repeat_min) о нем прекращаются. Алерт остается активным, пока условие выполняется.
+// @Tags Alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "ID алерта"
+// @Param payload body models.AlertAckRequest false "Кто подтверждает"
+// @Success 200 {object} models.Alert "Алерт подтвержден"
+// @Failure 404 {object} models.HTTPError "Алерт не найден"
+// @Router /api/v1/alerts/{id}/ack [post]
+func (a *API) AcknowledgeAlert(c *gin.Context) {
+	var req models.AlertAckRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+			return
+		}
+	}
+
+	alert, err := a.Engine.AcknowledgeAlert(c.Request.Context(), c.Param("id"), req.By)
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetAlertChannels godoc
+// @Summary Получить каналы доставки уведомлений
+// @Description Имена каналов, которые можно указать в channels правила оповещения (log — системный журнал, telegram — если заданы TELEGRAM_TOKEN и TELEGRAM_CHAT_ID, email — если заданы SMTP_HOST и SMTP_TO).
+// @Tags Alerts
+// @Produce json
+// @Success 200 {array} string "Каналы доставки"
+// @Router /api/v1/alerts/channels [get]
+func (a *API) GetAlertChannels(c *gin.Context) {
+	channels := a.Engine.NotifyChannels()
+	if channels == nil {
+		channels = []string{}
+	}
+	c.JSON(http.StatusOK, channels)
+}
+
+// ==========================================
+// ALERT RULES (ПРАВИЛА ОПОВЕЩЕНИЯ)
+// ==========================================
+
+// GetAlertRules godoc
+// @Summary Получить правила оповещения
+// @Description Возвращает все правила оповещения террариума (по имени).
+// @Tags Alerts
+// @Produce json
+// @Success 200 {array} models.AlertRule "Список правил"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/alerts/rules [get]
+func (a *API) GetAlertRules(c *gin.Context) {
+	rules, err := a.Repo.GetAlertRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения правил оповещения: " + err.Error()})
+		return
+	}
+	if rules == nil {
+		rules = []models.AlertRule{}
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateAlertRule godoc
+// @Summary Создать правило оповещения
+// @Description Создает правило: condition — условия (И) над показаниями, временем и реле, как у правил автоматизации (например cold_temp < 22); sensor_offline — датчик не отвечает. Алерт срабатывает, когда условие держится for_sec секунд, и решается, когда оно перестает выполняться.
+// @Tags Alerts
+// @Accept json
+// @Produce json
+// @Param payload body models.AlertRuleRequest true "Правило оповещения"
+// @Success 201 {object} models.AlertRule "Правило создано"
+// @Failure 400 {object} models.HTTPError "Невалидное правило"
+// @Failure 409 {object} models.HTTPError "Правило с таким именем уже существует"
+// @Failure 500 {object} models.HTTPError "Ошибка записи в БД"
+// @Router /api/v1/alerts/rules [post]
+func (a *API) CreateAlertRule(c *gin.Context) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	rule, err := a.Engine.CreateAlertRule(c.Request.Context(), req)
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateAlertRule godoc
+// @Summary Обновить правило оповещения
+// @Description Полностью обновляет правило оповещения. Отключение правила решает его активный алерт без уведомления.
+// @Tags Alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "ID правила"
+// @Param payload body models.AlertRuleRequest true "Правило оповещения"
+// @Success 200 {string} string "Правило обновлено"
+// @Failure 400 {object} models.HTTPError "Невалидное правило"
+// @Failure 404 {object} models.HTTPError "Правило не найдено"
+// @Failure 409 {object} models.HTTPError "Правило с таким именем уже существует"
+// @Router /api/v1/alerts/rules/{id} [put]
+func (a *API) UpdateAlertRule(c *gin.Context) {
+	var req models.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	if err := a.Engine.UpdateAlertRule(c.Request.Context(), c.Param("id"), req); err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Правило оповещения обновлено"})
+}
+
+// DeleteAlertRule godoc
+// @Summary Удалить правило оповещения
+// @Description Удаляет правило оповещения. История его алертов сохраняется, активный алерт решается без уведомления.
+// @Tags Alerts
+// @Produce json
+// @Param id path string true "ID правила"
+// @Success 200 {string} string "Правило удалено"
+// @Failure 404 {object} models.HTTPError "Правило не найдено"
+// @Router /api/v1/alerts/rules/{id} [delete]
+func (a *API) DeleteAlertRule(c *gin.Context) {
+	if err := a.Engine.DeleteAlertRule(c.Request.Context(), c.Param("id")); err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Правило оповещения удалено"})
+}
+
+// ==========================================
+// ALERT SILENCES (ОКНА ТИШИНЫ)
+// ==========================================
+
+// GetAlertSilences godoc
+// @Summary Получить окна тишины
+// @Description Действующие и запланированные окна тишины (закончившиеся не возвращаются).
+// @Tags Alerts
+// @Produce json
+// @Success 200 {array} models.AlertSilence "Окна тишины"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/alerts/silences [get]
+func (a *API) GetAlertSilences(c *gin.Context) {
+	silences, err := a.Repo.GetAlertSilences(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения окон тишины: " + err.Error()})
+		return
+	}
+	if silences == nil {
+		silences = []models.AlertSilence{}
+	}
+	c.JSON(http.StatusOK, silences)
+}
+
+// CreateAlertSilence godoc
+// @Summary Создать окно тишины
+// @Description На время окна алерты правила (или всех правил, если rule_id пуст) срабатывают и сохраняются, но уведомления не рассылаются — например, на время чистки террариума. Окно не длиннее 7 суток.
+// @Tags Alerts
+// @Accept json
+// @Produce json
+// @Param payload body models.AlertSilenceRequest true "Окно тишины"
+// @Success 201 {object} models.AlertSilence "Окно создано"
+// @Failure 400 {object} models.HTTPError "Невалидное окно"
+// @Failure 404 {object} models.HTTPError "Правило не найдено"
+// @Router /api/v1/alerts/silences [post]
+func (a *API) CreateAlertSilence(c *gin.Context) {
+	var req models.AlertSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	silence, err := a.Engine.CreateAlertSilence(c.Request.Context(), req)
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, silence)
+}
+
+// DeleteAlertSilence godoc
+// @Summary Удалить окно тишины
+// @Description Досрочно завершает (удаляет) окно тишины.
+// @Tags Alerts
+// @Produce json
+// @Param id path string true "ID окна тишины"
+// @Success 200 {string} string "Окно удалено"
+// @Failure 404 {object} models.HTTPError "Окно не найдено"
+// @Router /api/v1/alerts/silences/{id} [delete]
+func (a *API) DeleteAlertSilence(c *gin.Context) {
+	if err := a.Repo.DeleteAlertSilence(c.Request.Context(), c.Param("id")); err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Окно тишины удалено"})
+}
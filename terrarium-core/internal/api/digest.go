@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/digest"
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// DIGEST (ЕЖЕДНЕВНАЯ СВОДКА)
+// ==========================================
+
+// GetDigest godoc
+// @Summary Получить суточную сводку
+// @Description Сводка за сутки (местное время сервера): минимум, максимум и среднее показаний по зонам, время работы и скважность реле, энергопотребление по WATTAGE_MAPPING и события безопасности (алерты, отключения по правилам безопасности, аварийные стопы, опустошение резервуара). Та же сводка ежедневно отправляется письмом в DIGEST_TIME.
+// @Tags Digest
+// @Produce json
+// @Param date query string false "Дата ГГГГ-ММ-ДД (по умолчанию вчера)"
+// @Success 200 {object} models.DailyDigest "Сводка"
+// @Failure 400 {object} models.HTTPError "Неверная или будущая дата"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/digest [get]
+func (a *API) GetDigest(c *gin.Context) {
+	day, err := digestDay(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	d, err := a.Engine.Digest(c.Request.Context(), day)
+	if errors.Is(err, automation.ErrInvalidDigestDay) {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка сборки сводки: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// SendDigest godoc
+// @Summary Отправить сводку письмом
+// @Description Собирает сводку за сутки по всем террариумам и сразу отправляет ее на SMTP_TO (для проверки настроек SMTP). Доступно, если заданы SMTP_HOST и SMTP_TO.
+// @Tags Digest
+// @Produce json
+// @Param date query string false "Дата ГГГГ-ММ-ДД (по умолчанию вчера)"
+// @Success 200 {string} string "Сводка отправлена"
+// @Failure 400 {object} models.HTTPError "Неверная или будущая дата"
+// @Failure 502 {object} models.HTTPError "Ошибка сборки или отправки письма"
+// @Failure 503 {object} models.HTTPError "SMTP не настроен"
+// @Router /api/v1/system/digest/send [post]
+func SendDigest(scheduler *digest.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scheduler == nil {
+			c.JSON(http.StatusServiceUnavailable, models.HTTPError{Code: 503, Message: "Отправка почты не настроена: задайте SMTP_HOST и SMTP_TO"})
+			return
+		}
+		day, err := digestDay(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+			return
+		}
+
+		if err := scheduler.Send(c.Request.Context(), day); err != nil {
+			if errors.Is(err, automation.ErrInvalidDigestDay) {
+				c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadGateway, models.HTTPError{Code: 502, Message: "Ошибка отправки сводки: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"msg": "Сводка отправлена"})
+	}
+}
+
+// digestDay разбирает дату сводки из параметра date (по умолчанию — вчера).
+func digestDay(c *gin.Context) (time.Time, error) {
+	s := c.Query("date")
+	if s == "" {
+		return time.Now().AddDate(0, 0, -1), nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("date должна быть в формате ГГГГ-ММ-ДД")
+	}
+	return day, nil
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Enclosure объединяет зависимости одного террариума: репозиторий, ограниченный его enclosure_id,
+// его реле и его движок автоматизации.
+type Enclosure struct {
+	ID      string
+	Name    string
+	Repo    *storage.Repository
+	Relays  map[string]gpio.RelayController
+	Drivers map[string]string // драйвер каждого реле (gpio, shelly, mqtt, ...)
+	Engine  *automation.Engine
+}
+
+// ==========================================
+// ENCLOSURES (НЕСКОЛЬКО ТЕРРАРИУМОВ)
+// ==========================================
+
+// GetEnclosures godoc
+// @Summary Получить список террариумов
+// @Description Возвращает террариумы, обслуживаемые ядром. Все маршруты API доступны для каждого террариума с префиксом /api/v1/enclosures/{id}/... (например /api/v1/enclosures/gecko/config); маршруты без префикса относятся к террариуму "default".
+// @Tags Enclosures
+// @Produce json
+// @Success 200 {array} models.EnclosureInfo "Список террариумов"
+// @Router /api/v1/enclosures [get]
+func GetEnclosures(enclosures []*Enclosure) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := make([]models.EnclosureInfo, 0, len(enclosures))
+		for _, enc := range enclosures {
+			mode, _ := enc.Repo.GetSystemMode(c.Request.Context())
+			info := models.EnclosureInfo{
+				ID:       enc.ID,
+				Name:     enc.Name,
+				Mode:     mode,
+				Sensors:  make([]string, 0),
+				Relays:   make([]string, 0, len(enc.Relays)),
+				BasePath: "/api/v1/enclosures/" + enc.ID,
+			}
+			for _, sensor := range enc.Engine.Sensors() {
+				info.Sensors = append(info.Sensors, sensor.ID)
+			}
+			for name := range enc.Relays {
+				info.Relays = append(info.Relays, name)
+			}
+			sort.Strings(info.Relays)
+			result = append(result, info)
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
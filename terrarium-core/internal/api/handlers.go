@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -16,9 +17,10 @@ import (
 
 // API struct содержит все зависимости (БД, Реле и Движок), необходимые для обработки HTTP-запросов.
 type API struct {
-	Repo   *storage.Repository
-	Relays map[string]gpio.RelayController
-	Engine *automation.Engine
+	Repo    *storage.Repository
+	Relays  map[string]gpio.RelayController
+	Drivers map[string]string // драйвер каждого реле (gpio, shelly, mqtt, ...)
+	Engine  *automation.Engine
 }
 
 // ==========================================
@@ -77,7 +79,7 @@ func (a *API) UpdateConfig(c *gin.Context) {
 
 // GetSystemStatus godoc
 // @Summary Получить статус и общую "проверку здоровья" (Health check) системы
-// @Description Предоставляет uptime приложения и текущий режим работы автомата (AUTO/MANUAL) из БД.
+// @Description Предоставляет uptime и версию приложения, текущий режим работы автомата (AUTO/MANUAL) из БД, результаты самодиагностики нагревателя и датчиков, последний цикл движка, последний успешный опрос каждого датчика, связь с драйверами реле, а также задержку и загрузку PostgreSQL.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -92,22 +94,46 @@ func (a *API) GetSystemStatus(c *gin.Context) {
 	}
 
 	status := models.SystemStatus{
-		Uptime:   999, // TODO: Реализовать глобальный счетчик Uptime
-		Mode:     mode,
-		DBStatus: dbStat,
+		Uptime:         uptime(),
+		StartedAt:      startedAt,
+		Version:        Version,
+		Mode:           mode,
+		DBStatus:       dbStat,
+		Health:         a.Engine.GetHealth(),
+		PanicStopSince: a.Engine.PanicStopSince(),
+		Engine:         a.Engine.CycleStatus(),
+		Sensors:        a.Engine.SensorHealth(),
+		Relays:         a.Engine.RelayHealth(),
+	}
+	for i := range status.Relays {
+		status.Relays[i].Driver = a.Drivers[status.Relays[i].ID]
+	}
+	if st, err := a.Repo.StorageStatus(c.Request.Context()); err == nil {
+		status.Storage = st
+	} else {
+		status.DBStatus = "ERROR"
+	}
+
+	if mode == "MANUAL" {
+		if until, err := a.Repo.GetManualUntil(c.Request.Context()); err == nil && until != nil {
+			remaining := max(int64(time.Until(*until).Seconds()), 0)
+			status.ManualUntil = until
+			status.ManualRemainingSec = &remaining
+		}
 	}
 	c.JSON(http.StatusOK, status)
 }
 
 // SetSystemMode godoc
 // @Summary Изменить глобальный режим системы (AUTO или MANUAL)
-// @Description Позволяет пользователю полностью перехватить контроль над реле.
+// @Description Позволяет пользователю полностью перехватить контроль над реле. Для MANUAL можно указать duration_min — по истечении срока движок сам вернет систему в AUTO.
 // @Tags System
 // @Accept json
 // @Produce json
 // @Param payload body models.ModeRequest true "Целевой режим: 'AUTO' или 'MANUAL'"
 // @Success 200 {object} models.ModeRequest "Режим успешно изменен"
 // @Failure 400 {object} models.HTTPError "Неверно заданный режим"
+// @Failure 500 {object} models.HTTPError "Ошибка сохранения режима"
 // @Router /api/v1/system/mode [post]
 func (a *API) SetSystemMode(c *gin.Context) {
 	var req models.ModeRequest
@@ -116,7 +142,12 @@ func (a *API) SetSystemMode(c *gin.Context) {
 		return
 	}
 
-	if err := a.Repo.SetSystemMode(c.Request.Context(), req.Mode); err != nil {
+	err := a.Engine.SetMode(c.Request.Context(), req.Mode, req.DurationMin)
+	switch {
+	case errors.Is(err, automation.ErrInvalidMode):
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	case err != nil:
 		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка сохранения режима"})
 		return
 	}
@@ -128,26 +159,37 @@ func (a *API) SetSystemMode(c *gin.Context) {
 // ==========================================
 
 // GetRelays godoc
-// @Summary Получить фактическое состояние пинов GPIO (всех 4 реле)
-// @Description Выполняет прямой опрос состояния реле из памяти/оборудования.
+// @Summary Получить состояние реле
+// @Description Возвращает желаемое состояние реле (последняя подтвержденная команда), яркость диммируемых выходов (brightness) и в поле sync — результат последней сверки с оборудованием: фактическое состояние (уровень пина или ответ сетевой розетки), признак расхождения и последнюю ошибку. Движок сверяет состояние каждые 30 секунд и восстанавливает желаемое при расхождении.
 // @Tags Hardware Control (Manual Mode)
 // @Accept json
 // @Produce json
 // @Success 200 {object} models.RelayState "Состояние реле"
 // @Router /api/v1/relays [get]
 func (a *API) GetRelays(c *gin.Context) {
+	isOn := func(id string) bool {
+		relay, ok := a.Relays[id]
+		return ok && relay.IsOn() // spare есть не у каждого террариума
+	}
 	state := models.RelayState{
-		HeatMat: a.Relays["heat_mat"].IsOn(),
-		Fogger:  a.Relays["fogger"].IsOn(),
-		Light:   a.Relays["light"].IsOn(),
-		Spare:   a.Relays["spare"].IsOn(),
+		HeatMat:    isOn("heat_mat"),
+		Fogger:     isOn("fogger"),
+		Light:      isOn("light"),
+		Spare:      isOn("spare"),
+		Brightness: make(map[string]float64),
+		Sync:       a.Engine.RelaySync(),
+	}
+	for id, relay := range a.Relays {
+		if d, ok := gpio.AsDimmer(relay); ok {
+			state.Brightness[id] = d.Brightness()
+		}
 	}
 	c.JSON(http.StatusOK, state)
 }
 
 // ToggleRelay godoc
 // @Summary Переключить конкретное реле [Требует MANUAL режим]
-// @Description Сигнализирует Raspberry Pi переключить уровень GPIO на конкретном пине. Работает только в MANUAL.
+// @Description Сигнализирует Raspberry Pi переключить уровень GPIO на конкретном пине. Работает только в MANUAL. Для диммируемого выхода можно указать brightness — яркость при включении.
 // @Tags Hardware Control (Manual Mode)
 // @Accept json
 // @Produce json
@@ -156,6 +198,8 @@ func (a *API) GetRelays(c *gin.Context) {
 // @Success 200 {string} string "Реле успешно переключено"
 // @Failure 400 {object} models.HTTPError "Неизвестный ID реле"
 // @Failure 403 {object} models.HTTPError "Система находится в режиме AUTO (ручное управление запрещено)"
+// @Failure 409 {object} models.HTTPError "Команда отклонена защитой реле (минимальное время вкл/выкл или лимит переключений), аварийным стопом, пустым резервуаром или открытой крышкой"
+// @Failure 500 {object} models.HTTPError "Аппаратная ошибка переключения реле"
 // @Router /api/v1/relays/{id}/toggle [post]
 func (a *API) ToggleRelay(c *gin.Context) {
 	relayID := c.Param("id")
@@ -179,15 +223,28 @@ func (a *API) ToggleRelay(c *gin.Context) {
 		return
 	}
 
-	if req.State {
-		_ = relay.On()
-	} else {
-		_ = relay.Off()
+	if err := a.Engine.ToggleRelay(c.Request.Context(), relayID, req.State, req.Brightness); err != nil {
+		var perr *gpio.ProtectionError
+		switch {
+		case errors.Is(err, automation.ErrNotManual):
+			c.JSON(http.StatusForbidden, models.HTTPError{Code: 403, Message: "Ручное переключение разрешено только в режиме MANUAL"})
+		case errors.Is(err, automation.ErrUnknownRelay):
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "Неизвестное реле: " + relayID})
+		case errors.Is(err, automation.ErrNotDimmable):
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "Реле " + relayID + " не поддерживает регулировку яркости"})
+		case errors.Is(err, automation.ErrEmergencyActive), errors.Is(err, automation.ErrPanicStop),
+			errors.Is(err, automation.ErrReservoirEmpty), errors.Is(err, automation.ErrLidOpen), errors.As(err, &perr):
+			c.JSON(http.StatusConflict, models.HTTPError{Code: 409, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка переключения реле: " + err.Error()})
+		}
+		return
 	}
 
-	// Запись лога переключения
-	_ = a.Repo.InsertRelayLog(c.Request.Context(), relayID, req.State, "MANUAL_OVERRIDE")
-
+	if dimmer, ok := gpio.AsDimmer(relay); ok {
+		c.JSON(http.StatusOK, gin.H{"msg": fmt.Sprintf("Реле %s переведено в %t (яркость %.1f%%)", relayID, req.State, dimmer.Brightness())})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"msg": fmt.Sprintf("Реле %s переведено в %t", relayID, req.State)})
 }
 
@@ -197,7 +254,7 @@ func (a *API) ToggleRelay(c *gin.Context) {
 
 // GetSensorCurrent godoc
 // @Summary Получить текущие показания датчиков (температура + влажность, обе зоны)
-// @Description Возвращает последние мгновенные показания с DHT22. Данные кэшируются в Engine (обновляются каждые 5 сек). При отсутствии подключения к оборудованию возвращаются mock-значения.
+// @Description Возвращает последние мгновенные показания теплой и холодной зон (показания всех датчиков — /sensors/readings). Данные кэшируются в Engine (обновляются каждые 5 сек). При отсутствии подключения к оборудованию возвращаются mock-значения.
 // @Tags Sensors
 // @Produce json
 // @Success 200 {object} models.SensorCurrent "Текущие показания"
@@ -217,7 +274,7 @@ func (a *API) GetSensorCurrent(c *gin.Context) {
 
 // GetSensorMetrics godoc
 // @Summary Получить историю показаний датчиков
-// @Description Возвращает исторические данные температуры и влажности из sensor_logs. Поддерживает фильтрацию по дате и ограничение выборки. Если система подключена — данные реальные из БД; если нет — массив будет пуст.
+// @Description Возвращает исторические данные температуры и влажности датчиков warm и cold из sensor_readings (история по всем датчикам — /metrics/readings). Поддерживает фильтрацию по дате и ограничение выборки. Если система подключена — данные реальные из БД; если нет — массив будет пуст.
 // @Tags Metrics
 // @Produce json
 // @Param from query string false "Начало периода (RFC3339, например 2026-02-26T00:00:00Z)"
@@ -324,7 +381,7 @@ func (a *API) GetSchedules(c *gin.Context) {
 
 // CreateSchedule godoc
 // @Summary Создать новое расписание реле
-// @Description Создаёт запись расписания для автоматического включения/выключения реле по времени суток.
+// @Description Создаёт запись расписания для автоматического включения/выключения реле по времени суток. Для диммируемых выходов движок выставляет яркость brightness с плавным рассветом после start_time и закатом перед end_time длительностью ramp_min минут.
 // @Tags Schedules
 // @Accept json
 // @Produce json
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version — версия сборки. Задается при сборке:
+// go build -ldflags "-X terrarium-core/internal/api.Version=1.4.0"
+// Если не задана, берется ревизия git из информации о сборке.
+var Version = "dev"
+
+// startedAt — время запуска процесса
+var startedAt = time.Now()
+
+// readyzDBTimeout — сколько /readyz ждет ответа PostgreSQL
+const readyzDBTimeout = 2 * time.Second
+
+func init() {
+	if Version != "dev" {
+		return
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+				Version = "dev-" + s.Value[:12]
+			}
+		}
+	}
+}
+
+// uptime возвращает время работы процесса в секундах.
+func uptime() int64 {
+	return int64(time.Since(startedAt).Seconds())
+}
+
+// ==========================================
+// HEALTH (LIVENESS / READINESS)
+// ==========================================
+
+// Healthz godoc
+// @Summary Проверка живости (liveness)
+// @Description Отвечает 200, пока процесс работает и циклы движков автоматизации завершаются. 503 — движок завис (цикл не завершался дольше 15 секунд): процесс нужно перезапустить. Не обращается к БД.
+// @Tags System
+// @Produce json
+// @Success 200 {object} models.HealthStatus "Сервис жив"
+// @Failure 503 {object} models.HealthStatus "Движок автоматизации завис"
+// @Router /healthz [get]
+func Healthz(enclosures []*Enclosure) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checks := make([]models.HealthCheck, 0, len(enclosures))
+		for _, enc := range enclosures {
+			checks = append(checks, engineCheck(enc, false))
+		}
+		respondHealth(c, checks)
+	}
+}
+
+// Readyz godoc
+// @Summary Проверка готовности (readiness)
+// @Description Отвечает 200, когда PostgreSQL отвечает на ping и каждый движок автоматизации выполнил хотя бы один цикл и не завис. Иначе 503 со списком непройденных проверок.
+// @Tags System
+// @Produce json
+// @Success 200 {object} models.HealthStatus "Сервис готов"
+// @Failure 503 {object} models.HealthStatus "Сервис не готов"
+// @Router /readyz [get]
+func Readyz(enclosures []*Enclosure) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checks := make([]models.HealthCheck, 0, len(enclosures)+1)
+		if len(enclosures) > 0 {
+			// Пул соединений общий для всех террариумов
+			ctx, cancel := context.WithTimeout(c.Request.Context(), readyzDBTimeout)
+			st, err := enclosures[0].Repo.StorageStatus(ctx)
+			cancel()
+			check := models.HealthCheck{Name: "database", OK: err == nil}
+			if err != nil {
+				check.Detail = err.Error()
+			} else {
+				check.Detail = fmt.Sprintf("ping %.1f мс", st.LatencyMs)
+			}
+			checks = append(checks, check)
+		}
+		for _, enc := range enclosures {
+			checks = append(checks, engineCheck(enc, true))
+		}
+		respondHealth(c, checks)
+	}
+}
+
+// engineCheck проверяет, что движок террариума не завис. needCycle — движок должен выполнить хотя бы один цикл.
+func engineCheck(enc *Enclosure, needCycle bool) models.HealthCheck {
+	st := enc.Engine.CycleStatus()
+	check := models.HealthCheck{Name: "engine:" + enc.ID, OK: true}
+	switch {
+	case !st.Running:
+		check.OK = !needCycle
+		check.Detail = "движок еще не запущен"
+	case st.Stale:
+		check.OK = false
+		check.Detail = "цикл движка давно не завершался"
+	case st.LastCycleAt == nil:
+		check.OK = !needCycle
+		check.Detail = "первый цикл еще не выполнен"
+	default:
+		check.Detail = "последний цикл " + st.LastCycleAt.Format(time.DateTime)
+	}
+	return check
+}
+
+// respondHealth отвечает 200, если все проверки пройдены, иначе 503.
+func respondHealth(c *gin.Context, checks []models.HealthCheck) {
+	status := models.HealthStatus{Status: "ok", Uptime: uptime(), Version: Version, Checks: checks}
+	code := http.StatusOK
+	for _, check := range checks {
+		if !check.OK {
+			status.Status = "fail"
+			code = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(code, status)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// INPUTS (КРЫШКА, КНОПКА АВАРИЙНОГО СТОПА)
+// ==========================================
+
+// GetInputs godoc
+// @Summary Получить состояние цифровых входов
+// @Description Концевики крышки (role=lid) и кнопки аварийного стопа (role=panic) с подавлением дребезга. Пока крышка открыта, фоггер на паузе. Вход, который не отвечает, считается неактивным и содержит поле error.
+// @Tags Inputs
+// @Produce json
+// @Success 200 {array} models.InputStatus "Состояние входов"
+// @Router /api/v1/inputs [get]
+func (a *API) GetInputs(c *gin.Context) {
+	c.JSON(http.StatusOK, a.Engine.GetInputs())
+}
+
+// GetInputEvents godoc
+// @Summary Получить журнал цифровых входов
+// @Description Возвращает открытия (OPEN) и закрытия (CLOSE) крышки, включения (PANIC_STOP) и сбросы (PANIC_RESET) аварийного стопа, начиная с последних. Для стопа input_id — ID кнопки или "api".
+// @Tags Inputs
+// @Produce json
+// @Param limit query int false "Количество записей (по умолчанию 50, макс 500)"
+// @Success 200 {array} models.InputEvent "Журнал входов"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/inputs/events [get]
+func (a *API) GetInputEvents(c *gin.Context) {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	events, err := a.Repo.GetInputEvents(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения журнала входов: " + err.Error()})
+		return
+	}
+
+	if events == nil {
+		events = []models.InputEvent{}
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// SetPanicStop godoc
+// @Summary Включить или сбросить аварийный стоп
+// @Description То же, что нажатие физической кнопки: active=true сразу выключает все реле (в обход защиты от дребезга), снимает переопределения и останавливает автоматику; active=false сбрасывает стоп. Пока стоп активен, включить реле нельзя (409).
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param payload body models.PanicStopRequest true "Включить (true) или сбросить (false) стоп"
+// @Success 200 {object} models.SystemStatus "Состояние системы после команды"
+// @Failure 400 {object} models.HTTPError "Неверный формат запроса"
+// @Router /api/v1/system/panic [post]
+func (a *API) SetPanicStop(c *gin.Context) {
+	var req models.PanicStopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	a.Engine.SetPanicStop(c.Request.Context(), *req.Active, automation.PanicSourceAPI)
+	a.GetSystemStatus(c)
+}
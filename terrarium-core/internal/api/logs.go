@@ -0,0 +1,111 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// logsStreamHeartbeat — период комментария-пинга в потоке журнала, чтобы прокси не закрывали соединение
+const logsStreamHeartbeat = 15 * time.Second
+
+// ==========================================
+// LOGS (ЖУРНАЛ СЕРВИСА)
+// ==========================================
+
+// GetLogs godoc
+// @Summary Получить журнал сервиса
+// @Description Последние записи журнала из кольцевого буфера в памяти (от новых к старым) с фильтром по уровню, компоненту и террариуму. Размер буфера задается LOG_BUFFER_SIZE; журнал не сохраняется между перезапусками.
+// @Tags System
+// @Produce json
+// @Param level query string false "Минимальный уровень: debug, info (по умолчанию), warn, error"
+// @Param component query string false "Компонент (engine, sensors, gpio, storage, ...)"
+// @Param enclosure query string false "ID террариума"
+// @Param after_id query int false "Только записи новее указанной"
+// @Param limit query int false "Количество записей (по умолчанию 200, макс 2000)"
+// @Success 200 {array} models.LogEntry "Записи журнала"
+// @Failure 400 {object} models.HTTPError "Неверный уровень или after_id"
+// @Router /api/v1/system/logs [get]
+func GetLogs(c *gin.Context) {
+	filter, err := logFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+	limit := 200
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 && parsed <= 2000 {
+		limit = parsed
+	}
+	c.JSON(http.StatusOK, logging.Buffer().Query(filter, limit))
+}
+
+// StreamLogs godoc
+// @Summary Живой хвост журнала (Server-Sent Events)
+// @Description Поток text/event-stream: сначала последние tail записей (по умолчанию 50), затем каждая новая запись событием "log" с JSON models.LogEntry. Фильтры — как у /system/logs. Если клиент не успевает читать, часть записей пропускается (их можно дочитать через /system/logs?after_id=...).
+// @Tags System
+// @Produce text/event-stream
+// @Param level query string false "Минимальный уровень: debug, info (по умолчанию), warn, error"
+// @Param component query string false "Компонент (engine, sensors, gpio, storage, ...)"
+// @Param enclosure query string false "ID террариума"
+// @Param tail query int false "Сколько последних записей отправить сразу (по умолчанию 50, макс 2000)"
+// @Success 200 {object} models.LogEntry "Поток событий log"
+// @Failure 400 {object} models.HTTPError "Неверный уровень"
+// @Router /api/v1/system/logs/stream [get]
+func StreamLogs(c *gin.Context) {
+	filter, err := logFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+	tail := 50
+	if parsed, err := strconv.Atoi(c.Query("tail")); err == nil && parsed >= 0 && parsed <= 2000 {
+		tail = parsed
+	}
+
+	ring := logging.Buffer()
+	entries, cancel := ring.Subscribe() // подписка до чтения хвоста, чтобы не потерять записи между ними
+	defer cancel()
+	backlog := ring.Query(filter, tail)
+	for i := len(backlog) - 1; i >= 0; i-- {
+		c.SSEvent("log", backlog[i])
+		filter.AfterID = backlog[i].ID
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(logsStreamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": ping\n\n")
+		case entry := <-entries:
+			if filter.Match(entry) {
+				c.SSEvent("log", entry)
+			}
+		}
+		return true
+	})
+}
+
+// logFilter разбирает фильтр журнала из параметров запроса.
+func logFilter(c *gin.Context) (logging.Filter, error) {
+	level, err := logging.ParseLevel(c.Query("level"))
+	if err != nil {
+		return logging.Filter{}, err
+	}
+	filter := logging.Filter{MinLevel: level, Component: c.Query("component"), Enclosure: c.Query("enclosure")}
+	if s := c.Query("after_id"); s != "" {
+		if filter.AfterID, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return logging.Filter{}, err
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// MIST (ИМПУЛЬСНЫЙ РЕЖИМ ФОГГЕРА И ДОЖДЬ)
+// ==========================================
+
+// GetMistSettings godoc
+// @Summary Получить настройки импульсного режима фоггера
+// @Description Возвращает длительность импульса, паузу между импульсами и суточный лимит работы фоггера.
+// @Tags Mist
+// @Produce json
+// @Success 200 {object} models.MistSettings "Настройки импульсного режима"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/mist/settings [get]
+func (a *API) GetMistSettings(c *gin.Context) {
+	settings, err := a.Repo.GetMistSettings(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения БД"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateMistSettings godoc
+// @Summary Обновить настройки импульсного режима фоггера
+// @Description Включает/выключает режим misting и задает параметры импульсов. Изменения применяются со следующего цикла движка.
+// @Tags Mist
+// @Accept json
+// @Produce json
+// @Param payload body models.MistSettings true "Настройки импульсного режима"
+// @Success 200 {object} models.MistSettings "Настройки обновлены"
+// @Failure 400 {object} models.HTTPError "Невалидный Payload"
+// @Failure 500 {object} models.HTTPError "Ошибка записи в БД"
+// @Router /api/v1/mist/settings [put]
+func (a *API) UpdateMistSettings(c *gin.Context) {
+	var settings models.MistSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	if err := a.Repo.UpdateMistSettings(c.Request.Context(), settings); err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка записи в БД"})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetMistStatus godoc
+// @Summary Получить состояние импульсного режима фоггера
+// @Description Показывает, идет ли импульс сейчас, когда был последний импульс и сколько суточного лимита израсходовано.
+// @Tags Mist
+// @Produce json
+// @Success 200 {object} models.MistStatus "Состояние импульсного режима"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/mist/status [get]
+func (a *API) GetMistStatus(c *gin.Context) {
+	settings, err := a.Repo.GetMistSettings(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения БД"})
+		return
+	}
+	c.JSON(http.StatusOK, a.Engine.GetMistStatus(settings))
+}
+
+// GetRainEvents godoc
+// @Summary Получить расписание дождя
+// @Description Возвращает список запланированных событий дождя (принудительных импульсов фоггера по времени суток).
+// @Tags Mist
+// @Produce json
+// @Success 200 {array} models.RainEvent "Список событий дождя"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/mist/events [get]
+func (a *API) GetRainEvents(c *gin.Context) {
+	events, err := a.Repo.GetRainEvents(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения событий дождя: " + err.Error()})
+		return
+	}
+
+	if events == nil {
+		events = []models.RainEvent{}
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// CreateRainEvent godoc
+// @Summary Создать событие дождя
+// @Description Планирует ежедневный импульс фоггера на заданное время. Дождь учитывается в суточном лимите, но игнорирует паузу между импульсами.
+// @Tags Mist
+// @Accept json
+// @Produce json
+// @Param payload body models.RainEventRequest true "Данные события дождя"
+// @Success 201 {object} models.RainEvent "Событие создано"
+// @Failure 400 {object} models.HTTPError "Невалидный Payload"
+// @Failure 500 {object} models.HTTPError "Ошибка записи в БД"
+// @Router /api/v1/mist/events [post]
+func (a *API) CreateRainEvent(c *gin.Context) {
+	var req models.RainEventRequest
+	if !bindRainEvent(c, &req) {
+		return
+	}
+
+	event, err := a.Repo.CreateRainEvent(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка создания события дождя: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, event)
+}
+
+// UpdateRainEvent godoc
+// @Summary Обновить событие дождя
+// @Description Изменяет время, длительность или активность события дождя по UUID.
+// @Tags Mist
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID события дождя"
+// @Param payload body models.RainEventRequest true "Обновлённые данные"
+// @Success 200 {string} string "Событие обновлено"
+// @Failure 400 {object} models.HTTPError "Невалидный Payload"
+// @Failure 404 {object} models.HTTPError "Событие не найдено"
+// @Router /api/v1/mist/events/{id} [put]
+func (a *API) UpdateRainEvent(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.RainEventRequest
+	if !bindRainEvent(c, &req) {
+		return
+	}
+
+	if err := a.Repo.UpdateRainEvent(c.Request.Context(), id, req); err != nil {
+		c.JSON(http.StatusNotFound, models.HTTPError{Code: 404, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Событие дождя обновлено"})
+}
+
+// DeleteRainEvent godoc
+// @Summary Удалить событие дождя
+// @Description Удаляет запланированное событие дождя по UUID.
+// @Tags Mist
+// @Produce json
+// @Param id path string true "UUID события дождя"
+// @Success 200 {string} string "Событие удалено"
+// @Failure 404 {object} models.HTTPError "Событие не найдено"
+// @Router /api/v1/mist/events/{id} [delete]
+func (a *API) DeleteRainEvent(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := a.Repo.DeleteRainEvent(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, models.HTTPError{Code: 404, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Событие дождя удалено"})
+}
+
+// bindRainEvent разбирает тело запроса события дождя и проверяет время начала.
+// При ошибке отвечает 400 и возвращает false.
+func bindRainEvent(c *gin.Context, req *models.RainEventRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return false
+	}
+	if _, err := time.Parse("15:04", req.StartTime); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "неверный формат 'start_time', ожидается HH:MM (00:00–23:59)"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// OVERRIDES (ВРЕМЕННОЕ РУЧНОЕ УПРАВЛЕНИЕ В AUTO)
+// ==========================================
+
+// GetRelayOverrides godoc
+// @Summary Получить активные переопределения реле
+// @Description Возвращает список временных ручных переопределений с временем окончания. Пока переопределение действует, автоматика не управляет реле.
+// @Tags Overrides
+// @Produce json
+// @Success 200 {array} models.RelayOverride "Активные переопределения"
+// @Router /api/v1/overrides [get]
+func (a *API) GetRelayOverrides(c *gin.Context) {
+	c.JSON(http.StatusOK, a.Engine.GetOverrides())
+}
+
+// SetRelayOverride godoc
+// @Summary Временно переопределить реле (работает в AUTO)
+// @Description Включает/выключает реле на заданное время (duration_min) или до указанного времени суток (until, HH:MM), не переводя систему в MANUAL. По окончании реле возвращается в исходное состояние и автоматика возобновляет управление. Аварийный контур имеет приоритет над переопределением.
+// @Tags Overrides
+// @Accept json
+// @Produce json
+// @Param id path string true "ID реле" Enums(heat_mat, fogger, light)
+// @Param payload body models.RelayOverrideRequest true "Состояние и срок переопределения"
+// @Success 201 {object} models.RelayOverride "Переопределение создано"
+// @Failure 400 {object} models.HTTPError "Невалидный Payload или реле не управляется автоматикой"
+// @Failure 409 {object} models.HTTPError "Активно аварийное отключение или аварийный стоп, резервуар фоггера пуст, крышка открыта или команда отклонена защитой реле"
+// @Failure 500 {object} models.HTTPError "Аппаратная ошибка переключения реле"
+// @Router /api/v1/relays/{id}/override [post]
+func (a *API) SetRelayOverride(c *gin.Context) {
+	relayID := c.Param("id")
+
+	var req models.RelayOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	expiresAt, err := overrideExpiry(req, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	ov, err := a.Engine.SetOverride(c.Request.Context(), relayID, req.State, expiresAt)
+	if err != nil {
+		var perr *gpio.ProtectionError
+		switch {
+		case errors.Is(err, automation.ErrUnknownRelay):
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		case errors.Is(err, automation.ErrEmergencyActive), errors.Is(err, automation.ErrPanicStop),
+			errors.Is(err, automation.ErrReservoirEmpty), errors.Is(err, automation.ErrLidOpen), errors.As(err, &perr):
+			c.JSON(http.StatusConflict, models.HTTPError{Code: 409, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка переключения реле: " + err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, ov)
+}
+
+// CancelRelayOverride godoc
+// @Summary Отменить переопределение реле
+// @Description Досрочно снимает переопределение: реле возвращается в исходное состояние, автоматика возобновляет управление.
+// @Tags Overrides
+// @Produce json
+// @Param id path string true "ID реле" Enums(heat_mat, fogger, light)
+// @Success 200 {string} string "Переопределение отменено"
+// @Failure 404 {object} models.HTTPError "Активного переопределения нет"
+// @Router /api/v1/relays/{id}/override [delete]
+func (a *API) CancelRelayOverride(c *gin.Context) {
+	if err := a.Engine.CancelOverride(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, models.HTTPError{Code: 404, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Переопределение отменено"})
+}
+
+// overrideExpiry вычисляет время окончания переопределения: через duration_min минут
+// или в ближайшее наступление until (HH:MM).
+func overrideExpiry(req models.RelayOverrideRequest, now time.Time) (time.Time, error) {
+	if (req.DurationMin > 0) == (req.Until != "") {
+		return time.Time{}, errors.New("нужно указать ровно одно из полей: duration_min или until")
+	}
+	if req.DurationMin > 0 {
+		return now.Add(time.Duration(req.DurationMin) * time.Minute), nil
+	}
+
+	t, err := time.ParseInLocation("15:04", req.Until, now.Location())
+	if err != nil {
+		return time.Time{}, errors.New("неверный формат 'until', ожидается HH:MM")
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at, nil
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// RESERVOIR (УРОВЕНЬ ВОДЫ В РЕЗЕРВУАРЕ ФОГГЕРА)
+// ==========================================
+
+// GetReservoir godoc
+// @Summary Получить состояние резервуара фоггера
+// @Description Уровень воды по поплавковому выключателю (с подавлением дребезга), время последнего опустошения и пополнения. Пока резервуар пуст или датчик не отвечает, фоггер выключен и не включается ни автоматикой, ни вручную.
+// @Tags Reservoir
+// @Produce json
+// @Success 200 {object} models.ReservoirStatus "Состояние резервуара"
+// @Router /api/v1/reservoir [get]
+func (a *API) GetReservoir(c *gin.Context) {
+	c.JSON(http.StatusOK, a.Engine.GetReservoir())
+}
+
+// GetReservoirEvents godoc
+// @Summary Получить журнал резервуара фоггера
+// @Description Возвращает события опустошения (EMPTY) и пополнения (REFILL) резервуара, начиная с последних. Для пополнения указано, сколько резервуар простоял пустым.
+// @Tags Reservoir
+// @Produce json
+// @Param limit query int false "Количество записей (по умолчанию 50, макс 500)"
+// @Success 200 {array} models.ReservoirEvent "Журнал резервуара"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/reservoir/events [get]
+func (a *API) GetReservoirEvents(c *gin.Context) {
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	events, err := a.Repo.GetReservoirEvents(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения журнала резервуара: " + err.Error()})
+		return
+	}
+
+	if events == nil {
+		events = []models.ReservoirEvent{}
+	}
+	c.JSON(http.StatusOK, events)
+}
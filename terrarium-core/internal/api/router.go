@@ -1,24 +1,31 @@
 package api
 
 import (
+	"log/slog"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
-	"terrarium-core/internal/automation"
-	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/digest"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/metrics"
 	"terrarium-core/internal/storage"
 
 	_ "terrarium-core/docs"
 )
 
-// SetupRouter инициализирует движок Gin и принимает все аппаратные и системные зависимости.
-func SetupRouter(repo *storage.Repository, relays map[string]gpio.RelayController, engine *automation.Engine) *gin.Engine {
-	r := gin.Default()
+// SetupRouter инициализирует движок Gin и принимает зависимости всех террариумов.
+// Маршруты каждого террариума доступны под /api/v1/enclosures/{id}; маршруты /api/v1/... без
+// префикса относятся к террариуму по умолчанию. digests — отправка ежедневной сводки (nil, если SMTP не настроен).
+func SetupRouter(enclosures []*Enclosure, hw Hardware, digests *digest.Scheduler) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), requestLog())
 
 	// CORS-middleware: разрешаем запросы с фронтенда (Angular dev server и другие origins из .env)
 	allowedOrigins := []string{"http://localhost:4200", "http://localhost"}
@@ -38,45 +45,163 @@ func SetupRouter(repo *storage.Repository, relays map[string]gpio.RelayControlle
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
-
-	apiCtrl := &API{
-		Repo:   repo,
-		Relays: relays,
-		Engine: engine,
-	}
+	r.Use(httpMetrics())
 
 	// Swagger endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Метрики Prometheus (вне /api/v1: скрейпер опрашивает их без CORS и авторизации)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// Проверки живости и готовности (healthcheck docker-compose)
+	r.GET("/healthz", Healthz(enclosures))
+	r.GET("/readyz", Readyz(enclosures))
+
 	// Группа API v1
 	v1 := r.Group("/api/v1")
-	{
-		// Конфигурация и система
-		v1.GET("/config", apiCtrl.GetConfig)
-		v1.PUT("/config", apiCtrl.UpdateConfig)
-		v1.GET("/system/status", apiCtrl.GetSystemStatus)
-		v1.POST("/system/mode", apiCtrl.SetSystemMode)
-
-		// Реле (ручное управление)
-		v1.GET("/relays", apiCtrl.GetRelays)
-		v1.POST("/relays/:id/toggle", apiCtrl.ToggleRelay)
-
-		// Датчики — текущие показания
-		v1.GET("/sensors/current", apiCtrl.GetSensorCurrent)
-
-		// Метрики — история датчиков и энергопотребление
-		v1.GET("/metrics/sensors", apiCtrl.GetSensorMetrics)
-		v1.GET("/metrics/energy", apiCtrl.GetEnergyMetrics)
-
-		// Расписания реле (CRUD)
-		v1.GET("/schedules", apiCtrl.GetSchedules)
-		v1.POST("/schedules", apiCtrl.CreateSchedule)
-		v1.PUT("/schedules/:id", apiCtrl.UpdateSchedule)
-		v1.DELETE("/schedules/:id", apiCtrl.DeleteSchedule)
-
-		// Журнал переключений реле
-		v1.GET("/relay-logs", apiCtrl.GetRelayLogs)
+	v1.GET("/enclosures", GetEnclosures(enclosures))
+	v1.GET("/hardware/w1", GetW1Probes(hw, enclosures))
+	v1.GET("/system/logs", GetLogs)
+	v1.GET("/system/logs/stream", StreamLogs)
+	v1.POST("/system/digest/send", SendDigest(digests))
+	for _, enc := range enclosures {
+		apiCtrl := &API{
+			Repo:    enc.Repo,
+			Relays:  enc.Relays,
+			Drivers: enc.Drivers,
+			Engine:  enc.Engine,
+		}
+		registerRoutes(v1.Group("/enclosures/"+enc.ID), apiCtrl)
+		if enc.ID == storage.DefaultEnclosure {
+			registerRoutes(v1, apiCtrl)
+		}
 	}
 
 	return r
 }
+
+// registerRoutes регистрирует маршруты одного террариума в группе g.
+func registerRoutes(g *gin.RouterGroup, apiCtrl *API) {
+	// Конфигурация и система
+	g.GET("/config", apiCtrl.GetConfig)
+	g.PUT("/config", apiCtrl.UpdateConfig)
+	g.POST("/config/dry-run", apiCtrl.DryRunConfig)
+	g.GET("/system/status", apiCtrl.GetSystemStatus)
+	g.POST("/system/mode", apiCtrl.SetSystemMode)
+	g.POST("/system/panic", apiCtrl.SetPanicStop)
+
+	// Реле (ручное управление)
+	g.GET("/relays", apiCtrl.GetRelays)
+	g.POST("/relays/:id/toggle", apiCtrl.ToggleRelay)
+
+	// Временные переопределения реле (работают в AUTO)
+	g.GET("/overrides", apiCtrl.GetRelayOverrides)
+	g.POST("/relays/:id/override", apiCtrl.SetRelayOverride)
+	g.DELETE("/relays/:id/override", apiCtrl.CancelRelayOverride)
+
+	// Правила автоматизации (CRUD)
+	g.GET("/rules", apiCtrl.GetRules)
+	g.POST("/rules", apiCtrl.CreateRule)
+	g.PUT("/rules/:id", apiCtrl.UpdateRule)
+	g.DELETE("/rules/:id", apiCtrl.DeleteRule)
+
+	// Трассировка решений движка
+	g.GET("/engine/decisions", apiCtrl.GetEngineDecisions)
+	g.GET("/relays/:id/explain", apiCtrl.ExplainRelay)
+
+	// Датчики — реестр и текущие показания
+	g.GET("/sensors", apiCtrl.GetSensors)
+	g.GET("/sensors/current", apiCtrl.GetSensorCurrent)
+	g.GET("/sensors/readings", apiCtrl.GetSensorReadings)
+
+	// Метрики — история датчиков и энергопотребление
+	g.GET("/metrics/sensors", apiCtrl.GetSensorMetrics)
+	g.GET("/metrics/readings", apiCtrl.GetReadingsHistory)
+	g.GET("/metrics/energy", apiCtrl.GetEnergyMetrics)
+
+	// Расписания реле (CRUD)
+	g.GET("/schedules", apiCtrl.GetSchedules)
+	g.POST("/schedules", apiCtrl.CreateSchedule)
+	g.PUT("/schedules/:id", apiCtrl.UpdateSchedule)
+	g.DELETE("/schedules/:id", apiCtrl.DeleteSchedule)
+
+	// Журнал переключений реле
+	g.GET("/relay-logs", apiCtrl.GetRelayLogs)
+
+	// Импульсный режим фоггера и расписание дождя
+	g.GET("/mist/settings", apiCtrl.GetMistSettings)
+	g.PUT("/mist/settings", apiCtrl.UpdateMistSettings)
+	g.GET("/mist/status", apiCtrl.GetMistStatus)
+	g.GET("/mist/events", apiCtrl.GetRainEvents)
+	g.POST("/mist/events", apiCtrl.CreateRainEvent)
+	g.PUT("/mist/events/:id", apiCtrl.UpdateRainEvent)
+	g.DELETE("/mist/events/:id", apiCtrl.DeleteRainEvent)
+
+	// Резервуар фоггера (поплавковый выключатель)
+	g.GET("/reservoir", apiCtrl.GetReservoir)
+	g.GET("/reservoir/events", apiCtrl.GetReservoirEvents)
+
+	// Цифровые входы (крышка, кнопка аварийного стопа)
+	g.GET("/inputs", apiCtrl.GetInputs)
+	g.GET("/inputs/events", apiCtrl.GetInputEvents)
+
+	// Оповещения: алерты, правила, окна тишины и каналы доставки
+	g.GET("/alerts", apiCtrl.GetAlerts)
+	g.POST("/alerts/:id/ack", apiCtrl.AcknowledgeAlert)
+	g.GET("/alerts/channels", apiCtrl.GetAlertChannels)
+	g.GET("/alerts/rules", apiCtrl.GetAlertRules)
+	g.POST("/alerts/rules", apiCtrl.CreateAlertRule)
+	g.PUT("/alerts/rules/:id", apiCtrl.UpdateAlertRule)
+	g.DELETE("/alerts/rules/:id", apiCtrl.DeleteAlertRule)
+	g.GET("/alerts/silences", apiCtrl.GetAlertSilences)
+	g.POST("/alerts/silences", apiCtrl.CreateAlertSilence)
+	g.DELETE("/alerts/silences/:id", apiCtrl.DeleteAlertSilence)
+
+	// Исходящие webhooks: подписки и журнал доставок
+	g.GET("/webhooks", apiCtrl.GetWebhooks)
+	g.POST("/webhooks", apiCtrl.CreateWebhook)
+	g.PUT("/webhooks/:id", apiCtrl.UpdateWebhook)
+	g.DELETE("/webhooks/:id", apiCtrl.DeleteWebhook)
+	g.POST("/webhooks/:id/test", apiCtrl.TestWebhook)
+	g.GET("/webhooks/deliveries", apiCtrl.GetWebhookDeliveries)
+	g.POST("/webhooks/deliveries/:id/retry", apiCtrl.RetryWebhookDelivery)
+
+	// Суточная сводка
+	g.GET("/digest", apiCtrl.GetDigest)
+}
+
+// httpLog — журнал HTTP-запросов
+var httpLog = logging.Component("http")
+
+// requestLog пишет HTTP-запросы в журнал: ошибки сервера — WARN, остальные — DEBUG
+// (фронтенд опрашивает API каждые несколько секунд).
+func requestLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		level := slog.LevelDebug
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		httpLog.Log(c.Request.Context(), level, "HTTP-запрос",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"client", c.ClientIP(),
+		)
+	}
+}
+
+// httpMetrics учитывает каждый HTTP-запрос в метриках (маршрут — шаблон gin, чтобы ID не раздували метки).
+func httpMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
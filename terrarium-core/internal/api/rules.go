@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// RULES (ПРАВИЛА АВТОМАТИЗАЦИИ)
+// ==========================================
+
+// GetRules godoc
+// @Summary Получить правила автоматизации
+// @Description Возвращает все правила (встроенные и пользовательские) в порядке оценки: по приоритету (меньше — раньше), затем по имени. Правила безопасности оцениваются до остальных независимо от приоритета.
+// @Tags Rules
+// @Produce json
+// @Success 200 {array} models.AutomationRule "Список правил"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/rules [get]
+func (a *API) GetRules(c *gin.Context) {
+	rules, err := a.Repo.GetRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения правил: " + err.Error()})
+		return
+	}
+
+	if rules == nil {
+		rules = []models.AutomationRule{}
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule godoc
+// @Summary Создать правило автоматизации
+// @Description Создает пользовательское правило: условия (И) над показаниями датчиков, временем суток и состоянием/длительностью реле, и действие над любым реле. Первое сработавшее правило захватывает реле на цикл. Правило проверяется при сохранении.
+// @Tags Rules
+// @Accept json
+// @Produce json
+// @Param payload body models.AutomationRuleRequest true "Правило"
+// @Success 201 {object} models.AutomationRule "Правило создано"
+// @Failure 400 {object} models.HTTPError "Невалидное правило"
+// @Failure 409 {object} models.HTTPError "Правило с таким именем уже существует"
+// @Failure 500 {object} models.HTTPError "Ошибка записи в БД"
+// @Router /api/v1/rules [post]
+func (a *API) CreateRule(c *gin.Context) {
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	rule, err := a.Engine.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule godoc
+// @Summary Обновить правило автоматизации
+// @Description Полностью обновляет пользовательское правило. У встроенных правил учитывается только is_active; правила безопасности отключить нельзя.
+// @Tags Rules
+// @Accept json
+// @Produce json
+// @Param id path string true "ID правила"
+// @Param payload body models.AutomationRuleRequest true "Правило"
+// @Success 200 {string} string "Правило обновлено"
+// @Failure 400 {object} models.HTTPError "Невалидное правило"
+// @Failure 403 {object} models.HTTPError "Встроенное правило безопасности нельзя отключить"
+// @Failure 404 {object} models.HTTPError "Правило не найдено"
+// @Failure 409 {object} models.HTTPError "Правило с таким именем уже существует"
+// @Router /api/v1/rules/{id} [put]
+func (a *API) UpdateRule(c *gin.Context) {
+	var req models.AutomationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	if err := a.Engine.UpdateRule(c.Request.Context(), c.Param("id"), req); err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Правило обновлено"})
+}
+
+// DeleteRule godoc
+// @Summary Удалить правило автоматизации
+// @Description Удаляет пользовательское правило. Встроенные правила удалить нельзя (их можно отключить, кроме правил безопасности).
+// @Tags Rules
+// @Produce json
+// @Param id path string true "ID правила"
+// @Success 200 {string} string "Правило удалено"
+// @Failure 403 {object} models.HTTPError "Встроенное правило"
+// @Failure 404 {object} models.HTTPError "Правило не найдено"
+// @Router /api/v1/rules/{id} [delete]
+func (a *API) DeleteRule(c *gin.Context) {
+	if err := a.Engine.DeleteRule(c.Request.Context(), c.Param("id")); err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Правило удалено"})
+}
+
+func ruleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, automation.ErrInvalidRule), errors.Is(err, automation.ErrInvalidSilence):
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+	case errors.Is(err, automation.ErrBuiltinRule):
+		c.JSON(http.StatusForbidden, models.HTTPError{Code: 403, Message: err.Error()})
+	case errors.Is(err, storage.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.HTTPError{Code: 404, Message: err.Error()})
+	case errors.Is(err, storage.ErrDuplicate):
+		c.JSON(http.StatusConflict, models.HTTPError{Code: 409, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка записи правила: " + err.Error()})
+	}
+}
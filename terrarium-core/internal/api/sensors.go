@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// SENSOR REGISTRY (ПРОИЗВОЛЬНЫЕ ДАТЧИКИ И ЗОНЫ)
+// ==========================================
+
+// GetSensors godoc
+// @Summary Получить реестр датчиков
+// @Description Возвращает датчики террариума: ID, зону, драйвер и измеряемые величины. Датчики warm и cold задаются пинами warm_pin/cold_pin, дополнительные — списком sensors в ENCLOSURES. В условиях правил показание датчика указывается как "<датчик>.<величина>" (например basking.temperature), показание зоны — как "<зона>_temp" / "<зона>_hum".
+// @Tags Sensors
+// @Produce json
+// @Success 200 {array} models.SensorInfo "Реестр датчиков"
+// @Router /api/v1/sensors [get]
+func (a *API) GetSensors(c *gin.Context) {
+	c.JSON(http.StatusOK, a.Engine.Sensors())
+}
+
+// GetSensorReadings godoc
+// @Summary Получить текущие показания всех датчиков
+// @Description Возвращает последние показания каждого датчика реестра (ключ — ID датчика), включая ошибку последнего опроса. Данные кэшируются в Engine (обновляются каждые 5 сек).
+// @Tags Sensors
+// @Produce json
+// @Success 200 {object} models.SensorReadings "Текущие показания по датчикам"
+// @Router /api/v1/sensors/readings [get]
+func (a *API) GetSensorReadings(c *gin.Context) {
+	c.JSON(http.StatusOK, a.Engine.GetSensorReadings())
+}
+
+// GetReadingsHistory godoc
+// @Summary Получить историю показаний по датчикам
+// @Description Возвращает историю показаний из sensor_readings, сгруппированную по ID датчика (от новых к старым). Можно отфильтровать датчики, величину и период.
+// @Tags Metrics
+// @Produce json
+// @Param sensor query string false "ID датчиков через запятую (по умолчанию — все)"
+// @Param quantity query string false "Величина (temperature, humidity, ...)"
+// @Param from query string false "Начало периода (RFC3339, например 2026-02-26T00:00:00Z)"
+// @Param to query string false "Конец периода (RFC3339, например 2026-02-26T23:59:59Z)"
+// @Param limit query int false "Максимальное количество значений (по умолчанию 1000, макс 10000)"
+// @Success 200 {object} map[string][]models.SensorReading "История по датчикам"
+// @Failure 400 {object} models.HTTPError "Неверный формат параметров"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/metrics/readings [get]
+func (a *API) GetReadingsHistory(c *gin.Context) {
+	var from, to time.Time
+	var err error
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "Неверный формат 'from': " + err.Error()})
+			return
+		}
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "Неверный формат 'to': " + err.Error()})
+			return
+		}
+	}
+
+	limit := 1000
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+
+	var sensorIDs []string
+	for _, id := range strings.Split(c.Query("sensor"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			sensorIDs = append(sensorIDs, id)
+		}
+	}
+
+	readings, err := a.Repo.GetReadings(c.Request.Context(), sensorIDs, c.Query("quantity"), from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения истории датчиков: " + err.Error()})
+		return
+	}
+
+	result := make(map[string][]models.SensorReading)
+	for _, rd := range readings {
+		result[rd.SensorID] = append(result[rd.SensorID], rd)
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// DRY-RUN (ПРОВЕРКА КОНФИГУРАЦИИ НА ИСТОРИИ)
+// ==========================================
+
+// DryRunConfig godoc
+// @Summary Проверить кандидатную конфигурацию на истории датчиков
+// @Description Воспроизводит сохраненные показания sensor_readings за интервал (не более 7 суток) через логику решений движка с кандидатной конфигурацией, ничего не переключая и не сохраняя. Возвращает смоделированные переключения, скважность и оценку энергопотребления (по WATTAGE_MAPPING) в сравнении с фактической работой реле. Защита реле от дребезга, импульсный режим тумана и ручные переопределения не моделируются.
+// @Tags System, Configuration
+// @Accept json
+// @Produce json
+// @Param request body models.DryRunRequest true "Интервал истории и кандидатная конфигурация"
+// @Success 200 {object} models.DryRunResult "Результат симуляции"
+// @Failure 400 {object} models.HTTPError "Неверная конфигурация или интервал"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения истории из БД"
+// @Router /api/v1/config/dry-run [post]
+func (a *API) DryRunConfig(c *gin.Context) {
+	var req models.DryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+
+	if req.Config.WarmTargetMax <= req.Config.WarmTargetMin {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "WarmTargetMax должен быть больше WarmTargetMin"})
+		return
+	}
+
+	result, err := a.Engine.DryRun(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, automation.ErrInvalidDryRunWindow) {
+			c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка симуляции: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"terrarium-core/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// DECISION TRACE (ПОЧЕМУ РЕЛЕ ВКЛЮЧЕНО)
+// ==========================================
+
+// GetEngineDecisions godoc
+// @Summary Получить трассировку последних циклов движка
+// @Description Возвращает структурированные записи решений последних циклов (входные данные, эффективная конфигурация, оценка каждого правила, команды реле) из кольцевого буфера в памяти, от новых к старым.
+// @Tags Engine
+// @Produce json
+// @Param limit query int false "Количество циклов (по умолчанию 20, макс 240)"
+// @Param relay query string false "Только циклы с командами указанного реле"
+// @Success 200 {array} models.DecisionRecord "Записи решений"
+// @Router /api/v1/engine/decisions [get]
+func (a *API) GetEngineDecisions(c *gin.Context) {
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil {
+			limit = parsed
+		}
+	}
+	c.JSON(http.StatusOK, a.Engine.GetDecisions(limit, c.Query("relay")))
+}
+
+// ExplainRelay godoc
+// @Summary Объяснить текущее состояние реле
+// @Description Отвечает на вопрос "почему это реле включено/выключено": последнее переключение и правило, которое к нему привело, активные переопределения и текущая оценка правил.
+// @Tags Engine
+// @Produce json
+// @Param id path string true "ID реле" Enums(heat_mat, fogger, light)
+// @Success 200 {object} models.RelayExplanation "Объяснение состояния реле"
+// @Failure 404 {object} models.HTTPError "Реле не управляется автоматикой"
+// @Router /api/v1/relays/{id}/explain [get]
+func (a *API) ExplainRelay(c *gin.Context) {
+	exp, err := a.Engine.ExplainRelay(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.HTTPError{Code: 404, Message: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, exp)
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/storage"
+	"terrarium-core/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// WEBHOOKS (ИСХОДЯЩИЕ СОБЫТИЯ)
+// ==========================================
+
+// GetWebhooks godoc
+// @Summary Получить подписки webhook
+// @Description Подписки террариума на события. Секреты подписи не возвращаются.
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook "Список подписок"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/webhooks [get]
+func (a *API) GetWebhooks(c *gin.Context) {
+	hooks, err := a.Repo.GetWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения webhooks: " + err.Error()})
+		return
+	}
+	if hooks == nil {
+		hooks = []models.Webhook{}
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// CreateWebhook godoc
+// @Summary Создать подписку webhook
+// @Description Подписывает URL на события террариума: relay.switched, mode.changed, alert.fired, alert.resolved, alert.acknowledged или "*" (все). Каждое событие отправляется POST-запросом с JSON models.WebhookEvent и заголовками X-Terrarium-Event, X-Terrarium-Delivery и X-Terrarium-Signature (sha256=<hex HMAC-SHA256 тела на секрете>). Неудачная доставка (не 2xx) повторяется с экспоненциальной задержкой от 10 с до 1 ч; после 10 попыток доставка получает статус DEAD. События алертов отправляются и во время окон тишины. Если secret не задан, он генерируется и возвращается только в этом ответе.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param payload body models.WebhookRequest true "Подписка"
+// @Success 201 {object} models.Webhook "Подписка создана (с секретом)"
+// @Failure 400 {object} models.HTTPError "Невалидная подписка"
+// @Failure 500 {object} models.HTTPError "Ошибка записи в БД"
+// @Router /api/v1/webhooks [post]
+func (a *API) CreateWebhook(c *gin.Context) {
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+	if err := webhooks.Validate(&req); err != nil {
+		webhookError(c, err)
+		return
+	}
+	if req.Secret == "" {
+		req.Secret = webhooks.NewSecret()
+	}
+
+	hook, err := a.Repo.CreateWebhook(c.Request.Context(), req)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, hook)
+}
+
+// UpdateWebhook godoc
+// @Summary Обновить подписку webhook
+// @Description Полностью обновляет подписку. Пустой secret сохраняет прежний. Доставки отключенной подписки остаются в очереди до ее включения.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Param payload body models.WebhookRequest true "Подписка"
+// @Success 200 {string} string "Подписка обновлена"
+// @Failure 400 {object} models.HTTPError "Невалидная подписка"
+// @Failure 404 {object} models.HTTPError "Подписка не найдена"
+// @Router /api/v1/webhooks/{id} [put]
+func (a *API) UpdateWebhook(c *gin.Context) {
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+		return
+	}
+	if err := webhooks.Validate(&req); err != nil {
+		webhookError(c, err)
+		return
+	}
+
+	if err := a.Repo.UpdateWebhook(c.Request.Context(), c.Param("id"), req); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Подписка webhook обновлена"})
+}
+
+// DeleteWebhook godoc
+// @Summary Удалить подписку webhook
+// @Description Удаляет подписку вместе с ее очередью и журналом доставок.
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Success 200 {string} string "Подписка удалена"
+// @Failure 404 {object} models.HTTPError "Подписка не найдена"
+// @Router /api/v1/webhooks/{id} [delete]
+func (a *API) DeleteWebhook(c *gin.Context) {
+	if err := a.Repo.DeleteWebhook(c.Request.Context(), c.Param("id")); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"msg": "Подписка webhook удалена"})
+}
+
+// TestWebhook godoc
+// @Summary Проверить подписку webhook
+// @Description Ставит в очередь подписки событие ping. Результат доставки виден в журнале /webhooks/deliveries.
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID подписки"
+// @Success 202 {object} map[string]string "ID доставки"
+// @Failure 404 {object} models.HTTPError "Подписка не найдена"
+// @Router /api/v1/webhooks/{id}/test [post]
+func (a *API) TestWebhook(c *gin.Context) {
+	id, err := a.Repo.EnqueuePing(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"delivery_id": id})
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Журнал доставок webhook
+// @Description Доставки событий подписчикам террариума, начиная с последних: состояние, число попыток, код ответа и ошибка последней попытки. Успешные доставки хранятся 7 суток.
+// @Tags Webhooks
+// @Produce json
+// @Param webhook_id query string false "ID подписки (по умолчанию все)"
+// @Param status query string false "Состояние: PENDING, DELIVERED или DEAD (по умолчанию все)"
+// @Param limit query int false "Количество записей (по умолчанию 50, макс 500)"
+// @Success 200 {array} models.WebhookDelivery "Журнал доставок"
+// @Failure 400 {object} models.HTTPError "Неизвестное состояние"
+// @Failure 500 {object} models.HTTPError "Ошибка чтения из БД"
+// @Router /api/v1/webhooks/deliveries [get]
+func (a *API) GetWebhookDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != storage.DeliveryPending && status != storage.DeliveryDelivered && status != storage.DeliveryDead {
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: "status должен быть PENDING, DELIVERED или DEAD"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	deliveries, err := a.Repo.GetDeliveries(c.Request.Context(), c.Query("webhook_id"), status, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка чтения журнала доставок: " + err.Error()})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RetryWebhookDelivery godoc
+// @Summary Повторить доставку webhook
+// @Description Возвращает доставку в состоянии DEAD в очередь с новым счетчиком попыток.
+// @Tags Webhooks
+// @Produce json
+// @Param id path string true "ID доставки"
+// @Success 200 {object} models.WebhookDelivery "Доставка снова в очереди"
+// @Failure 404 {object} models.HTTPError "Доставка в состоянии DEAD не найдена"
+// @Router /api/v1/webhooks/deliveries/{id}/retry [post]
+func (a *API) RetryWebhookDelivery(c *gin.Context) {
+	delivery, err := a.Repo.RetryDelivery(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// webhookError отвечает кодом, соответствующим ошибке операции с подпиской.
+func webhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, models.HTTPError{Code: 400, Message: err.Error()})
+	case errors.Is(err, storage.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.HTTPError{Code: 404, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, models.HTTPError{Code: 500, Message: "Ошибка записи webhook: " + err.Error()})
+	}
+}
@@ -0,0 +1,432 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
+	"terrarium-core/internal/storage"
+)
+
+// Типы правил оповещения
+const (
+	AlertCondition     = "condition"
+	AlertSensorOffline = "sensor_offline"
+)
+
+// anySensor — значение AlertRule.Sensor: алерт срабатывает, если не отвечает любой датчик
+const anySensor = "*"
+
+// maxSilence — максимальная длительность окна тишины
+const maxSilence = 7 * 24 * time.Hour
+
+// ErrInvalidSilence возвращается, если окно тишины не прошло проверку.
+var ErrInvalidSilence = errors.New("некорректное окно тишины")
+
+// alertLevels — допустимая важность правил оповещения
+var alertLevels = []string{notify.LevelInfo, notify.LevelWarning, notify.LevelCritical}
+
+// alertState — состояние правила оповещения между циклами.
+type alertState struct {
+	pendingSince time.Time     // когда условие начало выполняться (нулевое — не выполняется)
+	alert        *models.Alert // активный алерт (nil — не сработал)
+	notifiedAt   time.Time     // последнее уведомление об активном алерте
+}
+
+// Переходы алерта, о которых рассылаются уведомления
+const (
+	alertFired    = "fired"
+	alertRepeated = "repeated"
+	alertResolved = "resolved"
+)
+
+// alertTransition — переход алерта в цикле. Запись в БД и уведомления выполняются после
+// обновления состояния, вне блокировки.
+type alertTransition struct {
+	kind  string
+	rule  models.AlertRule
+	state *alertState
+	alert models.Alert // снимок алерта на момент перехода
+}
+
+// restoreAlerts восстанавливает активные алерты из БД при старте движка, чтобы они решились,
+// когда условие перестанет выполняться.
+func (e *Engine) restoreAlerts(ctx context.Context) {
+	firing, err := e.repo.GetAlerts(ctx, storage.AlertFiring, 500)
+	if err != nil {
+		e.logger("alerts").Error("Не удалось восстановить активные алерты", logging.Err(err))
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range firing {
+		a := firing[i]
+		e.alerts[a.RuleID] = &alertState{pendingSince: a.StartedAt, alert: &a, notifiedAt: a.FiredAt}
+	}
+}
+
+// evaluateAlerts проверяет правила оповещения по показаниям цикла. Алерт срабатывает, когда условие
+// держится for_sec секунд, и решается, как только условие перестает выполняться. Если показание,
+// нужное правилу, в этом цикле не получено, состояние правила не меняется.
+func (e *Engine) evaluateAlerts(ctx context.Context, now time.Time, inputs map[string]float64, measured map[string]gpio.Measurement) {
+	rules, err := e.repo.GetAlertRules(ctx)
+	if err != nil {
+		e.logger("alerts").Error("Невозможно получить правила оповещения", logging.Err(err))
+		return
+	}
+
+	// Окружение условий (с конфигурацией для порогов ref) строится только при необходимости
+	var env *ruleEnv
+	envReady := false
+	getEnv := func() *ruleEnv {
+		if !envReady {
+			envReady = true
+			if cfg, err := e.repo.GetConfig(ctx); err == nil {
+				env = e.ruleEnv(now, inputs, cfg)
+			}
+		}
+		return env
+	}
+
+	type check struct {
+		matched bool
+		detail  string
+	}
+	checks := make(map[string]check, len(rules))
+	for _, rule := range rules {
+		if !rule.IsActive {
+			continue
+		}
+		var c check
+		var known bool
+		switch rule.Type {
+		case AlertSensorOffline:
+			c.matched, c.detail, known = e.checkSensorOffline(rule.Sensor, measured)
+		case AlertCondition:
+			if env := getEnv(); env != nil && hasInputs(rule.Conditions, inputs) {
+				c.matched, c.detail = env.check(rule.Conditions)
+				known = true
+			}
+		}
+		if known {
+			checks[rule.ID] = c
+		}
+	}
+
+	var transitions []alertTransition
+	active := make(map[string]bool, len(rules))
+	e.mu.Lock()
+	for _, rule := range rules {
+		if !rule.IsActive {
+			continue
+		}
+		active[rule.ID] = true
+		c, ok := checks[rule.ID]
+		if !ok {
+			continue
+		}
+		st := e.alerts[rule.ID]
+		if st == nil {
+			st = &alertState{}
+			e.alerts[rule.ID] = st
+		}
+
+		if !c.matched {
+			st.pendingSince = time.Time{}
+			if st.alert != nil {
+				resolvedAt := now
+				st.alert.State = storage.AlertResolved
+				st.alert.ResolvedAt = &resolvedAt
+				transitions = append(transitions, alertTransition{kind: alertResolved, rule: rule, state: st, alert: *st.alert})
+				st.alert = nil
+			}
+			continue
+		}
+
+		if st.pendingSince.IsZero() {
+			st.pendingSince = now
+		}
+		switch {
+		case st.alert == nil && now.Sub(st.pendingSince) >= time.Duration(rule.ForSec)*time.Second:
+			st.alert = &models.Alert{
+				RuleID:    rule.ID,
+				RuleName:  rule.Name,
+				Severity:  rule.Severity,
+				State:     storage.AlertFiring,
+				Message:   c.detail,
+				StartedAt: st.pendingSince,
+				FiredAt:   now,
+			}
+			st.notifiedAt = now
+			transitions = append(transitions, alertTransition{kind: alertFired, rule: rule, state: st, alert: *st.alert})
+		case st.alert != nil && rule.RepeatMin > 0 && st.alert.AcknowledgedAt == nil &&
+			now.Sub(st.notifiedAt) >= time.Duration(rule.RepeatMin)*time.Minute:
+			st.notifiedAt = now
+			snapshot := *st.alert
+			snapshot.Message = c.detail
+			transitions = append(transitions, alertTransition{kind: alertRepeated, rule: rule, state: st, alert: snapshot})
+		}
+	}
+
+	// Правила удалены или отключены: активные алерты решаются без уведомления
+	var orphaned []models.Alert
+	for ruleID, st := range e.alerts {
+		if active[ruleID] {
+			continue
+		}
+		if st.alert != nil {
+			orphaned = append(orphaned, *st.alert)
+		}
+		delete(e.alerts, ruleID)
+	}
+	e.mu.Unlock()
+
+	for _, a := range orphaned {
+		e.logger("alerts").Info("Алерт решен: правило отключено или удалено", "rule", a.RuleName)
+		if a.ID != "" {
+			if err := e.repo.ResolveAlert(ctx, a.ID, now); err != nil {
+				e.logger("alerts").Error("Не удалось записать решение алерта", "rule", a.RuleName, logging.Err(err))
+			}
+		}
+	}
+	if len(transitions) > 0 {
+		e.applyAlertTransitions(ctx, now, transitions)
+	}
+}
+
+// applyAlertTransitions записывает переходы алертов в БД и рассылает уведомления
+// (кроме правил в окне тишины).
+func (e *Engine) applyAlertTransitions(ctx context.Context, now time.Time, transitions []alertTransition) {
+	silences, err := e.repo.GetAlertSilences(ctx, now)
+	if err != nil {
+		e.logger("alerts").Error("Не удалось прочитать окна тишины. Уведомления отправляются", logging.Err(err))
+	}
+
+	for _, t := range transitions {
+		log := e.logger("alerts").With("rule", t.rule.Name, "severity", t.rule.Severity)
+		switch t.kind {
+		case alertFired:
+			log.Warn("Алерт сработал", "detail", t.alert.Message)
+			id, err := e.repo.InsertAlert(ctx, t.alert)
+			if err != nil {
+				log.Error("Не удалось записать алерт", logging.Err(err))
+				break
+			}
+			t.alert.ID = id
+			e.mu.Lock()
+			if t.state.alert != nil && t.state.alert.FiredAt.Equal(t.alert.FiredAt) {
+				t.state.alert.ID = id
+			}
+			e.mu.Unlock()
+		case alertResolved:
+			log.Info("Алерт решен", "duration", now.Sub(t.alert.FiredAt).Round(time.Second))
+			if t.alert.ID != "" {
+				if err := e.repo.ResolveAlert(ctx, t.alert.ID, now); err != nil {
+					log.Error("Не удалось записать решение алерта", logging.Err(err))
+				}
+			}
+		}
+
+		if silenced(silences, t.rule.ID, now) {
+			log.Info("Уведомление об алерте подавлено окном тишины", "transition", t.kind)
+			continue
+		}
+		e.notify(ctx, alertNotification(t, now))
+	}
+}
+
+// alertNotification собирает уведомление о переходе алерта.
+func alertNotification(t alertTransition, now time.Time) notify.Notification {
+	n := notify.Notification{
+		Level:    t.rule.Severity,
+		Source:   "alerts",
+		Title:    t.rule.Name,
+		Message:  t.alert.Message,
+		Time:     now,
+		Channels: t.rule.Channels,
+		Alert:    &t.alert,
+	}
+	if t.rule.Description != "" {
+		n.Message = t.rule.Description + ": " + t.alert.Message
+	}
+	switch t.kind {
+	case alertRepeated:
+		n.Title += " (не подтвержден)"
+	case alertResolved:
+		n.Level = notify.LevelInfo
+		n.Title += " — решен"
+		n.Message = "Условие больше не выполняется (алерт длился " + now.Sub(t.alert.FiredAt).Round(time.Second).String() + ")"
+	}
+	return n
+}
+
+// silenced сообщает, действует ли для правила окно тишины в момент at.
+func silenced(silences []models.AlertSilence, ruleID string, at time.Time) bool {
+	return slices.ContainsFunc(silences, func(s models.AlertSilence) bool {
+		return (s.RuleID == "" || s.RuleID == ruleID) && !at.Before(s.StartsAt) && at.Before(s.EndsAt)
+	})
+}
+
+// checkSensorOffline проверяет, что датчик (или любой датчик для "*") не ответил в этом цикле.
+// known = false, если датчика нет в реестре.
+func (e *Engine) checkSensorOffline(sensorID string, measured map[string]gpio.Measurement) (matched bool, detail string, known bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var offline []string
+	for _, slot := range e.sensors {
+		id := slot.info.ID
+		if sensorID != anySensor && id != sensorID {
+			continue
+		}
+		known = true
+		if _, ok := measured[id]; !ok {
+			entry := id
+			if snap := e.lastSensors[id]; snap.Error != "" {
+				entry += " (" + snap.Error + ")"
+			}
+			offline = append(offline, entry)
+		}
+	}
+	if len(offline) == 0 {
+		return false, "датчики отвечают", known
+	}
+	return true, "не отвечает: " + strings.Join(offline, ", "), known
+}
+
+// hasInputs сообщает, получены ли в цикле все показания, нужные условиям типа sensor.
+func hasInputs(conditions []models.RuleCondition, inputs map[string]float64) bool {
+	for _, c := range conditions {
+		if _, ok := inputs[c.Input]; c.Type == CondSensor && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ==========================================
+// УПРАВЛЕНИЕ ПРАВИЛАМИ, АЛЕРТАМИ И ОКНАМИ ТИШИНЫ
+// ==========================================
+
+// CreateAlertRule проверяет и сохраняет правило оповещения.
+func (e *Engine) CreateAlertRule(ctx context.Context, req models.AlertRuleRequest) (*models.AlertRule, error) {
+	if err := e.validateAlertRule(&req); err != nil {
+		return nil, err
+	}
+	return e.repo.CreateAlertRule(ctx, req)
+}
+
+// UpdateAlertRule проверяет и обновляет правило оповещения. Активный алерт правила сохраняется,
+// пока условие выполняется.
+func (e *Engine) UpdateAlertRule(ctx context.Context, id string, req models.AlertRuleRequest) error {
+	if err := e.validateAlertRule(&req); err != nil {
+		return err
+	}
+	return e.repo.UpdateAlertRule(ctx, id, req)
+}
+
+// DeleteAlertRule удаляет правило оповещения. Его активный алерт решается в следующем цикле.
+func (e *Engine) DeleteAlertRule(ctx context.Context, id string) error {
+	return e.repo.DeleteAlertRule(ctx, id)
+}
+
+// AcknowledgeAlert подтверждает алерт: повторные уведомления о нем прекращаются.
+func (e *Engine) AcknowledgeAlert(ctx context.Context, id, by string) (*models.Alert, error) {
+	a, err := e.repo.AcknowledgeAlert(ctx, id, by, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, st := range e.alerts {
+		if st.alert != nil && st.alert.ID == id {
+			st.alert.AcknowledgedAt = a.AcknowledgedAt
+			st.alert.AcknowledgedBy = a.AcknowledgedBy
+		}
+	}
+	e.logger("alerts").Info("Алерт подтвержден", "rule", a.RuleName, "by", a.AcknowledgedBy)
+	return a, nil
+}
+
+// CreateAlertSilence проверяет и создает окно тишины.
+func (e *Engine) CreateAlertSilence(ctx context.Context, req models.AlertSilenceRequest) (*models.AlertSilence, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSilence, fmt.Sprintf(format, args...))
+	}
+
+	s := models.AlertSilence{RuleID: req.RuleID, StartsAt: time.Now(), Comment: req.Comment}
+	if req.StartsAt != nil {
+		s.StartsAt = *req.StartsAt
+	}
+	switch {
+	case (req.EndsAt == nil) == (req.DurationMin == 0):
+		return nil, invalid("нужно указать ровно одно из ends_at или duration_min")
+	case req.EndsAt != nil:
+		s.EndsAt = *req.EndsAt
+	default:
+		s.EndsAt = s.StartsAt.Add(time.Duration(req.DurationMin) * time.Minute)
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return nil, invalid("окно тишины должно заканчиваться позже, чем начинается")
+	}
+	if s.EndsAt.Sub(s.StartsAt) > maxSilence {
+		return nil, invalid("окно тишины длиннее %s", maxSilence)
+	}
+	if s.RuleID != "" {
+		if _, err := e.repo.GetAlertRule(ctx, s.RuleID); err != nil {
+			return nil, err
+		}
+	}
+	return e.repo.CreateAlertSilence(ctx, s)
+}
+
+// validateAlertRule проверяет правило оповещения и подставляет важность по умолчанию (WARNING).
+func (e *Engine) validateAlertRule(req *models.AlertRuleRequest) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+	}
+
+	if req.Severity == "" {
+		req.Severity = notify.LevelWarning
+	}
+	switch {
+	case len(req.Name) > 64:
+		return invalid("имя длиннее 64 символов")
+	case !slices.Contains(alertLevels, req.Severity):
+		return invalid("неизвестная важность %q (допустимо: %v)", req.Severity, alertLevels)
+	case req.ForSec < 0:
+		return invalid("for_sec не может быть отрицательным")
+	case req.RepeatMin < 0:
+		return invalid("repeat_min не может быть отрицательным")
+	}
+	if channels := e.NotifyChannels(); channels != nil {
+		for _, ch := range req.Channels {
+			if !slices.Contains(channels, ch) {
+				return invalid("неизвестный канал доставки %q (допустимо: %v)", ch, channels)
+			}
+		}
+	}
+
+	switch req.Type {
+	case AlertCondition:
+		if len(req.Conditions) == 0 {
+			return invalid("правилу типа condition нужно хотя бы одно условие")
+		}
+		return e.validateConditions(req.Conditions)
+	case AlertSensorOffline:
+		if len(req.Conditions) > 0 {
+			return invalid("правило типа sensor_offline не принимает условия")
+		}
+		if req.Sensor != anySensor && !slices.ContainsFunc(e.Sensors(), func(s models.SensorInfo) bool { return s.ID == req.Sensor }) {
+			return invalid("неизвестный датчик %q (ID датчика или \"*\")", req.Sensor)
+		}
+		return nil
+	}
+	return invalid("неизвестный тип %q (допустимо: condition, sensor_offline)", req.Type)
+}
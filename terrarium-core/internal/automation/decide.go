@@ -0,0 +1,380 @@
+package automation
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/models"
+)
+
+// Имена служебных (не настраиваемых) правил движка, используются в трассировке решений
+const (
+	RuleMaxOnTime  = "MAX_ON_TIME"
+	RuleOverride   = "OVERRIDE"
+	RuleManualMode = "MANUAL_MODE"
+	RuleMist       = "MIST_PULSE"
+)
+
+// Решения правил
+const (
+	DecisionOn   = "ON"
+	DecisionOff  = "OFF"
+	DecisionHold = "HOLD"
+	DecisionSkip = "SKIP"
+)
+
+// allRelays — значение RuleEvaluation.Relay (и RuleAction.Relay) для правил, действующих на все реле
+const allRelays = "*"
+
+// Типы условий правил
+const (
+	CondSensor = "sensor"
+	CondTime   = "time"
+	CondRelay  = "relay"
+)
+
+// requiredInputs — показания зон, без которых цикл пропускается (на них опираются
+// аварийный контур, защита холодной зоны и термостаты)
+var requiredInputs = []string{"warm_temp", "warm_hum", "cold_temp"}
+
+// zoneSuffixes — сокращения величин в показаниях зон ("<зона>_temp", "<зона>_hum")
+var zoneSuffixes = map[string]string{gpio.QuantityTemperature: "temp", gpio.QuantityHumidity: "hum"}
+
+// inverseOp используется в объяснении невыполненного условия
+var inverseOp = map[string]string{"<": ">=", "<=": ">", ">": "<=", ">=": "<"}
+
+// Функции ниже — чистая логика решений (без побочных эффектов). Используются и движком,
+// и симуляцией, поэтому правила одинаково оцениваются в обоих.
+
+// ruleEnv — входные данные для оценки правил в одном цикле.
+type ruleEnv struct {
+	now      time.Time
+	inputs   map[string]float64
+	config   map[string]float64
+	relays   map[string]relayStatus
+	relayIDs []string // порядок реле для действий над "*"
+}
+
+// relayStatus — состояние реле для условий типа relay.
+type relayStatus struct {
+	on   bool
+	held time.Duration // сколько реле в текущем состоянии (<0 — неизвестно)
+}
+
+// ruleCommand — команда реле, выданная сработавшим правилом.
+type ruleCommand struct {
+	relay  string
+	state  bool
+	reason string
+	rule   string
+	detail string
+	all    bool // правило действует на все реле (аварийное отключение)
+}
+
+// evaluateRules оценивает правила в заданном порядке. Первое сработавшее правило "захватывает" реле
+// (claimed: ID реле -> имя правила) — правила ниже по приоритету это реле в цикле уже не переключают.
+// skip позволяет пропустить правило с объяснением (например, реле под ручным переопределением).
+func evaluateRules(rules []models.AutomationRule, env *ruleEnv, claimed map[string]string, skip func(*models.AutomationRule) string) ([]models.RuleEvaluation, []ruleCommand) {
+	evals := make([]models.RuleEvaluation, 0, len(rules))
+	var cmds []ruleCommand
+
+	for i := range rules {
+		rule := &rules[i]
+		ev := models.RuleEvaluation{Rule: rule.Name, Relay: rule.Action.Relay, Decision: DecisionHold}
+
+		targets := env.targets(rule.Action.Relay)
+		if len(targets) == 0 {
+			ev.Decision = DecisionSkip
+			ev.Detail = "реле " + rule.Action.Relay + " не управляется движком"
+			evals = append(evals, ev)
+			continue
+		}
+		if skip != nil {
+			if reason := skip(rule); reason != "" {
+				ev.Decision = DecisionSkip
+				ev.Detail = reason
+				evals = append(evals, ev)
+				continue
+			}
+		}
+
+		ev.Matched, ev.Detail = env.check(rule.Conditions)
+		if !ev.Matched {
+			evals = append(evals, ev)
+			continue
+		}
+
+		var free []string
+		for _, relay := range targets {
+			if claimed[relay] == "" {
+				free = append(free, relay)
+			}
+		}
+		if len(free) == 0 {
+			ev.Decision = DecisionSkip
+			ev.Detail += "; реле уже управляется правилом " + claimed[targets[0]]
+			evals = append(evals, ev)
+			continue
+		}
+
+		ev.Decision = decisionFor(rule.Action.State)
+		reason := rule.Action.Reason
+		if reason == "" {
+			reason = "RULE:" + rule.Name
+		}
+		for _, relay := range free {
+			claimed[relay] = rule.Name
+			cmds = append(cmds, ruleCommand{
+				relay: relay, state: rule.Action.State, reason: reason,
+				rule: rule.Name, detail: ev.Detail, all: rule.Action.Relay == allRelays,
+			})
+		}
+		evals = append(evals, ev)
+	}
+	return evals, cmds
+}
+
+// splitRules отбирает активные правила и делит их на правила безопасности и обычные (порядок сохраняется).
+func splitRules(rules []models.AutomationRule) (safety, normal []models.AutomationRule) {
+	for _, rule := range rules {
+		switch {
+		case !rule.IsActive:
+		case rule.Safety && rule.Action.State:
+			// Правило безопасности только выключает реле: включающее (записанное в БД в обход
+			// проверки validateRule) не исполняется
+		case rule.Safety:
+			safety = append(safety, rule)
+		default:
+			normal = append(normal, rule)
+		}
+	}
+	return safety, normal
+}
+
+func (env *ruleEnv) targets(relay string) []string {
+	if relay == allRelays {
+		return env.relayIDs
+	}
+	if _, ok := env.relays[relay]; ok {
+		return []string{relay}
+	}
+	return nil
+}
+
+// check проверяет все условия (И). Объяснение перечисляет выполненные условия,
+// а если правило не сработало — только невыполненные.
+func (env *ruleEnv) check(conditions []models.RuleCondition) (bool, string) {
+	var passed, failed []string
+	for _, c := range conditions {
+		ok, detail := env.checkCondition(c)
+		if ok {
+			passed = append(passed, detail)
+		} else {
+			failed = append(failed, detail)
+		}
+	}
+	if len(failed) > 0 {
+		return false, strings.Join(failed, "; ")
+	}
+	return true, strings.Join(passed, " и ")
+}
+
+func (env *ruleEnv) checkCondition(c models.RuleCondition) (bool, string) {
+	switch c.Type {
+	case CondSensor:
+		value, ok := env.inputs[c.Input]
+		if !ok {
+			return false, "нет показания " + c.Input
+		}
+		threshold, label, err := conditionThreshold(c, env.config)
+		if err != nil {
+			return false, err.Error()
+		}
+		ok = compare(value, c.Op, threshold)
+		op := c.Op
+		if !ok {
+			op = inverseOp[c.Op]
+		}
+		detail := fmt.Sprintf("%s %.1f %s %.1f", c.Input, value, op, threshold)
+		if label != "" {
+			detail += " (" + label + ")"
+		}
+		return ok, detail
+
+	case CondTime:
+		from, _ := parseClock(c.From)
+		to, _ := parseClock(c.To)
+		now := env.now.Hour()*60 + env.now.Minute()
+		in := now >= from && now < to
+		if from > to { // окно через полночь
+			in = now >= from || now < to
+		}
+		where := "вне окна"
+		if in {
+			where = "в окне"
+		}
+		return in, fmt.Sprintf("время %s %s %s–%s", env.now.Format("15:04"), where, c.From, c.To)
+
+	case CondRelay:
+		st, ok := env.relays[c.Relay]
+		if !ok || c.State == nil {
+			return false, "реле " + c.Relay + " не управляется движком"
+		}
+		if st.on != *c.State {
+			return false, fmt.Sprintf("%s %s, требуется %s", c.Relay, stateLabel(st.on), stateLabel(*c.State))
+		}
+		need := time.Duration(c.ForSec) * time.Second
+		if need == 0 {
+			return true, c.Relay + " " + stateLabel(st.on)
+		}
+		if st.held < 0 {
+			return false, fmt.Sprintf("%s %s, длительность состояния неизвестна", c.Relay, stateLabel(st.on))
+		}
+		held := st.held.Round(time.Second)
+		if st.held < need {
+			return false, fmt.Sprintf("%s %s %s < %s", c.Relay, stateLabel(st.on), held, need)
+		}
+		return true, fmt.Sprintf("%s %s %s >= %s", c.Relay, stateLabel(st.on), held, need)
+	}
+	return false, "неизвестный тип условия " + c.Type
+}
+
+// conditionThreshold возвращает порог условия sensor и его подпись (выражение ref, если задано).
+func conditionThreshold(c models.RuleCondition, config map[string]float64) (float64, string, error) {
+	if c.Value != nil {
+		return *c.Value, "", nil
+	}
+	v, err := resolveRef(c.Ref, config)
+	return v, c.Ref, err
+}
+
+// resolveRef вычисляет выражение из параметров конфигурации и чисел, соединенных + и -
+// (например "warm_target_min-hysteresis_temp").
+func resolveRef(ref string, config map[string]float64) (float64, error) {
+	expr := strings.ReplaceAll(ref, " ", "")
+	if expr == "" {
+		return 0, fmt.Errorf("пустое выражение порога")
+	}
+
+	total, sign, start := 0.0, 1.0, 0
+	for i := 0; i <= len(expr); i++ {
+		if i < len(expr) && ((expr[i] != '+' && expr[i] != '-') || i == start) {
+			continue
+		}
+		term := expr[start:i]
+		v, ok := config[term]
+		if !ok {
+			parsed, err := strconv.ParseFloat(term, 64)
+			if err != nil {
+				return 0, fmt.Errorf("неизвестный параметр конфигурации %q в выражении %q", term, ref)
+			}
+			v = parsed
+		}
+		total += sign * v
+		if i < len(expr) {
+			sign = 1
+			if expr[i] == '-' {
+				sign = -1
+			}
+			start = i + 1
+		}
+	}
+	return total, nil
+}
+
+// sensorKey возвращает имя показания датчика в условиях правил: "<датчик>.<величина>".
+func sensorKey(sensorID, quantity string) string {
+	return sensorID + "." + quantity
+}
+
+// zoneKey возвращает имя показания зоны: "<зона>_temp", "<зона>_hum" или "<зона>_<величина>".
+func zoneKey(zone, quantity string) string {
+	suffix, ok := zoneSuffixes[quantity]
+	if !ok {
+		suffix = quantity
+	}
+	return zone + "_" + suffix
+}
+
+// readingInputs дополняет показания датчиков ("<датчик>.<величина>") показаниями зон.
+// Показание зоны берется у первого датчика зоны в порядке реестра, ответившего в этом цикле.
+func readingInputs(values map[string]float64, sensors []models.SensorInfo) map[string]float64 {
+	inputs := make(map[string]float64, len(values)*2)
+	for key, v := range values {
+		inputs[key] = v
+	}
+	for _, s := range sensors {
+		for _, q := range s.Quantities {
+			v, ok := values[sensorKey(s.ID, q)]
+			if _, taken := inputs[zoneKey(s.Zone, q)]; ok && !taken {
+				inputs[zoneKey(s.Zone, q)] = v
+			}
+		}
+	}
+	return inputs
+}
+
+// inputNames перечисляет показания, доступные условиям типа sensor при данном реестре датчиков.
+func inputNames(sensors []models.SensorInfo) []string {
+	var names []string
+	for _, s := range sensors {
+		for _, q := range s.Quantities {
+			for _, name := range []string{sensorKey(s.ID, q), zoneKey(s.Zone, q)} {
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configValues возвращает параметры конфигурации, доступные в выражениях порогов (ключи — как в JSON).
+func configValues(cfg *models.ConfigPayload) map[string]float64 {
+	return map[string]float64{
+		"warm_target_min":         cfg.WarmTargetMin,
+		"warm_target_max":         cfg.WarmTargetMax,
+		"cold_max_threshold":      cfg.ColdMaxThreshold,
+		"emergency_max_threshold": cfg.EmergencyMaxThreshold,
+		"humidity_min":            cfg.HumidityMin,
+		"humidity_max":            cfg.HumidityMax,
+		"hysteresis_temp":         cfg.HysteresisTemp,
+		"hysteresis_hum":          cfg.HysteresisHum,
+	}
+}
+
+func compare(value float64, op string, threshold float64) bool {
+	switch op {
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	}
+	return false
+}
+
+// parseClock разбирает HH:MM в минуты от полуночи.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func stateLabel(on bool) string {
+	if on {
+		return "ВКЛ"
+	}
+	return "ВЫКЛ"
+}
@@ -0,0 +1,153 @@
+package automation
+
+import (
+	"context"
+	"time"
+
+	"terrarium-core/internal/diagnostics"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
+)
+
+// sensorFreezeTimeout — время неизменности показаний, после которого датчик считается зависшим
+const sensorFreezeTimeout = 30 * time.Minute
+
+// SetNotifier задает канал доставки уведомлений (по умолчанию — системный лог).
+func (e *Engine) SetNotifier(n notify.Notifier) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.notifier = n
+}
+
+// GetHealth возвращает результаты самодиагностики нагревателя и датчиков. Потокобезопасно.
+func (e *Engine) GetHealth() []models.ComponentHealth {
+	health := []models.ComponentHealth{e.heaterMon.Health()}
+	for _, slot := range e.sensors {
+		health = append(health, slot.freeze.Health())
+	}
+	return health
+}
+
+// CycleStatus возвращает состояние цикла движка. Движок считается зависшим, если цикл
+// не завершался дольше cycleStaleAfter. Потокобезопасно.
+func (e *Engine) CycleStatus() models.EngineStatus {
+	e.mu.RLock()
+	started := e.startedAt
+	e.mu.RUnlock()
+	if started.IsZero() {
+		return models.EngineStatus{}
+	}
+
+	status := models.EngineStatus{Running: true}
+	lastDone := started
+	if rec := e.traces.latest(); rec != nil {
+		at := rec.StartedAt
+		status.LastCycleAt = &at
+		status.LastCycleMs = rec.DurationMs
+		status.LastSkipped = rec.Skipped
+		lastDone = at.Add(time.Duration(rec.DurationMs * float64(time.Millisecond)))
+	}
+	status.Stale = time.Since(lastDone) > cycleStaleAfter
+	return status
+}
+
+// SensorHealth возвращает время последнего успешного опроса и ошибку каждого датчика. Потокобезопасно.
+func (e *Engine) SensorHealth() []models.SensorHealth {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	result := make([]models.SensorHealth, 0, len(e.sensors))
+	for _, slot := range e.sensors {
+		snap := e.lastSensors[slot.info.ID]
+		result = append(result, models.SensorHealth{
+			ID:            slot.info.ID,
+			Driver:        slot.info.Driver,
+			LastSuccessAt: snap.ReadAt,
+			Error:         snap.Error,
+		})
+	}
+	return result
+}
+
+// Состояния драйвера реле (RelayHealth)
+const (
+	RelayHealthOK          = "OK"
+	RelayHealthDesync      = "DESYNC"
+	RelayHealthUnreachable = "UNREACHABLE"
+)
+
+// RelayHealth возвращает состояние драйвера каждого реле по результатам сверки. Потокобезопасно.
+func (e *Engine) RelayHealth() []models.RelayHealth {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	result := make([]models.RelayHealth, 0, len(e.relays))
+	for _, relay := range e.relays {
+		h := models.RelayHealth{ID: relay.Name(), Status: RelayHealthOK}
+		if s := e.relaySync[relay.Name()]; s != nil {
+			switch {
+			case s.unreachable:
+				h.Status = RelayHealthUnreachable
+			case s.desynced:
+				h.Status = RelayHealthDesync
+			}
+			h.LastError = s.lastErr
+			if !s.lastErrAt.IsZero() {
+				at := s.lastErrAt
+				h.LastErrorAt = &at
+			}
+		}
+		result = append(result, h)
+	}
+	return result
+}
+
+// runDiagnostics передает показания цикла в диагностику и рассылает уведомления о неисправностях.
+// Зависание проверяется только у датчиков, ответивших в этом цикле.
+func (e *Engine) runDiagnostics(ctx context.Context, now time.Time, inputs map[string]float64, measured map[string]gpio.Measurement) {
+	e.publishFaults(ctx, "heater", e.heaterMon.Observe(now, inputs["warm_temp"], e.heatRelay.IsOn()))
+	for _, slot := range e.sensors {
+		if m, ok := measured[slot.info.ID]; ok {
+			e.publishFaults(ctx, slot.info.Name, slot.freeze.Observe(now, m))
+		}
+	}
+}
+
+func (e *Engine) publishFaults(ctx context.Context, component string, events []diagnostics.Event) {
+	for _, ev := range events {
+		n := notify.Notification{
+			Level:   notify.LevelInfo,
+			Source:  "diagnostics",
+			Title:   component + ": " + ev.Fault + " снята",
+			Message: ev.Message,
+			Time:    time.Now(),
+		}
+		if ev.Raised {
+			n.Level = notify.LevelCritical
+			if ev.Fault == diagnostics.FaultSensorFrozen {
+				n.Level = notify.LevelWarning
+			}
+			n.Title = component + ": " + ev.Fault
+		}
+		e.notify(ctx, n)
+	}
+}
+
+// notify передает уведомление в настроенный канал. notify.Center доставляет его в фоне,
+// поэтому недоступный Telegram или SMTP не задерживает цикл и контур безопасности.
+func (e *Engine) notify(ctx context.Context, n notify.Notification) {
+	e.mu.RLock()
+	notifier := e.notifier
+	e.mu.RUnlock()
+	n.Enclosure = e.repo.Enclosure()
+	_ = notifier.Notify(ctx, n)
+}
+
+// NotifyChannels возвращает имена каналов доставки уведомлений (nil — канал один, без имен).
+func (e *Engine) NotifyChannels() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if c, ok := e.notifier.(interface{ Channels() []string }); ok {
+		return c.Channels()
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"terrarium-core/internal/models"
+)
+
+// ErrInvalidDigestDay возвращается при запросе сводки за еще не начавшиеся сутки.
+var ErrInvalidDigestDay = errors.New("сводка доступна только за прошедшие или текущие сутки")
+
+// Digest собирает сводку за сутки day (местное время): климат по зонам, работу реле по relay_logs,
+// энергопотребление по WATTAGE_MAPPING и события безопасности. Текущие сутки учитываются до текущего момента.
+func (e *Engine) Digest(ctx context.Context, day time.Time) (*models.DailyDigest, error) {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+
+	if !from.Before(time.Now()) {
+		return nil, ErrInvalidDigestDay
+	}
+
+	zones, err := e.repo.GetZoneStats(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Причины отключений по правилам безопасности (EMERGENCY_CUTOFF, COLD_ZONE_PROTECTION, ...)
+	var safetyReasons []string
+	for _, rule := range e.loadRules(ctx) {
+		if rule.Safety && !slices.Contains(safetyReasons, rule.Action.Reason) {
+			safetyReasons = append(safetyReasons, rule.Action.Reason)
+		}
+	}
+	events, err := e.repo.GetSafetyEvents(ctx, from, to, safetyReasons)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	wattage := e.wattage
+	e.mu.RUnlock()
+
+	digest := &models.DailyDigest{
+		Enclosure: e.repo.Enclosure(),
+		Date:      from.Format(time.DateOnly),
+		From:      from,
+		To:        to,
+		Zones:     zones,
+		Relays:    make([]models.DigestRelay, 0, len(e.relays)),
+		Events:    events,
+	}
+	if digest.Zones == nil {
+		digest.Zones = []models.DigestZoneStat{}
+	}
+	if digest.Events == nil {
+		digest.Events = []models.DigestEvent{}
+	}
+
+	end := to // текущие сутки считаются по текущий момент
+	if now := time.Now(); now.Before(to) {
+		end = now
+	}
+	for _, relay := range e.relays {
+		activity, err := e.repo.GetRelayActivity(ctx, relay.Name(), from, end)
+		if err != nil {
+			return nil, err
+		}
+		kwh := roundKwh(wattage.Kwh(relay.Name(), activity.OnTime))
+		digest.Relays = append(digest.Relays, models.DigestRelay{
+			RelayID:  relay.Name(),
+			OnSec:    int64(activity.OnTime.Seconds()),
+			DutyPct:  dutyPct(activity.OnTime, end.Sub(from)),
+			Switches: activity.Switches,
+			Kwh:      kwh,
+		})
+		digest.TotalKwh += kwh
+	}
+	digest.TotalKwh = roundKwh(digest.TotalKwh)
+	return digest, nil
+}
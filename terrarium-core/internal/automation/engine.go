@@ -2,24 +2,37 @@ package automation
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
+	"terrarium-core/internal/diagnostics"
+	"terrarium-core/internal/energy"
+	"terrarium-core/internal/events"
 	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/metrics"
 	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
 	"terrarium-core/internal/storage"
 )
 
 // Engine представляет собой ядро, управляющее циклами климат-контроля.
 type Engine struct {
 	repo       *storage.Repository
-	warmSensor gpio.SensorReader
-	coldSensor gpio.SensorReader
 	heatRelay  gpio.RelayController
 	fogRelay   gpio.RelayController
 	lightRelay gpio.RelayController
 
+	// Все реле под управлением движка (правила могут действовать на любое из них)
+	relays []gpio.RelayController
+
+	// Реестр датчиков: теплая и холодная зоны и любые дополнительные датчики
+	sensors []*sensorSlot
+
 	// mu защищает доступ к кэшированным конфигурациям и показаниям
 	mu sync.RWMutex
 
@@ -28,21 +41,83 @@ type Engine struct {
 
 	// Кэш последних показаний датчиков (обновляется каждый цикл)
 	lastReadings *models.SensorCurrent
+	// Последние показания каждого датчика реестра (ID датчика -> показания)
+	lastSensors   map[string]models.SensorSnapshot
+	lastSensorsAt time.Time
+
+	// Состояние импульсного режима фоггера (misting)
+	mist mistState
+
+	// Диагностика теплового отклика нагревателя (зависание датчиков — в sensorSlot)
+	heaterMon *diagnostics.HeaterMonitor
+
+	// Канал уведомлений об авариях и неисправностях
+	notifier notify.Notifier
+	// Состояние правил оповещения (ID правила -> ожидание и активный алерт)
+	alerts map[string]*alertState
+
+	// Временные ручные переопределения реле (ID реле -> переопределение)
+	overrides map[string]*models.RelayOverride
+	// Активно ли аварийное отключение (по результату последнего цикла)
+	emergency bool
+
+	// Трассировка решений последних циклов и режим ее сохранения в БД
+	traces       *traceRing
+	tracePersist string
+
+	// Мощность нагрузок реле (Вт) для оценки энергопотребления
+	wattage energy.Wattage
+
+	// Сверка желаемого и фактического состояния реле (ID реле -> результат)
+	relaySync     map[string]*relaySyncState
+	lastReconcile time.Time
+
+	// Фаза расписания освещения диммируемых выходов (ID реле -> DAWN/DAY/DUSK/NIGHT)
+	lightPhase map[string]string
+
+	// Поплавковый выключатель резервуара фоггера (nil — не установлен)
+	reservoir *reservoirState
+	// Цифровые входы (крышка, кнопка аварийного стопа) и время включения аварийного стопа
+	inputs     []*inputSlot
+	panicSince time.Time
+
+	// Время запуска движка (нулевое — Start еще не вызывался)
+	startedAt time.Time
 }
 
-// NewEngine инициализирует Конечный Автомат.
-func NewEngine(repo *storage.Repository, warmS, coldS gpio.SensorReader, heat, fog, light gpio.RelayController) *Engine {
+// cycleInterval — период цикла движка (опрос датчиков и правила)
+const cycleInterval = 5 * time.Second
+
+// cycleStaleAfter — сколько цикл может не завершаться, прежде чем движок считается зависшим
+const cycleStaleAfter = 3 * cycleInterval
+
+// NewEngine инициализирует Конечный Автомат. Датчики регистрируются через AddSensor:
+// для работы нужны датчики зон warm и cold.
+func NewEngine(repo *storage.Repository, heat, fog, light gpio.RelayController) *Engine {
 	return &Engine{
-		repo:        repo,
-		warmSensor:  warmS,
-		coldSensor:  coldS,
-		heatRelay:   heat,
-		fogRelay:    fog,
-		lightRelay:  light,
-		currentMode: "AUTO", // По дефолту при старте
+		repo:         repo,
+		heatRelay:    heat,
+		fogRelay:     fog,
+		lightRelay:   light,
+		relays:       []gpio.RelayController{heat, fog, light},
+		currentMode:  "AUTO", // По дефолту при старте
+		heaterMon:    diagnostics.NewHeaterMonitor(diagnostics.DefaultHeaterConfig()),
+		notifier:     notify.LogNotifier{},
+		alerts:       make(map[string]*alertState),
+		overrides:    make(map[string]*models.RelayOverride),
+		relaySync:    make(map[string]*relaySyncState),
+		lightPhase:   make(map[string]string),
+		traces:       newTraceRing(traceRingSize),
+		tracePersist: TracePersistOff,
 	}
 }
 
+// AddRelay передает под управление движка дополнительное реле (например, spare).
+// Вызывается до Start.
+func (e *Engine) AddRelay(relay gpio.RelayController) {
+	e.relays = append(e.relays, relay)
+}
+
 // GetCurrentReadings возвращает последние показания датчиков из кэша Engine.
 // Потокобезопасно — вызывается из HTTP-обработчиков.
 func (e *Engine) GetCurrentReadings() *models.SensorCurrent {
@@ -51,24 +126,45 @@ func (e *Engine) GetCurrentReadings() *models.SensorCurrent {
 	return e.lastReadings
 }
 
+// logger возвращает журнал компонента движка; записи получают поле террариума.
+func (e *Engine) logger(component string) *slog.Logger {
+	return logging.Component(component).With(logging.KeyEnclosure, e.repo.Enclosure())
+}
+
 // Start запускает фоновую (non-blocking) горутину для контроля климата.
 func (e *Engine) Start(ctx context.Context) {
-	log.Println("Запуск движка автоматизации климата (Automation Engine)...")
+	e.logger("engine").Info("Запуск движка автоматизации климата (Automation Engine)...")
+
+	// Реестр датчиков и встроенные правила должны быть в БД до первого цикла
+	e.registerSensors(ctx)
+	e.seedRules(ctx)
+	e.restoreAlerts(ctx)
+	metrics.RegisterRelays(e.repo.Enclosure(), e.relays)
+
+	if e.reservoir != nil {
+		e.reservoir.input.Start(ctx)
+	}
+	e.restorePanicStop(ctx)
+	e.startInputs(ctx)
 
 	// Получаем первоначальный режим из БД
 	if mode, err := e.repo.GetSystemMode(ctx); err == nil {
 		e.currentMode = mode
-		log.Printf("[ENGINE] Режим при старте восстановлен: %s\n", e.currentMode)
+		e.logger("engine").Info("Режим при старте восстановлен", "mode", e.currentMode)
 	}
 
-	// Тикер на опрос датчиков (например, каждые 5 секунд)
-	ticker := time.NewTicker(5 * time.Second)
+	e.mu.Lock()
+	e.startedAt = time.Now()
+	e.mu.Unlock()
+
+	// Тикер на опрос датчиков
+	ticker := time.NewTicker(cycleInterval)
 	go func() {
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
-				log.Println("Движок автоматизации остановлен.")
+				e.logger("engine").Info("Движок автоматизации остановлен")
 				return
 			case <-ticker.C:
 				e.evaluateCycle(ctx)
@@ -78,124 +174,296 @@ func (e *Engine) Start(ctx context.Context) {
 }
 
 // updateModeCheck синхронизирует режим работы с БД, предотвращая рассинхрон при ручном вызове из API.
+// Если истек срок аренды MANUAL, возвращает систему в AUTO.
 func (e *Engine) updateModeCheck(ctx context.Context) {
+	mode, err := e.repo.GetSystemMode(ctx)
+	if err != nil {
+		return
+	}
+
+	if mode == "MANUAL" {
+		if until, err := e.repo.GetManualUntil(ctx); err == nil && until != nil && !time.Now().Before(*until) {
+			e.revertManual(ctx, *until)
+			mode = "AUTO"
+		}
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
-	mode, err := e.repo.GetSystemMode(ctx)
-	if err == nil && mode != e.currentMode {
-		log.Printf("[ENGINE] Режим изменен %s -> %s\n", e.currentMode, mode)
+	if mode != e.currentMode {
+		e.logger("engine").Info("Режим изменен", "from", e.currentMode, "to", mode)
 		e.currentMode = mode
 	}
 }
 
-// evaluateCycle - одна итерация цикла Конечного Автомата: чтение сенсоров -> проверка безопасности -> гистерезис.
+// revertManual возвращает систему в AUTO по истечении аренды режима MANUAL.
+func (e *Engine) revertManual(ctx context.Context, until time.Time) {
+	if err := e.repo.SetSystemMode(ctx, "AUTO", nil); err != nil {
+		e.logger("engine").Error("Не удалось вернуть режим AUTO по истечении аренды MANUAL", logging.Err(err))
+		return
+	}
+	e.logger("engine").Warn("Срок режима MANUAL истек. Автоматика возобновлена (AUTO)", "until", until.Format(time.DateTime))
+	e.notify(ctx, notify.Notification{
+		Level:   notify.LevelWarning,
+		Source:  "engine",
+		Title:   "Режим MANUAL истек — система вернулась в AUTO",
+		Message: "Срок ручного режима закончился в " + until.Format(time.DateTime) + ", автоматика снова управляет реле",
+		Time:    time.Now(),
+	})
+}
+
+// evaluateCycle - одна итерация цикла Конечного Автомата: чтение сенсоров -> правила безопасности -> правила автоматизации.
+// Каждое правило и каждая команда реле фиксируются в записи решения (DecisionRecord).
 func (e *Engine) evaluateCycle(ctx context.Context) {
+	defer func(start time.Time) { metrics.ObserveCycle(e.repo.Enclosure(), time.Since(start)) }(time.Now())
+	ctx, rec := e.beginTrace(ctx)
+	defer e.finishTrace(ctx, rec)
+
 	e.updateModeCheck(ctx)
 
 	// ШАГ 1: Чтение датчиков (Сбор данных)
-	warmData, errWarm := e.warmSensor.Read()
-	coldData, errCold := e.coldSensor.Read()
+	now := time.Now()
+	// Сверка фактического состояния реле не зависит от датчиков и режима
+	e.reconcileRelays(ctx, now)
+	// Защита фоггера от работы всухую, пауза при открытой крышке и аварийный стоп — тоже до датчиков, в любом режиме
+	fogBlocked := e.checkReservoir(ctx, now)
+	lidOpen := e.checkLid(ctx)
+	panicStop := e.enforcePanicStop(ctx)
+	measured := e.readSensors(now)
+	readings := e.sensorReadings(measured)
+	events.Publish(events.Event{Type: events.Readings, Enclosure: e.repo.Enclosure(), Time: now, Data: readings})
+	values := sensorValues(measured)
+	inputs := readingInputs(values, e.Sensors())
+	// Правила оповещения проверяются и тогда, когда цикл пропускается из-за отсутствия показаний
+	e.evaluateAlerts(ctx, now, inputs, measured)
+
+	e.mu.RLock()
+	rec.Mode = e.currentMode
+	e.mu.RUnlock()
 
-	if errWarm != nil || errCold != nil {
-		log.Println("[ENGINE] ВНИМАНИЕ: Ошибка чтения с датчиков DHT22! Пропускаем цикл.")
+	if missing := missingInputs(inputs); len(missing) > 0 {
+		e.logger("engine").Warn("Нет показаний (ошибка чтения датчиков)! Пропускаем цикл", "missing", missing)
+		rec.Skipped = "SENSOR_ERROR"
 		return
 	}
+	rec.Inputs = &models.DecisionInputs{
+		WarmTemp: inputs["warm_temp"],
+		WarmHum:  inputs["warm_hum"],
+		ColdTemp: inputs["cold_temp"],
+		ColdHum:  inputs["cold_hum"],
+		Readings: values,
+	}
 
-	// Обновляем кэш последних показаний (для эндпоинта /sensors/current)
+	// Обновляем кэш последних показаний зон (для эндпоинта /sensors/current)
 	e.mu.Lock()
 	e.lastReadings = &models.SensorCurrent{
-		WarmTemp:  warmData.Temperature,
-		WarmHum:   warmData.Humidity,
-		ColdTemp:  coldData.Temperature,
-		ColdHum:   coldData.Humidity,
-		Timestamp: time.Now(),
+		WarmTemp:  inputs["warm_temp"],
+		WarmHum:   inputs["warm_hum"],
+		ColdTemp:  inputs["cold_temp"],
+		ColdHum:   inputs["cold_hum"],
+		Timestamp: now,
 		Mode:      e.currentMode,
 	}
 	e.mu.Unlock()
 
-	// Пишем лог в базу каждый цикл (5 сек); в проде стоит делать batching
-	_ = e.repo.InsertSensorLog(ctx, warmData.Temperature, warmData.Humidity, coldData.Temperature, coldData.Humidity)
+	// Самодиагностика: тепловой отклик нагревателя и зависание датчиков
+	e.runDiagnostics(ctx, now, inputs, measured)
+
+	// Пишем показания в базу каждый цикл (5 сек); в проде стоит делать batching
+	_ = e.repo.InsertReadings(ctx, now, readings)
 
 	// Читаем текущую конфигурацию (целевые значения) из БД
 	cfg, err := e.repo.GetConfig(ctx)
 	if err != nil {
-		log.Printf("[ENGINE] Невозможно получить конфигурацию из БД: %v. Пропуск цикла.", err)
+		e.logger("engine").Error("Невозможно получить конфигурацию из БД. Пропуск цикла", logging.Err(err))
+		rec.Skipped = "CONFIG_ERROR"
 		return
 	}
+	rec.Config = cfg
+	metrics.SetSetpoints(e.repo.Enclosure(), cfg)
 
-	// ШАГ 2: БЕЗОПАСНЫЙ (АВАРИЙНЫЙ) КОНТУР - Игнорирует режим (AUTO/MANUAL)! Жизнь важнее.
-
-	// Контур теплового удара
-	if warmData.Temperature >= cfg.EmergencyMaxThreshold {
-		log.Printf("[EMERGENCY!!!] Температура в теплой зоне %.1f C превысила критическую отметку (%.1f C)!", warmData.Temperature, cfg.EmergencyMaxThreshold)
-		_ = e.heatRelay.Off()
-		_ = e.fogRelay.Off()
-		_ = e.lightRelay.Off() // Свет тоже может греть
-		e.repo.InsertRelayLog(ctx, "heat_mat", false, "EMERGENCY_CUTOFF")
+	rules := e.loadRules(ctx)
+	safetyRules, autoRules := splitRules(rules)
+	env := e.ruleEnv(now, inputs, cfg)
+	claimed := make(map[string]string) // реле, уже захваченные правилами в этом цикле
 
-		// TODO: Отправить в Telegram Alert
-		return // Блокируем дальнейшую логику цикла
+	// ШАГ 2: БЕЗОПАСНЫЙ (АВАРИЙНЫЙ) КОНТУР - Игнорирует режим (AUTO/MANUAL)! Жизнь важнее.
+	// Правила безопасности только выключают реле, в обход защиты от дребезга и ручных переопределений.
+	evals, cmds := evaluateRules(safetyRules, env, claimed, nil)
+	for _, ev := range evals {
+		traceRule(ctx, ev)
 	}
+	emergency := slices.ContainsFunc(cmds, func(c ruleCommand) bool { return c.all })
+	e.mu.Lock()
+	wasEmergency := e.emergencyLocked()
+	e.emergency = emergency
+	e.publishEmergency(wasEmergency, e.emergencyLocked(), now)
+	e.mu.Unlock()
 
-	// Контур перегрева холодной зоны (должна оставаться холодной для терморегуляции змеи)
-	if coldData.Temperature >= cfg.ColdMaxThreshold {
-		log.Printf("[SAFETY] Температура холодной зоны %.1f C превысила предел %.1f C. Отключаем обогрев.", coldData.Temperature, cfg.ColdMaxThreshold)
-		if e.heatRelay.IsOn() {
-			_ = e.heatRelay.Off()
-			e.repo.InsertRelayLog(ctx, "heat_mat", false, "COLD_ZONE_PROTECTION")
+	for _, cmd := range cmds {
+		if emergency && cmd.all {
+			e.logger("safety").Error("АВАРИЯ! Отключаем реле", "rule", cmd.rule, "detail", cmd.detail, logging.KeyRelay, cmd.relay)
+		} else if e.relayByName(cmd.relay).IsOn() {
+			e.logger("safety").Warn("Отключаем реле", "rule", cmd.rule, "detail", cmd.detail, logging.KeyRelay, cmd.relay)
 		}
+		e.forceOff(ctx, e.relayByName(cmd.relay), cmd.reason)
+		e.dropOnOverride(cmd.relay, cmd.reason)
+	}
+	if emergency {
+		if !wasEmergency {
+			e.notify(ctx, notify.Notification{
+				Level:   notify.LevelCritical,
+				Source:  "engine",
+				Title:   "Аварийное отключение всех реле",
+				Message: emergencyDetail(cmds),
+				Time:    now,
+			})
+		}
+		return // Блокируем дальнейшую логику цикла
+	}
+	if panicStop {
+		return // Аварийный стоп: реле выключены до сброса кнопкой или через API
+	}
+	switch { // ни правила, ни импульсы тумана не включат фоггер
+	case fogBlocked:
+		claimed[e.fogRelay.Name()] = RuleReservoir
+	case lidOpen:
+		claimed[e.fogRelay.Name()] = RuleLid
 	}
 
-	// ШАГ 3: Если режим MANUAL, мы ничего больше не делаем.
+	// Контур максимального времени непрерывной работы (защита реле и нагрузки)
+	e.enforceMaxOnTime(ctx)
+
 	e.mu.RLock()
 	mode := e.currentMode
 	e.mu.RUnlock()
 
+	// Временные переопределения реле: снимаем истекшие, в AUTO удерживаем действующие
+	e.processOverrides(ctx, mode != "MANUAL")
+
+	// ШАГ 3: Если режим MANUAL, мы ничего больше не делаем.
 	if mode == "MANUAL" {
+		traceRule(ctx, models.RuleEvaluation{Rule: RuleManualMode, Relay: allRelays, Matched: true, Decision: DecisionSkip, Detail: "режим MANUAL — автоматика не управляет реле"})
 		return
 	}
 
-	// ШАГ 4: ЛОГИКА АВТОМАТИЗАЦИИ (РЕЖИМ AUTO - ГИСТЕРЕЗИС)
-	e.evaluateHeating(ctx, warmData.Temperature, cfg)
-	e.evaluateFogger(ctx, warmData.Humidity, cfg)
+	// ШАГ 4: ЛОГИКА АВТОМАТИЗАЦИИ (РЕЖИМ AUTO - ПРАВИЛА В ПОРЯДКЕ ПРИОРИТЕТА)
+	mistCfg, err := e.repo.GetMistSettings(ctx)
+	if err != nil {
+		e.logger("engine").Error("Невозможно получить настройки тумана. Используем гистерезис", logging.Err(err))
+	}
+	rec.Mist = mistCfg
+	misting := mistCfg != nil && mistCfg.Enabled
+
+	// Реле под ручным переопределением автоматика не трогает до его окончания;
+	// в импульсном режиме фоггером управляет evaluateMisting вместо термостата.
+	evals, cmds = evaluateRules(autoRules, env, claimed, func(rule *models.AutomationRule) string {
+		if e.isOverridden(rule.Action.Relay) {
+			return "реле под ручным переопределением"
+		}
+		if misting && (rule.ID == builtinFogOn || rule.ID == builtinFogOff) {
+			return "включен импульсный режим тумана"
+		}
+		return ""
+	})
+	for _, ev := range evals {
+		traceRule(ctx, ev)
+	}
+	for _, cmd := range cmds {
+		relay := e.relayByName(cmd.relay)
+		if relay.IsOn() == cmd.state {
+			continue
+		}
+		e.logger("auto").Info("Переключение реле", "rule", cmd.rule, "detail", cmd.detail, logging.KeyRelay, cmd.relay, "state", stateLabel(cmd.state))
+		e.setRelay(ctx, relay, cmd.state, cmd.reason)
+	}
+
+	// Диммируемое освещение: яркость по расписаниям с плавным рассветом и закатом
+	e.applySchedules(ctx, now, claimed)
+
+	// Фоггер: импульсный режим (misting), если реле не захвачено правилом или переопределением
+	fog := e.fogRelay.Name()
+	if !misting {
+		e.resetMist()
+		return
+	}
+	if claimed[fog] == "" && !e.isOverridden(fog) {
+		e.evaluateMisting(ctx, inputs["warm_hum"], cfg, mistCfg)
+	}
 }
 
-// evaluateHeating проверяет необходимость включения/выключения термоковрика с учетом гистерезиса
-func (e *Engine) evaluateHeating(ctx context.Context, currentTemp float64, cfg *models.ConfigPayload) {
-	lowerBound := cfg.WarmTargetMin - cfg.HysteresisTemp
-	upperBound := cfg.WarmTargetMax + cfg.HysteresisTemp
+// setRelay переключает реле с учетом защитных ограничений и записывает событие в аудит.
+// Если защита отклонила команду, переключение откладывается до следующего цикла.
+// Возвращает true, если состояние реле фактически изменилось.
+func (e *Engine) setRelay(ctx context.Context, relay gpio.RelayController, state bool, reason string) bool {
+	if relay.IsOn() == state {
+		return false
+	}
 
-	if currentTemp <= lowerBound {
-		if !e.heatRelay.IsOn() {
-			log.Printf("[AUTO] Температура %.1f упала ниже %.1f. Включаем нагрев.", currentTemp, lowerBound)
-			_ = e.heatRelay.On()
-			e.repo.InsertRelayLog(ctx, e.heatRelay.Name(), true, "AUTO_TEMP_TRIGGER")
-		}
-	} else if currentTemp >= upperBound {
-		if e.heatRelay.IsOn() {
-			log.Printf("[AUTO] Температура %.1f достигла предела %.1f. Отключаем нагрев.", currentTemp, upperBound)
-			_ = e.heatRelay.Off()
-			e.repo.InsertRelayLog(ctx, e.heatRelay.Name(), false, "AUTO_TEMP_TRIGGER")
+	err := e.switchRelay(relay, state)
+	traceAction(ctx, relay.Name(), state, reason, err)
+	e.noteRelayError(relay.Name(), err)
+	if err != nil {
+		var perr *gpio.ProtectionError
+		if errors.As(err, &perr) {
+			e.logger("protection").Info("Переключение реле отложено", logging.KeyRelay, relay.Name(), "on", state, "reason", perr.Reason, "retry_after", perr.RetryAfter.Round(time.Second))
+		} else {
+			e.logger("engine").Error("Ошибка переключения реле", logging.KeyRelay, relay.Name(), "on", state, logging.Err(err))
 		}
+		return false
 	}
+
+	_ = e.repo.InsertRelayLog(ctx, relay.Name(), state, reason)
+	return true
 }
 
-// evaluateFogger проверяет необходимость вызова дождя/тумана
-func (e *Engine) evaluateFogger(ctx context.Context, currentHum float64, cfg *models.ConfigPayload) {
-	lowerBound := cfg.HumidityMin - cfg.HysteresisHum
-	upperBound := cfg.HumidityMax + cfg.HysteresisHum
+// forceOff выключает реле в обход защиты от дребезга (аварийные и защитные контуры).
+func (e *Engine) forceOff(ctx context.Context, relay gpio.RelayController, reason string) bool {
+	if !relay.IsOn() {
+		return false
+	}
+	err := gpio.ForceOff(relay)
+	traceAction(ctx, relay.Name(), false, reason, err)
+	e.noteRelayError(relay.Name(), err)
+	if err != nil {
+		e.logger("safety").Error("Ошибка аварийного отключения реле", logging.KeyRelay, relay.Name(), logging.Err(err))
+		return false
+	}
+	metrics.RelaySwitched(e.repo.Enclosure(), relay.Name(), false)
+	if relay.Name() == e.fogRelay.Name() {
+		// Импульс тумана прерван: в суточный расход идет только фактическая работа фоггера
+		e.mu.Lock()
+		e.mist.finishPulse(time.Now())
+		e.mu.Unlock()
+	}
+	_ = e.repo.InsertRelayLog(ctx, relay.Name(), false, reason)
+	return true
+}
 
-	if currentHum <= lowerBound {
-		if !e.fogRelay.IsOn() {
-			log.Printf("[AUTO] Влажность %.1f%% упала ниже %.1f%%. Включаем генератор тумана.", currentHum, lowerBound)
-			_ = e.fogRelay.On()
-			e.repo.InsertRelayLog(ctx, e.fogRelay.Name(), true, "AUTO_HUMIDITY_TRIGGER")
+// enforceMaxOnTime принудительно выключает реле, превысившие максимальное время непрерывной работы.
+// Работает в любом режиме (AUTO/MANUAL), так как защищает оборудование.
+func (e *Engine) enforceMaxOnTime(ctx context.Context) {
+	for _, relay := range e.relays {
+		p, ok := relay.(*gpio.ProtectedRelay)
+		if !ok || !p.MaxOnTimeExceeded() {
+			continue
 		}
-	} else if currentHum >= upperBound {
-		if e.fogRelay.IsOn() {
-			log.Printf("[AUTO] Влажность %.1f%% достигла нормы %.1f%%. Отключаем туман.", currentHum, upperBound)
-			_ = e.fogRelay.Off()
-			e.repo.InsertRelayLog(ctx, e.fogRelay.Name(), false, "AUTO_HUMIDITY_TRIGGER")
+		e.logger("protection").Warn("Реле работает дольше допустимого. Принудительное отключение", logging.KeyRelay, p.Name(), "max_on_time", p.Limits().MaxOnTime)
+		traceRule(ctx, models.RuleEvaluation{
+			Rule: RuleMaxOnTime, Relay: p.Name(), Matched: true, Decision: DecisionOff,
+			Detail: fmt.Sprintf("работает %s >= max_on_time %s", p.OnDuration().Round(time.Second), p.Limits().MaxOnTime),
+		})
+		e.forceOff(ctx, p, "MAX_ON_TIME_CUTOFF")
+		e.dropOnOverride(p.Name(), "MAX_ON_TIME_CUTOFF")
+	}
+}
+
+// emergencyDetail объясняет срабатывание правила аварийного отключения.
+func emergencyDetail(cmds []ruleCommand) string {
+	for _, cmd := range cmds {
+		if cmd.all {
+			return cmd.rule + ": " + cmd.detail
 		}
 	}
+	return ""
 }
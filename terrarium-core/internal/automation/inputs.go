@@ -0,0 +1,241 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
+)
+
+// Назначения цифровых входов террариума
+const (
+	InputLid   = "lid"   // концевик крышки/дверцы: пока крышка открыта, фоггер на паузе
+	InputPanic = "panic" // кнопка аварийного стопа: нажатие выключает все реле, повторное — сбрасывает стоп
+)
+
+// Служебные правила трассировки цифровых входов
+const (
+	RuleLid   = "LID_OPEN"
+	RulePanic = "PANIC_STOP"
+)
+
+// Причины в аудите реле
+const (
+	ReasonLidOpen   = "LID_OPEN"
+	ReasonPanicStop = "PANIC_STOP"
+)
+
+// События журнала входов (input_events)
+const (
+	InputEventOpen       = "OPEN"
+	InputEventClose      = "CLOSE"
+	InputEventPanicStop  = "PANIC_STOP"
+	InputEventPanicReset = "PANIC_RESET"
+)
+
+// PanicSourceAPI — источник аварийного стопа, включенного или сброшенного через API
+const PanicSourceAPI = "api"
+
+// ErrPanicStop возвращается при попытке включить реле во время аварийного стопа.
+var ErrPanicStop = errors.New("активен аварийный стоп: включение реле запрещено до его сброса")
+
+// ErrLidOpen возвращается при попытке включить фоггер, пока крышка террариума открыта.
+var ErrLidOpen = errors.New("крышка террариума открыта: фоггер на паузе")
+
+// inputSlot — цифровой вход движка.
+type inputSlot struct {
+	id     string
+	role   string
+	input  *gpio.DebouncedInput
+	invert bool // вход активен (крышка открыта, кнопка нажата) при разомкнутом контакте
+}
+
+// active сообщает, что вход активен. Если вход не отвечает, он считается неактивным.
+func (s *inputSlot) active(st gpio.InputState) bool {
+	return st.Known && st.Err == nil && st.Active != s.invert
+}
+
+// AddInput подключает цифровой вход с назначением InputLid или InputPanic. Вызывается до Start.
+// invert — вход активен при разомкнутом контакте (например, геркон крышки размыкается при открытии).
+func (e *Engine) AddInput(id, role string, input *gpio.DebouncedInput, invert bool) {
+	e.inputs = append(e.inputs, &inputSlot{id: id, role: role, input: input, invert: invert})
+}
+
+// GetInputs возвращает состояние цифровых входов. Потокобезопасно.
+func (e *Engine) GetInputs() []models.InputStatus {
+	result := make([]models.InputStatus, 0, len(e.inputs))
+	for _, slot := range e.inputs {
+		st := slot.input.State()
+		status := models.InputStatus{ID: slot.id, Role: slot.role, Active: slot.active(st)}
+		if st.Known {
+			since := st.Since
+			status.Since = &since
+		}
+		if st.Err != nil {
+			status.Error = st.Err.Error()
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+// PanicStopSince возвращает время включения аварийного стопа (nil — стоп не активен). Потокобезопасно.
+func (e *Engine) PanicStopSince() *time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.panicSince.IsZero() {
+		return nil
+	}
+	since := e.panicSince
+	return &since
+}
+
+// SetPanicStop включает или сбрасывает аварийный стоп. При включении все реле сразу выключаются
+// в обход защиты от дребезга, переопределения снимаются, а автоматика не управляет реле до сброса.
+// source — ID кнопки или PanicSourceAPI.
+func (e *Engine) SetPanicStop(ctx context.Context, active bool, source string) {
+	now := time.Now()
+	e.mu.Lock()
+	if active == !e.panicSince.IsZero() {
+		e.mu.Unlock()
+		return
+	}
+	wasEmergency := e.emergencyLocked()
+	e.panicSince = time.Time{}
+	if active {
+		e.panicSince = now
+		e.overrides = make(map[string]*models.RelayOverride)
+	}
+	e.publishEmergency(wasEmergency, e.emergencyLocked(), now)
+	e.mu.Unlock()
+
+	if !active {
+		e.logger("panic").Warn("Аварийный стоп сброшен. Автоматика возобновляет управление", "source", source)
+		e.persistPanicStop(ctx, nil)
+		e.recordInputEvent(ctx, source, InputEventPanicReset, now)
+		e.notify(ctx, notify.Notification{
+			Level:   notify.LevelInfo,
+			Source:  "engine",
+			Title:   "Аварийный стоп сброшен",
+			Message: "Стоп сброшен (" + source + "), автоматика снова управляет реле",
+			Time:    now,
+		})
+		return
+	}
+
+	e.logger("panic").Error("Аварийный стоп: все реле выключены, автоматика остановлена до сброса", "source", source)
+	for _, relay := range e.relays {
+		e.forceOff(ctx, relay, ReasonPanicStop)
+	}
+	e.persistPanicStop(ctx, &now)
+	e.recordInputEvent(ctx, source, InputEventPanicStop, now)
+	e.notify(ctx, notify.Notification{
+		Level:   notify.LevelCritical,
+		Source:  "engine",
+		Title:   "Аварийный стоп",
+		Message: "Все реле выключены (" + source + "). Автоматика остановлена до сброса кнопкой или через API",
+		Time:    now,
+	})
+}
+
+// persistPanicStop сохраняет состояние аварийного стопа в БД (nil — стоп сброшен).
+func (e *Engine) persistPanicStop(ctx context.Context, since *time.Time) {
+	if err := e.repo.SetPanicStop(ctx, since); err != nil {
+		e.logger("panic").Error("Не удалось сохранить состояние аварийного стопа", logging.Err(err))
+	}
+}
+
+// restorePanicStop восстанавливает аварийный стоп, активный до перезапуска сервиса:
+// сброс возможен только кнопкой или через API. Вызывается в Start до первого цикла.
+func (e *Engine) restorePanicStop(ctx context.Context) {
+	since, err := e.repo.GetPanicStop(ctx)
+	if err != nil {
+		e.logger("panic").Error("Не удалось восстановить состояние аварийного стопа", logging.Err(err))
+		return
+	}
+	if since == nil {
+		return
+	}
+	e.mu.Lock()
+	e.panicSince = *since
+	e.overrides = make(map[string]*models.RelayOverride)
+	e.mu.Unlock()
+	e.logger("panic").Warn("Аварийный стоп восстановлен после перезапуска: реле выключены до сброса", "since", *since)
+}
+
+// startInputs подписывается на фронты цифровых входов и запускает их чтение.
+func (e *Engine) startInputs(ctx context.Context) {
+	for _, slot := range e.inputs {
+		slot.input.OnEdge(func(edge gpio.InputEdge) {
+			e.onInputEdge(ctx, slot, edge)
+		})
+		slot.input.Start(ctx)
+	}
+}
+
+// onInputEdge реагирует на фронт входа сразу, не дожидаясь цикла движка.
+func (e *Engine) onInputEdge(ctx context.Context, slot *inputSlot, edge gpio.InputEdge) {
+	active := edge.Active != slot.invert
+	switch slot.role {
+	case InputLid:
+		if !active {
+			e.logger("input").Info("Крышка закрыта. Фоггер возобновит работу", "input", slot.id)
+			e.recordInputEvent(ctx, slot.id, InputEventClose, edge.Time)
+			return
+		}
+		e.logger("input").Info("Крышка открыта. Фоггер на паузе", "input", slot.id)
+		e.recordInputEvent(ctx, slot.id, InputEventOpen, edge.Time)
+		e.forceOff(ctx, e.fogRelay, ReasonLidOpen)
+		e.dropOnOverride(e.fogRelay.Name(), ReasonLidOpen)
+	case InputPanic:
+		if active { // реагируем на нажатие, отпускание кнопки ничего не делает
+			e.SetPanicStop(ctx, e.PanicStopSince() == nil, slot.id)
+		}
+	}
+}
+
+// checkLid держит фоггер выключенным, пока открыта любая крышка. Возвращает true, если фоггер на паузе.
+func (e *Engine) checkLid(ctx context.Context) bool {
+	for _, slot := range e.inputs {
+		if slot.role != InputLid || !slot.active(slot.input.State()) {
+			continue
+		}
+		fog := e.fogRelay.Name()
+		traceRule(ctx, models.RuleEvaluation{
+			Rule: RuleLid, Relay: fog, Matched: true, Decision: DecisionOff,
+			Detail: fmt.Sprintf("крышка '%s' открыта — фоггер на паузе", slot.id),
+		})
+		e.forceOff(ctx, e.fogRelay, ReasonLidOpen)
+		e.dropOnOverride(fog, ReasonLidOpen)
+		return true
+	}
+	return false
+}
+
+// enforcePanicStop держит все реле выключенными во время аварийного стопа. Возвращает true, если стоп активен.
+func (e *Engine) enforcePanicStop(ctx context.Context) bool {
+	since := e.PanicStopSince()
+	if since == nil {
+		return false
+	}
+	traceRule(ctx, models.RuleEvaluation{
+		Rule: RulePanic, Relay: allRelays, Matched: true, Decision: DecisionOff,
+		Detail: "аварийный стоп с " + since.Format(time.DateTime) + " — все реле выключены до сброса",
+	})
+	for _, relay := range e.relays {
+		e.forceOff(ctx, relay, ReasonPanicStop)
+	}
+	return true
+}
+
+// recordInputEvent записывает событие цифрового входа в журнал.
+func (e *Engine) recordInputEvent(ctx context.Context, inputID, event string, at time.Time) {
+	if err := e.repo.InsertInputEvent(ctx, inputID, event, at); err != nil {
+		e.logger("input").Error("Не удалось записать событие входа", "input", inputID, logging.Err(err))
+	}
+}
@@ -0,0 +1,228 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"terrarium-core/internal/events"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
+	"terrarium-core/internal/storage"
+)
+
+// recordNotifier запоминает отправленные уведомления.
+type recordNotifier struct {
+	mu   sync.Mutex
+	sent []notify.Notification
+}
+
+func (r *recordNotifier) Notify(_ context.Context, n notify.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func (r *recordNotifier) levels() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	levels := make([]string, 0, len(r.sent))
+	for _, n := range r.sent {
+		levels = append(levels, n.Level)
+	}
+	return levels
+}
+
+// newTestEngine создает движок с mock-реле и репозиторием без БД: пул указывает на закрытый порт,
+// поэтому запись аудита и журнала входов завершается ошибкой, которую движок только логирует.
+func newTestEngine(t *testing.T) (*Engine, *recordNotifier) {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgres://test@127.0.0.1:1/test?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("pgxpool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	e := NewEngine(storage.NewRepository(&storage.DB{Pool: pool}),
+		gpio.NewMockRelay("heat_mat"), gpio.NewMockRelay("fogger"), gpio.NewMockRelay("light"))
+	n := &recordNotifier{}
+	e.SetNotifier(n)
+	return e, n
+}
+
+// addTestInput подключает mock-вход и запускает его чтение; первое показание задает состояние без фронта.
+func addTestInput(t *testing.T, e *Engine, id, role string, closed, invert bool) *inputSlot {
+	t.Helper()
+	mock := gpio.NewMockInput(id)
+	mock.Set(closed)
+	e.AddInput(id, role, gpio.NewDebouncedInput(mock, time.Hour), invert)
+	slot := e.inputs[len(e.inputs)-1]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	slot.input.Start(ctx)
+	return slot
+}
+
+func TestPanicButtonTogglesStop(t *testing.T) {
+	e, n := newTestEngine(t)
+	slot := addTestInput(t, e, "panic_btn", InputPanic, false, false)
+	ctx := context.Background()
+
+	for _, r := range e.relays {
+		_ = r.On()
+	}
+	e.overrides["light"] = &models.RelayOverride{RelayID: "light", State: true}
+
+	// Нажатие включает стоп: все реле выключены, переопределения сняты, включение запрещено
+	e.onInputEdge(ctx, slot, gpio.InputEdge{Active: true, Time: time.Now()})
+	if e.PanicStopSince() == nil {
+		t.Fatal("аварийный стоп не включен")
+	}
+	for _, r := range e.relays {
+		if r.IsOn() {
+			t.Errorf("реле %s осталось включенным", r.Name())
+		}
+	}
+	if len(e.GetOverrides()) != 0 {
+		t.Error("переопределения должны сниматься аварийным стопом")
+	}
+	if err := e.BlockedOn("heat_mat"); !errors.Is(err, ErrPanicStop) {
+		t.Errorf("BlockedOn = %v, want ErrPanicStop", err)
+	}
+
+	// Цикл движка держит реле выключенными, даже если их включили в обход
+	_ = e.heatRelay.On()
+	if !e.enforcePanicStop(ctx) || e.heatRelay.IsOn() {
+		t.Error("enforcePanicStop должен выключать реле во время стопа")
+	}
+
+	// Отпускание кнопки ничего не делает, повторное нажатие сбрасывает стоп
+	e.onInputEdge(ctx, slot, gpio.InputEdge{Active: false, Time: time.Now()})
+	if e.PanicStopSince() == nil {
+		t.Fatal("отпускание кнопки не должно сбрасывать стоп")
+	}
+	e.onInputEdge(ctx, slot, gpio.InputEdge{Active: true, Time: time.Now()})
+	if e.PanicStopSince() != nil {
+		t.Fatal("повторное нажатие должно сбрасывать стоп")
+	}
+	if err := e.BlockedOn("heat_mat"); err != nil {
+		t.Errorf("BlockedOn после сброса = %v", err)
+	}
+	if e.enforcePanicStop(ctx) {
+		t.Error("enforcePanicStop после сброса должен возвращать false")
+	}
+
+	got := n.levels()
+	if len(got) != 2 || got[0] != notify.LevelCritical || got[1] != notify.LevelInfo {
+		t.Errorf("уведомления = %v, want [critical info]", got)
+	}
+}
+
+func TestSetPanicStopIsIdempotent(t *testing.T) {
+	e, n := newTestEngine(t)
+	ctx := context.Background()
+
+	e.SetPanicStop(ctx, false, PanicSourceAPI)
+	e.SetPanicStop(ctx, true, PanicSourceAPI)
+	since := e.PanicStopSince()
+	e.SetPanicStop(ctx, true, PanicSourceAPI)
+
+	if got := e.PanicStopSince(); got == nil || !got.Equal(*since) {
+		t.Errorf("повторное включение не должно менять время стопа: %v -> %v", since, got)
+	}
+	if got := n.levels(); len(got) != 1 {
+		t.Errorf("уведомления = %v, want одно", got)
+	}
+}
+
+func TestPanicStopIsEmergency(t *testing.T) {
+	e, _ := newTestEngine(t)
+	ctx := context.Background()
+	sub, cancel := events.Subscribe()
+	defer cancel()
+
+	// Аварийное состояние (MQTT emergency/state) включает аварийный стоп, а не только правила безопасности
+	e.SetPanicStop(ctx, true, PanicSourceAPI)
+	if !e.Emergency() {
+		t.Error("Emergency во время аварийного стопа = false")
+	}
+	e.SetPanicStop(ctx, false, PanicSourceAPI)
+	if e.Emergency() {
+		t.Error("Emergency после сброса стопа = true")
+	}
+
+	var got []bool
+	for len(got) < 2 {
+		select {
+		case ev := <-sub:
+			if ev.Type == events.EmergencyChanged {
+				got = append(got, ev.Data.(events.Emergency).Active)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("события EmergencyChanged = %v, want [true false]", got)
+		}
+	}
+	if !got[0] || got[1] {
+		t.Errorf("события EmergencyChanged = %v, want [true false]", got)
+	}
+}
+
+func TestLidEdgePausesFogger(t *testing.T) {
+	e, _ := newTestEngine(t)
+	// Геркон крышки размыкается при открытии (invert): крышка закрыта
+	slot := addTestInput(t, e, "lid", InputLid, true, true)
+	ctx := context.Background()
+
+	_ = e.fogRelay.On()
+	_ = e.heatRelay.On()
+	e.overrides["fogger"] = &models.RelayOverride{RelayID: "fogger", State: true}
+
+	if err := e.BlockedOn("fogger"); err != nil {
+		t.Fatalf("BlockedOn при закрытой крышке = %v", err)
+	}
+	if e.checkLid(ctx) {
+		t.Fatal("checkLid при закрытой крышке должен возвращать false")
+	}
+
+	// Открытие (контакт разомкнут): фоггер выключается сразу, не дожидаясь цикла
+	e.onInputEdge(ctx, slot, gpio.InputEdge{Active: false, Time: time.Now()})
+	if e.fogRelay.IsOn() {
+		t.Error("фоггер должен выключаться при открытии крышки")
+	}
+	if !e.heatRelay.IsOn() {
+		t.Error("крышка не должна выключать другие реле")
+	}
+	if len(e.GetOverrides()) != 0 {
+		t.Error("переопределение фоггера должно сниматься при открытии крышки")
+	}
+	if e.PanicStopSince() != nil {
+		t.Error("крышка не должна включать аварийный стоп")
+	}
+}
+
+func TestOpenLidBlocksFogger(t *testing.T) {
+	e, _ := newTestEngine(t)
+	// Крышка открыта с момента запуска: контакт геркона разомкнут
+	addTestInput(t, e, "lid", InputLid, false, true)
+	ctx := context.Background()
+
+	if err := e.BlockedOn("fogger"); !errors.Is(err, ErrLidOpen) {
+		t.Errorf("BlockedOn(fogger) = %v, want ErrLidOpen", err)
+	}
+	if err := e.BlockedOn("heat_mat"); err != nil {
+		t.Errorf("BlockedOn(heat_mat) = %v, крышка блокирует только фоггер", err)
+	}
+
+	// Цикл движка держит фоггер выключенным, пока крышка открыта
+	_ = e.fogRelay.On()
+	if !e.checkLid(ctx) || e.fogRelay.IsOn() {
+		t.Error("checkLid должен выключать фоггер при открытой крышке")
+	}
+}
@@ -0,0 +1,144 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/metrics"
+	"terrarium-core/internal/models"
+)
+
+// RuleSchedule — служебное правило трассировки: яркость диммируемого выхода по расписанию
+const RuleSchedule = "SCHEDULE"
+
+// Фазы расписания освещения (используются и как причина в relay_logs: SCHEDULE_<фаза>)
+const (
+	PhaseDawn  = "DAWN"  // рассвет: яркость растет после start_time
+	PhaseDay   = "DAY"   // полная яркость расписания
+	PhaseDusk  = "DUSK"  // закат: яркость спадает перед end_time
+	PhaseNight = "NIGHT" // вне расписаний — выход погашен
+)
+
+var phaseLabels = map[string]string{
+	PhaseDawn:  "рассвет",
+	PhaseDay:   "день",
+	PhaseDusk:  "закат",
+	PhaseNight: "ночь",
+}
+
+// scheduleLevel вычисляет яркость выхода в момент now по его активным расписаниям.
+// Внутри окна [start_time, end_time) яркость линейно растет первые ramp_min минут и спадает
+// последние ramp_min минут (окно через полночь допускается). Если окна пересекаются, берется
+// наибольшая яркость.
+func scheduleLevel(schedules []models.Schedule, now time.Time) (float64, string) {
+	const day = 24 * 60 * 60
+	clock := now.Hour()*3600 + now.Minute()*60 + now.Second()
+
+	level, phase := 0.0, PhaseNight
+	for _, s := range schedules {
+		start, err1 := parseClock(s.StartTime)
+		end, err2 := parseClock(s.EndTime)
+		if err1 != nil || err2 != nil || start == end {
+			continue
+		}
+		length := ((end-start)*60 + day) % day
+		elapsed := (clock - start*60 + day) % day
+		if elapsed >= length {
+			continue
+		}
+
+		factor, p := 1.0, PhaseDay
+		if ramp := float64(s.RampMin * 60); ramp > 0 {
+			up, down := float64(elapsed)/ramp, float64(length-elapsed)/ramp
+			switch {
+			case up < 1 && up <= down:
+				factor, p = up, PhaseDawn
+			case down < 1:
+				factor, p = down, PhaseDusk
+			}
+		}
+		if l := math.Round(s.Brightness*factor*10) / 10; l > level || phase == PhaseNight {
+			level, phase = l, p
+		}
+	}
+	return level, phase
+}
+
+// applySchedules управляет яркостью диммируемых выходов по расписаниям (рассвет и закат).
+// Выходы без активных расписаний, захваченные правилами в этом цикле или под ручным
+// переопределением, не трогаются. Обычные реле расписания не переключают.
+func (e *Engine) applySchedules(ctx context.Context, now time.Time, claimed map[string]string) {
+	schedules, err := e.repo.GetSchedules(ctx)
+	if err != nil {
+		e.logger("lighting").Error("Невозможно получить расписания", logging.Err(err))
+		return
+	}
+	byRelay := make(map[string][]models.Schedule)
+	for _, s := range schedules {
+		if s.IsActive {
+			byRelay[s.RelayID] = append(byRelay[s.RelayID], s)
+		}
+	}
+
+	for _, relay := range e.relays {
+		name := relay.Name()
+		dimmer, ok := gpio.AsDimmer(relay)
+		if !ok || len(byRelay[name]) == 0 || claimed[name] != "" || e.isOverridden(name) {
+			continue
+		}
+		level, phase := scheduleLevel(byRelay[name], now)
+		decision := DecisionOff
+		if level > 0 {
+			decision = DecisionOn
+		}
+		traceRule(ctx, models.RuleEvaluation{
+			Rule: RuleSchedule, Relay: name, Matched: level > 0, Decision: decision,
+			Detail: fmt.Sprintf("%s: яркость %.1f%%", phaseLabels[phase], level),
+		})
+		e.setBrightness(ctx, dimmer, level, phase)
+	}
+}
+
+// setBrightness задает яркость диммера с учетом защиты реле. В аудит (relay_logs) попадают
+// включение, выключение и смена фазы расписания, а не каждый шаг плавного перехода.
+func (e *Engine) setBrightness(ctx context.Context, dimmer gpio.Dimmer, level float64, phase string) {
+	name := dimmer.Name()
+	current := dimmer.Brightness()
+	prev, known := e.lightPhase[name]
+	phaseChanged := known && prev != phase
+	changed := math.Abs(current-level) >= 0.05
+	if !changed && !phaseChanged {
+		return
+	}
+
+	reason := "SCHEDULE_" + phase
+	if changed {
+		err := dimmer.SetBrightness(level)
+		traceBrightness(ctx, name, level, reason, err)
+		e.noteRelayError(name, err)
+		var perr *gpio.ProtectionError
+		switch {
+		case errors.As(err, &perr):
+			e.logger("protection").Info("Изменение яркости отложено", logging.KeyRelay, name, "level", level, "reason", perr.Reason, "retry_after", perr.RetryAfter.Round(time.Second))
+			return
+		case err != nil:
+			e.logger("lighting").Error("Ошибка установки яркости", logging.KeyRelay, name, "level", level, logging.Err(err))
+			return
+		}
+	}
+
+	switched := (current > 0) != (level > 0)
+	if changed && switched {
+		metrics.RelaySwitched(e.repo.Enclosure(), name, level > 0)
+	}
+	if switched || phaseChanged {
+		e.logger("lighting").Info("Фаза освещения", logging.KeyRelay, name, "phase", phaseLabels[phase], "level", level)
+		_ = e.repo.InsertBrightnessLog(ctx, name, level, reason)
+	}
+	e.lightPhase[name] = phase
+}
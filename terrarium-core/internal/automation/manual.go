@@ -0,0 +1,118 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"terrarium-core/internal/events"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/metrics"
+)
+
+// ReasonManual — причина в аудите реле для ручного переключения (API, MQTT)
+const ReasonManual = "MANUAL_OVERRIDE"
+
+// ErrNotManual возвращается при ручном переключении реле вне режима MANUAL.
+var ErrNotManual = errors.New("ручное переключение разрешено только в режиме MANUAL")
+
+// ErrNotDimmable возвращается, если яркость задана для реле без регулировки яркости.
+var ErrNotDimmable = errors.New("реле не поддерживает регулировку яркости")
+
+// ErrInvalidMode возвращается при неизвестном режиме или неверном сроке аренды MANUAL.
+var ErrInvalidMode = errors.New("режим должен быть AUTO или MANUAL, срок аренды — от 1 до 1440 минут")
+
+// ToggleRelay вручную переключает реле (только в режиме MANUAL). Включение запрещено во время
+// аварийного отключения и аварийного стопа, а фоггера — еще и при пустом резервуаре или открытой
+// крышке (см. BlockedOn). brightness задает яркость диммируемого выхода при включении.
+// Отказ защиты реле возвращается как *gpio.ProtectionError.
+func (e *Engine) ToggleRelay(ctx context.Context, relayID string, state bool, brightness *float64) error {
+	if mode, err := e.repo.GetSystemMode(ctx); err != nil || mode != "MANUAL" {
+		return ErrNotManual
+	}
+	relay := e.relayByName(relayID)
+	if relay == nil {
+		return ErrUnknownRelay
+	}
+	if state {
+		if err := e.BlockedOn(relayID); err != nil {
+			return err
+		}
+	}
+	dimmer, dimmable := gpio.AsDimmer(relay)
+	if brightness != nil && !dimmable {
+		return ErrNotDimmable
+	}
+
+	wasOn := relay.IsOn()
+	var wasBrightness float64
+	if dimmable {
+		wasBrightness = dimmer.Brightness()
+	}
+	var err error
+	switch {
+	case state && brightness != nil:
+		err = dimmer.SetBrightness(*brightness)
+	case state:
+		err = relay.On()
+	default:
+		err = relay.Off()
+	}
+	if err != nil {
+		return err
+	}
+	switched := relay.IsOn() != wasOn
+	if switched {
+		metrics.RelaySwitched(e.repo.Enclosure(), relayID, !wasOn)
+	}
+
+	// Запись лога переключения: повторная команда с тем же состоянием в аудит не попадает
+	if dimmable {
+		if brightness := dimmer.Brightness(); switched || brightness != wasBrightness {
+			_ = e.repo.InsertBrightnessLog(ctx, relayID, brightness, ReasonManual)
+		}
+		return nil
+	}
+	if switched {
+		_ = e.repo.InsertRelayLog(ctx, relayID, state, ReasonManual)
+	}
+	return nil
+}
+
+// SetMode переключает режим AUTO/MANUAL. durationMin > 0 задает срок аренды MANUAL в минутах,
+// по истечении которого движок сам вернет систему в AUTO.
+func (e *Engine) SetMode(ctx context.Context, mode string, durationMin int) error {
+	if (mode != "AUTO" && mode != "MANUAL") || durationMin < 0 || durationMin > 1440 {
+		return ErrInvalidMode
+	}
+	var manualUntil *time.Time
+	if mode == "MANUAL" && durationMin > 0 {
+		until := time.Now().Add(time.Duration(durationMin) * time.Minute)
+		manualUntil = &until
+	}
+	return e.repo.SetSystemMode(ctx, mode, manualUntil)
+}
+
+// Emergency сообщает, активно ли аварийное отключение: по правилам безопасности или аварийным стопом.
+func (e *Engine) Emergency() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.emergencyLocked()
+}
+
+// emergencyLocked — Emergency под уже захваченной блокировкой e.mu.
+func (e *Engine) emergencyLocked() bool {
+	return e.emergency || !e.panicSince.IsZero()
+}
+
+// publishEmergency публикует событие EmergencyChanged, если аварийное состояние изменилось.
+func (e *Engine) publishEmergency(was, active bool, now time.Time) {
+	if active != was {
+		events.Publish(events.Event{Type: events.EmergencyChanged, Enclosure: e.repo.Enclosure(), Time: now, Data: events.Emergency{Active: active}})
+	}
+}
+
+// Mode возвращает текущий режим системы (AUTO / MANUAL) из БД.
+func (e *Engine) Mode(ctx context.Context) (string, error) {
+	return e.repo.GetSystemMode(ctx)
+}
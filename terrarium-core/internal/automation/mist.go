@@ -0,0 +1,243 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+// rainEventWindow — окно, в течение которого запланированный дождь может стартовать
+// (цикл движка 5 сек, поэтому событие не будет пропущено).
+const rainEventWindow = time.Minute
+
+// mistState хранит состояние импульсного режима фоггера. Защищено Engine.mu.
+type mistState struct {
+	synced       bool              // суточный расход загружен из relay_logs
+	day          string            // сутки учета расхода (YYYY-MM-DD)
+	usedToday    time.Duration     // суммарная работа фоггера за сутки
+	pulseStart   time.Time         // начало текущего импульса (zero — импульса нет)
+	pulseUntil   time.Time         // плановое окончание текущего импульса
+	lastPulseEnd time.Time         // окончание последнего импульса (для паузы)
+	firedRain    map[string]string // ID события дождя -> сутки, когда оно сработало
+}
+
+// GetMistStatus возвращает состояние импульсного режима фоггера. Потокобезопасно.
+func (e *Engine) GetMistStatus(settings *models.MistSettings) models.MistStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	status := models.MistStatus{
+		Enabled:        settings.Enabled,
+		PulseActive:    !e.mist.pulseStart.IsZero(),
+		UsedTodaySec:   int(e.mist.usedToday.Seconds()),
+		DailyBudgetSec: settings.DailyMaxMin * 60,
+	}
+	if status.PulseActive {
+		until := e.mist.pulseUntil
+		status.PulseEndsAt = &until
+		status.UsedTodaySec += int(time.Since(e.mist.pulseStart).Seconds())
+	}
+	if !e.mist.lastPulseEnd.IsZero() {
+		last := e.mist.lastPulseEnd
+		status.LastPulseAt = &last
+	}
+	return status
+}
+
+// evaluateMisting управляет фоггером импульсами: по расписанию дождя и по низкой влажности
+// (с паузой между импульсами), не превышая суточный лимит работы.
+func (e *Engine) evaluateMisting(ctx context.Context, currentHum float64, cfg *models.ConfigPayload, ms *models.MistSettings) {
+	now := time.Now()
+	e.syncMistDay(ctx, now)
+
+	e.mu.RLock()
+	st := e.mist
+	e.mu.RUnlock()
+
+	fog := e.fogRelay.Name()
+
+	// Импульс идет: ждем окончания (или реле уже выключено аварийным контуром)
+	if !st.pulseStart.IsZero() {
+		if now.Before(st.pulseUntil) && e.fogRelay.IsOn() {
+			traceRule(ctx, mistRule(fog, true, DecisionHold, "импульс идет до "+st.pulseUntil.Format(time.TimeOnly)))
+			return
+		}
+		traceRule(ctx, mistRule(fog, true, DecisionOff, "импульс завершен"))
+		end := now
+		if e.fogRelay.IsOn() {
+			if !e.setRelay(ctx, e.fogRelay, false, "MIST_PULSE") {
+				return // Защита отложила выключение — повторим в следующем цикле
+			}
+		} else {
+			// Фоггер выключили в обход импульса — момент неизвестен, учитываем не дольше плана
+			end = minTime(now, st.pulseUntil)
+		}
+		e.mu.Lock()
+		e.mist.finishPulse(end)
+		e.mu.Unlock()
+		e.logger("mist").Info("Импульс тумана завершен", "used_today", e.mistUsedToday().Round(time.Second), "daily_max_min", ms.DailyMaxMin)
+		return
+	}
+
+	// Фоггер остался включенным после термостатного режима — выключаем
+	if e.fogRelay.IsOn() {
+		traceRule(ctx, mistRule(fog, true, DecisionOff, "фоггер включен вне импульса"))
+		e.setRelay(ctx, e.fogRelay, false, "MIST_PULSE")
+		return
+	}
+
+	budget := time.Duration(ms.DailyMaxMin)*time.Minute - st.usedToday
+	if budget <= 0 {
+		traceRule(ctx, mistRule(fog, false, DecisionHold, fmt.Sprintf("суточный лимит %d мин исчерпан", ms.DailyMaxMin)))
+		return
+	}
+
+	// Запланированный дождь имеет приоритет над импульсами по влажности и игнорирует паузу
+	if ev := e.dueRainEvent(ctx, now); ev != nil {
+		duration := min(time.Duration(ev.DurationSec)*time.Second, budget)
+		e.logger("mist").Info("Запланированный дождь", "start_time", ev.StartTime, "duration", duration)
+		traceRule(ctx, mistRule(fog, true, DecisionOn, fmt.Sprintf("запланированный дождь %s, импульс %s", ev.StartTime, duration)))
+		if e.startMistPulse(ctx, now, duration) {
+			e.mu.Lock()
+			e.mist.firedRain[ev.ID] = now.Format(time.DateOnly)
+			e.mu.Unlock()
+		}
+		return
+	}
+
+	if currentHum >= cfg.HumidityMin {
+		traceRule(ctx, mistRule(fog, false, DecisionHold, fmt.Sprintf("warm_hum %.1f >= humidity_min %.1f", currentHum, cfg.HumidityMin)))
+		return
+	}
+	if !st.lastPulseEnd.IsZero() && now.Sub(st.lastPulseEnd) < time.Duration(ms.CooldownSec)*time.Second {
+		traceRule(ctx, mistRule(fog, false, DecisionHold, fmt.Sprintf("warm_hum %.1f < %.1f, но пауза между импульсами до %s",
+			currentHum, cfg.HumidityMin, st.lastPulseEnd.Add(time.Duration(ms.CooldownSec)*time.Second).Format(time.TimeOnly))))
+		return
+	}
+
+	duration := min(time.Duration(ms.PulseSec)*time.Second, budget)
+	traceRule(ctx, mistRule(fog, true, DecisionOn, fmt.Sprintf("warm_hum %.1f < humidity_min %.1f, импульс %s", currentHum, cfg.HumidityMin, duration)))
+	e.logger("mist").Info("Влажность ниже минимума. Импульс тумана", "humidity", currentHum, "humidity_min", cfg.HumidityMin, "duration", duration)
+	e.startMistPulse(ctx, now, duration)
+}
+
+// startMistPulse включает фоггер на заданное время. Возвращает false, если защита реле отклонила включение.
+func (e *Engine) startMistPulse(ctx context.Context, now time.Time, duration time.Duration) bool {
+	if !e.setRelay(ctx, e.fogRelay, true, "MIST_PULSE") {
+		return false
+	}
+	e.mu.Lock()
+	e.mist.pulseStart = now
+	e.mist.pulseUntil = now.Add(duration)
+	e.mu.Unlock()
+	return true
+}
+
+// dueRainEvent возвращает активное событие дождя, время которого наступило и которое еще не срабатывало сегодня.
+func (e *Engine) dueRainEvent(ctx context.Context, now time.Time) *models.RainEvent {
+	events, err := e.repo.GetRainEvents(ctx)
+	if err != nil {
+		e.logger("mist").Error("Ошибка чтения расписания дождя", logging.Err(err))
+		return nil
+	}
+
+	today := now.Format(time.DateOnly)
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for i := range events {
+		ev := &events[i]
+		if !ev.IsActive || e.mist.firedRain[ev.ID] == today {
+			continue
+		}
+		start, err := time.ParseInLocation("15:04", ev.StartTime, now.Location())
+		if err != nil {
+			continue
+		}
+		at := time.Date(now.Year(), now.Month(), now.Day(), start.Hour(), start.Minute(), 0, 0, now.Location())
+		if !now.Before(at) && now.Sub(at) < rainEventWindow {
+			return ev
+		}
+	}
+	return nil
+}
+
+// syncMistDay сбрасывает суточный учет в полночь и при необходимости восстанавливает
+// расход за сегодня из журнала relay_logs (после рестарта или включения режима).
+func (e *Engine) syncMistDay(ctx context.Context, now time.Time) {
+	today := now.Format(time.DateOnly)
+
+	e.mu.RLock()
+	upToDate := e.mist.synced && e.mist.day == today
+	e.mu.RUnlock()
+	if upToDate {
+		return
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	used, err := e.repo.GetRelayOnTime(ctx, e.fogRelay.Name(), midnight)
+	if err != nil {
+		e.logger("mist").Error("Не удалось восстановить суточный расход фоггера", logging.Err(err))
+		used = 0
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mist.synced = true
+	e.mist.day = today
+	e.mist.usedToday = used
+	if !e.mist.pulseStart.IsZero() {
+		// Идущий импульс уже попал в журнал — вычитаем его, он будет учтен при завершении
+		e.mist.pulseStart = maxTime(e.mist.pulseStart, midnight)
+		e.mist.usedToday = max(e.mist.usedToday-now.Sub(e.mist.pulseStart), 0)
+	}
+	if e.mist.firedRain == nil {
+		e.mist.firedRain = make(map[string]string)
+	}
+}
+
+// finishPulse учитывает импульс в суточном расходе: end — момент выключения фоггера.
+// Вызывается под Engine.mu.
+func (m *mistState) finishPulse(end time.Time) {
+	if m.pulseStart.IsZero() {
+		return
+	}
+	m.usedToday += max(end.Sub(m.pulseStart), 0)
+	m.pulseStart = time.Time{}
+	m.lastPulseEnd = end
+}
+
+// resetMist сбрасывает синхронизацию расхода (режим misting выключен).
+func (e *Engine) resetMist() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mist.synced = false
+	e.mist.pulseStart = time.Time{}
+}
+
+func (e *Engine) mistUsedToday() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mist.usedToday
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func mistRule(relay string, matched bool, decision, detail string) models.RuleEvaluation {
+	return models.RuleEvaluation{Rule: RuleMist, Relay: relay, Matched: matched, Decision: decision, Detail: detail}
+}
@@ -0,0 +1,47 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestForceOffEndsMistPulse(t *testing.T) {
+	e, _ := newTestEngine(t)
+	ctx := context.Background()
+
+	// Импульс на 5 минут идет 30 секунд, затем аварийный контур выключает фоггер
+	start := time.Now().Add(-30 * time.Second)
+	_ = e.fogRelay.On()
+	e.mist.pulseStart, e.mist.pulseUntil = start, start.Add(5*time.Minute)
+
+	if !e.forceOff(ctx, e.fogRelay, ReasonPanicStop) {
+		t.Fatal("forceOff не выключил фоггер")
+	}
+	if !e.mist.pulseStart.IsZero() {
+		t.Error("прерванный импульс должен завершаться")
+	}
+	if used := e.mist.usedToday; used < 30*time.Second || used > 35*time.Second {
+		t.Errorf("суточный расход = %s, want ~30s фактической работы", used)
+	}
+}
+
+func TestFinishPulseClampsToPlan(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := mistState{pulseStart: start, pulseUntil: start.Add(time.Minute), usedToday: 10 * time.Minute}
+
+	// Фоггер выключили в обход импульса, а заметили это через час: учитывается только план
+	m.finishPulse(minTime(start.Add(time.Hour), m.pulseUntil))
+	if m.usedToday != 11*time.Minute {
+		t.Errorf("суточный расход = %s, want 11m", m.usedToday)
+	}
+	if !m.pulseStart.IsZero() || !m.lastPulseEnd.Equal(start.Add(time.Minute)) {
+		t.Errorf("состояние после импульса = %+v", m)
+	}
+
+	// Повторное завершение ничего не меняет
+	m.finishPulse(start.Add(2 * time.Hour))
+	if m.usedToday != 11*time.Minute {
+		t.Errorf("повторное завершение изменило расход: %s", m.usedToday)
+	}
+}
@@ -0,0 +1,237 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/metrics"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
+)
+
+// ErrEmergencyActive возвращается при попытке включить реле во время аварийного отключения.
+var ErrEmergencyActive = errors.New("активно аварийное отключение: включение реле запрещено")
+
+// ErrUnknownRelay возвращается, если реле не управляется движком автоматизации.
+var ErrUnknownRelay = errors.New("реле не управляется автоматикой")
+
+// ErrNoOverride возвращается при отмене несуществующего переопределения.
+var ErrNoOverride = errors.New("для реле нет активного переопределения")
+
+// SetOverride временно переопределяет состояние реле до expiresAt. Реле переключается сразу;
+// до окончания переопределения автоматика этим реле не управляет. Аварийный контур имеет приоритет.
+func (e *Engine) SetOverride(ctx context.Context, relayID string, state bool, expiresAt time.Time) (*models.RelayOverride, error) {
+	relay := e.relayByName(relayID)
+	if relay == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRelay, relayID)
+	}
+
+	if state {
+		if err := e.BlockedOn(relayID); err != nil {
+			return nil, err
+		}
+	}
+	// Чтение прежнего переопределения, переключение и запись нового — под одной блокировкой,
+	// чтобы параллельный цикл или запрос не потерял исходное состояние реле
+	e.mu.Lock()
+	wasOn := relay.IsOn()
+	ov := &models.RelayOverride{
+		RelayID:   relayID,
+		State:     state,
+		PrevState: wasOn,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if existing := e.overrides[relayID]; existing != nil {
+		ov.PrevState = existing.PrevState // Продление: возвращаемся к состоянию до первого переопределения
+	}
+	if err := e.switchRelay(relay, state); err != nil {
+		e.mu.Unlock()
+		return nil, err
+	}
+	e.overrides[relayID] = ov
+	e.mu.Unlock()
+
+	// В аудит попадает только фактическое переключение (продление с тем же состоянием — нет)
+	if wasOn != state {
+		_ = e.repo.InsertRelayLog(ctx, relayID, state, ReasonManual)
+	}
+
+	e.logger("override").Info("Ручное переопределение реле", logging.KeyRelay, relayID, "on", state, "expires_at", expiresAt.Format(time.DateTime))
+	return withRemaining(ov, time.Now()), nil
+}
+
+// CancelOverride снимает переопределение и возвращает реле в исходное состояние.
+func (e *Engine) CancelOverride(ctx context.Context, relayID string) error {
+	e.mu.Lock()
+	ov, ok := e.overrides[relayID]
+	delete(e.overrides, relayID)
+	e.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoOverride, relayID)
+	}
+
+	e.logger("override").Info("Переопределение реле отменено. Автоматика возобновляет управление", logging.KeyRelay, relayID)
+	e.setRelay(ctx, e.relayByName(relayID), ov.PrevState, "OVERRIDE_CANCELLED")
+	return nil
+}
+
+// GetOverrides возвращает список активных переопределений. Потокобезопасно.
+func (e *Engine) GetOverrides() []models.RelayOverride {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]models.RelayOverride, 0, len(e.overrides))
+	for _, ov := range e.overrides {
+		result = append(result, *withRemaining(ov, now))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ExpiresAt.Before(result[j].ExpiresAt) })
+	return result
+}
+
+// BlockedOn возвращает причину, по которой реле сейчас нельзя включать: аварийное отключение,
+// аварийный стоп, а для фоггера — пустой резервуар или открытая крышка. nil — включать можно.
+func (e *Engine) BlockedOn(relayID string) error {
+	e.mu.RLock()
+	emergency, panicStop := e.emergency, !e.panicSince.IsZero()
+	e.mu.RUnlock()
+
+	switch {
+	case emergency:
+		return ErrEmergencyActive
+	case panicStop:
+		return ErrPanicStop
+	case relayID != e.fogRelay.Name():
+		return nil
+	case e.reservoirBlocks():
+		return ErrReservoirEmpty
+	}
+	for _, slot := range e.inputs {
+		if slot.role == InputLid && slot.active(slot.input.State()) {
+			return ErrLidOpen
+		}
+	}
+	return nil
+}
+
+// isOverridden сообщает, что реле сейчас под ручным переопределением.
+func (e *Engine) isOverridden(relayID string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.overrides[relayID]
+	return ok
+}
+
+// processOverrides снимает истекшие переопределения и, если hold=true (режим AUTO), возвращает
+// исходное состояние реле и удерживает состояние действующих (защита реле могла отложить переключение).
+// В режиме MANUAL истекшее переопределение снимается без переключения: реле управляет пользователь.
+func (e *Engine) processOverrides(ctx context.Context, hold bool) {
+	now := time.Now()
+
+	e.mu.Lock()
+	var expired, active []*models.RelayOverride
+	for id, ov := range e.overrides {
+		if now.Before(ov.ExpiresAt) {
+			active = append(active, ov)
+			continue
+		}
+		expired = append(expired, ov)
+		delete(e.overrides, id)
+	}
+	e.mu.Unlock()
+
+	for _, ov := range expired {
+		expiredAt := "переопределение истекло в " + ov.ExpiresAt.Format(time.DateTime)
+		n := notify.Notification{
+			Level:  notify.LevelInfo,
+			Source: "engine",
+			Title:  "Переопределение реле " + ov.RelayID + " истекло",
+			Time:   now,
+		}
+		if hold {
+			traceRule(ctx, models.RuleEvaluation{
+				Rule: RuleOverride, Relay: ov.RelayID, Matched: true, Decision: decisionFor(ov.PrevState),
+				Detail: expiredAt + " — возврат к исходному состоянию",
+			})
+			e.logger("override").Info("Переопределение реле истекло. Автоматика возобновляет управление", logging.KeyRelay, ov.RelayID)
+			e.setRelay(ctx, e.relayByName(ov.RelayID), ov.PrevState, "OVERRIDE_EXPIRED")
+			n.Message = "Автоматика возобновила управление реле"
+		} else {
+			traceRule(ctx, models.RuleEvaluation{
+				Rule: RuleOverride, Relay: ov.RelayID, Matched: true, Decision: DecisionSkip,
+				Detail: expiredAt + " — режим MANUAL, реле не переключается",
+			})
+			e.logger("override").Info("Переопределение реле истекло в режиме MANUAL. Реле остается в текущем состоянии", logging.KeyRelay, ov.RelayID)
+			n.Message = "Режим MANUAL: реле остается в текущем состоянии"
+		}
+		e.notify(ctx, n)
+	}
+	if !hold {
+		return
+	}
+	for _, ov := range active {
+		traceRule(ctx, models.RuleEvaluation{
+			Rule: RuleOverride, Relay: ov.RelayID, Matched: true, Decision: decisionFor(ov.State),
+			Detail: "ручное переопределение до " + ov.ExpiresAt.Format(time.DateTime),
+		})
+		e.setRelay(ctx, e.relayByName(ov.RelayID), ov.State, ReasonManual)
+	}
+}
+
+// dropOnOverride снимает переопределение, удерживающее реле включенным, без восстановления
+// состояния: реле уже выключено защитным контуром, и удерживать его включенным нельзя.
+func (e *Engine) dropOnOverride(relayID, reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if ov, ok := e.overrides[relayID]; ok && ov.State {
+		delete(e.overrides, relayID)
+		e.logger("override").Warn("Переопределение реле снято защитой", logging.KeyRelay, relayID, "reason", reason)
+	}
+}
+
+// relayByName возвращает реле движка по ID или nil.
+func (e *Engine) relayByName(id string) gpio.RelayController {
+	for _, r := range e.relays {
+		if r.Name() == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// switchRelay переключает реле без записи в аудит (ошибки защиты возвращаются вызывающему).
+func (e *Engine) switchRelay(relay gpio.RelayController, state bool) error {
+	if relay.IsOn() == state {
+		return nil
+	}
+	var err error
+	if state {
+		err = relay.On()
+	} else {
+		err = relay.Off()
+	}
+	if err == nil {
+		metrics.RelaySwitched(e.repo.Enclosure(), relay.Name(), state)
+	}
+	return err
+}
+
+// withRemaining возвращает копию переопределения с остатком времени на момент now.
+func withRemaining(ov *models.RelayOverride, now time.Time) *models.RelayOverride {
+	c := *ov
+	c.RemainingSec = max(int(ov.ExpiresAt.Sub(now).Seconds()), 0)
+	return &c
+}
+
+func decisionFor(state bool) string {
+	if state {
+		return DecisionOn
+	}
+	return DecisionOff
+}
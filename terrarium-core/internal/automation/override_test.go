@@ -0,0 +1,55 @@
+package automation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOverrideExtensionKeepsPrevState(t *testing.T) {
+	e, _ := newTestEngine(t)
+	ctx := context.Background()
+
+	if _, err := e.SetOverride(ctx, "light", true, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("SetOverride: %v", err)
+	}
+	ov, err := e.SetOverride(ctx, "light", true, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("продление: %v", err)
+	}
+	if ov.PrevState {
+		t.Error("продление должно сохранять состояние до первого переопределения (выключено)")
+	}
+}
+
+func TestExpiredOverride(t *testing.T) {
+	tests := []struct {
+		name   string
+		hold   bool // режим AUTO
+		wantOn bool
+	}{
+		{"AUTO возвращает исходное состояние", true, false},
+		{"MANUAL не переключает реле", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, n := newTestEngine(t)
+			ctx := context.Background()
+			if _, err := e.SetOverride(ctx, "light", true, time.Now().Add(time.Minute)); err != nil {
+				t.Fatalf("SetOverride: %v", err)
+			}
+			e.overrides["light"].ExpiresAt = time.Now().Add(-time.Second)
+
+			e.processOverrides(ctx, tt.hold)
+			if got := e.lightRelay.IsOn(); got != tt.wantOn {
+				t.Errorf("реле после истечения: on = %v, want %v", got, tt.wantOn)
+			}
+			if len(e.GetOverrides()) != 0 {
+				t.Error("истекшее переопределение должно сниматься")
+			}
+			if got := n.levels(); len(got) != 1 {
+				t.Errorf("уведомления = %v, want одно", got)
+			}
+		})
+	}
+}
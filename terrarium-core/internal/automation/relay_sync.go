@@ -0,0 +1,159 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/metrics"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
+)
+
+// reconcileInterval — период сверки желаемого и фактического состояния реле
+const reconcileInterval = 30 * time.Second
+
+// ReasonResync — причина в аудите реле, когда движок восстановил состояние, измененное извне
+const ReasonResync = "RELAY_RESYNC"
+
+// relaySyncState — результат последней сверки реле и его последняя ошибка.
+type relaySyncState struct {
+	actual      *bool
+	checkedAt   time.Time
+	desynced    bool // расхождение обнаружено при последней сверке
+	desyncs     int
+	lastErr     string
+	lastErrAt   time.Time
+	unreachable bool // последнее чтение завершилось ошибкой
+}
+
+// RelaySync возвращает желаемое и фактическое состояние всех реле движка. Потокобезопасно.
+func (e *Engine) RelaySync() map[string]models.RelaySync {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := make(map[string]models.RelaySync, len(e.relays))
+	for _, relay := range e.relays {
+		st := models.RelaySync{Desired: relay.IsOn()}
+		if s := e.relaySync[relay.Name()]; s != nil {
+			st.Actual = s.actual
+			st.InSync = s.actual != nil && !s.desynced
+			st.Desyncs = s.desyncs
+			st.LastError = s.lastErr
+			if !s.checkedAt.IsZero() {
+				st.CheckedAt = &s.checkedAt
+			}
+			if !s.lastErrAt.IsZero() {
+				st.LastErrorAt = &s.lastErrAt
+			}
+		}
+		result[relay.Name()] = st
+	}
+	return result
+}
+
+// reconcileRelays раз в reconcileInterval читает фактическое состояние реле и, если оно
+// расходится с желаемым (например, пин изменил другой процесс или розетку переключили кнопкой),
+// повторно применяет желаемое состояние. Работает в любом режиме (AUTO/MANUAL).
+func (e *Engine) reconcileRelays(ctx context.Context, now time.Time) {
+	if now.Sub(e.lastReconcile) < reconcileInterval {
+		return
+	}
+	e.lastReconcile = now
+
+	for _, relay := range e.relays {
+		name := relay.Name()
+		desired := relay.IsOn()
+		actual, err := gpio.ReadState(relay)
+		if err != nil {
+			if !e.syncState(name).unreachable {
+				e.logger("relay_sync").Warn("Не удалось прочитать состояние реле", logging.KeyRelay, name, logging.Err(err))
+			}
+			e.updateSync(name, now, func(s *relaySyncState) {
+				s.actual, s.desynced, s.unreachable = nil, false, true
+				s.lastErr, s.lastErrAt = err.Error(), now
+			})
+			continue
+		}
+
+		if actual == desired {
+			e.updateSync(name, now, func(s *relaySyncState) {
+				s.actual, s.desynced, s.unreachable = &actual, false, false
+			})
+			continue
+		}
+
+		e.logger("relay_sync").Warn("Состояние реле расходится с ожидаемым. Восстанавливаем", logging.KeyRelay, name, "desired", stateLabel(desired), "actual", stateLabel(actual))
+		first := !e.syncState(name).desynced
+		err = gpio.Reapply(relay)
+		traceAction(ctx, name, desired, ReasonResync, err)
+		message := fmt.Sprintf("реле %s: ожидалось %s, фактически %s", name, stateLabel(desired), stateLabel(actual))
+		if err != nil {
+			e.logger("relay_sync").Error("Ошибка восстановления реле", logging.KeyRelay, name, logging.Err(err))
+			message += "; восстановить не удалось: " + err.Error()
+		} else {
+			_ = e.repo.InsertRelayLog(ctx, name, desired, ReasonResync)
+			message += "; желаемое состояние восстановлено"
+		}
+		e.updateSync(name, now, func(s *relaySyncState) {
+			s.actual, s.desynced, s.unreachable = &actual, true, false
+			s.desyncs++
+			if err != nil {
+				s.lastErr, s.lastErrAt = err.Error(), now
+			}
+		})
+		if first {
+			e.notify(ctx, notify.Notification{
+				Level:   notify.LevelWarning,
+				Source:  "engine",
+				Title:   "Рассинхронизация реле " + name,
+				Message: message,
+				Time:    now,
+			})
+		}
+	}
+}
+
+// noteRelayError запоминает аппаратную ошибку переключения реле (отказы защиты не учитываются).
+func (e *Engine) noteRelayError(name string, err error) {
+	var perr *gpio.ProtectionError
+	if err == nil || errors.As(err, &perr) {
+		return
+	}
+	metrics.RelayFailed(e.repo.Enclosure(), name)
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.syncEntry(name)
+	s.lastErr, s.lastErrAt = err.Error(), now
+}
+
+func (e *Engine) syncState(name string) relaySyncState {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if s := e.relaySync[name]; s != nil {
+		return *s
+	}
+	return relaySyncState{}
+}
+
+func (e *Engine) updateSync(name string, now time.Time, update func(s *relaySyncState)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := e.syncEntry(name)
+	s.checkedAt = now
+	update(s)
+}
+
+// syncEntry возвращает запись сверки реле, создавая ее при необходимости. Вызывается под e.mu.
+func (e *Engine) syncEntry(name string) *relaySyncState {
+	s := e.relaySync[name]
+	if s == nil {
+		s = &relaySyncState{}
+		e.relaySync[name] = s
+	}
+	return s
+}
@@ -0,0 +1,242 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/notify"
+)
+
+// RuleReservoir — служебное правило трассировки: блокировка фоггера при пустом резервуаре
+const RuleReservoir = "RESERVOIR"
+
+// ReasonReservoirEmpty — причина отключения фоггера в аудите реле
+const ReasonReservoirEmpty = "RESERVOIR_EMPTY"
+
+// Уровни резервуара фоггера
+const (
+	ReservoirOK      = "OK"
+	ReservoirEmpty   = "EMPTY"
+	ReservoirUnknown = "UNKNOWN" // датчик не отвечает — фоггер блокируется (fail-safe)
+)
+
+// События журнала резервуара (reservoir_events)
+const (
+	ReservoirEventEmpty  = "EMPTY"
+	ReservoirEventRefill = "REFILL"
+)
+
+// ErrReservoirEmpty возвращается при попытке включить фоггер, когда резервуар пуст.
+var ErrReservoirEmpty = errors.New("резервуар фоггера пуст или датчик уровня недоступен: включение фоггера запрещено")
+
+// reservoirState — поплавковый выключатель резервуара фоггера и история его событий.
+// Поля, кроме input и emptyWhenActive, защищены Engine.mu.
+type reservoirState struct {
+	input           *gpio.DebouncedInput
+	emptyWhenActive bool // резервуар пуст, когда контакт замкнут (иначе — когда разомкнут)
+
+	synced       bool             // время последних событий загружено из reservoir_events
+	level        string           // уровень при последней проверке ("" — проверки еще не было)
+	pending      []gpio.InputEdge // фронты поплавка, еще не записанные в журнал
+	lastEmptyAt  time.Time
+	lastRefillAt time.Time
+}
+
+// levelOf возвращает уровень резервуара по состоянию поплавкового выключателя.
+func (r *reservoirState) levelOf(st gpio.InputState) string {
+	switch {
+	case !st.Known || st.Err != nil:
+		return ReservoirUnknown
+	case st.Active == r.emptyWhenActive:
+		return ReservoirEmpty
+	}
+	return ReservoirOK
+}
+
+// SetReservoir подключает поплавковый выключатель резервуара фоггера. Вызывается до Start.
+// emptyWhenActive задает, какое состояние контакта означает пустой резервуар.
+func (e *Engine) SetReservoir(input *gpio.DebouncedInput, emptyWhenActive bool) {
+	r := &reservoirState{input: input, emptyWhenActive: emptyWhenActive}
+	input.OnEdge(func(edge gpio.InputEdge) {
+		// Фронты записываются в журнал циклом движка (checkReservoir)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		r.pending = append(r.pending, edge)
+	})
+	e.reservoir = r
+}
+
+// GetReservoir возвращает состояние резервуара фоггера. Потокобезопасно.
+func (e *Engine) GetReservoir() models.ReservoirStatus {
+	r := e.reservoir
+	if r == nil {
+		return models.ReservoirStatus{Level: ReservoirUnknown}
+	}
+
+	st := r.input.State()
+	status := models.ReservoirStatus{Configured: true, Level: r.levelOf(st)}
+	status.FoggerBlocked = status.Level != ReservoirOK
+	if st.Known {
+		since := st.Since
+		status.Since = &since
+	}
+	if st.Err != nil {
+		status.Error = st.Err.Error()
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if !r.lastEmptyAt.IsZero() {
+		at := r.lastEmptyAt
+		status.LastEmptyAt = &at
+	}
+	if !r.lastRefillAt.IsZero() {
+		at := r.lastRefillAt
+		status.LastRefillAt = &at
+	}
+	return status
+}
+
+// reservoirBlocks сообщает, что включение фоггера запрещено: резервуар пуст или датчик не отвечает.
+func (e *Engine) reservoirBlocks() bool {
+	r := e.reservoir
+	return r != nil && r.levelOf(r.input.State()) != ReservoirOK
+}
+
+// checkReservoir записывает события резервуара, уведомляет о смене уровня и, если резервуар пуст
+// (или датчик не отвечает), выключает фоггер в обход защиты от дребезга, чтобы он не работал всухую.
+// Работает в любом режиме (AUTO/MANUAL). Возвращает true, если фоггер заблокирован.
+func (e *Engine) checkReservoir(ctx context.Context, now time.Time) bool {
+	r := e.reservoir
+	if r == nil {
+		return false
+	}
+	e.syncReservoir(ctx)
+
+	e.mu.Lock()
+	pending := r.pending
+	r.pending = nil
+	e.mu.Unlock()
+	for _, edge := range pending {
+		e.recordReservoirEvent(ctx, edge.Active == r.emptyWhenActive, edge.Time)
+	}
+
+	st := r.input.State()
+	level := r.levelOf(st)
+	e.mu.Lock()
+	prev := r.level
+	r.level = level
+	lastIsEmpty := r.lastEmptyAt.After(r.lastRefillAt)
+	e.mu.Unlock()
+
+	if prev == "" && level != ReservoirUnknown && lastIsEmpty != (level == ReservoirEmpty) {
+		// Уровень изменился, пока сервис был остановлен: дополняем журнал
+		e.recordReservoirEvent(ctx, level == ReservoirEmpty, now)
+	}
+	if level == ReservoirOK {
+		if level != prev {
+			e.notifyReservoir(ctx, prev, level, st, now)
+		}
+		return false
+	}
+
+	fog := e.fogRelay.Name()
+	detail := "резервуар пуст — фоггер заблокирован до пополнения"
+	if level == ReservoirUnknown {
+		detail = "датчик уровня воды недоступен — фоггер заблокирован"
+	}
+	traceRule(ctx, models.RuleEvaluation{Rule: RuleReservoir, Relay: fog, Matched: true, Decision: DecisionOff, Detail: detail})
+	if e.forceOff(ctx, e.fogRelay, ReasonReservoirEmpty) {
+		e.logger("reservoir").Warn("Фоггер выключен", "detail", detail, logging.KeyRelay, fog)
+	}
+	e.dropOnOverride(fog, ReasonReservoirEmpty)
+	// Уведомляем после отключения: фоггер не должен работать всухую, пока доставляется уведомление
+	if level != prev {
+		e.notifyReservoir(ctx, prev, level, st, now)
+	}
+	return true
+}
+
+// syncReservoir однократно загружает время последних событий резервуара из БД.
+func (e *Engine) syncReservoir(ctx context.Context) {
+	r := e.reservoir
+	e.mu.RLock()
+	synced := r.synced
+	e.mu.RUnlock()
+	if synced {
+		return
+	}
+
+	last, err := e.repo.GetLastReservoirEvents(ctx)
+	if err != nil {
+		e.logger("reservoir").Error("Не удалось загрузить журнал резервуара", logging.Err(err))
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	r.synced = true
+	r.lastEmptyAt = maxTime(r.lastEmptyAt, last[ReservoirEventEmpty])
+	r.lastRefillAt = maxTime(r.lastRefillAt, last[ReservoirEventRefill])
+}
+
+// recordReservoirEvent записывает опустошение или пополнение резервуара в журнал.
+func (e *Engine) recordReservoirEvent(ctx context.Context, empty bool, at time.Time) {
+	r := e.reservoir
+	event := ReservoirEventRefill
+	var emptySec *int
+
+	e.mu.Lock()
+	if empty {
+		event = ReservoirEventEmpty
+		r.lastEmptyAt = at
+	} else {
+		if r.lastEmptyAt.After(r.lastRefillAt) {
+			sec := int(at.Sub(r.lastEmptyAt).Seconds())
+			emptySec = &sec
+		}
+		r.lastRefillAt = at
+	}
+	e.mu.Unlock()
+
+	if empty {
+		e.logger("reservoir").Warn("Резервуар фоггера опустел", "at", at.Format(time.DateTime))
+	} else {
+		e.logger("reservoir").Info("Резервуар фоггера пополнен", "at", at.Format(time.DateTime))
+	}
+	if err := e.repo.InsertReservoirEvent(ctx, event, emptySec, at); err != nil {
+		e.logger("reservoir").Error("Не удалось записать событие резервуара", logging.Err(err))
+	}
+}
+
+// notifyReservoir уведомляет оператора о смене уровня резервуара.
+func (e *Engine) notifyReservoir(ctx context.Context, prev, level string, st gpio.InputState, now time.Time) {
+	n := notify.Notification{Source: "engine", Time: now}
+	switch {
+	case level == ReservoirEmpty:
+		n.Level = notify.LevelCritical
+		n.Title = "Резервуар фоггера пуст"
+		n.Message = "Поплавковый выключатель сообщает о низком уровне воды. Фоггер заблокирован до пополнения резервуара"
+	case level == ReservoirUnknown:
+		n.Level = notify.LevelWarning
+		n.Title = "Датчик уровня воды недоступен"
+		n.Message = "Фоггер заблокирован, пока уровень воды неизвестен"
+		if st.Err != nil {
+			n.Message += ": " + st.Err.Error()
+		}
+	case prev == ReservoirEmpty:
+		n.Level = notify.LevelInfo
+		n.Title = "Резервуар фоггера пополнен"
+		n.Message = "Уровень воды в норме, фоггер снова доступен автоматике"
+	case prev == ReservoirUnknown:
+		n.Level = notify.LevelInfo
+		n.Title = "Датчик уровня воды восстановлен"
+		n.Message = "Уровень воды в норме, фоггер снова доступен автоматике"
+	default:
+		return // старт с полным резервуаром
+	}
+	e.notify(ctx, n)
+}
@@ -0,0 +1,247 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+// ID встроенных правил
+const (
+	builtinEmergency = "builtin-emergency"
+	builtinColdZone  = "builtin-cold-zone"
+	builtinHeatOn    = "builtin-heat-on"
+	builtinHeatOff   = "builtin-heat-off"
+	builtinFogOn     = "builtin-fog-on"
+	builtinFogOff    = "builtin-fog-off"
+)
+
+// ErrInvalidRule возвращается, если правило не прошло проверку при сохранении.
+var ErrInvalidRule = errors.New("некорректное правило")
+
+// ErrBuiltinRule возвращается при попытке удалить или изменить встроенное правило.
+var ErrBuiltinRule = errors.New("встроенное правило нельзя удалить или изменить (допускается только включение/отключение обычных правил)")
+
+// DefaultRules описывает встроенное поведение движка в виде правил: аварийное отключение,
+// защита холодной зоны и термостаты нагрева и тумана с гистерезисом.
+func DefaultRules(heatRelay, fogRelay string) []models.AutomationRule {
+	sensor := func(input, op, ref string) models.RuleCondition {
+		return models.RuleCondition{Type: CondSensor, Input: input, Op: op, Ref: ref}
+	}
+	return []models.AutomationRule{
+		{
+			ID: builtinEmergency, Name: "EMERGENCY_CUTOFF", Priority: 0, Safety: true,
+			Description: "Аварийно отключить все реле при перегреве теплой зоны",
+			Conditions:  []models.RuleCondition{sensor("warm_temp", ">=", "emergency_max_threshold")},
+			Action:      models.RuleAction{Relay: allRelays, State: false, Reason: "EMERGENCY_CUTOFF"},
+		},
+		{
+			ID: builtinColdZone, Name: "COLD_ZONE_PROTECTION", Priority: 10, Safety: true,
+			Description: "Отключить обогрев при перегреве холодной зоны (нужна для терморегуляции)",
+			Conditions:  []models.RuleCondition{sensor("cold_temp", ">=", "cold_max_threshold")},
+			Action:      models.RuleAction{Relay: heatRelay, State: false, Reason: "COLD_ZONE_PROTECTION"},
+		},
+		{
+			ID: builtinHeatOn, Name: "HEAT_HYSTERESIS_ON", Priority: 100,
+			Description: "Включить обогрев, когда теплая зона остыла ниже нижней границы гистерезиса",
+			Conditions:  []models.RuleCondition{sensor("warm_temp", "<=", "warm_target_min-hysteresis_temp")},
+			Action:      models.RuleAction{Relay: heatRelay, State: true, Reason: "AUTO_TEMP_TRIGGER"},
+		},
+		{
+			ID: builtinHeatOff, Name: "HEAT_HYSTERESIS_OFF", Priority: 100,
+			Description: "Выключить обогрев, когда теплая зона прогрелась выше верхней границы гистерезиса",
+			Conditions:  []models.RuleCondition{sensor("warm_temp", ">=", "warm_target_max+hysteresis_temp")},
+			Action:      models.RuleAction{Relay: heatRelay, State: false, Reason: "AUTO_TEMP_TRIGGER"},
+		},
+		{
+			ID: builtinFogOn, Name: "FOG_HYSTERESIS_ON", Priority: 100,
+			Description: "Включить фоггер, когда влажность упала ниже нижней границы гистерезиса (если импульсный режим выключен)",
+			Conditions:  []models.RuleCondition{sensor("warm_hum", "<=", "humidity_min-hysteresis_hum")},
+			Action:      models.RuleAction{Relay: fogRelay, State: true, Reason: "AUTO_HUMIDITY_TRIGGER"},
+		},
+		{
+			ID: builtinFogOff, Name: "FOG_HYSTERESIS_OFF", Priority: 100,
+			Description: "Выключить фоггер, когда влажность превысила верхнюю границу гистерезиса (если импульсный режим выключен)",
+			Conditions:  []models.RuleCondition{sensor("warm_hum", ">=", "humidity_max+hysteresis_hum")},
+			Action:      models.RuleAction{Relay: fogRelay, State: false, Reason: "AUTO_HUMIDITY_TRIGGER"},
+		},
+	}
+}
+
+func (e *Engine) defaultRules() []models.AutomationRule {
+	rules := DefaultRules(e.heatRelay.Name(), e.fogRelay.Name())
+	for i := range rules {
+		rules[i].IsActive = true
+		rules[i].Builtin = true
+	}
+	return rules
+}
+
+// seedRules записывает встроенные правила в БД при старте движка.
+func (e *Engine) seedRules(ctx context.Context) {
+	if err := e.repo.SeedRules(ctx, e.defaultRules()); err != nil {
+		e.logger("rules").Error("Не удалось записать встроенные правила", logging.Err(err))
+	}
+}
+
+// loadRules читает правила из БД. Встроенные правила безопасности действуют всегда:
+// если БД недоступна или строка пропала, используется версия из кода.
+func (e *Engine) loadRules(ctx context.Context) []models.AutomationRule {
+	rules, err := e.repo.GetRules(ctx)
+	if err != nil {
+		e.logger("rules").Error("Ошибка чтения правил из БД. Используем встроенные правила", logging.Err(err))
+		return e.defaultRules()
+	}
+	for _, def := range e.defaultRules() {
+		if !def.Safety || slices.ContainsFunc(rules, func(r models.AutomationRule) bool { return r.ID == def.ID }) {
+			continue
+		}
+		rules = append([]models.AutomationRule{def}, rules...)
+	}
+	return rules
+}
+
+// ruleEnv собирает входные данные правил из показаний, конфигурации и текущего состояния реле.
+func (e *Engine) ruleEnv(now time.Time, inputs map[string]float64, cfg *models.ConfigPayload) *ruleEnv {
+	env := &ruleEnv{
+		now:    now,
+		inputs: inputs,
+		config: configValues(cfg),
+		relays: make(map[string]relayStatus, len(e.relays)),
+	}
+	for _, r := range e.relays {
+		st := relayStatus{on: r.IsOn(), held: -1}
+		if d, ok := r.(interface{ StateDuration() time.Duration }); ok {
+			st.held = d.StateDuration()
+		}
+		env.relays[r.Name()] = st
+		env.relayIDs = append(env.relayIDs, r.Name())
+	}
+	return env
+}
+
+// CreateRule проверяет и сохраняет пользовательское правило.
+func (e *Engine) CreateRule(ctx context.Context, req models.AutomationRuleRequest) (*models.AutomationRule, error) {
+	if err := e.validateRule(req); err != nil {
+		return nil, err
+	}
+	return e.repo.CreateRule(ctx, req)
+}
+
+// UpdateRule проверяет и обновляет правило. У встроенных правил меняется только is_active,
+// а правила безопасности отключить нельзя.
+func (e *Engine) UpdateRule(ctx context.Context, id string, req models.AutomationRuleRequest) error {
+	existing, err := e.repo.GetRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing.Builtin {
+		active := req.IsActive == nil || *req.IsActive
+		if existing.Safety && !active {
+			return fmt.Errorf("%w: %s — правило безопасности", ErrBuiltinRule, existing.Name)
+		}
+		return e.repo.SetRuleActive(ctx, id, active)
+	}
+	if err := e.validateRule(req); err != nil {
+		return err
+	}
+	return e.repo.UpdateRule(ctx, id, req)
+}
+
+// DeleteRule удаляет пользовательское правило.
+func (e *Engine) DeleteRule(ctx context.Context, id string) error {
+	existing, err := e.repo.GetRule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing.Builtin {
+		return fmt.Errorf("%w: %s", ErrBuiltinRule, existing.Name)
+	}
+	return e.repo.DeleteRule(ctx, id)
+}
+
+// validateRule проверяет правило перед сохранением: известные реле и показания,
+// корректные операторы, окна времени и выражения порогов, ограничения правил безопасности.
+func (e *Engine) validateRule(req models.AutomationRuleRequest) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+	}
+
+	if len(req.Name) > 64 {
+		return invalid("имя длиннее 64 символов")
+	}
+	if req.Priority < 0 {
+		return invalid("приоритет не может быть отрицательным")
+	}
+
+	switch {
+	case req.Action.Relay == allRelays && !req.Safety:
+		return invalid("действие над всеми реле (\"*\") допускается только в правиле безопасности")
+	case req.Action.Relay != allRelays && e.relayByName(req.Action.Relay) == nil:
+		return invalid("неизвестное реле %q в действии", req.Action.Relay)
+	case req.Safety && req.Action.State:
+		return invalid("правило безопасности может только выключать реле")
+	case len(req.Action.Reason) > 100:
+		return invalid("причина длиннее 100 символов")
+	}
+
+	return e.validateConditions(req.Conditions)
+}
+
+// validateConditions проверяет условия правила: известные реле и показания, корректные операторы,
+// окна времени и выражения порогов.
+func (e *Engine) validateConditions(conditions []models.RuleCondition) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+	}
+
+	keys := configValues(&models.ConfigPayload{})
+	inputs := inputNames(e.Sensors())
+	for i, c := range conditions {
+		n := i + 1
+		switch c.Type {
+		case CondSensor:
+			if !slices.Contains(inputs, c.Input) {
+				return invalid("условие %d: неизвестное показание %q (допустимо: %v)", n, c.Input, inputs)
+			}
+			if _, ok := inverseOp[c.Op]; !ok {
+				return invalid("условие %d: неизвестный оператор %q", n, c.Op)
+			}
+			if (c.Value == nil) == (c.Ref == "") {
+				return invalid("условие %d: нужно указать ровно одно из value или ref", n)
+			}
+			if c.Ref != "" {
+				if _, err := resolveRef(c.Ref, keys); err != nil {
+					return invalid("условие %d: %v", n, err)
+				}
+			}
+		case CondTime:
+			from, errFrom := parseClock(c.From)
+			to, errTo := parseClock(c.To)
+			if errFrom != nil || errTo != nil {
+				return invalid("условие %d: from и to должны быть в формате HH:MM", n)
+			}
+			if from == to {
+				return invalid("условие %d: окно времени пустое", n)
+			}
+		case CondRelay:
+			if e.relayByName(c.Relay) == nil {
+				return invalid("условие %d: неизвестное реле %q", n, c.Relay)
+			}
+			if c.State == nil {
+				return invalid("условие %d: не указано состояние реле", n)
+			}
+			if c.ForSec < 0 {
+				return invalid("условие %d: for_sec не может быть отрицательным", n)
+			}
+		default:
+			return invalid("условие %d: неизвестный тип %q (допустимо: sensor, time, relay)", n, c.Type)
+		}
+	}
+	return nil
+}
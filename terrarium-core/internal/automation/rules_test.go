@@ -0,0 +1,55 @@
+package automation
+
+import (
+	"errors"
+	"testing"
+
+	"terrarium-core/internal/models"
+)
+
+func TestValidateRuleAction(t *testing.T) {
+	e, _ := newTestEngine(t)
+	daytime := []models.RuleCondition{{Type: CondTime, From: "08:00", To: "20:00"}}
+
+	tests := []struct {
+		name   string
+		safety bool
+		action models.RuleAction
+		ok     bool
+	}{
+		{"обычное правило включает реле", false, models.RuleAction{Relay: "light", State: true}, true},
+		{"правило безопасности выключает реле", true, models.RuleAction{Relay: "heat_mat"}, true},
+		{"правило безопасности выключает все реле", true, models.RuleAction{Relay: allRelays}, true},
+		{"правило безопасности включает реле", true, models.RuleAction{Relay: "heat_mat", State: true}, false},
+		{"правило безопасности включает все реле", true, models.RuleAction{Relay: allRelays, State: true}, false},
+		{"обычное правило над всеми реле", false, models.RuleAction{Relay: allRelays}, false},
+		{"неизвестное реле", false, models.RuleAction{Relay: "pump", State: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e.validateRule(models.AutomationRuleRequest{Name: "TEST", Safety: tt.safety, Conditions: daytime, Action: tt.action})
+			if tt.ok && err != nil {
+				t.Errorf("validateRule: %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("validateRule = %v, want ErrInvalidRule", err)
+			}
+		})
+	}
+}
+
+func TestSplitRulesSkipsSafetyRuleTurningOn(t *testing.T) {
+	rules := []models.AutomationRule{
+		{ID: "cut", IsActive: true, Safety: true, Action: models.RuleAction{Relay: allRelays}},
+		{ID: "bad", IsActive: true, Safety: true, Action: models.RuleAction{Relay: "heat_mat", State: true}},
+		{ID: "off", IsActive: false, Action: models.RuleAction{Relay: "light", State: true}},
+		{ID: "auto", IsActive: true, Action: models.RuleAction{Relay: "light", State: true}},
+	}
+	safety, normal := splitRules(rules)
+	if len(safety) != 1 || safety[0].ID != "cut" {
+		t.Errorf("правила безопасности = %v, want [cut]", safety)
+	}
+	if len(normal) != 1 || normal[0].ID != "auto" {
+		t.Errorf("обычные правила = %v, want [auto]", normal)
+	}
+}
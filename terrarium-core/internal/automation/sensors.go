@@ -0,0 +1,160 @@
+package automation
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"terrarium-core/internal/diagnostics"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/metrics"
+	"terrarium-core/internal/models"
+)
+
+// sensorSlot — датчик из реестра движка вместе с детектором зависания.
+type sensorSlot struct {
+	info   models.SensorInfo
+	sensor gpio.Sensor
+	freeze *diagnostics.FreezeDetector
+}
+
+// AddSensor регистрирует датчик в реестре движка. Зона по умолчанию совпадает с ID датчика,
+// а измеряемые величины берутся у драйвера. Вызывается до Start.
+func (e *Engine) AddSensor(info models.SensorInfo, s gpio.Sensor) {
+	if info.Name == "" {
+		info.Name = s.Name()
+	}
+	if info.Zone == "" {
+		info.Zone = info.ID
+	}
+	info.Quantities = s.Quantities()
+	e.sensors = append(e.sensors, &sensorSlot{
+		info:   info,
+		sensor: s,
+		freeze: diagnostics.NewFreezeDetector(info.Name, sensorFreezeTimeout),
+	})
+}
+
+// Sensors возвращает реестр датчиков движка в порядке регистрации.
+func (e *Engine) Sensors() []models.SensorInfo {
+	result := make([]models.SensorInfo, 0, len(e.sensors))
+	for _, slot := range e.sensors {
+		result = append(result, slot.info)
+	}
+	return result
+}
+
+// GetSensorReadings возвращает последние показания всех датчиков (ключ — ID датчика). Потокобезопасно.
+func (e *Engine) GetSensorReadings() models.SensorReadings {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := models.SensorReadings{
+		Sensors:   make(map[string]models.SensorSnapshot, len(e.sensors)),
+		Timestamp: e.lastSensorsAt,
+		Mode:      e.currentMode,
+	}
+	for _, slot := range e.sensors {
+		snap, ok := e.lastSensors[slot.info.ID]
+		if !ok {
+			snap = models.SensorSnapshot{SensorInfo: slot.info, Values: map[string]float64{}}
+		}
+		result.Sensors[slot.info.ID] = snap
+	}
+	return result
+}
+
+// registerSensors записывает реестр датчиков в БД при старте движка.
+func (e *Engine) registerSensors(ctx context.Context) {
+	if err := e.repo.RegisterSensors(ctx, e.Sensors()); err != nil {
+		e.logger("sensors").Error("Не удалось записать реестр датчиков", logging.Err(err))
+	}
+}
+
+// readSensors опрашивает все датчики реестра и обновляет кэш последних показаний.
+// Возвращает показания ответивших датчиков (ID датчика -> величина -> значение).
+func (e *Engine) readSensors(now time.Time) map[string]gpio.Measurement {
+	measured := make(map[string]gpio.Measurement, len(e.sensors))
+	errs := make(map[string]error)
+	for _, slot := range e.sensors {
+		start := time.Now()
+		m, err := slot.sensor.Measure()
+		metrics.ObserveSensorRead(e.repo.Enclosure(), slot.info, m, time.Since(start), err)
+		if err != nil {
+			errs[slot.info.ID] = err
+			continue
+		}
+		measured[slot.info.ID] = m
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lastSensors == nil {
+		e.lastSensors = make(map[string]models.SensorSnapshot, len(e.sensors))
+	}
+	for _, slot := range e.sensors {
+		id := slot.info.ID
+		snap := e.lastSensors[id]
+		snap.SensorInfo = slot.info
+		if err, failed := errs[id]; failed {
+			// Сообщаем только о новой ошибке, чтобы не засорять лог каждые 5 секунд
+			if snap.Error == "" {
+				e.logger("sensors").Warn("Ошибка опроса датчика", logging.KeySensor, id, logging.Err(err))
+			}
+			snap.Error = err.Error()
+			if snap.Values == nil {
+				snap.Values = map[string]float64{}
+			}
+		} else {
+			if snap.Error != "" {
+				e.logger("sensors").Info("Датчик снова отвечает", logging.KeySensor, id)
+			}
+			at := now
+			snap.Values = maps.Clone(map[string]float64(measured[id]))
+			snap.ReadAt = &at
+			snap.Error = ""
+		}
+		e.lastSensors[id] = snap
+	}
+	e.lastSensorsAt = now
+	return measured
+}
+
+// sensorValues разворачивает показания в ключи "<датчик>.<величина>".
+func sensorValues(measured map[string]gpio.Measurement) map[string]float64 {
+	values := make(map[string]float64)
+	for id, m := range measured {
+		for q, v := range m {
+			values[sensorKey(id, q)] = v
+		}
+	}
+	return values
+}
+
+// sensorReadings преобразует показания цикла в строки sensor_readings (порядок — по реестру).
+func (e *Engine) sensorReadings(measured map[string]gpio.Measurement) []models.SensorReading {
+	var readings []models.SensorReading
+	for _, slot := range e.sensors {
+		m, ok := measured[slot.info.ID]
+		if !ok {
+			continue
+		}
+		for _, q := range slices.Sorted(maps.Keys(m)) {
+			readings = append(readings, models.SensorReading{SensorID: slot.info.ID, Quantity: q, Value: m[q]})
+		}
+	}
+	return readings
+}
+
+// missingInputs возвращает обязательные показания зон, которых нет в этом цикле.
+func missingInputs(inputs map[string]float64) []string {
+	var missing []string
+	for _, key := range requiredInputs {
+		if _, ok := inputs[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,172 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"terrarium-core/internal/energy"
+	"terrarium-core/internal/models"
+)
+
+// maxDryRunWindow — максимальный интервал истории для симуляции
+const maxDryRunWindow = 7 * 24 * time.Hour
+
+// maxSampleGap — разрыв между показаниями, дольше которого время работы не накапливается
+// (система была выключена, реле при старте всегда выключены).
+const maxSampleGap = time.Minute
+
+// ErrInvalidDryRunWindow возвращается при неверном интервале симуляции.
+var ErrInvalidDryRunWindow = errors.New("интервал симуляции должен быть положительным и не длиннее 7 суток")
+
+// SetWattage задает мощность нагрузок реле для оценки энергопотребления.
+func (e *Engine) SetWattage(w energy.Wattage) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.wattage = w
+}
+
+// DryRun воспроизводит историю sensor_readings за интервал через текущие правила автоматизации с кандидатной
+// конфигурацией и сравнивает результат с фактической работой реле по relay_logs.
+// Защита реле от дребезга, импульсный режим тумана, ручные переопределения и режим MANUAL не моделируются.
+func (e *Engine) DryRun(ctx context.Context, req models.DryRunRequest) (*models.DryRunResult, error) {
+	window := req.To.Sub(req.From)
+	if window <= 0 || window > maxDryRunWindow {
+		return nil, ErrInvalidDryRunWindow
+	}
+
+	samples, err := e.repo.GetReadingSamples(ctx, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	wattage := e.wattage
+	e.mu.RUnlock()
+
+	relays := make([]string, 0, len(e.relays))
+	for _, r := range e.relays {
+		relays = append(relays, r.Name())
+	}
+	initial := make(map[string]bool, len(relays))
+	actual := make(map[string]*models.DryRunRelaySummary, len(relays))
+	for _, id := range relays {
+		activity, err := e.repo.GetRelayActivity(ctx, id, req.From, req.To)
+		if err != nil {
+			return nil, err
+		}
+		initial[id] = activity.InitialState
+		actual[id] = &models.DryRunRelaySummary{
+			RelayID:        id,
+			ActualSwitches: activity.Switches,
+			ActualOnSec:    int64(activity.OnTime.Seconds()),
+			ActualDutyPct:  dutyPct(activity.OnTime, window),
+			ActualKwh:      roundKwh(wattage.Kwh(id, activity.OnTime)),
+		}
+	}
+
+	sim := simulate(samples, e.Sensors(), &req.Config, e.loadRules(ctx), relays, initial, req.To)
+
+	result := &models.DryRunResult{
+		From:        req.From,
+		To:          req.To,
+		Samples:     len(samples),
+		Relays:      make([]models.DryRunRelaySummary, 0, len(relays)),
+		Transitions: sim.transitions,
+	}
+	for _, id := range relays {
+		summary := actual[id]
+		summary.SimulatedSwitches = sim.switches[id]
+		summary.SimulatedOnSec = int64(sim.onTime[id].Seconds())
+		summary.SimulatedDutyPct = dutyPct(sim.onTime[id], window)
+		summary.SimulatedKwh = roundKwh(wattage.Kwh(id, sim.onTime[id]))
+		result.Relays = append(result.Relays, *summary)
+	}
+	return result, nil
+}
+
+type simulation struct {
+	transitions []models.SimulatedTransition
+	switches    map[string]int
+	onTime      map[string]time.Duration
+}
+
+// simulate прогоняет показания через те же правила, что и движок (decide.go): сначала правила
+// безопасности, затем обычные в порядке приоритета. Режим считается AUTO.
+func simulate(samples []models.ReadingSample, sensors []models.SensorInfo, cfg *models.ConfigPayload, rules []models.AutomationRule, relayIDs []string, initial map[string]bool, end time.Time) *simulation {
+	sim := &simulation{
+		transitions: []models.SimulatedTransition{},
+		switches:    map[string]int{},
+		onTime:      map[string]time.Duration{},
+	}
+	safetyRules, autoRules := splitRules(rules)
+	config := configValues(cfg)
+
+	state := make(map[string]bool, len(relayIDs))
+	since := make(map[string]time.Time, len(relayIDs))
+	for _, id := range relayIDs {
+		state[id] = initial[id]
+	}
+
+	apply := func(at time.Time, cmds []ruleCommand) {
+		for _, cmd := range cmds {
+			if state[cmd.relay] == cmd.state {
+				continue
+			}
+			state[cmd.relay] = cmd.state
+			since[cmd.relay] = at
+			sim.switches[cmd.relay]++
+			sim.transitions = append(sim.transitions, models.SimulatedTransition{At: at, Relay: cmd.relay, State: cmd.state, Rule: cmd.rule, Detail: cmd.detail})
+		}
+	}
+
+	for i, s := range samples {
+		env := &ruleEnv{
+			now:      s.Timestamp.Local(),
+			inputs:   readingInputs(s.Values, sensors),
+			config:   config,
+			relays:   make(map[string]relayStatus, len(relayIDs)),
+			relayIDs: relayIDs,
+		}
+		for _, id := range relayIDs {
+			st := relayStatus{on: state[id], held: -1}
+			if t, ok := since[id]; ok {
+				st.held = s.Timestamp.Sub(t)
+			}
+			env.relays[id] = st
+		}
+
+		// Как и движок, пропускаем циклы без обязательных показаний зон (SENSOR_ERROR)
+		if len(missingInputs(env.inputs)) == 0 {
+			claimed := make(map[string]string)
+			_, cmds := evaluateRules(safetyRules, env, claimed, nil)
+			apply(s.Timestamp, cmds)
+			if !slices.ContainsFunc(cmds, func(c ruleCommand) bool { return c.all }) {
+				_, cmds = evaluateRules(autoRules, env, claimed, nil)
+				apply(s.Timestamp, cmds)
+			}
+		}
+
+		next := end
+		if i+1 < len(samples) {
+			next = samples[i+1].Timestamp
+		}
+		step := min(next.Sub(s.Timestamp), maxSampleGap)
+		for relay, on := range state {
+			if on && step > 0 {
+				sim.onTime[relay] += step
+			}
+		}
+	}
+	return sim
+}
+
+func dutyPct(on, window time.Duration) float64 {
+	return math.Round(on.Seconds()/window.Seconds()*1000) / 10
+}
+
+func roundKwh(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
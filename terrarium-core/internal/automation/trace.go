@@ -0,0 +1,315 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+// traceRingSize — сколько последних циклов хранится в памяти (~20 минут при цикле 5 сек)
+const traceRingSize = 240
+
+// Режимы сохранения трассировки в БД (DECISION_TRACE_PERSIST)
+const (
+	TracePersistOff     = "off"
+	TracePersistActions = "actions" // только циклы, в которых были команды реле
+	TracePersistAll     = "all"
+)
+
+// traceRing — кольцевой буфер последних записей решений.
+type traceRing struct {
+	mu   sync.RWMutex
+	seq  uint64
+	buf  []*models.DecisionRecord
+	next int
+}
+
+func newTraceRing(size int) *traceRing {
+	return &traceRing{buf: make([]*models.DecisionRecord, 0, size)}
+}
+
+func (r *traceRing) nextID() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	return r.seq
+}
+
+func (r *traceRing) push(rec *models.DecisionRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.buf) < cap(r.buf) {
+		r.buf = append(r.buf, rec)
+		return
+	}
+	r.buf[r.next] = rec
+	r.next = (r.next + 1) % len(r.buf)
+}
+
+// latest возвращает последнюю запись (nil — циклов еще не было).
+func (r *traceRing) latest() *models.DecisionRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := len(r.buf)
+	switch {
+	case n == 0:
+		return nil
+	case n < cap(r.buf):
+		return r.buf[n-1]
+	}
+	return r.buf[(r.next-1+n)%n]
+}
+
+// newestFirst возвращает записи от новых к старым.
+func (r *traceRing) newestFirst() []*models.DecisionRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := len(r.buf)
+	start := n - 1 // буфер еще не заполнен: новейшая запись в конце
+	if n == cap(r.buf) {
+		start = r.next - 1 + n
+	}
+	result := make([]*models.DecisionRecord, 0, n)
+	for i := 0; i < n; i++ {
+		result = append(result, r.buf[(start-i+n)%n])
+	}
+	return result
+}
+
+type traceKey struct{}
+
+// beginTrace создает запись решения для нового цикла и кладет ее в контекст.
+func (e *Engine) beginTrace(ctx context.Context) (context.Context, *models.DecisionRecord) {
+	rec := &models.DecisionRecord{
+		CycleID:   e.traces.nextID(),
+		StartedAt: time.Now(),
+		Rules:     []models.RuleEvaluation{},
+		Actions:   []models.RelayAction{},
+	}
+	return context.WithValue(ctx, traceKey{}, rec), rec
+}
+
+// finishTrace фиксирует длительность цикла, кладет запись в буфер и при необходимости сохраняет в БД.
+func (e *Engine) finishTrace(ctx context.Context, rec *models.DecisionRecord) {
+	rec.DurationMs = float64(time.Since(rec.StartedAt).Microseconds()) / 1000
+	e.traces.push(rec)
+
+	e.mu.RLock()
+	persist := e.tracePersist
+	e.mu.RUnlock()
+	if persist == TracePersistAll || (persist == TracePersistActions && len(rec.Actions) > 0) {
+		if err := e.repo.InsertDecisionTrace(ctx, rec); err != nil {
+			e.logger("engine").Error("Не удалось сохранить трассировку цикла", "cycle_id", rec.CycleID, logging.Err(err))
+		}
+	}
+}
+
+// traceFrom возвращает запись решения текущего цикла (nil для вызовов вне цикла, например из API).
+func traceFrom(ctx context.Context) *models.DecisionRecord {
+	rec, _ := ctx.Value(traceKey{}).(*models.DecisionRecord)
+	return rec
+}
+
+// traceRule добавляет оценку правила в запись текущего цикла.
+func traceRule(ctx context.Context, ev models.RuleEvaluation) {
+	if rec := traceFrom(ctx); rec != nil {
+		rec.Rules = append(rec.Rules, ev)
+	}
+}
+
+// traceAction добавляет команду реле в запись текущего цикла.
+func traceAction(ctx context.Context, relay string, state bool, reason string, err error) {
+	rec := traceFrom(ctx)
+	if rec == nil {
+		return
+	}
+	action := models.RelayAction{Relay: relay, State: state, Reason: reason, Applied: err == nil, At: time.Now()}
+	if err != nil {
+		action.Error = err.Error()
+	}
+	rec.Actions = append(rec.Actions, action)
+}
+
+// traceBrightness фиксирует в записи решения команду яркости диммируемого выхода.
+func traceBrightness(ctx context.Context, relay string, level float64, reason string, err error) {
+	traceAction(ctx, relay, level > 0, reason, err)
+	if rec := traceFrom(ctx); rec != nil {
+		rec.Actions[len(rec.Actions)-1].Brightness = &level
+	}
+}
+
+// SetTracePersistence задает режим сохранения трассировки в БД (off / actions / all).
+func (e *Engine) SetTracePersistence(mode string) {
+	switch mode {
+	case TracePersistActions, TracePersistAll:
+	default:
+		mode = TracePersistOff
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tracePersist = mode
+}
+
+// GetDecisions возвращает последние записи решений (от новых к старым).
+// Если relayID задан, возвращаются только циклы с командами этого реле.
+func (e *Engine) GetDecisions(limit int, relayID string) []models.DecisionRecord {
+	if limit <= 0 || limit > traceRingSize {
+		limit = 20
+	}
+	result := make([]models.DecisionRecord, 0, limit)
+	for _, rec := range e.traces.newestFirst() {
+		if relayID != "" && !hasAction(rec, relayID) {
+			continue
+		}
+		result = append(result, *rec)
+		if len(result) == limit {
+			break
+		}
+	}
+	return result
+}
+
+// ExplainRelay объясняет текущее состояние реле: последнее переключение и правила, которые к нему привели,
+// а также как правила оценили реле в последнем цикле.
+func (e *Engine) ExplainRelay(ctx context.Context, relayID string) (*models.RelayExplanation, error) {
+	relay := e.relayByName(relayID)
+	if relay == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRelay, relayID)
+	}
+
+	e.mu.RLock()
+	mode := e.currentMode
+	emergency, panicSince := e.emergencyLocked(), e.panicSince
+	var override *models.RelayOverride
+	if ov, ok := e.overrides[relayID]; ok {
+		override = withRemaining(ov, time.Now())
+	}
+	e.mu.RUnlock()
+
+	exp := &models.RelayExplanation{
+		RelayID:         relayID,
+		State:           relay.IsOn(),
+		Mode:            mode,
+		Override:        override,
+		LastChangeRules: []models.RuleEvaluation{},
+		CurrentRules:    []models.RuleEvaluation{},
+	}
+	if p, ok := relay.(*gpio.ProtectedRelay); ok {
+		exp.OnForSec = int64(p.OnDuration().Seconds())
+	}
+
+	records := e.traces.newestFirst()
+	if len(records) > 0 {
+		exp.CurrentRules = rulesFor(records[0], relayID)
+	}
+	for _, rec := range records {
+		if action := lastAppliedAction(rec, relayID); action != nil {
+			exp.LastChange = action
+			exp.LastChangeRules = rulesFor(rec, relayID)
+			break
+		}
+	}
+
+	// Переключение было раньше, чем хранит буфер (или вручную через API) — берем из журнала реле
+	if exp.LastChange == nil {
+		if entry, err := e.repo.GetLastRelayLog(ctx, relayID); err == nil && entry != nil {
+			exp.LastChange = &models.RelayAction{Relay: relayID, State: entry.State, Reason: entry.Reason, Applied: true, At: entry.RecordedAt}
+		}
+	}
+
+	exp.Summary = explainSummary(exp, emergency, panicSince)
+	return exp, nil
+}
+
+func explainSummary(exp *models.RelayExplanation, emergency bool, panicSince time.Time) string {
+	var sb strings.Builder
+	state := "ВЫКЛЮЧЕНО"
+	if exp.State {
+		state = "ВКЛЮЧЕНО"
+	}
+	fmt.Fprintf(&sb, "Реле %s %s.", exp.RelayID, state)
+
+	if exp.LastChange != nil {
+		verb := "Выключено"
+		if exp.LastChange.State {
+			verb = "Включено"
+		}
+		fmt.Fprintf(&sb, " %s %s (%s)", verb, exp.LastChange.At.Format(time.DateTime), exp.LastChange.Reason)
+		if cause := decisiveRule(exp.LastChangeRules, exp.LastChange.State); cause != nil {
+			fmt.Fprintf(&sb, ": %s", cause.Detail)
+		}
+		sb.WriteString(".")
+	}
+
+	switch {
+	case !panicSince.IsZero():
+		fmt.Fprintf(&sb, " Активен аварийный стоп с %s — все реле удерживаются выключенными до сброса кнопкой или через API.", panicSince.Format(time.DateTime))
+	case emergency:
+		sb.WriteString(" Активно аварийное отключение — все реле удерживаются выключенными.")
+	case exp.Override != nil:
+		fmt.Fprintf(&sb, " Удерживается ручным переопределением до %s.", exp.Override.ExpiresAt.Format(time.DateTime))
+	case exp.Mode == "MANUAL":
+		sb.WriteString(" Система в режиме MANUAL — автоматика реле не управляет.")
+	default:
+		for _, ev := range exp.CurrentRules {
+			if ev.Decision == DecisionHold || ev.Decision == DecisionSkip {
+				continue
+			}
+			fmt.Fprintf(&sb, " Сейчас %s требует %s: %s.", ev.Rule, ev.Decision, ev.Detail)
+			return sb.String()
+		}
+		if len(exp.CurrentRules) > 0 {
+			last := exp.CurrentRules[len(exp.CurrentRules)-1]
+			fmt.Fprintf(&sb, " Сейчас %s: %s.", last.Rule, last.Detail)
+		}
+	}
+	return sb.String()
+}
+
+// decisiveRule находит сработавшее правило, решение которого совпадает с новым состоянием реле.
+func decisiveRule(rules []models.RuleEvaluation, state bool) *models.RuleEvaluation {
+	want := DecisionOff
+	if state {
+		want = DecisionOn
+	}
+	for i := range rules {
+		if rules[i].Matched && rules[i].Decision == want {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func rulesFor(rec *models.DecisionRecord, relayID string) []models.RuleEvaluation {
+	result := []models.RuleEvaluation{}
+	for _, ev := range rec.Rules {
+		if ev.Relay == relayID || ev.Relay == allRelays {
+			result = append(result, ev)
+		}
+	}
+	return result
+}
+
+func hasAction(rec *models.DecisionRecord, relayID string) bool {
+	for _, a := range rec.Actions {
+		if a.Relay == relayID {
+			return true
+		}
+	}
+	return false
+}
+
+func lastAppliedAction(rec *models.DecisionRecord, relayID string) *models.RelayAction {
+	for i := len(rec.Actions) - 1; i >= 0; i-- {
+		if a := rec.Actions[i]; a.Relay == relayID && a.Applied {
+			return &a
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package automation
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExplainRelayDuringPanicStop(t *testing.T) {
+	e, _ := newTestEngine(t)
+	ctx := context.Background()
+	e.SetPanicStop(ctx, true, PanicSourceAPI)
+
+	exp, err := e.ExplainRelay(ctx, "heat_mat")
+	if err != nil {
+		t.Fatalf("ExplainRelay: %v", err)
+	}
+	if !strings.Contains(exp.Summary, "аварийный стоп") {
+		t.Errorf("объяснение не упоминает аварийный стоп: %q", exp.Summary)
+	}
+
+	e.SetPanicStop(ctx, false, PanicSourceAPI)
+	if exp, _ := e.ExplainRelay(ctx, "heat_mat"); strings.Contains(exp.Summary, "аварийный стоп") {
+		t.Errorf("после сброса стопа объяснение = %q", exp.Summary)
+	}
+}
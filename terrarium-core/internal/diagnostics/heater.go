@@ -0,0 +1,191 @@
+package diagnostics
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/models"
+)
+
+// Типы неисправностей, выявляемых по тепловому отклику
+const (
+	FaultHeaterNotHeating   = "HEATER_NOT_HEATING"
+	FaultTempRisingWhileOff = "TEMP_RISING_WHILE_OFF"
+	FaultSensorFrozen       = "SENSOR_FROZEN"
+)
+
+// learnedPhasesForBaseline — сколько завершенных фаз нужно, чтобы доверять выученной скорости
+const learnedPhasesForBaseline = 3
+
+// HeaterConfig задает пороги диагностики нагревателя.
+type HeaterConfig struct {
+	// Grace — время после переключения, в течение которого инерция не считается неисправностью
+	Grace time.Duration
+	// MinRise — минимальный ожидаемый прирост (°C) за окно после Grace при включенном нагреве
+	MinRise float64
+	// ExpectedFraction — доля от выученной скорости нагрева, ниже которой нагрев считается неработающим
+	ExpectedFraction float64
+	// MaxRiseWhileOff — прирост (°C) после Grace при выключенном нагреве, указывающий на залипшее реле
+	MaxRiseWhileOff float64
+	// LearnAlpha — коэффициент экспоненциального сглаживания выученных скоростей
+	LearnAlpha float64
+}
+
+// DefaultHeaterConfig возвращает пороги, подходящие для термоковрика под террариумом.
+func DefaultHeaterConfig() HeaterConfig {
+	return HeaterConfig{
+		Grace:            15 * time.Minute,
+		MinRise:          0.3,
+		ExpectedFraction: 0.3,
+		MaxRiseWhileOff:  1.5,
+		LearnAlpha:       0.3,
+	}
+}
+
+// Event — переход неисправности в активное или снятое состояние.
+type Event struct {
+	Fault   string
+	Raised  bool
+	Message string
+}
+
+// HeaterMonitor изучает отклик температуры на включение/выключение нагревателя
+// и выявляет неисправности: нагреватель не греет или температура растет при выключенном нагреве.
+type HeaterMonitor struct {
+	cfg HeaterConfig
+
+	mu         sync.Mutex
+	started    bool
+	phaseOn    bool      // состояние нагревателя в текущей фазе
+	phaseStart time.Time // начало фазы
+	startTemp  float64   // температура в начале фазы
+	refTemp    float64   // опорная температура после Grace (для фазы OFF)
+	refSet     bool
+	lastTemp   float64
+
+	onRate     float64 // выученная скорость нагрева, °C/мин
+	offRate    float64 // выученная скорость остывания, °C/мин
+	onLearned  int
+	offLearned int
+
+	faults map[string]*models.FaultInfo
+}
+
+// NewHeaterMonitor создает монитор нагревателя.
+func NewHeaterMonitor(cfg HeaterConfig) *HeaterMonitor {
+	return &HeaterMonitor{
+		cfg:    cfg,
+		faults: make(map[string]*models.FaultInfo),
+	}
+}
+
+// Observe принимает очередное показание теплой зоны и текущее состояние нагревателя.
+// Возвращает события поднятия/снятия неисправностей.
+func (m *HeaterMonitor) Observe(at time.Time, temp float64, heaterOn bool) []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.started || heaterOn != m.phaseOn {
+		if m.started {
+			m.learnPhase(at)
+		}
+		m.started = true
+		m.phaseOn = heaterOn
+		m.phaseStart = at
+		m.startTemp = temp
+		m.refSet = false
+	}
+	m.lastTemp = temp
+
+	var events []Event
+	elapsed := at.Sub(m.phaseStart)
+
+	// Нагреватель включен, но температура не растет
+	notHeating := false
+	var msg string
+	if m.phaseOn && elapsed >= m.cfg.Grace {
+		rise := temp - m.startTemp
+		expected := m.cfg.MinRise
+		if m.onLearned >= learnedPhasesForBaseline && m.onRate > 0 {
+			expected = max(expected, m.onRate*elapsed.Minutes()*m.cfg.ExpectedFraction)
+		}
+		if rise < expected {
+			notHeating = true
+			msg = fmt.Sprintf("нагрев включен %s, прирост %.2f°C при ожидаемых ≥%.2f°C", elapsed.Round(time.Minute), rise, expected)
+		}
+	}
+	events = setFault(m.faults, events, FaultHeaterNotHeating, notHeating, at, msg)
+
+	// Нагреватель выключен, но температура продолжает расти (залипшее реле)
+	risingWhileOff := false
+	msg = ""
+	if !m.phaseOn && elapsed >= m.cfg.Grace {
+		if !m.refSet {
+			m.refTemp = temp
+			m.refSet = true
+		}
+		threshold := m.cfg.MaxRiseWhileOff
+		if m.offLearned >= learnedPhasesForBaseline && m.offRate > 0 {
+			// Если террариум обычно сам нагревается (солнечная комната), учитываем это
+			threshold += m.offRate * (elapsed - m.cfg.Grace).Minutes()
+		}
+		if rise := temp - m.refTemp; rise > threshold {
+			risingWhileOff = true
+			msg = fmt.Sprintf("нагрев выключен %s, температура выросла на %.2f°C (порог %.2f°C)", elapsed.Round(time.Minute), rise, threshold)
+		}
+	}
+	events = setFault(m.faults, events, FaultTempRisingWhileOff, risingWhileOff, at, msg)
+
+	return events
+}
+
+// learnPhase обновляет выученные скорости по завершенной фазе. Вызывается под мьютексом.
+func (m *HeaterMonitor) learnPhase(end time.Time) {
+	duration := end.Sub(m.phaseStart)
+	if duration < m.cfg.Grace || m.faults[FaultHeaterNotHeating] != nil || m.faults[FaultTempRisingWhileOff] != nil {
+		return // Короткие и аномальные фазы не учим
+	}
+	rate := (m.lastTemp - m.startTemp) / duration.Minutes()
+	if m.phaseOn {
+		m.onRate = ewma(m.onRate, rate, m.cfg.LearnAlpha, m.onLearned)
+		m.onLearned++
+	} else {
+		m.offRate = ewma(m.offRate, rate, m.cfg.LearnAlpha, m.offLearned)
+		m.offLearned++
+	}
+}
+
+// Health возвращает состояние нагревателя для /system/status.
+func (m *HeaterMonitor) Health() models.ComponentHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := models.ComponentHealth{
+		Component:   "heater",
+		Status:      healthStatusOK,
+		OnRateCpm:   round2(m.onRate),
+		OffRateCpm:  round2(m.offRate),
+		LearnedRuns: m.onLearned + m.offLearned,
+	}
+	if m.onLearned < learnedPhasesForBaseline || m.offLearned < learnedPhasesForBaseline {
+		h.Status = healthStatusLearning
+	}
+	h.Faults = faultList(m.faults)
+	if len(h.Faults) > 0 {
+		h.Status = healthStatusFault
+	}
+	return h
+}
+
+func ewma(prev, sample, alpha float64, n int) float64 {
+	if n == 0 {
+		return sample
+	}
+	return alpha*sample + (1-alpha)*prev
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,110 @@
+package diagnostics
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/models"
+)
+
+// Статусы здоровья компонентов
+const (
+	healthStatusOK       = "OK"
+	healthStatusLearning = "LEARNING"
+	healthStatusFault    = "FAULT"
+)
+
+// FreezeDetector выявляет "замерзший" датчик: показания не меняются дольше заданного времени.
+// DHT22 при зависании шины часто отдает последнее значение бесконечно.
+type FreezeDetector struct {
+	sensor string
+	after  time.Duration
+
+	mu        sync.Mutex
+	last      map[string]float64
+	sameSince time.Time
+	faults    map[string]*models.FaultInfo
+}
+
+// NewFreezeDetector создает детектор для датчика с заданным временем неизменности показаний.
+func NewFreezeDetector(sensor string, after time.Duration) *FreezeDetector {
+	return &FreezeDetector{
+		sensor: sensor,
+		after:  after,
+		faults: make(map[string]*models.FaultInfo),
+	}
+}
+
+// Observe принимает очередные показания датчика (величина -> значение).
+// Возвращает события поднятия/снятия неисправности.
+func (d *FreezeDetector) Observe(at time.Time, values map[string]float64) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sameSince.IsZero() || !maps.Equal(values, d.last) {
+		d.last = maps.Clone(values)
+		d.sameSince = at
+	}
+
+	frozen := at.Sub(d.sameSince) >= d.after
+	msg := ""
+	if frozen {
+		msg = fmt.Sprintf("датчик %s отдает %s без изменений %s", d.sensor, formatValues(values), at.Sub(d.sameSince).Round(time.Minute))
+	}
+	return setFault(d.faults, nil, FaultSensorFrozen, frozen, at, msg)
+}
+
+// Health возвращает состояние датчика для /system/status.
+func (d *FreezeDetector) Health() models.ComponentHealth {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h := models.ComponentHealth{
+		Component: "sensor:" + d.sensor,
+		Status:    healthStatusOK,
+		Faults:    faultList(d.faults),
+	}
+	if len(h.Faults) > 0 {
+		h.Status = healthStatusFault
+	}
+	return h
+}
+
+// setFault поднимает или снимает неисправность и дописывает событие перехода.
+func setFault(faults map[string]*models.FaultInfo, events []Event, fault string, active bool, at time.Time, msg string) []Event {
+	existing := faults[fault]
+	switch {
+	case active && existing == nil:
+		faults[fault] = &models.FaultInfo{Fault: fault, Message: msg, Since: at}
+		events = append(events, Event{Fault: fault, Raised: true, Message: msg})
+	case active:
+		existing.Message = msg
+	case existing != nil:
+		delete(faults, fault)
+		events = append(events, Event{Fault: fault, Raised: false, Message: "состояние восстановлено"})
+	}
+	return events
+}
+
+func faultList(faults map[string]*models.FaultInfo) []models.FaultInfo {
+	result := make([]models.FaultInfo, 0, len(faults))
+	for _, f := range faults {
+		result = append(result, *f)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Fault < result[j].Fault })
+	return result
+}
+
+// formatValues форматирует показания в виде "humidity 55.0, temperature 31.2" (величины по алфавиту).
+func formatValues(values map[string]float64) string {
+	parts := make([]string, 0, len(values))
+	for _, q := range slices.Sorted(maps.Keys(values)) {
+		parts = append(parts, fmt.Sprintf("%s %.1f", q, values[q]))
+	}
+	return strings.Join(parts, ", ")
+}
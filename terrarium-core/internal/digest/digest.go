@@ -0,0 +1,187 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"terrarium-core/internal/automation"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+var logger = logging.Component("digest")
+
+// Source — террариум, включаемый в ежедневную сводку.
+type Source struct {
+	ID     string
+	Name   string
+	Engine *automation.Engine
+}
+
+// Entry — сводка одного террариума для письма (Err — сводку собрать не удалось).
+type Entry struct {
+	Source
+	Digest *models.DailyDigest
+	Err    error
+}
+
+// Sender отправляет письмо сводки (notify.Email).
+type Sender interface {
+	Send(ctx context.Context, subject, body string) error
+}
+
+// Scheduler раз в сутки в заданное время отправляет сводку за прошедшие сутки по всем террариумам.
+type Scheduler struct {
+	sources []Source
+	sender  Sender
+}
+
+// ParseTime разбирает время отправки DIGEST_TIME в формате ЧЧ:ММ.
+func ParseTime(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("DIGEST_TIME должно быть в формате ЧЧ:ММ: %q", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// NewScheduler создает планировщик сводки по террариумам sources.
+func NewScheduler(sources []Source, sender Sender) *Scheduler {
+	return &Scheduler{sources: sources, sender: sender}
+}
+
+// Start запускает ежедневную отправку в hour:minute местного времени в фоне до отмены ctx.
+// Сводка, время которой пришлось на простой сервиса, не досылается.
+func (s *Scheduler) Start(ctx context.Context, hour, minute int) {
+	go func() {
+		for {
+			next := nextRun(time.Now(), hour, minute)
+			logger.Debug("Следующая сводка запланирована", "at", next)
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				if err := s.Send(ctx, next.AddDate(0, 0, -1)); err != nil {
+					logger.Error("Не удалось отправить ежедневную сводку", logging.Err(err))
+				}
+			}
+		}
+	}()
+}
+
+// nextRun возвращает ближайшее после now время hour:minute.
+func nextRun(now time.Time, hour, minute int) time.Time {
+	at := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, time.Local)
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at
+}
+
+// Send собирает сводку за сутки day по всем террариумам и отправляет ее одним письмом.
+// Террариум, сводку которого собрать не удалось, отмечается в письме, остальные отправляются.
+func (s *Scheduler) Send(ctx context.Context, day time.Time) error {
+	var digests []Entry
+	for _, src := range s.sources {
+		d, err := src.Engine.Digest(ctx, day)
+		if errors.Is(err, automation.ErrInvalidDigestDay) {
+			return err
+		}
+		if err != nil {
+			logger.Warn("Не удалось собрать сводку террариума", logging.KeyEnclosure, src.ID, logging.Err(err))
+		}
+		digests = append(digests, Entry{Source: src, Digest: d, Err: err})
+	}
+
+	subject, body := Render(day, digests)
+	if err := s.sender.Send(ctx, subject, body); err != nil {
+		return fmt.Errorf("отправка сводки: %w", err)
+	}
+	logger.Info("Ежедневная сводка отправлена", "date", day.Format(time.DateOnly), "enclosures", len(digests))
+	return nil
+}
+
+// units — единицы измерения величин в тексте письма
+var units = map[string]string{
+	gpio.QuantityTemperature: "°C",
+	gpio.QuantityHumidity:    "%",
+	gpio.QuantityPressure:    " гПа",
+}
+
+// quantityNames — названия величин в тексте письма
+var quantityNames = map[string]string{
+	gpio.QuantityTemperature: "температура",
+	gpio.QuantityHumidity:    "влажность",
+	gpio.QuantityPressure:    "давление",
+}
+
+// eventSources — названия источников событий безопасности в тексте письма
+var eventSources = map[string]string{
+	"alert":     "алерт",
+	"safety":    "отключение",
+	"input":     "вход",
+	"reservoir": "резервуар",
+}
+
+// Render формирует тему и текст письма сводки за сутки day.
+func Render(day time.Time, entries []Entry) (subject, body string) {
+	date := day.Format("02.01.2006")
+	events := 0
+	var b strings.Builder
+	fmt.Fprintf(&b, "Сводка работы террариумов за %s\n", date)
+
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n=== %s (%s) ===\n", e.Name, e.ID)
+		if e.Err != nil {
+			fmt.Fprintf(&b, "Сводку собрать не удалось: %v\n", e.Err)
+			continue
+		}
+		d := e.Digest
+
+		b.WriteString("\nКлимат по зонам:\n")
+		if len(d.Zones) == 0 {
+			b.WriteString("  нет показаний\n")
+		}
+		for _, z := range d.Zones {
+			name, unit := quantityNames[z.Quantity], units[z.Quantity]
+			if name == "" {
+				name = z.Quantity
+			}
+			fmt.Fprintf(&b, "  %-10s %-12s мин %.1f%s  макс %.1f%s  сред %.1f%s  (%d изм.)\n",
+				z.Zone, name, z.Min, unit, z.Max, unit, z.Avg, unit, z.Samples)
+		}
+
+		b.WriteString("\nРеле:\n")
+		for _, r := range d.Relays {
+			on := time.Duration(r.OnSec) * time.Second
+			fmt.Fprintf(&b, "  %-10s в работе %dч %02dм (%.1f%%), переключений %d, %.3f кВт⋅ч\n",
+				r.RelayID, int(on.Hours()), int(on.Minutes())%60, r.DutyPct, r.Switches, r.Kwh)
+		}
+		fmt.Fprintf(&b, "\nЭнергопотребление: %.3f кВт⋅ч\n", d.TotalKwh)
+
+		b.WriteString("\nСобытия безопасности:\n")
+		if len(d.Events) == 0 {
+			b.WriteString("  нет\n")
+		}
+		for _, ev := range d.Events {
+			line := fmt.Sprintf("  %s  %s: %s", ev.Time.Local().Format("15:04:05"), eventSources[ev.Source], ev.Event)
+			if ev.Detail != "" {
+				line += " — " + ev.Detail
+			}
+			b.WriteString(line + "\n")
+		}
+		events += len(d.Events)
+	}
+
+	subject = "Террариум: сводка за " + date
+	if events > 0 {
+		subject += fmt.Sprintf(" — событий безопасности: %d", events)
+	}
+	return subject, b.String()
+}
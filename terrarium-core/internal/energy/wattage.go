@@ -0,0 +1,44 @@
+package energy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// wattageKeys сопоставляет ключи WATTAGE_MAPPING с ID реле.
+var wattageKeys = map[string]string{
+	"relay_heat":  "heat_mat",
+	"relay_fog":   "fogger",
+	"relay_light": "light",
+	"relay_spare": "spare",
+}
+
+// Wattage — заявленная мощность нагрузки каждого реле (Вт), ключ — ID реле.
+type Wattage map[string]float64
+
+// ParseWattage разбирает WATTAGE_MAPPING вида {"relay_heat": 45, "relay_fog": 15, ...}.
+// Допускаются также ключи, совпадающие с ID реле ("heat_mat").
+func ParseWattage(raw string) (Wattage, error) {
+	result := Wattage{}
+	if raw == "" {
+		return result, nil
+	}
+
+	var parsed map[string]float64
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("ошибка разбора WATTAGE_MAPPING: %w", err)
+	}
+	for key, watts := range parsed {
+		if relayID, ok := wattageKeys[key]; ok {
+			key = relayID
+		}
+		result[key] = watts
+	}
+	return result, nil
+}
+
+// Kwh вычисляет энергию (кВт⋅ч), потребленную реле за время работы onTime.
+func (w Wattage) Kwh(relayID string, onTime time.Duration) float64 {
+	return w[relayID] * onTime.Hours() / 1000
+}
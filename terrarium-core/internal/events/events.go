@@ -0,0 +1,83 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Типы событий
+const (
+	RelaySwitched     = "relay.switched"
+	ModeChanged       = "mode.changed"
+	AlertFired        = "alert.fired"
+	AlertResolved     = "alert.resolved"
+	AlertAcknowledged = "alert.acknowledged"
+	// Readings — показания датчиков цикла движка (Data — []models.SensorReading)
+	Readings = "sensors.readings"
+	// EmergencyChanged — вход в аварийное отключение или выход из него (Data — Emergency)
+	EmergencyChanged = "emergency.changed"
+)
+
+// subscriberBuffer — очередь событий подписчика; если подписчик не успевает, лишние события пропускаются
+const subscriberBuffer = 256
+
+// Event — событие террариума для подписчиков внутри процесса (мост MQTT, ...).
+type Event struct {
+	Type      string
+	Enclosure string
+	Time      time.Time
+	Data      any
+}
+
+// RelayChange — данные события relay.switched.
+type RelayChange struct {
+	Relay      string   `json:"relay"`
+	State      bool     `json:"state"`
+	Brightness *float64 `json:"brightness,omitempty"` // яркость диммируемого выхода
+	Reason     string   `json:"reason"`
+}
+
+// ModeChange — данные события mode.changed.
+type ModeChange struct {
+	Mode        string     `json:"mode"`
+	Previous    string     `json:"previous"`
+	ManualUntil *time.Time `json:"manual_until"`
+}
+
+// Emergency — данные события emergency.changed.
+type Emergency struct {
+	Active bool `json:"active"`
+}
+
+var (
+	mu   sync.RWMutex
+	subs = make(map[chan Event]struct{})
+)
+
+// Publish рассылает событие подписчикам. Не блокируется: медленный подписчик теряет события.
+func Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for ch := range subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe подписывается на события всех террариумов. Вызывающий обязан вызвать cancel.
+func Subscribe() (events <-chan Event, cancel func()) {
+	ch := make(chan Event, subscriberBuffer)
+	mu.Lock()
+	subs[ch] = struct{}{}
+	mu.Unlock()
+	return ch, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(subs, ch)
+	}
+}
@@ -0,0 +1,228 @@
+package gpio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuantityPressure — атмосферное давление, гПа
+const QuantityPressure = "pressure"
+
+// Адреса BME280/BMP280 на шине (вывод SDO на GND / на VDDIO)
+const (
+	BME280AddrPrimary   = 0x76
+	BME280AddrSecondary = 0x77
+)
+
+// Регистры BME280 (datasheet BST-BME280-DS002, раздел 5.3)
+const (
+	bme280RegCalib00  = 0x88 // dig_T1..dig_P9, 0xA1 — dig_H1
+	bme280RegChipID   = 0xD0
+	bme280RegCalib26  = 0xE1 // dig_H2..dig_H6
+	bme280RegCtrlHum  = 0xF2
+	bme280RegStatus   = 0xF3
+	bme280RegCtrlMeas = 0xF4
+	bme280RegData     = 0xF7 // press[3], temp[3], hum[2]
+
+	bme280ChipID = 0x60
+	bmp280ChipID = 0x58
+
+	// Передискретизация x1 для всех величин, forced mode: одно измерение по запросу (~10 мс)
+	bme280CtrlHum  = 0x01
+	bme280CtrlMeas = 0x01<<5 | 0x01<<2 | 0x01
+
+	bme280SkippedTP = 0x80000 // значение АЦП, если измерение отключено
+	bme280SkippedH  = 0x8000
+)
+
+// bme280Calib — калибровочные коэффициенты, прошитые в датчик на заводе.
+type bme280Calib struct {
+	T1     uint16
+	T2, T3 int16
+	P1     uint16
+	P2, P3 int16
+	P4, P5 int16
+	P6, P7 int16
+	P8, P9 int16
+	H1     uint8
+	H2     int16
+	H3     uint8
+	H4, H5 int16
+	H6     int8
+}
+
+// BME280 — датчик температуры, влажности и давления Bosch BME280 на шине I2C.
+// В режиме BMP280 (тот же корпус без датчика влажности) измеряет только температуру и давление.
+// Калибровка читается при первом успешном опросе, поэтому датчик может появиться на шине после старта.
+type BME280 struct {
+	name     string
+	bus      I2CBus
+	addr     uint16
+	chipID   byte
+	humidity bool
+
+	mu    sync.Mutex
+	calib *bme280Calib
+}
+
+// NewBME280 создает датчик BME280 (температура, влажность, давление).
+func NewBME280(name string, bus I2CBus, addr uint16) *BME280 {
+	return &BME280{name: name, bus: bus, addr: addr, chipID: bme280ChipID, humidity: true}
+}
+
+// NewBMP280 создает датчик BMP280 (температура и давление).
+func NewBMP280(name string, bus I2CBus, addr uint16) *BME280 {
+	return &BME280{name: name, bus: bus, addr: addr, chipID: bmp280ChipID}
+}
+
+func (d *BME280) Name() string { return d.name }
+
+func (d *BME280) Quantities() []string {
+	if d.humidity {
+		return []string{QuantityTemperature, QuantityHumidity, QuantityPressure}
+	}
+	return []string{QuantityTemperature, QuantityPressure}
+}
+
+func (d *BME280) Measure() (Measurement, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.calib == nil {
+		calib, err := d.readCalibration()
+		if err != nil {
+			return nil, err
+		}
+		d.calib = calib
+	}
+
+	// Запуск одного измерения (forced mode). ctrl_hum применяется только после записи ctrl_meas.
+	if d.humidity {
+		if err := d.bus.Tx(d.addr, []byte{bme280RegCtrlHum, bme280CtrlHum}, nil); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.bus.Tx(d.addr, []byte{bme280RegCtrlMeas, bme280CtrlMeas}, nil); err != nil {
+		return nil, err
+	}
+	if err := d.waitMeasurement(); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 8)
+	if err := d.bus.Tx(d.addr, []byte{bme280RegData}, data); err != nil {
+		return nil, err
+	}
+	adcP := int32(data[0])<<12 | int32(data[1])<<4 | int32(data[2])>>4
+	adcT := int32(data[3])<<12 | int32(data[4])<<4 | int32(data[5])>>4
+	adcH := int32(data[6])<<8 | int32(data[7])
+	if adcT == bme280SkippedTP || adcP == bme280SkippedTP {
+		return nil, fmt.Errorf("bme280 0x%02x: измерение не выполнено", d.addr)
+	}
+
+	temp, tFine := d.calib.temperature(adcT)
+	m := Measurement{
+		QuantityTemperature: temp,
+		QuantityPressure:    d.calib.pressure(adcP, tFine) / 100,
+	}
+	if d.humidity {
+		if adcH == bme280SkippedH {
+			return nil, fmt.Errorf("bme280 0x%02x: измерение влажности не выполнено", d.addr)
+		}
+		m[QuantityHumidity] = d.calib.humidity(adcH, tFine)
+	}
+	return m, nil
+}
+
+// waitMeasurement ждет окончания измерения (бит measuring в регистре status), не дольше 50 мс.
+func (d *BME280) waitMeasurement() error {
+	status := make([]byte, 1)
+	for deadline := time.Now().Add(50 * time.Millisecond); ; {
+		time.Sleep(5 * time.Millisecond)
+		if err := d.bus.Tx(d.addr, []byte{bme280RegStatus}, status); err != nil {
+			return err
+		}
+		if status[0]&0x08 == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("bme280 0x%02x: измерение не завершилось", d.addr)
+		}
+	}
+}
+
+// readCalibration проверяет ID чипа и читает калибровочные коэффициенты.
+func (d *BME280) readCalibration() (*bme280Calib, error) {
+	id := make([]byte, 1)
+	if err := d.bus.Tx(d.addr, []byte{bme280RegChipID}, id); err != nil {
+		return nil, err
+	}
+	if id[0] != d.chipID {
+		return nil, fmt.Errorf("bme280 0x%02x: неожиданный ID чипа 0x%02x (ожидается 0x%02x; 0x60 — BME280, 0x58 — BMP280)", d.addr, id[0], d.chipID)
+	}
+
+	b := make([]byte, 26)
+	if err := d.bus.Tx(d.addr, []byte{bme280RegCalib00}, b); err != nil {
+		return nil, err
+	}
+	le := binary.LittleEndian
+	c := &bme280Calib{
+		T1: le.Uint16(b[0:]), T2: int16(le.Uint16(b[2:])), T3: int16(le.Uint16(b[4:])),
+		P1: le.Uint16(b[6:]), P2: int16(le.Uint16(b[8:])), P3: int16(le.Uint16(b[10:])),
+		P4: int16(le.Uint16(b[12:])), P5: int16(le.Uint16(b[14:])), P6: int16(le.Uint16(b[16:])),
+		P7: int16(le.Uint16(b[18:])), P8: int16(le.Uint16(b[20:])), P9: int16(le.Uint16(b[22:])),
+		H1: b[25],
+	}
+	if d.humidity {
+		h := make([]byte, 7)
+		if err := d.bus.Tx(d.addr, []byte{bme280RegCalib26}, h); err != nil {
+			return nil, err
+		}
+		c.H2 = int16(le.Uint16(h[0:]))
+		c.H3 = h[2]
+		c.H4 = int16(int8(h[3]))<<4 | int16(h[4]&0x0F)
+		c.H5 = int16(int8(h[5]))<<4 | int16(h[4]>>4)
+		c.H6 = int8(h[6])
+	}
+	return c, nil
+}
+
+// Компенсация в арифметике с плавающей точкой (datasheet BME280, раздел 8.1).
+
+// temperature возвращает температуру (°C) и t_fine для компенсации давления и влажности.
+func (c *bme280Calib) temperature(adcT int32) (float64, float64) {
+	v1 := (float64(adcT)/16384 - float64(c.T1)/1024) * float64(c.T2)
+	v2 := (float64(adcT)/131072 - float64(c.T1)/8192)
+	v2 = v2 * v2 * float64(c.T3)
+	tFine := v1 + v2
+	return tFine / 5120, tFine
+}
+
+// pressure возвращает давление в Па.
+func (c *bme280Calib) pressure(adcP int32, tFine float64) float64 {
+	v1 := tFine/2 - 64000
+	v2 := v1 * v1 * float64(c.P6) / 32768
+	v2 = v2 + v1*float64(c.P5)*2
+	v2 = v2/4 + float64(c.P4)*65536
+	v1 = (float64(c.P3)*v1*v1/524288 + float64(c.P2)*v1) / 524288
+	v1 = (1 + v1/32768) * float64(c.P1)
+	if v1 == 0 {
+		return 0 // защита от деления на ноль (нет калибровки)
+	}
+	p := 1048576 - float64(adcP)
+	p = (p - v2/4096) * 6250 / v1
+	v1 = float64(c.P9) * p * p / 2147483648
+	v2 = p * float64(c.P8) / 32768
+	return p + (v1+v2+float64(c.P7))/16
+}
+
+// humidity возвращает относительную влажность (%), ограниченную диапазоном 0..100.
+func (c *bme280Calib) humidity(adcH int32, tFine float64) float64 {
+	h := tFine - 76800
+	h = (float64(adcH) - (float64(c.H4)*64 + float64(c.H5)/16384*h)) *
+		(float64(c.H2) / 65536 * (1 + float64(c.H6)/67108864*h*(1+float64(c.H3)/67108864*h)))
+	h = h * (1 - float64(c.H1)*h/524288)
+	return min(max(h, 0), 100)
+}
@@ -0,0 +1,171 @@
+package gpio
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Калибровка и значения АЦП из примера расчета в datasheet BMP280 (BST-BMP280-DS001, раздел 8.2):
+// adc_T = 519888 → T = 25.08 °C, t_fine = 128422; adc_P = 415148 → p = 100653.27 Pa.
+var datasheetCalib = bme280Calib{
+	T1: 27504, T2: 26435, T3: -1000,
+	P1: 36477, P2: -10685, P3: 3024, P4: 2855, P5: 140, P6: -7, P7: 15500, P8: -14600, P9: 6000,
+	// Влажность: типичные заводские коэффициенты BME280 (в datasheet нет примера расчета)
+	H1: 75, H2: 362, H3: 0, H4: 313, H5: 50, H6: 30,
+}
+
+const (
+	datasheetAdcT = 519888
+	datasheetAdcP = 415148
+	testAdcH      = 30000
+)
+
+func TestBME280CompensationDatasheet(t *testing.T) {
+	c := datasheetCalib
+	temp, tFine := c.temperature(datasheetAdcT)
+	if math.Abs(temp-25.08) > 0.005 {
+		t.Errorf("температура = %.4f, want 25.08", temp)
+	}
+	if math.Abs(tFine-128422) > 1 {
+		t.Errorf("t_fine = %.2f, want 128422", tFine)
+	}
+	if p := c.pressure(datasheetAdcP, tFine); math.Abs(p-100653.27) > 0.01 {
+		t.Errorf("давление = %.2f Па, want 100653.27", p)
+	}
+}
+
+// bme280HumidityInt — целочисленная компенсация влажности из datasheet BME280 (раздел 4.2.3),
+// результат в %RH. Используется как независимая проверка версии с плавающей точкой.
+func bme280HumidityInt(c bme280Calib, adcH, tFine int32) float64 {
+	v := tFine - 76800
+	a := (adcH<<14 - int32(c.H4)<<20 - int32(c.H5)*v + 16384) >> 15
+	b := ((v*int32(c.H6))>>10)*(((v*int32(c.H3))>>11)+32768)>>10 + 2097152
+	v = a * ((b*int32(c.H2) + 8192) >> 14)
+	v -= ((((v >> 15) * (v >> 15)) >> 7) * int32(c.H1)) >> 4
+	v = min(max(v, 0), 419430400)
+	return float64(v>>12) / 1024
+}
+
+func TestBME280HumidityMatchesIntegerReference(t *testing.T) {
+	c := datasheetCalib
+	_, tFine := c.temperature(datasheetAdcT)
+	got := c.humidity(testAdcH, tFine)
+	want := bme280HumidityInt(c, testAdcH, int32(tFine))
+	if math.Abs(got-want) > 0.05 {
+		t.Errorf("влажность = %.3f%%, целочисленная версия datasheet = %.3f%%", got, want)
+	}
+	if got <= 0 || got >= 100 {
+		t.Errorf("влажность %.3f%% вне диапазона", got)
+	}
+}
+
+func TestBME280HumidityClamped(t *testing.T) {
+	c := datasheetCalib
+	_, tFine := c.temperature(datasheetAdcT)
+	if h := c.humidity(0, tFine); h != 0 {
+		t.Errorf("влажность при adc_H=0 = %v, want 0", h)
+	}
+	if h := c.humidity(0xFFFF, tFine); h != 100 {
+		t.Errorf("влажность при adc_H=0xFFFF = %v, want 100", h)
+	}
+}
+
+// fakeBME280 размещает калибровку и результат измерения в регистровой карте, как в реальном датчике.
+func fakeBME280(chipID byte, c bme280Calib, adcT, adcP, adcH int32) *FakeRegisters {
+	regs := new(FakeRegisters)
+	regs[bme280RegChipID] = chipID
+
+	le := binary.LittleEndian
+	calib := regs[bme280RegCalib00:]
+	for i, v := range []uint16{
+		c.T1, uint16(c.T2), uint16(c.T3),
+		c.P1, uint16(c.P2), uint16(c.P3), uint16(c.P4), uint16(c.P5),
+		uint16(c.P6), uint16(c.P7), uint16(c.P8), uint16(c.P9),
+	} {
+		le.PutUint16(calib[i*2:], v)
+	}
+	regs[0xA1] = c.H1
+
+	// dig_H4 и dig_H5 — 12-битные числа, упакованные в регистры 0xE4..0xE6
+	h := regs[bme280RegCalib26:]
+	le.PutUint16(h[0:], uint16(c.H2))
+	h[2] = c.H3
+	h[3] = byte(c.H4 >> 4)
+	h[4] = byte(c.H4&0x0F) | byte(c.H5&0x0F)<<4
+	h[5] = byte(c.H5 >> 4)
+	h[6] = byte(c.H6)
+
+	data := regs[bme280RegData:]
+	data[0], data[1], data[2] = byte(adcP>>12), byte(adcP>>4), byte(adcP<<4)
+	data[3], data[4], data[5] = byte(adcT>>12), byte(adcT>>4), byte(adcT<<4)
+	data[6], data[7] = byte(adcH>>8), byte(adcH)
+	return regs
+}
+
+func TestBME280MeasureOverFakeBus(t *testing.T) {
+	regs := fakeBME280(bme280ChipID, datasheetCalib, datasheetAdcT, datasheetAdcP, testAdcH)
+	bus := NewFakeI2C()
+	bus.Attach(BME280AddrPrimary, regs)
+
+	m, err := NewBME280("ambient", bus, BME280AddrPrimary).Measure()
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	if math.Abs(m[QuantityTemperature]-25.08) > 0.005 {
+		t.Errorf("температура = %.4f, want 25.08", m[QuantityTemperature])
+	}
+	if math.Abs(m[QuantityPressure]-1006.5327) > 0.0001 {
+		t.Errorf("давление = %.4f гПа, want 1006.5327", m[QuantityPressure])
+	}
+	c := datasheetCalib
+	_, tFine := c.temperature(datasheetAdcT)
+	if want := c.humidity(testAdcH, tFine); math.Abs(m[QuantityHumidity]-want) > 1e-9 {
+		t.Errorf("влажность = %.4f, want %.4f (проверка упаковки dig_H4/dig_H5)", m[QuantityHumidity], want)
+	}
+
+	// Датчик запущен в forced mode с передискретизацией x1
+	if regs[bme280RegCtrlHum] != bme280CtrlHum || regs[bme280RegCtrlMeas] != bme280CtrlMeas {
+		t.Errorf("ctrl_hum = %#x, ctrl_meas = %#x", regs[bme280RegCtrlHum], regs[bme280RegCtrlMeas])
+	}
+}
+
+func TestBMP280MeasureWithoutHumidity(t *testing.T) {
+	bus := NewFakeI2C()
+	bus.Attach(BME280AddrSecondary, fakeBME280(bmp280ChipID, datasheetCalib, datasheetAdcT, datasheetAdcP, 0))
+
+	m, err := NewBMP280("ambient", bus, BME280AddrSecondary).Measure()
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	if _, ok := m[QuantityHumidity]; ok {
+		t.Error("BMP280 не должен возвращать влажность")
+	}
+	if math.Abs(m[QuantityPressure]-1006.5327) > 0.0001 {
+		t.Errorf("давление = %.4f гПа, want 1006.5327", m[QuantityPressure])
+	}
+}
+
+func TestBME280Errors(t *testing.T) {
+	t.Run("wrong chip id", func(t *testing.T) {
+		bus := NewFakeI2C()
+		bus.Attach(BME280AddrPrimary, fakeBME280(bmp280ChipID, datasheetCalib, datasheetAdcT, datasheetAdcP, testAdcH))
+		_, err := NewBME280("ambient", bus, BME280AddrPrimary).Measure()
+		if err == nil || !strings.Contains(err.Error(), "ID чипа 0x58") {
+			t.Fatalf("ожидалась ошибка ID чипа, получено %v", err)
+		}
+	})
+	t.Run("skipped measurement", func(t *testing.T) {
+		bus := NewFakeI2C()
+		bus.Attach(BME280AddrPrimary, fakeBME280(bme280ChipID, datasheetCalib, bme280SkippedTP, datasheetAdcP, testAdcH))
+		if _, err := NewBME280("ambient", bus, BME280AddrPrimary).Measure(); err == nil {
+			t.Fatal("ожидалась ошибка невыполненного измерения")
+		}
+	})
+	t.Run("no device", func(t *testing.T) {
+		if _, err := NewBME280("ambient", NewFakeI2C(), BME280AddrPrimary).Measure(); err == nil {
+			t.Fatal("ожидалась ошибка NACK")
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package gpio
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/logging"
+)
+
+// Dimmer — выход с регулировкой яркости (аппаратный ШИМ или диммер-розетка).
+// On включает выход с последней ненулевой яркостью (по умолчанию 100%), Off — гасит его;
+// IsOn — яркость больше нуля.
+type Dimmer interface {
+	RelayController
+	// SetBrightness задает яркость в процентах (0..100, 0 — выключено)
+	SetBrightness(pct float64) error
+	// Brightness возвращает желаемую яркость (последнюю подтвержденную команду)
+	Brightness() float64
+}
+
+// AsDimmer возвращает реле как диммер, если оно поддерживает регулировку яркости
+// (в том числе под защитной оберткой ProtectedRelay).
+func AsDimmer(r RelayController) (Dimmer, bool) {
+	if p, ok := r.(*ProtectedRelay); ok {
+		if _, ok := p.inner.(Dimmer); !ok {
+			return nil, false
+		}
+		return p, true
+	}
+	d, ok := r.(Dimmer)
+	return d, ok
+}
+
+// clampBrightness ограничивает яркость диапазоном 0..100 и округляет до 0.1%.
+func clampBrightness(pct float64) float64 {
+	return math.Round(min(max(pct, 0), 100)*10) / 10
+}
+
+// brightnessLevels хранит желаемую яркость и последнюю ненулевую (для On после Off).
+type brightnessLevels struct {
+	level float64
+	last  float64
+}
+
+func (b *brightnessLevels) onLevel() float64 {
+	if b.last > 0 {
+		return b.last
+	}
+	return 100
+}
+
+// set запоминает подтвержденную яркость и возвращает true, если выход включился или погас.
+func (b *brightnessLevels) set(pct float64) bool {
+	switched := (b.level > 0) != (pct > 0)
+	b.level = pct
+	if pct > 0 {
+		b.last = pct
+	}
+	return switched
+}
+
+// ==========================================
+// АППАРАТНЫЙ ШИМ (sysfs /sys/class/pwm)
+// ==========================================
+
+// DefaultPWMRoot — каталог контроллеров ШИМ в sysfs (нужен dtoverlay=pwm или pwm-2chan)
+const DefaultPWMRoot = "/sys/class/pwm"
+
+// DefaultPWMFrequency — частота ШИМ по умолчанию, Гц (выше порога заметного мерцания)
+const DefaultPWMFrequency = 1000
+
+// PWMDimmer управляет яркостью через аппаратный ШИМ Linux (pwmchipN/pwmM в sysfs),
+// например драйвером светодиодной ленты или диммером 0-10 В с ШИМ-входом.
+type PWMDimmer struct {
+	name   string
+	dir    string
+	period int64 // нс
+
+	mu sync.Mutex
+	brightnessLevels
+}
+
+// NewPWMDimmer экспортирует канал ШИМ, задает частоту и гасит выход (fail-safe).
+// Пустой root — DefaultPWMRoot, frequency <= 0 — DefaultPWMFrequency.
+func NewPWMDimmer(name, root string, chip, channel, frequency int) (*PWMDimmer, error) {
+	if root == "" {
+		root = DefaultPWMRoot
+	}
+	if frequency <= 0 {
+		frequency = DefaultPWMFrequency
+	}
+	chipDir := filepath.Join(root, fmt.Sprintf("pwmchip%d", chip))
+	if _, err := os.Stat(chipDir); err != nil {
+		return nil, fmt.Errorf("контроллер ШИМ %s не найден (включен ли dtoverlay=pwm-2chan?): %w", chipDir, err)
+	}
+	dir := filepath.Join(chipDir, fmt.Sprintf("pwm%d", channel))
+	if err := exportPWM(chipDir, dir, channel); err != nil {
+		return nil, err
+	}
+
+	d := &PWMDimmer{name: name, dir: dir, period: int64(time.Second) / int64(frequency)}
+	// duty_cycle не может превышать period, поэтому сначала обнуляем его
+	_ = d.write("duty_cycle", 0)
+	for _, step := range []struct {
+		file  string
+		value int64
+	}{{"period", d.period}, {"duty_cycle", 0}, {"enable", 1}} {
+		if err := d.write(step.file, step.value); err != nil {
+			return nil, err
+		}
+	}
+	logger.Info("Диммер инициализирован (0%)", logging.KeyRelay, name, "pwm", dir, "frequency_hz", frequency)
+	return d, nil
+}
+
+// exportPWM экспортирует канал и ждет появления его файлов (udev выставляет права не сразу).
+func exportPWM(chipDir, dir string, channel int) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(chipDir, "export"), []byte(strconv.Itoa(channel)), 0); err != nil {
+		return fmt.Errorf("ошибка экспорта канала ШИМ %d (%s): %w", channel, chipDir, err)
+	}
+	for deadline := time.Now().Add(time.Second); ; {
+		f, err := os.OpenFile(filepath.Join(dir, "period"), os.O_WRONLY, 0)
+		if err == nil {
+			f.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("канал ШИМ %s недоступен после экспорта: %w", dir, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (d *PWMDimmer) Name() string { return d.name }
+
+func (d *PWMDimmer) On() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.apply(d.onLevel())
+}
+
+func (d *PWMDimmer) Off() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.apply(0)
+}
+
+func (d *PWMDimmer) IsOn() bool {
+	return d.Brightness() > 0
+}
+
+func (d *PWMDimmer) SetBrightness(pct float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.apply(clampBrightness(pct))
+}
+
+func (d *PWMDimmer) Brightness() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.level
+}
+
+// ReadState читает фактическое состояние канала: включен и скважность больше нуля.
+func (d *PWMDimmer) ReadState() (bool, error) {
+	enable, err := d.read("enable")
+	if err != nil {
+		return false, err
+	}
+	duty, err := d.read("duty_cycle")
+	if err != nil {
+		return false, err
+	}
+	return enable == 1 && duty > 0, nil
+}
+
+// apply записывает скважность и проверяет ее обратным чтением. Вызывается под мьютексом.
+func (d *PWMDimmer) apply(pct float64) error {
+	duty := int64(math.Round(float64(d.period) * pct / 100))
+	if err := d.write("enable", 1); err != nil { // канал мог быть выключен извне
+		return err
+	}
+	if err := d.write("duty_cycle", duty); err != nil {
+		return err
+	}
+	if got, err := d.read("duty_cycle"); err != nil {
+		return err
+	} else if got != duty {
+		return fmt.Errorf("диммер %s: после записи duty_cycle=%d читается %d", d.name, duty, got)
+	}
+
+	if d.set(pct) {
+		logger.Info("Диммер переключен", logging.KeyRelay, d.name, "on", pct > 0, "brightness", pct)
+	}
+	return nil
+}
+
+func (d *PWMDimmer) write(file string, value int64) error {
+	if err := os.WriteFile(filepath.Join(d.dir, file), []byte(strconv.FormatInt(value, 10)), 0); err != nil {
+		return fmt.Errorf("диммер %s: запись %s: %w", d.name, file, err)
+	}
+	return nil
+}
+
+func (d *PWMDimmer) read(file string) (int64, error) {
+	raw, err := os.ReadFile(filepath.Join(d.dir, file))
+	if err != nil {
+		return 0, fmt.Errorf("диммер %s: чтение %s: %w", d.name, file, err)
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("диммер %s: %s: %w", d.name, file, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,152 @@
+package gpio
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+
+	"terrarium-core/internal/logging"
+)
+
+// Протоколы диммер-розеток
+const (
+	HTTPShellyDimmer  = "shelly_dimmer"  // Shelly Dimmer / RGBW (Gen1): /light/{channel}?turn=on&brightness=N
+	HTTPTasmotaDimmer = "tasmota_dimmer" // Tasmota с диммером: /cm?cmnd=Dimmer N
+)
+
+// HTTPDimmer управляет диммер-розеткой через ее локальный HTTP API. Устройства принимают
+// целые проценты; после каждой команды состояние и яркость перечитываются с устройства.
+type HTTPDimmer struct {
+	httpDevice
+	protocol string
+	channel  int
+
+	mu sync.Mutex
+	brightnessLevels
+}
+
+// NewHTTPDimmer создает HTTP-диммер и гасит его при старте (fail-safe).
+func NewHTTPDimmer(name, protocol, baseURL string, channel int, user, password string) (*HTTPDimmer, error) {
+	switch protocol {
+	case HTTPShellyDimmer, HTTPTasmotaDimmer:
+	default:
+		return nil, fmt.Errorf("неизвестный протокол HTTP-диммера %q", protocol)
+	}
+	dev, err := newHTTPDevice(name, baseURL, user, password, protocol == HTTPTasmotaDimmer)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &HTTPDimmer{httpDevice: dev, protocol: protocol, channel: channel}
+	if err := d.Off(); err != nil {
+		logger.Warn("Диммер недоступен при старте", logging.KeyRelay, name, "host", dev.base.Host, logging.Err(err))
+	} else {
+		logger.Info("Диммер инициализирован (0%)", logging.KeyRelay, name, "protocol", protocol, "host", dev.base.Host, "channel", channel)
+	}
+	return d, nil
+}
+
+func (d *HTTPDimmer) Name() string { return d.name }
+
+func (d *HTTPDimmer) On() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.apply(d.onLevel())
+}
+
+func (d *HTTPDimmer) Off() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.apply(0)
+}
+
+func (d *HTTPDimmer) IsOn() bool {
+	return d.Brightness() > 0
+}
+
+func (d *HTTPDimmer) SetBrightness(pct float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.apply(clampBrightness(pct))
+}
+
+func (d *HTTPDimmer) Brightness() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.level
+}
+
+// ReadState запрашивает у устройства, включен ли выход.
+func (d *HTTPDimmer) ReadState() (bool, error) {
+	on, _, err := d.read()
+	return on, err
+}
+
+// apply отправляет команду и сверяет ответ устройства. Вызывается под мьютексом.
+func (d *HTTPDimmer) apply(pct float64) error {
+	// Устройства принимают целые проценты; ненулевая яркость не округляется до нуля
+	level := math.Round(pct)
+	if pct > 0 {
+		level = max(level, 1)
+	}
+
+	query := url.Values{}
+	var path string
+	switch d.protocol {
+	case HTTPShellyDimmer:
+		path = fmt.Sprintf("/light/%d", d.channel)
+		if level > 0 {
+			query.Set("turn", "on")
+			query.Set("brightness", fmt.Sprint(level))
+		} else {
+			query.Set("turn", "off")
+		}
+	case HTTPTasmotaDimmer:
+		path = "/cm"
+		if level > 0 {
+			query.Set("cmnd", fmt.Sprintf("Dimmer %v", level)) // Tasmota включает выход при Dimmer > 0
+		} else {
+			query.Set("cmnd", "Power Off")
+		}
+	}
+	if _, err := d.get(path, query); err != nil {
+		return err
+	}
+
+	on, actual, err := d.read()
+	if err != nil {
+		return err
+	}
+	if on != (level > 0) || (on && actual != level) {
+		return fmt.Errorf("диммер %s: команда %v%% не выполнена, устройство сообщает %s %v%%", d.name, level, onOff(on), actual)
+	}
+	if d.set(pct) {
+		logger.Info("Диммер переключен", logging.KeyRelay, d.name, "on", on, "brightness", level)
+	}
+	return nil
+}
+
+// read возвращает состояние и яркость: {"ison": true, "brightness": 40} (Shelly),
+// {"POWER": "ON", "Dimmer": 40} (Tasmota, команда State).
+func (d *HTTPDimmer) read() (bool, float64, error) {
+	query := url.Values{}
+	var path, onKey, levelKey string
+	switch d.protocol {
+	case HTTPShellyDimmer:
+		path, onKey, levelKey = fmt.Sprintf("/light/%d", d.channel), "ison", "brightness"
+	case HTTPTasmotaDimmer:
+		path, onKey, levelKey = "/cm", "POWER", "Dimmer"
+		query.Set("cmnd", "State")
+	}
+	resp, err := d.getJSON(path, query)
+	if err != nil {
+		return false, 0, err
+	}
+	on, ok := boolField(resp, onKey)
+	level, isNum := resp[levelKey].(float64)
+	if !ok || !isNum {
+		return false, 0, fmt.Errorf("диммер %s: в ответе устройства нет %s/%s: %v", d.name, onKey, levelKey, resp)
+	}
+	return on, level, nil
+}
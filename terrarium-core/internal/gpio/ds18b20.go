@@ -0,0 +1,162 @@
+package gpio
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// DefaultW1Root — каталог устройств 1-wire в sysfs (модуль ядра w1-gpio, dtoverlay=w1-gpio)
+const DefaultW1Root = "/sys/bus/w1/devices"
+
+// ds18b20Family — код семейства DS18B20 в ID устройства 1-wire ("28-0316a2799cff")
+const ds18b20Family = "28"
+
+// ds18b20PowerOnReset — значение регистра температуры после сброса (85°C): датчик не выполнил
+// преобразование (просадка питания, паразитное питание без сильной подтяжки).
+const ds18b20PowerOnReset = 0x0550
+
+var ds18b20IDPattern = regexp.MustCompile(`^` + ds18b20Family + `-[0-9a-f]{12}$`)
+
+// ErrCRC возвращается, если контрольная сумма scratchpad датчика 1-wire не совпала.
+var ErrCRC = errors.New("ошибка CRC")
+
+// DS18B20 читает температуру с датчика DS18B20 через интерфейс ядра w1 в sysfs
+// (<root>/28-xxxxxxxxxxxx/w1_slave). Каждый опрос занимает ~750 мс (преобразование в датчике).
+type DS18B20 struct {
+	name string
+	id   string
+	root string
+}
+
+// NewDS18B20 создает датчик по ID устройства 1-wire. Пустой root — DefaultW1Root.
+// Наличие датчика на шине не проверяется: отключенный датчик вернет ошибку при опросе.
+func NewDS18B20(name, id, root string) (*DS18B20, error) {
+	if !ds18b20IDPattern.MatchString(id) {
+		return nil, fmt.Errorf("неверный ID датчика DS18B20 %q (ожидается 28-xxxxxxxxxxxx)", id)
+	}
+	if root == "" {
+		root = DefaultW1Root
+	}
+	return &DS18B20{name: name, id: id, root: root}, nil
+}
+
+func (d *DS18B20) Name() string { return d.name }
+
+// ID возвращает ID устройства 1-wire.
+func (d *DS18B20) ID() string { return d.id }
+
+func (d *DS18B20) Quantities() []string { return []string{QuantityTemperature} }
+
+func (d *DS18B20) Measure() (Measurement, error) {
+	temp, err := ReadDS18B20(d.root, d.id)
+	if err != nil {
+		return nil, err
+	}
+	return Measurement{QuantityTemperature: temp}, nil
+}
+
+// ReadDS18B20 читает температуру (°C) датчика DS18B20 с указанным ID. Пустой root — DefaultW1Root.
+func ReadDS18B20(root, id string) (float64, error) {
+	if root == "" {
+		root = DefaultW1Root
+	}
+	data, err := os.ReadFile(filepath.Join(root, id, "w1_slave"))
+	if err != nil {
+		return 0, fmt.Errorf("ds18b20 %s: %w", id, err)
+	}
+	temp, err := parseW1Slave(data)
+	if err != nil {
+		return 0, fmt.Errorf("ds18b20 %s: %w", id, err)
+	}
+	return temp, nil
+}
+
+// DiscoverDS18B20 возвращает ID датчиков DS18B20, найденных ядром на шине 1-wire (отсортированы).
+func DiscoverDS18B20(root string) ([]string, error) {
+	if root == "" {
+		root = DefaultW1Root
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("шина 1-wire недоступна (%s): %w", root, err)
+	}
+	var ids []string
+	for _, e := range entries {
+		if ds18b20IDPattern.MatchString(e.Name()) {
+			ids = append(ids, e.Name())
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// parseW1Slave разбирает содержимое w1_slave:
+//
+//	72 01 4b 46 7f ff 0e 10 57 : crc=57 YES
+//	72 01 4b 46 7f ff 0e 10 57 t=23125
+//
+// Scratchpad (9 байт) проверяется собственным расчетом CRC-8 Dallas/Maxim, а не только флагом ядра.
+func parseW1Slave(data []byte) (float64, error) {
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	head, _, found := bytes.Cut(lines[0], []byte(":"))
+	if !found {
+		return 0, fmt.Errorf("неожиданный формат w1_slave: %q", lines[0])
+	}
+	scratchpad, err := hex.DecodeString(string(bytes.ReplaceAll(bytes.TrimSpace(head), []byte(" "), nil)))
+	if err != nil || len(scratchpad) != 9 {
+		return 0, fmt.Errorf("неверный scratchpad в w1_slave: %q", head)
+	}
+
+	if !bytes.HasSuffix(bytes.TrimSpace(lines[0]), []byte("YES")) {
+		return 0, fmt.Errorf("%w (ядро w1)", ErrCRC)
+	}
+	if crc := crc8Dallas(scratchpad[:8]); crc != scratchpad[8] {
+		return 0, fmt.Errorf("%w: вычислено %02x, получено %02x", ErrCRC, crc, scratchpad[8])
+	}
+	if bytes.Count(scratchpad, []byte{0}) == len(scratchpad) {
+		// Нулевой scratchpad проходит CRC — так читается оборванная линия данных
+		return 0, errors.New("нулевой scratchpad (датчик не отвечает)")
+	}
+
+	raw := int16(uint16(scratchpad[0]) | uint16(scratchpad[1])<<8)
+	if raw == ds18b20PowerOnReset {
+		return 0, errors.New("значение сброса 85°C (преобразование не выполнено)")
+	}
+
+	// Температура из scratchpad совпадает с t= в строке ядра; t= сверяем как защиту от рассинхрона строк
+	temp := float64(raw) / 16
+	if len(lines) < 2 {
+		return 0, fmt.Errorf("неполный ответ w1_slave: %q", data)
+	}
+	if _, tStr, ok := bytes.Cut(lines[1], []byte("t=")); ok {
+		milli, err := strconv.Atoi(string(bytes.TrimSpace(tStr)))
+		if err == nil && math.Abs(float64(milli)/1000-temp) > 1.0/16 {
+			return 0, fmt.Errorf("t=%d не совпадает со scratchpad (%.3f°C)", milli, temp)
+		}
+	}
+	return temp, nil
+}
+
+// crc8Dallas вычисляет CRC-8 Dallas/Maxim (полином x^8 + x^5 + x^4 + 1, отраженный 0x8C).
+func crc8Dallas(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		for i := 0; i < 8; i++ {
+			mix := (crc ^ b) & 0x01
+			crc >>= 1
+			if mix != 0 {
+				crc ^= 0x8C
+			}
+			b >>= 1
+		}
+	}
+	return crc
+}
@@ -0,0 +1,168 @@
+package gpio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// w1Slave формирует содержимое w1_slave, как его выдает ядро, для scratchpad из 8 байт.
+// CRC дописывается автоматически; crc и milli (t=) можно переопределить.
+type w1Slave struct {
+	scratchpad []byte
+	crc        *byte
+	kernelOK   bool
+	milli      *int
+}
+
+func (w w1Slave) String() string {
+	sp := slices.Clone(w.scratchpad)
+	if w.crc != nil {
+		sp = append(sp, *w.crc)
+	} else {
+		sp = append(sp, crc8Dallas(sp))
+	}
+	hexBytes := make([]string, len(sp))
+	for i, b := range sp {
+		hexBytes[i] = fmt.Sprintf("%02x", b)
+	}
+	head := strings.Join(hexBytes, " ")
+	flag := "NO"
+	if w.kernelOK {
+		flag = "YES"
+	}
+	milli := int(int16(uint16(sp[0])|uint16(sp[1])<<8)) * 1000 / 16
+	if w.milli != nil {
+		milli = *w.milli
+	}
+	return fmt.Sprintf("%s : crc=%02x %s\n%s t=%d\n", head, sp[8], flag, head, milli)
+}
+
+// w1Tree создает фейковое дерево sysfs шины 1-wire с устройствами id → w1_slave.
+func w1Tree(t *testing.T, devices map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for id, content := range devices {
+		dir := filepath.Join(root, id)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if content == "" {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, "w1_slave"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// Scratchpad из примера в документации ядра: 0x0172 = 23.125°C
+var goodScratchpad = []byte{0x72, 0x01, 0x4b, 0x46, 0x7f, 0xff, 0x0e, 0x10}
+
+func TestDiscoverDS18B20(t *testing.T) {
+	root := w1Tree(t, map[string]string{
+		"28-0316a2799cff":   "",
+		"28-000005e2fdc3":   "",
+		"10-000801b5c7f3":   "", // DS18S20 — другое семейство
+		"w1_bus_master1":    "",
+		"28-0316a2799cff.x": "",
+	})
+
+	ids, err := DiscoverDS18B20(root)
+	if err != nil {
+		t.Fatalf("DiscoverDS18B20: %v", err)
+	}
+	want := []string{"28-000005e2fdc3", "28-0316a2799cff"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	if _, err := DiscoverDS18B20(filepath.Join(root, "missing")); err == nil {
+		t.Error("ожидалась ошибка для отсутствующей шины")
+	}
+}
+
+func TestDS18B20Measure(t *testing.T) {
+	const id = "28-0316a2799cff"
+	root := w1Tree(t, map[string]string{id: w1Slave{scratchpad: goodScratchpad, kernelOK: true}.String()})
+
+	sensor, err := NewDS18B20("basking", id, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := sensor.Measure()
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	if m[QuantityTemperature] != 23.125 {
+		t.Errorf("температура = %v, want 23.125", m[QuantityTemperature])
+	}
+
+	// Отрицательная температура: 0xFF5E = -10.125°C
+	negative := slices.Clone(goodScratchpad)
+	negative[0], negative[1] = 0x5E, 0xFF
+	root = w1Tree(t, map[string]string{id: w1Slave{scratchpad: negative, kernelOK: true}.String()})
+	if temp, err := ReadDS18B20(root, id); err != nil || temp != -10.125 {
+		t.Errorf("ReadDS18B20 = %v, %v; want -10.125", temp, err)
+	}
+}
+
+func TestDS18B20ReadErrors(t *testing.T) {
+	const id = "28-0316a2799cff"
+	badCRC := byte(0x00)
+	wrongMilli := 30000
+	powerOn := slices.Clone(goodScratchpad)
+	powerOn[0], powerOn[1] = 0x50, 0x05 // 0x0550 = 85°C
+
+	tests := []struct {
+		name    string
+		content string
+		isCRC   bool
+		errText string
+	}{
+		{"crc mismatch", w1Slave{scratchpad: goodScratchpad, crc: &badCRC, kernelOK: true}.String(), true, "вычислено"},
+		{"kernel crc NO", w1Slave{scratchpad: goodScratchpad}.String(), true, "ядро w1"},
+		{"power-on 85C", w1Slave{scratchpad: powerOn, kernelOK: true}.String(), false, "85°C"},
+		{"all-zero scratchpad", w1Slave{scratchpad: make([]byte, 8), kernelOK: true}.String(), false, "нулевой scratchpad"},
+		{"t= mismatch", w1Slave{scratchpad: goodScratchpad, kernelOK: true, milli: &wrongMilli}.String(), false, "t=30000"},
+		{"garbage", "not a w1_slave\n", false, "формат"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := w1Tree(t, map[string]string{id: tt.content})
+			_, err := ReadDS18B20(root, id)
+			if err == nil {
+				t.Fatal("ожидалась ошибка")
+			}
+			if errors.Is(err, ErrCRC) != tt.isCRC {
+				t.Errorf("errors.Is(err, ErrCRC) = %v, err = %v", !tt.isCRC, err)
+			}
+			if !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("err = %v, want содержит %q", err, tt.errText)
+			}
+		})
+	}
+}
+
+func TestDS18B20Unplugged(t *testing.T) {
+	sensor, err := NewDS18B20("basking", "28-0316a2799cff", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sensor.Measure(); err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Measure отключенного датчика: err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestNewDS18B20InvalidID(t *testing.T) {
+	for _, id := range []string{"", "10-000801b5c7f3", "28-0316A2799CFF", "28-0316a2799c"} {
+		if _, err := NewDS18B20("x", id, ""); err == nil {
+			t.Errorf("NewDS18B20(%q): ожидалась ошибка", id)
+		}
+	}
+}
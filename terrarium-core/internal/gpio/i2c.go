@@ -0,0 +1,140 @@
+package gpio
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultI2CBus — шина I2C на разъеме Raspberry Pi (GPIO 2/3, dtparam=i2c_arm=on)
+const DefaultI2CBus = "/dev/i2c-1"
+
+// i2cSlave — ioctl I2C_SLAVE из linux/i2c-dev.h: адрес устройства для последующих read/write
+const i2cSlave = 0x0703
+
+// I2CBus описывает шину I2C: запись w и затем чтение r у устройства с адресом addr.
+// Пустой w — только чтение, пустой r — только запись.
+type I2CBus interface {
+	Tx(addr uint16, w, r []byte) error
+}
+
+// LinuxI2C — шина I2C через символьное устройство ядра /dev/i2c-N. Потокобезопасна:
+// несколько датчиков на одной шине обращаются к ней по очереди.
+type LinuxI2C struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	addr uint16
+}
+
+var (
+	i2cMu    sync.Mutex
+	i2cBuses = make(map[string]*LinuxI2C)
+)
+
+// OpenI2C открывает шину I2C. Повторное открытие того же пути возвращает ту же шину,
+// поэтому датчики разных террариумов могут делить одну шину.
+func OpenI2C(path string) (*LinuxI2C, error) {
+	if path == "" {
+		path = DefaultI2CBus
+	}
+	i2cMu.Lock()
+	defer i2cMu.Unlock()
+
+	if bus, ok := i2cBuses[path]; ok {
+		return bus, nil
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия шины I2C %s: %w", path, err)
+	}
+	bus := &LinuxI2C{path: path, f: f}
+	i2cBuses[path] = bus
+	return bus, nil
+}
+
+func (b *LinuxI2C) Tx(addr uint16, w, r []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.addr != addr {
+		if err := unix.IoctlSetInt(int(b.f.Fd()), i2cSlave, int(addr)); err != nil {
+			return fmt.Errorf("i2c %s: выбор устройства 0x%02x: %w", b.path, addr, err)
+		}
+		b.addr = addr
+	}
+	if len(w) > 0 {
+		if _, err := b.f.Write(w); err != nil {
+			return fmt.Errorf("i2c %s@0x%02x: запись: %w", b.path, addr, err)
+		}
+	}
+	if len(r) > 0 {
+		if _, err := b.f.Read(r); err != nil {
+			return fmt.Errorf("i2c %s@0x%02x: чтение: %w", b.path, addr, err)
+		}
+	}
+	return nil
+}
+
+// ==========================================
+// FAKE РЕАЛИЗАЦИЯ (для ПК / отладки без железа)
+// ==========================================
+
+// FakeI2CDevice имитирует устройство на фейковой шине.
+type FakeI2CDevice interface {
+	Tx(w, r []byte) error
+}
+
+// FakeI2C — шина I2C в памяти. Обращение к адресу без устройства возвращает ошибку, как отсутствие ACK.
+type FakeI2C struct {
+	mu      sync.Mutex
+	devices map[uint16]FakeI2CDevice
+}
+
+// NewFakeI2C создает пустую фейковую шину.
+func NewFakeI2C() *FakeI2C {
+	return &FakeI2C{devices: make(map[uint16]FakeI2CDevice)}
+}
+
+// Attach подключает устройство к фейковой шине.
+func (b *FakeI2C) Attach(addr uint16, dev FakeI2CDevice) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.devices[addr] = dev
+}
+
+func (b *FakeI2C) Tx(addr uint16, w, r []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dev, ok := b.devices[addr]
+	if !ok {
+		return fmt.Errorf("i2c fake@0x%02x: нет ответа (NACK)", addr)
+	}
+	return dev.Tx(w, r)
+}
+
+// FakeRegisters — устройство с регистровой картой (как BME280): запись [рег, данные...] сохраняет
+// данные начиная с регистра, запись [рег] с последующим чтением возвращает регистры подряд.
+type FakeRegisters [256]byte
+
+func (m *FakeRegisters) Tx(w, r []byte) error {
+	if len(w) == 0 {
+		return fmt.Errorf("не задан регистр")
+	}
+	reg := int(w[0])
+	for i, v := range w[1:] {
+		m[(reg+i)%len(m)] = v
+	}
+	for i := range r {
+		r[i] = m[(reg+i)%len(m)]
+	}
+	return nil
+}
+
+// FakeI2CFunc — устройство, заданное функцией (для командных протоколов, как SHT3x).
+type FakeI2CFunc func(w, r []byte) error
+
+func (f FakeI2CFunc) Tx(w, r []byte) error { return f(w, r) }
@@ -0,0 +1,273 @@
+package gpio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"terrarium-core/internal/logging"
+
+	"github.com/stianeikeland/go-rpio/v4"
+)
+
+// DigitalInput описывает цифровой вход: поплавковый или концевой выключатель, кнопку.
+type DigitalInput interface {
+	// Read возвращает true, если вход активен (контакт замкнут)
+	Read() (bool, error)
+	// Name возвращает имя входа
+	Name() string
+}
+
+// Подтяжка входа GPIO
+const (
+	PullUp   = "up"   // контакт замыкает пин на GND (активный уровень LOW)
+	PullDown = "down" // контакт замыкает пин на 3.3 В (активный уровень HIGH)
+	PullNone = "none" // внешняя подтяжка к 3.3 В (активный уровень LOW)
+)
+
+// RealInput читает контакт, подключенный к пину Raspberry Pi (через /dev/gpiomem).
+type RealInput struct {
+	name      string
+	pin       rpio.Pin
+	activeLow bool
+}
+
+// NewRealInput настраивает пин как вход с заданной подтяжкой (PullUp, PullDown или PullNone).
+func NewRealInput(name string, pinNumber int, pull string) (*RealInput, error) {
+	if err := rpio.Open(); err != nil {
+		return nil, fmt.Errorf("ошибка инициализации /dev/gpiomem: %w", err)
+	}
+
+	pin := rpio.Pin(pinNumber)
+	pin.Input()
+	switch pull {
+	case PullUp:
+		pin.PullUp()
+	case PullDown:
+		pin.PullDown()
+	case PullNone:
+		pin.PullOff()
+	default:
+		return nil, fmt.Errorf("вход %s: неизвестная подтяжка %q (допустимо: up, down, none)", name, pull)
+	}
+
+	logger.Info("Вход инициализирован", "input", name, "pin", pinNumber, "pull", pull)
+	return &RealInput{name: name, pin: pin, activeLow: pull != PullDown}, nil
+}
+
+func (r *RealInput) Name() string { return r.name }
+
+func (r *RealInput) Read() (bool, error) {
+	return (r.pin.Read() == rpio.Low) == r.activeLow, nil
+}
+
+// ==========================================
+// ПОДАВЛЕНИЕ ДРЕБЕЗГА И ФРОНТЫ
+// ==========================================
+
+// DefaultDebounce — время, которое уровень входа должен держаться, чтобы считаться новым
+// состоянием (поплавок качается от волн и вибрации фоггера)
+const DefaultDebounce = 2 * time.Second
+
+// inputPollInterval — период опроса входа
+const inputPollInterval = 50 * time.Millisecond
+
+// InputEdge — подтвержденное изменение состояния входа (после подавления дребезга).
+type InputEdge struct {
+	Active bool      // новое состояние входа
+	Time   time.Time // когда вход перешел в новое состояние (начало стабильного уровня)
+}
+
+// InputState — текущее состояние входа с подавленным дребезгом.
+type InputState struct {
+	Known  bool      // получено ли хотя бы одно показание
+	Active bool      // текущее подтвержденное состояние
+	Since  time.Time // с какого момента вход в этом состоянии
+	Err    error     // ошибка последнего чтения (nil — вход отвечает)
+}
+
+// EdgeSource реализуют входы, которые сообщают об изменении уровня сами (прерывания GPIO
+// через символьное устройство). DebouncedInput тогда перечитывает вход по событию, а не опрашивает его.
+type EdgeSource interface {
+	// WaitEdge блокируется до изменения уровня входа или отмены ctx
+	WaitEdge(ctx context.Context) error
+}
+
+// DebouncedInput признает новое состояние входа, только если уровень держится не меньше debounce,
+// и вызывает подписчиков OnEdge на каждом подтвержденном фронте. Входы EdgeSource читаются
+// по прерыванию (опрос нужен лишь на время подавления дребезга), остальные опрашиваются.
+type DebouncedInput struct {
+	in       DigitalInput
+	debounce time.Duration
+	polling  atomic.Bool // вход без прерываний (или они перестали работать) — постоянный опрос
+
+	mu        sync.Mutex
+	state     InputState
+	candidate *InputEdge // уровень, отличный от текущего, и когда он появился
+	handlers  []func(InputEdge)
+}
+
+// NewDebouncedInput создает вход с подавлением дребезга (debounce <= 0 — DefaultDebounce).
+// Чтение начинается после Start.
+func NewDebouncedInput(in DigitalInput, debounce time.Duration) *DebouncedInput {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	return &DebouncedInput{in: in, debounce: debounce}
+}
+
+func (d *DebouncedInput) Name() string { return d.in.Name() }
+
+// OnEdge подписывает обработчик на подтвержденные фронты (первое показание фронтом не считается).
+// Обработчики вызываются из горутины чтения входа по очереди и не должны надолго блокироваться.
+// Подписываться нужно до Start.
+func (d *DebouncedInput) OnEdge(handler func(InputEdge)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, handler)
+}
+
+// Start запускает фоновое чтение входа до отмены ctx.
+func (d *DebouncedInput) Start(ctx context.Context) {
+	d.sample(time.Now())
+
+	wake := make(chan struct{}, 1)
+	if src, ok := d.in.(EdgeSource); ok {
+		go d.watch(ctx, src, wake)
+	} else {
+		d.polling.Store(true)
+	}
+
+	go func() {
+		ticker := time.NewTicker(inputPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+				d.sample(time.Now())
+			case now := <-ticker.C:
+				if d.polling.Load() || d.settling() {
+					d.sample(now)
+				}
+			}
+		}
+	}()
+}
+
+// watch ждет прерываний входа. Если они перестали работать, вход переводится на опрос.
+func (d *DebouncedInput) watch(ctx context.Context, src EdgeSource, wake chan<- struct{}) {
+	for {
+		if err := src.WaitEdge(ctx); err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Прерывания входа недоступны, переход на опрос", "input", d.in.Name(), logging.Err(err))
+				d.polling.Store(true)
+			}
+			return
+		}
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// State возвращает текущее состояние входа. Потокобезопасно.
+func (d *DebouncedInput) State() InputState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
+
+// settling сообщает, что идет подавление дребезга (новый уровень еще не подтвержден).
+func (d *DebouncedInput) settling() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.candidate != nil
+}
+
+func (d *DebouncedInput) sample(now time.Time) {
+	active, err := d.in.Read()
+
+	d.mu.Lock()
+	if err != nil {
+		if d.state.Err == nil {
+			logger.Error("Ошибка чтения входа", "input", d.in.Name(), logging.Err(err))
+		}
+		d.state.Err = err
+		d.mu.Unlock()
+		return
+	}
+	d.state.Err = nil
+
+	switch {
+	case !d.state.Known:
+		d.state = InputState{Known: true, Active: active, Since: now}
+		d.mu.Unlock()
+		return
+	case active == d.state.Active:
+		d.candidate = nil // кратковременный выброс — уровень вернулся
+		d.mu.Unlock()
+		return
+	case d.candidate == nil:
+		d.candidate = &InputEdge{Active: active, Time: now}
+	}
+	if now.Sub(d.candidate.Time) < d.debounce {
+		d.mu.Unlock()
+		return
+	}
+
+	edge := *d.candidate
+	d.candidate = nil
+	d.state.Active, d.state.Since = edge.Active, edge.Time
+	handlers := d.handlers
+	d.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(edge)
+	}
+}
+
+// ==========================================
+// MOCK РЕАЛИЗАЦИЯ (для ПК / тестов без железа)
+// ==========================================
+
+// MockInput имитирует цифровой вход; состояние задается через Set.
+type MockInput struct {
+	name   string
+	active atomic.Bool
+	err    atomic.Pointer[error]
+}
+
+// NewMockInput создает неактивный (разомкнутый) вход.
+func NewMockInput(name string) *MockInput {
+	return &MockInput{name: name}
+}
+
+func (m *MockInput) Name() string { return m.name }
+
+func (m *MockInput) Read() (bool, error) {
+	if err := m.err.Load(); err != nil {
+		return false, *err
+	}
+	return m.active.Load(), nil
+}
+
+// Set замыкает (true) или размыкает (false) контакт.
+func (m *MockInput) Set(active bool) {
+	if m.active.Swap(active) != active {
+		logger.Info("Mock-вход переключен", "input", m.name, "closed", active)
+	}
+}
+
+// Fail имитирует ошибку чтения (nil — вход снова отвечает).
+func (m *MockInput) Fail(err error) {
+	if err == nil {
+		m.err.Store(nil)
+		return
+	}
+	m.err.Store(&err)
+}
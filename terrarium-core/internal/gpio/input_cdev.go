@@ -0,0 +1,156 @@
+package gpio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultGPIOChip — контроллер GPIO разъема Raspberry Pi (на Pi 5 с ядрами до 6.6.45 — /dev/gpiochip4).
+// Номер линии контроллера совпадает с номером BCM.
+const DefaultGPIOChip = "/dev/gpiochip0"
+
+// Константы uAPI v2 символьного устройства GPIO (linux/gpio.h)
+const (
+	gpioV2GetLineIoctl       = 0xc250b407 // _IOWR(0xB4, 0x07, struct gpio_v2_line_request)
+	gpioV2LineGetValuesIoctl = 0xc010b40e // _IOWR(0xB4, 0x0E, struct gpio_v2_line_values)
+
+	gpioV2LineFlagActiveLow    = 1 << 1
+	gpioV2LineFlagInput        = 1 << 2
+	gpioV2LineFlagEdgeRising   = 1 << 4
+	gpioV2LineFlagEdgeFalling  = 1 << 5
+	gpioV2LineFlagBiasPullUp   = 1 << 8
+	gpioV2LineFlagBiasPullDown = 1 << 9
+	gpioV2LineFlagBiasDisabled = 1 << 10
+
+	gpioV2LineEventSize = 48 // sizeof(struct gpio_v2_line_event)
+)
+
+// gpioV2LineRequest повторяет struct gpio_v2_line_request (592 байта).
+type gpioV2LineRequest struct {
+	Offsets         [64]uint32
+	Consumer        [32]byte
+	Config          gpioV2LineConfig
+	NumLines        uint32
+	EventBufferSize uint32
+	_               [5]uint32
+	Fd              int32
+}
+
+// gpioV2LineConfig повторяет struct gpio_v2_line_config (атрибуты не используются).
+type gpioV2LineConfig struct {
+	Flags    uint64
+	NumAttrs uint32
+	_        [5]uint32
+	Attrs    [10][24]byte
+}
+
+// gpioV2LineValues повторяет struct gpio_v2_line_values.
+type gpioV2LineValues struct {
+	Bits uint64
+	Mask uint64
+}
+
+// CdevInput читает вход через символьное устройство GPIO ядра (/dev/gpiochipN, как libgpiod)
+// и получает прерывания по обоим фронтам — опрашивать такой вход не нужно.
+type CdevInput struct {
+	name string
+	line *os.File // дескриптор запрошенной линии: ioctl чтения уровня и события фронтов
+}
+
+// NewCdevInput запрашивает у ядра линию offset контроллера chip как вход с подтяжкой pull
+// (PullUp, PullDown или PullNone). Пустой chip — DefaultGPIOChip.
+func NewCdevInput(name, chip string, offset int, pull string) (*CdevInput, error) {
+	if chip == "" {
+		chip = DefaultGPIOChip
+	}
+
+	flags := uint64(gpioV2LineFlagInput | gpioV2LineFlagEdgeRising | gpioV2LineFlagEdgeFalling)
+	switch pull {
+	case PullUp:
+		flags |= gpioV2LineFlagBiasPullUp | gpioV2LineFlagActiveLow
+	case PullDown:
+		flags |= gpioV2LineFlagBiasPullDown
+	case PullNone:
+		flags |= gpioV2LineFlagBiasDisabled | gpioV2LineFlagActiveLow
+	default:
+		return nil, fmt.Errorf("вход %s: неизвестная подтяжка %q (допустимо: up, down, none)", name, pull)
+	}
+
+	f, err := os.OpenFile(chip, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия контроллера GPIO %s: %w", chip, err)
+	}
+	defer f.Close()
+
+	req := gpioV2LineRequest{NumLines: 1}
+	req.Offsets[0] = uint32(offset)
+	req.Config.Flags = flags
+	copy(req.Consumer[:len(req.Consumer)-1], "terrarium-core")
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), gpioV2GetLineIoctl, uintptr(unsafe.Pointer(&req))); errno != 0 {
+		return nil, fmt.Errorf("вход %s: ошибка запроса линии %d у %s: %w", name, offset, chip, errno)
+	}
+
+	// Неблокирующий дескриптор отдается планировщику Go: чтение событий прерывается дедлайном и Close
+	if err := unix.SetNonblock(int(req.Fd), true); err != nil {
+		unix.Close(int(req.Fd))
+		return nil, fmt.Errorf("вход %s: %w", name, err)
+	}
+	in := &CdevInput{name: name, line: os.NewFile(uintptr(req.Fd), fmt.Sprintf("%s:%d", chip, offset))}
+
+	logger.Info("Вход инициализирован (прерывания)", "input", name, "chip", chip, "line", offset, "pull", pull)
+	return in, nil
+}
+
+func (c *CdevInput) Name() string { return c.name }
+
+// Read возвращает логический уровень линии (с учетом активного LOW при подтяжке к питанию).
+func (c *CdevInput) Read() (bool, error) {
+	raw, err := c.line.SyscallConn()
+	if err != nil {
+		return false, fmt.Errorf("вход %s: %w", c.name, err)
+	}
+	values := gpioV2LineValues{Mask: 1}
+	var errno unix.Errno
+	if err := raw.Control(func(fd uintptr) {
+		_, _, errno = unix.Syscall(unix.SYS_IOCTL, fd, gpioV2LineGetValuesIoctl, uintptr(unsafe.Pointer(&values)))
+	}); err != nil {
+		return false, fmt.Errorf("вход %s: %w", c.name, err)
+	}
+	if errno != 0 {
+		return false, fmt.Errorf("вход %s: ошибка чтения линии: %w", c.name, errno)
+	}
+	return values.Bits&1 == 1, nil
+}
+
+// WaitEdge ждет событие фронта от ядра. Накопившиеся события вычитываются разом:
+// уровень после них все равно перечитывается через Read.
+func (c *CdevInput) WaitEdge(ctx context.Context) error {
+	buf := make([]byte, gpioV2LineEventSize*16)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		_ = c.line.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := c.line.Read(buf)
+		switch {
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			continue
+		case err != nil:
+			return fmt.Errorf("вход %s: ошибка чтения событий: %w", c.name, err)
+		case n < gpioV2LineEventSize:
+			return fmt.Errorf("вход %s: неполное событие (%d байт)", c.name, n)
+		}
+		return nil
+	}
+}
+
+// Close освобождает линию GPIO.
+func (c *CdevInput) Close() error {
+	return c.line.Close()
+}
@@ -0,0 +1,111 @@
+package gpio
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestInput создает вход с подавлением дребезга 2 с и журналом подтвержденных фронтов.
+// Тесты вызывают sample с синтетическим временем вместо Start.
+func newTestInput() (*MockInput, *DebouncedInput, *[]InputEdge) {
+	mock := NewMockInput("float")
+	in := NewDebouncedInput(mock, 2*time.Second)
+	var edges []InputEdge
+	in.OnEdge(func(edge InputEdge) { edges = append(edges, edge) })
+	return mock, in, &edges
+}
+
+func TestDebouncedInputFirstSampleIsNotEdge(t *testing.T) {
+	mock, in, edges := newTestInput()
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock.Set(true)
+	in.sample(t0)
+
+	if len(*edges) != 0 {
+		t.Fatalf("первое показание не должно быть фронтом, получено %v", *edges)
+	}
+	st := in.State()
+	if !st.Known || !st.Active || !st.Since.Equal(t0) {
+		t.Errorf("состояние = %+v, want Known, Active с %v", st, t0)
+	}
+}
+
+func TestDebouncedInputIgnoresGlitch(t *testing.T) {
+	mock, in, edges := newTestInput()
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	in.sample(t0)
+
+	// Выброс короче окна подавления дребезга: уровень вернулся через 1.5 с
+	mock.Set(true)
+	in.sample(t0.Add(1 * time.Second))
+	in.sample(t0.Add(2 * time.Second))
+	mock.Set(false)
+	in.sample(t0.Add(2500 * time.Millisecond))
+	// После возврата уровня отсчет начинается заново
+	mock.Set(true)
+	in.sample(t0.Add(3 * time.Second))
+	in.sample(t0.Add(4 * time.Second))
+
+	if len(*edges) != 0 {
+		t.Fatalf("выброс не должен давать фронт, получено %v", *edges)
+	}
+	if st := in.State(); st.Active || !st.Since.Equal(t0) {
+		t.Errorf("состояние = %+v, want неактивен с %v", st, t0)
+	}
+}
+
+func TestDebouncedInputEdges(t *testing.T) {
+	mock, in, edges := newTestInput()
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	in.sample(t0)
+
+	// Уровень держится дольше окна — фронт со временем начала стабильного уровня
+	mock.Set(true)
+	in.sample(t0.Add(10 * time.Second))
+	in.sample(t0.Add(11 * time.Second))
+	in.sample(t0.Add(12 * time.Second))
+
+	mock.Set(false)
+	in.sample(t0.Add(20 * time.Second))
+	in.sample(t0.Add(25 * time.Second))
+
+	want := []InputEdge{
+		{Active: true, Time: t0.Add(10 * time.Second)},
+		{Active: false, Time: t0.Add(20 * time.Second)},
+	}
+	if len(*edges) != len(want) {
+		t.Fatalf("фронты = %v, want %v", *edges, want)
+	}
+	for i, edge := range *edges {
+		if edge.Active != want[i].Active || !edge.Time.Equal(want[i].Time) {
+			t.Errorf("фронт %d = %+v, want %+v", i, edge, want[i])
+		}
+	}
+	if st := in.State(); st.Active || !st.Since.Equal(t0.Add(20*time.Second)) {
+		t.Errorf("состояние = %+v", st)
+	}
+}
+
+func TestDebouncedInputReadError(t *testing.T) {
+	mock, in, edges := newTestInput()
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	in.sample(t0)
+
+	mock.Fail(errors.New("нет ответа"))
+	mock.Set(true)
+	in.sample(t0.Add(5 * time.Second))
+	if st := in.State(); st.Err == nil || st.Active {
+		t.Fatalf("ошибка чтения должна сохраняться без смены уровня, состояние = %+v", st)
+	}
+
+	mock.Fail(nil)
+	in.sample(t0.Add(6 * time.Second))
+	in.sample(t0.Add(8 * time.Second))
+	if st := in.State(); st.Err != nil || !st.Active {
+		t.Errorf("после восстановления входа состояние = %+v", st)
+	}
+	if len(*edges) != 1 {
+		t.Errorf("фронты = %v, want один фронт после восстановления", *edges)
+	}
+}
@@ -1,9 +1,12 @@
 package gpio
 
 import (
-	"log"
+	"terrarium-core/internal/logging"
 )
 
+// logger — журнал драйверов оборудования (реле, диммеры, входы, датчики)
+var logger = logging.Component("gpio")
+
 // RelayController описывает интерфейс переключения аппаратного реле.
 type RelayController interface {
 	// On подает HIGH на пин (включает устройство)
@@ -16,6 +19,13 @@ type RelayController interface {
 	Name() string
 }
 
+// StateReader реализуют реле, способные прочитать фактическое состояние с оборудования
+// (уровень пина GPIO, ответ сетевой розетки). IsOn при этом остается желаемым состоянием —
+// последним подтвержденным командой. Ошибка означает, что устройство недоступно или ответило некорректно.
+type StateReader interface {
+	ReadState() (bool, error)
+}
+
 // ==========================================
 // MOCK РЕАЛИЗАЦИЯ (для ПК / Windows / Mac)
 // ==========================================
@@ -40,7 +50,7 @@ func (m *MockRelay) Name() string { return m.relayName }
 func (m *MockRelay) On() error {
 	if !m.state {
 		m.state = true
-		log.Printf("[GPIO MOCK] Реле '%s' -> ВКЛЮЧЕНО (HIGH)\n", m.relayName)
+		logger.Info("Mock-реле переключено", logging.KeyRelay, m.relayName, "on", true)
 	}
 	return nil
 }
@@ -48,7 +58,7 @@ func (m *MockRelay) On() error {
 func (m *MockRelay) Off() error {
 	if m.state {
 		m.state = false
-		log.Printf("[GPIO MOCK] Реле '%s' -> ВЫКЛЮЧЕНО (LOW)\n", m.relayName)
+		logger.Info("Mock-реле переключено", logging.KeyRelay, m.relayName, "on", false)
 	}
 	return nil
 }
@@ -56,3 +66,7 @@ func (m *MockRelay) Off() error {
 func (m *MockRelay) IsOn() bool {
 	return m.state
 }
+
+func (m *MockRelay) ReadState() (bool, error) {
+	return m.state, nil
+}
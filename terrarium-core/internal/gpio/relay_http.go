@@ -0,0 +1,245 @@
+package gpio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/logging"
+)
+
+// httpRelayTimeout — таймаут запроса к сетевому реле
+const httpRelayTimeout = 3 * time.Second
+
+// Протоколы HTTP-реле
+const (
+	HTTPShelly    = "shelly"     // Shelly Gen1: /relay/{channel}?turn=on
+	HTTPShellyRPC = "shelly_rpc" // Shelly Plus/Pro (Gen2+): /rpc/Switch.Set?id={channel}&on=true
+	HTTPTasmota   = "tasmota"    // Tasmota: /cm?cmnd=Power{channel+1} On
+)
+
+// HTTPRelay управляет умной розеткой через ее локальный HTTP API. После каждой команды
+// фактическое состояние перечитывается с устройства.
+type HTTPRelay struct {
+	httpDevice
+	protocol string
+	channel  int
+
+	mu    sync.Mutex
+	state bool // желаемое состояние (последняя подтвержденная команда)
+}
+
+// httpDevice — общий транспорт сетевых розеток и диммеров: GET-запросы к локальному API устройства.
+// Tasmota принимает учетные данные в параметрах запроса, Shelly — через Basic Auth.
+type httpDevice struct {
+	name      string
+	base      *url.URL
+	user      string
+	password  string
+	queryAuth bool
+	client    *http.Client
+}
+
+func newHTTPDevice(name, baseURL, user, password string, queryAuth bool) (httpDevice, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return httpDevice{}, fmt.Errorf("неверный URL устройства %q (например http://192.168.0.50)", baseURL)
+	}
+	return httpDevice{
+		name:      name,
+		base:      base,
+		user:      user,
+		password:  password,
+		queryAuth: queryAuth,
+		client:    &http.Client{Timeout: httpRelayTimeout},
+	}, nil
+}
+
+// NewHTTPRelay создает HTTP-реле. Как и GPIO-реле, при старте розетка выключается (fail-safe);
+// если устройство недоступно, это только логируется — движок повторит команду позже.
+func NewHTTPRelay(name, protocol, baseURL string, channel int, user, password string) (*HTTPRelay, error) {
+	switch protocol {
+	case HTTPShelly, HTTPShellyRPC, HTTPTasmota:
+	default:
+		return nil, fmt.Errorf("неизвестный протокол HTTP-реле %q", protocol)
+	}
+	dev, err := newHTTPDevice(name, baseURL, user, password, protocol == HTTPTasmota)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &HTTPRelay{httpDevice: dev, protocol: protocol, channel: channel}
+	if err := r.set(false); err != nil {
+		logger.Warn("Реле недоступно при старте", logging.KeyRelay, name, "host", dev.base.Host, logging.Err(err))
+	} else {
+		logger.Info("Реле инициализировано (OFF)", logging.KeyRelay, name, "protocol", protocol, "host", dev.base.Host, "channel", channel)
+	}
+	return r, nil
+}
+
+func (r *HTTPRelay) Name() string { return r.name }
+
+func (r *HTTPRelay) On() error { return r.set(true) }
+
+func (r *HTTPRelay) Off() error { return r.set(false) }
+
+// IsOn возвращает последнее подтвержденное устройством состояние после команды.
+func (r *HTTPRelay) IsOn() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// ReadState запрашивает фактическое состояние у устройства.
+func (r *HTTPRelay) ReadState() (bool, error) {
+	var path string
+	query := url.Values{}
+	switch r.protocol {
+	case HTTPShelly:
+		path = fmt.Sprintf("/relay/%d", r.channel)
+	case HTTPShellyRPC:
+		path = "/rpc/Switch.GetStatus"
+		query.Set("id", fmt.Sprint(r.channel))
+	case HTTPTasmota:
+		path = "/cm"
+		query.Set("cmnd", r.tasmotaPower())
+	}
+	resp, err := r.getJSON(path, query)
+	if err != nil {
+		return false, err
+	}
+	on, err := r.parseState(resp)
+	if err != nil {
+		return false, fmt.Errorf("реле %s: %w", r.name, err)
+	}
+	return on, nil
+}
+
+func (r *HTTPRelay) set(on bool) error {
+	var path string
+	query := url.Values{}
+	switch r.protocol {
+	case HTTPShelly:
+		path = fmt.Sprintf("/relay/%d", r.channel)
+		query.Set("turn", map[bool]string{true: "on", false: "off"}[on])
+	case HTTPShellyRPC:
+		path = "/rpc/Switch.Set"
+		query.Set("id", fmt.Sprint(r.channel))
+		query.Set("on", fmt.Sprint(on))
+	case HTTPTasmota:
+		path = "/cm"
+		query.Set("cmnd", r.tasmotaPower()+" "+map[bool]string{true: "On", false: "Off"}[on])
+	}
+	if _, err := r.get(path, query); err != nil {
+		return err
+	}
+
+	actual, err := r.ReadState()
+	if err != nil {
+		return err
+	}
+	if actual != on {
+		return fmt.Errorf("реле %s: команда %s не выполнена, устройство сообщает %s", r.name, onOff(on), onOff(actual))
+	}
+
+	r.mu.Lock()
+	changed := r.state != on
+	r.state = on
+	r.mu.Unlock()
+	if !changed {
+		return nil
+	}
+	logger.Info("Реле переключено", logging.KeyRelay, r.name, "on", on)
+	return nil
+}
+
+func (d *httpDevice) get(path string, query url.Values) ([]byte, error) {
+	if d.queryAuth && d.user != "" {
+		query.Set("user", d.user)
+		query.Set("password", d.password)
+	}
+	u := *d.base
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !d.queryAuth && d.user != "" {
+		req.SetBasicAuth(d.user, d.password)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("реле %s недоступно: %w", d.name, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("реле %s: ошибка чтения ответа: %w", d.name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("реле %s: HTTP %d: %s", d.name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// getJSON выполняет запрос и разбирает ответ устройства как JSON-объект.
+func (d *httpDevice) getJSON(path string, query url.Values) (map[string]any, error) {
+	body, err := d.get(path, query)
+	if err != nil {
+		return nil, err
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("реле %s: некорректный ответ устройства: %s", d.name, body)
+	}
+	return resp, nil
+}
+
+// parseState извлекает состояние из ответа: {"ison": true} (Shelly Gen1), {"output": true} (Gen2+),
+// {"POWER1": "ON"} или {"POWER": "ON"} (Tasmota).
+func (r *HTTPRelay) parseState(resp map[string]any) (bool, error) {
+	var keys []string
+	switch r.protocol {
+	case HTTPShelly:
+		keys = []string{"ison"}
+	case HTTPShellyRPC:
+		keys = []string{"output"}
+	case HTTPTasmota:
+		keys = []string{r.tasmotaPower(), strings.ToUpper(r.tasmotaPower()), "POWER"}
+	}
+	if on, ok := boolField(resp, keys...); ok {
+		return on, nil
+	}
+	return false, fmt.Errorf("в ответе устройства нет состояния (%v): %v", keys, resp)
+}
+
+// boolField возвращает первое найденное поле состояния: true/false или "ON"/"OFF".
+func boolField(resp map[string]any, keys ...string) (bool, bool) {
+	for _, key := range keys {
+		switch v := resp[key].(type) {
+		case bool:
+			return v, true
+		case string:
+			return strings.EqualFold(v, "ON"), true
+		}
+	}
+	return false, false
+}
+
+func (r *HTTPRelay) tasmotaPower() string {
+	return fmt.Sprintf("POWER%d", r.channel+1)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "ON"
+	}
+	return "OFF"
+}
@@ -0,0 +1,225 @@
+package gpio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubDevice имитирует локальный HTTP API умной розетки (Shelly Gen1, Shelly Gen2 RPC, Tasmota).
+type stubDevice struct {
+	protocol string
+
+	mu       sync.Mutex
+	on       bool
+	stuck    bool // устройство принимает команду, но не переключается
+	status   int  // ответ на все запросы (0 — 200 OK)
+	requests []string
+	user     string
+	password string
+}
+
+func (d *stubDevice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = append(d.requests, r.URL.RequestURI())
+
+	if d.status != 0 {
+		http.Error(w, "stub error", d.status)
+		return
+	}
+	if d.user != "" {
+		user, password, ok := r.BasicAuth()
+		if d.protocol == HTTPTasmota {
+			user, password, ok = r.URL.Query().Get("user"), r.URL.Query().Get("password"), true
+		}
+		if !ok || user != d.user || password != d.password {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	q := r.URL.Query()
+	var resp any
+	switch d.protocol {
+	case HTTPShelly:
+		if r.URL.Path != "/relay/1" {
+			http.NotFound(w, r)
+			return
+		}
+		if turn := q.Get("turn"); turn != "" && !d.stuck {
+			d.on = turn == "on"
+		}
+		resp = map[string]any{"ison": d.on, "has_timer": false}
+	case HTTPShellyRPC:
+		if q.Get("id") != "1" {
+			http.Error(w, "bad id", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/rpc/Switch.Set":
+			was := d.on
+			if !d.stuck {
+				d.on = q.Get("on") == "true"
+			}
+			resp = map[string]any{"was_on": was}
+		case "/rpc/Switch.GetStatus":
+			resp = map[string]any{"id": 1, "output": d.on}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+	case HTTPTasmota:
+		cmd, arg, _ := strings.Cut(q.Get("cmnd"), " ")
+		if r.URL.Path != "/cm" || cmd != "POWER2" {
+			http.Error(w, "bad command", http.StatusBadRequest)
+			return
+		}
+		if arg != "" && !d.stuck {
+			d.on = strings.EqualFold(arg, "On")
+		}
+		resp = map[string]any{"POWER2": onOff(d.on)}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (d *stubDevice) set(f func(d *stubDevice)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f(d)
+}
+
+func newStubRelay(t *testing.T, protocol string) (*HTTPRelay, *stubDevice) {
+	t.Helper()
+	dev := &stubDevice{protocol: protocol, on: true, user: "admin", password: "p@ss"}
+	srv := httptest.NewServer(dev)
+	t.Cleanup(srv.Close)
+
+	// Канал 1: у Shelly и Gen2 — второй выход, у Tasmota — POWER2
+	relay, err := NewHTTPRelay("light", protocol, srv.URL+"/", 1, "admin", "p@ss")
+	if err != nil {
+		t.Fatalf("NewHTTPRelay: %v", err)
+	}
+	return relay, dev
+}
+
+var httpProtocols = []string{HTTPShelly, HTTPShellyRPC, HTTPTasmota}
+
+func TestHTTPRelaySwitchAndReadBack(t *testing.T) {
+	for _, protocol := range httpProtocols {
+		t.Run(protocol, func(t *testing.T) {
+			relay, dev := newStubRelay(t, protocol)
+
+			// Fail-safe: при создании розетка выключается
+			if dev.on || relay.IsOn() {
+				t.Fatalf("после старта: устройство %v, IsOn %v; want выключено", dev.on, relay.IsOn())
+			}
+
+			if err := relay.On(); err != nil {
+				t.Fatalf("On: %v", err)
+			}
+			if !dev.on || !relay.IsOn() {
+				t.Fatalf("после On: устройство %v, IsOn %v", dev.on, relay.IsOn())
+			}
+
+			// Состояние, измененное кнопкой на устройстве, читается с устройства
+			dev.set(func(d *stubDevice) { d.on = false })
+			if on, err := relay.ReadState(); err != nil || on {
+				t.Fatalf("ReadState = %v, %v; want false", on, err)
+			}
+
+			if err := relay.Off(); err != nil {
+				t.Fatalf("Off: %v", err)
+			}
+			if relay.IsOn() {
+				t.Fatal("после Off IsOn = true")
+			}
+		})
+	}
+}
+
+func TestHTTPRelayAuth(t *testing.T) {
+	relay, dev := newStubRelay(t, HTTPTasmota)
+	if err := relay.On(); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+	dev.mu.Lock()
+	last := dev.requests[len(dev.requests)-1]
+	dev.mu.Unlock()
+	if !strings.Contains(last, "user=admin") || !strings.Contains(last, "password=p%40ss") {
+		t.Errorf("Tasmota: учетные данные не переданы в запросе: %s", last)
+	}
+}
+
+func TestHTTPRelayNon2xx(t *testing.T) {
+	for _, protocol := range httpProtocols {
+		t.Run(protocol, func(t *testing.T) {
+			relay, dev := newStubRelay(t, protocol)
+			dev.set(func(d *stubDevice) { d.status = http.StatusServiceUnavailable })
+
+			err := relay.On()
+			if err == nil || !strings.Contains(err.Error(), "HTTP 503") {
+				t.Fatalf("On: err = %v, want HTTP 503", err)
+			}
+			if relay.IsOn() {
+				t.Error("IsOn = true после неудачной команды")
+			}
+			if _, err := relay.ReadState(); err == nil {
+				t.Error("ReadState: ожидалась ошибка")
+			}
+		})
+	}
+}
+
+func TestHTTPRelayCommandNotApplied(t *testing.T) {
+	for _, protocol := range httpProtocols {
+		t.Run(protocol, func(t *testing.T) {
+			relay, dev := newStubRelay(t, protocol)
+			dev.set(func(d *stubDevice) { d.stuck = true })
+
+			err := relay.On()
+			if err == nil || !strings.Contains(err.Error(), "не выполнена") {
+				t.Fatalf("On: err = %v, want команда не выполнена", err)
+			}
+			if relay.IsOn() {
+				t.Error("IsOn = true, хотя устройство не включилось")
+			}
+		})
+	}
+}
+
+func TestHTTPRelayUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	for _, protocol := range httpProtocols {
+		t.Run(protocol, func(t *testing.T) {
+			// Недоступное при старте устройство не мешает созданию реле
+			relay, err := NewHTTPRelay("fogger", protocol, url, 0, "", "")
+			if err != nil {
+				t.Fatalf("NewHTTPRelay: %v", err)
+			}
+			if err := relay.On(); err == nil || !strings.Contains(err.Error(), "недоступно") {
+				t.Fatalf("On: err = %v, want реле недоступно", err)
+			}
+			if _, err := relay.ReadState(); err == nil {
+				t.Error("ReadState: ожидалась ошибка")
+			}
+		})
+	}
+}
+
+func TestNewHTTPRelayValidation(t *testing.T) {
+	if _, err := NewHTTPRelay("x", "zigbee", "http://192.168.0.50", 0, "", ""); err == nil {
+		t.Error("неизвестный протокол: ожидалась ошибка")
+	}
+	for _, u := range []string{"", "192.168.0.50", "http://"} {
+		if _, err := NewHTTPRelay("x", HTTPShelly, u, 0, "", ""); err == nil {
+			t.Errorf("URL %q: ожидалась ошибка", u)
+		}
+	}
+}
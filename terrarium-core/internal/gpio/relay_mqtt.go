@@ -0,0 +1,147 @@
+package gpio
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/logging"
+)
+
+// mqttConfirmTimeout — сколько ждать подтверждения команды в топике состояния
+const mqttConfirmTimeout = 3 * time.Second
+
+// MQTTRelayConfig описывает топики умной розетки, управляемой через MQTT.
+type MQTTRelayConfig struct {
+	// CommandTopic — топик команд (Tasmota: cmnd/<устройство>/POWER)
+	CommandTopic string
+	// StateTopic — топик фактического состояния (Tasmota: stat/<устройство>/POWER)
+	StateTopic string
+	// PayloadOn/PayloadOff — значения команд и состояния (по умолчанию ON/OFF, сравниваются без учета регистра)
+	PayloadOn  string
+	PayloadOff string
+	// QoS команд и подписки на состояние
+	QoS byte
+}
+
+// MQTTRelay управляет умной розеткой через топики команд и состояния MQTT.
+// Команда считается выполненной, только когда устройство подтвердило новое состояние.
+type MQTTRelay struct {
+	name   string
+	broker *MQTTBroker
+	cfg    MQTTRelayConfig
+
+	mu      sync.Mutex
+	desired bool          // последняя подтвержденная команда
+	state   bool          // состояние из топика состояния
+	known   bool          // получено ли состояние от устройства
+	changed chan struct{} // закрывается при каждом обновлении состояния
+}
+
+// NewMQTTRelay создает MQTT-реле и подписывается на топик состояния. При старте
+// розетке отправляется команда выключения (fail-safe).
+func NewMQTTRelay(name string, broker *MQTTBroker, cfg MQTTRelayConfig) (*MQTTRelay, error) {
+	if cfg.CommandTopic == "" || cfg.StateTopic == "" {
+		return nil, fmt.Errorf("у MQTT-реле '%s' должны быть заданы топики команд и состояния", name)
+	}
+	if cfg.QoS > 2 {
+		return nil, fmt.Errorf("у MQTT-реле '%s' неверный QoS %d", name, cfg.QoS)
+	}
+	if cfg.PayloadOn == "" {
+		cfg.PayloadOn = "ON"
+	}
+	if cfg.PayloadOff == "" {
+		cfg.PayloadOff = "OFF"
+	}
+
+	r := &MQTTRelay{name: name, broker: broker, cfg: cfg, changed: make(chan struct{})}
+	broker.Subscribe(cfg.StateTopic, cfg.QoS, r.onState)
+
+	if err := r.set(false); err != nil {
+		logger.Warn("Реле недоступно при старте", logging.KeyRelay, name, "broker", broker.URL(), logging.Err(err))
+	} else {
+		logger.Info("Реле инициализировано (OFF)", logging.KeyRelay, name, "broker", broker.URL(), "topic", cfg.CommandTopic)
+	}
+	return r, nil
+}
+
+func (r *MQTTRelay) Name() string { return r.name }
+
+func (r *MQTTRelay) On() error { return r.set(true) }
+
+func (r *MQTTRelay) Off() error { return r.set(false) }
+
+// IsOn возвращает последнее подтвержденное устройством состояние после команды.
+func (r *MQTTRelay) IsOn() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.desired
+}
+
+// ReadState возвращает состояние из топика состояния. Ошибка — если нет соединения
+// с брокером или устройство еще ни разу не сообщило состояние.
+func (r *MQTTRelay) ReadState() (bool, error) {
+	if !r.broker.Connected() {
+		return false, fmt.Errorf("реле %s: нет соединения с MQTT-брокером %s", r.name, r.broker.URL())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.known {
+		return false, fmt.Errorf("реле %s: устройство не сообщило состояние в %s", r.name, r.cfg.StateTopic)
+	}
+	return r.state, nil
+}
+
+func (r *MQTTRelay) set(on bool) error {
+	payload := r.cfg.PayloadOff
+	if on {
+		payload = r.cfg.PayloadOn
+	}
+	if err := r.broker.Publish(r.cfg.CommandTopic, r.cfg.QoS, false, []byte(payload)); err != nil {
+		return fmt.Errorf("реле %s: %w", r.name, err)
+	}
+
+	deadline := time.After(mqttConfirmTimeout)
+	for {
+		r.mu.Lock()
+		confirmed := r.known && r.state == on
+		switched := confirmed && r.desired != on
+		if confirmed {
+			r.desired = on
+		}
+		changed := r.changed
+		r.mu.Unlock()
+		if confirmed {
+			if switched {
+				logger.Info("Реле переключено", logging.KeyRelay, r.name, "on", on)
+			}
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			return fmt.Errorf("реле %s: устройство не подтвердило команду %s за %s", r.name, payload, mqttConfirmTimeout)
+		}
+	}
+}
+
+func (r *MQTTRelay) onState(payload []byte) {
+	value := strings.TrimSpace(string(payload))
+	var on bool
+	switch {
+	case strings.EqualFold(value, r.cfg.PayloadOn):
+		on = true
+	case strings.EqualFold(value, r.cfg.PayloadOff):
+		on = false
+	default:
+		logger.Warn("Неизвестное состояние реле", logging.KeyRelay, r.name, "value", value, "topic", r.cfg.StateTopic)
+		return
+	}
+
+	r.mu.Lock()
+	r.state, r.known = on, true
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.mu.Unlock()
+}
@@ -0,0 +1,177 @@
+package gpio
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// startTestBroker запускает встроенный MQTT-брокер на свободном порту. Возвращает брокер, его URL
+// и функцию остановки (вызывается и при завершении теста).
+func startTestBroker(t *testing.T) (*mochi.Server, string, func()) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("свободный порт: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	srv := mochi.New(&mochi.Options{InlineClient: true, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	_ = srv.AddHook(new(auth.AllowHook), nil)
+	if err := srv.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: addr})); err != nil {
+		t.Fatalf("listener: %v", err)
+	}
+	if err := srv.Serve(); err != nil {
+		t.Fatalf("брокер: %v", err)
+	}
+	stop := sync.OnceFunc(func() { _ = srv.Close() })
+	t.Cleanup(stop)
+	return srv, "mqtt://" + addr, stop
+}
+
+// mqttPlug имитирует розетку Tasmota: принимает команды и сообщает состояние в stat-топик.
+type mqttPlug struct {
+	srv *mochi.Server
+
+	mu       sync.Mutex
+	on       bool
+	silent   bool // устройство принимает команды, но не отвечает
+	commands []string
+}
+
+func newMQTTPlug(t *testing.T, srv *mochi.Server) *mqttPlug {
+	t.Helper()
+	p := &mqttPlug{srv: srv}
+	err := srv.Subscribe("cmnd/plug/POWER", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		p.mu.Lock()
+		cmd := string(pk.Payload)
+		p.commands = append(p.commands, cmd)
+		silent := p.silent
+		if !silent {
+			p.on = strings.EqualFold(cmd, "ON")
+		}
+		state := map[bool]string{true: "ON", false: "OFF"}[p.on]
+		p.mu.Unlock()
+		if !silent {
+			_ = srv.Publish("stat/plug/POWER", []byte(state), false, 0)
+		}
+	})
+	if err != nil {
+		t.Fatalf("подписка розетки: %v", err)
+	}
+	return p
+}
+
+func (p *mqttPlug) sent() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.commands...)
+}
+
+func newTestMQTTRelay(t *testing.T, url string) *MQTTRelay {
+	t.Helper()
+	broker, err := OpenMQTT(url)
+	if err != nil {
+		t.Fatalf("OpenMQTT: %v", err)
+	}
+	r, err := NewMQTTRelay("heat_mat", broker, MQTTRelayConfig{CommandTopic: "cmnd/plug/POWER", StateTopic: "stat/plug/POWER", QoS: 1})
+	if err != nil {
+		t.Fatalf("NewMQTTRelay: %v", err)
+	}
+	return r
+}
+
+func TestMQTTRelaySwitches(t *testing.T) {
+	srv, url, _ := startTestBroker(t)
+	plug := newMQTTPlug(t, srv)
+	r := newTestMQTTRelay(t, url)
+
+	// При старте розетке отправляется OFF, и устройство его подтверждает
+	if got := plug.sent(); len(got) != 1 || got[0] != "OFF" {
+		t.Fatalf("команды при старте = %v, want [OFF]", got)
+	}
+	if on, err := r.ReadState(); err != nil || on {
+		t.Fatalf("ReadState после старта = %v, %v", on, err)
+	}
+
+	if err := r.On(); err != nil {
+		t.Fatalf("On: %v", err)
+	}
+	if !r.IsOn() {
+		t.Error("IsOn после подтвержденного On = false")
+	}
+	if on, err := r.ReadState(); err != nil || !on {
+		t.Errorf("ReadState после On = %v, %v", on, err)
+	}
+
+	// Состояние, сообщенное самим устройством (переключили кнопкой на розетке), видно в ReadState
+	_ = srv.Publish("stat/plug/POWER", []byte("off"), false, 0)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if on, err := r.ReadState(); err == nil && !on {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ReadState не отразил состояние из топика")
+		}
+	}
+	if err := r.Off(); err != nil {
+		t.Fatalf("Off: %v", err)
+	}
+	if got := plug.sent(); len(got) != 3 || got[1] != "ON" || got[2] != "OFF" {
+		t.Errorf("команды = %v, want [OFF ON OFF]", got)
+	}
+}
+
+func TestMQTTRelayNoState(t *testing.T) {
+	srv, url, _ := startTestBroker(t)
+	plug := newMQTTPlug(t, srv)
+	plug.mu.Lock()
+	plug.silent = true
+	plug.mu.Unlock()
+
+	// Устройство не отвечает: реле создается, но состояние неизвестно
+	r := newTestMQTTRelay(t, url)
+	if _, err := r.ReadState(); err == nil || !strings.Contains(err.Error(), "не сообщило состояние") {
+		t.Errorf("ReadState без состояния = %v, want ошибку", err)
+	}
+	if err := r.On(); err == nil || !strings.Contains(err.Error(), "не подтвердило") {
+		t.Errorf("On без ответа устройства = %v, want ошибку подтверждения", err)
+	}
+	if r.IsOn() {
+		t.Error("неподтвержденная команда не должна менять IsOn")
+	}
+	if got := plug.sent(); len(got) != 2 {
+		t.Errorf("команды = %v, want [OFF ON]", got)
+	}
+}
+
+func TestMQTTRelayBrokerUnreachable(t *testing.T) {
+	srv, url, stop := startTestBroker(t)
+	newMQTTPlug(t, srv)
+	r := newTestMQTTRelay(t, url)
+
+	stop()
+	broker, _ := OpenMQTT(url)
+	for deadline := time.Now().Add(5 * time.Second); broker.Connected(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("клиент не заметил остановку брокера")
+		}
+	}
+
+	if err := r.On(); err == nil || !strings.Contains(err.Error(), "нет соединения") {
+		t.Errorf("On без брокера = %v, want ошибку соединения", err)
+	}
+	if _, err := r.ReadState(); err == nil {
+		t.Error("ReadState без брокера должен возвращать ошибку")
+	}
+}
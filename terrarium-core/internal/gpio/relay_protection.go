@@ -0,0 +1,303 @@
+package gpio
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/logging"
+)
+
+// RelayLimits описывает защитные ограничения механического реле от "дребезга".
+// Нулевое значение любого поля отключает соответствующую проверку.
+type RelayLimits struct {
+	// MinOnTime — минимальное время во включенном состоянии перед выключением
+	MinOnTime time.Duration
+	// MinOffTime — минимальная пауза после выключения перед повторным включением
+	MinOffTime time.Duration
+	// MaxSwitchesPerHour — максимум включений за скользящий час
+	MaxSwitchesPerHour int
+	// MaxOnTime — максимальное непрерывное время работы (принудительное отключение движком)
+	MaxOnTime time.Duration
+}
+
+// Причины отказа защиты реле
+const (
+	ProtectionMinOnTime  = "MIN_ON_TIME"
+	ProtectionMinOffTime = "MIN_OFF_TIME"
+	ProtectionMaxRate    = "MAX_SWITCH_RATE"
+)
+
+// ProtectionError возвращается, когда команда отклонена защитными ограничениями реле.
+type ProtectionError struct {
+	Relay      string
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *ProtectionError) Error() string {
+	return fmt.Sprintf("реле '%s': команда отклонена защитой (%s), повторите через %s",
+		e.Relay, e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+// ProtectedRelay оборачивает любой RelayController и применяет к нему RelayLimits.
+// Безопасен для конкурентного вызова из движка и HTTP-обработчиков.
+type ProtectedRelay struct {
+	inner  RelayController
+	limits RelayLimits
+
+	mu         sync.Mutex
+	lastChange time.Time   // время последнего переключения
+	onSince    time.Time   // момент включения (если реле включено)
+	stateSince time.Time   // момент перехода в текущее состояние (или создания обертки)
+	activation []time.Time // времена включений за последний час
+}
+
+// NewProtectedRelay создает защитную обертку над реле.
+func NewProtectedRelay(inner RelayController, limits RelayLimits) *ProtectedRelay {
+	p := &ProtectedRelay{
+		inner:      inner,
+		limits:     limits,
+		stateSince: time.Now(),
+	}
+	if inner.IsOn() {
+		p.onSince = time.Now()
+	}
+	return p
+}
+
+func (p *ProtectedRelay) Name() string { return p.inner.Name() }
+
+func (p *ProtectedRelay) IsOn() bool { return p.inner.IsOn() }
+
+// Limits возвращает действующие ограничения реле.
+func (p *ProtectedRelay) Limits() RelayLimits { return p.limits }
+
+// On включает реле, если это не нарушает минимальную паузу и лимит частоты включений.
+func (p *ProtectedRelay) On() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.inner.IsOn() {
+		return nil
+	}
+	return p.on(p.inner.On)
+}
+
+// SetBrightness задает яркость диммера. Включение (из 0) и выключение (в 0) проходят
+// через те же ограничения, что On и Off; изменение яркости включенного выхода не ограничивается.
+func (p *ProtectedRelay) SetBrightness(pct float64) error {
+	d, ok := p.inner.(Dimmer)
+	if !ok {
+		return fmt.Errorf("реле '%s' не поддерживает регулировку яркости", p.Name())
+	}
+	if pct <= 0 {
+		return p.Off()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inner.IsOn() {
+		return d.SetBrightness(pct)
+	}
+	return p.on(func() error { return d.SetBrightness(pct) })
+}
+
+// Brightness возвращает яркость диммера (для обычного реле — 100 или 0).
+func (p *ProtectedRelay) Brightness() float64 {
+	if d, ok := p.inner.(Dimmer); ok {
+		return d.Brightness()
+	}
+	if p.inner.IsOn() {
+		return 100
+	}
+	return 0
+}
+
+// on включает выход функцией turnOn с проверкой ограничений. Вызывается под мьютексом.
+func (p *ProtectedRelay) on(turnOn func() error) error {
+	now := time.Now()
+	if p.limits.MinOffTime > 0 && !p.lastChange.IsZero() {
+		if elapsed := now.Sub(p.lastChange); elapsed < p.limits.MinOffTime {
+			return &ProtectionError{Relay: p.Name(), Reason: ProtectionMinOffTime, RetryAfter: p.limits.MinOffTime - elapsed}
+		}
+	}
+
+	p.pruneActivations(now)
+	if p.limits.MaxSwitchesPerHour > 0 && len(p.activation) >= p.limits.MaxSwitchesPerHour {
+		return &ProtectionError{Relay: p.Name(), Reason: ProtectionMaxRate, RetryAfter: p.activation[0].Add(time.Hour).Sub(now)}
+	}
+
+	if err := turnOn(); err != nil {
+		return err
+	}
+	p.lastChange = now
+	p.onSince = now
+	p.stateSince = now
+	p.activation = append(p.activation, now)
+	return nil
+}
+
+// Off выключает реле, если оно отработало минимальное время.
+func (p *ProtectedRelay) Off() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.inner.IsOn() {
+		return nil
+	}
+
+	now := time.Now()
+	if p.limits.MinOnTime > 0 && !p.onSince.IsZero() {
+		if elapsed := now.Sub(p.onSince); elapsed < p.limits.MinOnTime {
+			return &ProtectionError{Relay: p.Name(), Reason: ProtectionMinOnTime, RetryAfter: p.limits.MinOnTime - elapsed}
+		}
+	}
+	return p.off(now)
+}
+
+// ForceOff выключает реле в обход всех ограничений. Используется аварийным контуром:
+// выключение всегда безопасно, а защита от дребезга не должна мешать спасать животное.
+func (p *ProtectedRelay) ForceOff() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.inner.IsOn() {
+		return nil
+	}
+	return p.off(time.Now())
+}
+
+// ReadState читает фактическое состояние оборудования. Реле без обратного чтения
+// возвращают желаемое состояние.
+func (p *ProtectedRelay) ReadState() (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ReadState(p.inner)
+}
+
+// Reapply повторно применяет желаемое состояние к оборудованию в обход ограничений:
+// это не переключение, а восстановление состояния, измененного извне.
+func (p *ProtectedRelay) Reapply() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inner.IsOn() {
+		return p.inner.On()
+	}
+	return p.inner.Off()
+}
+
+// OnDuration возвращает время непрерывной работы реле (0, если выключено).
+func (p *ProtectedRelay) OnDuration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.inner.IsOn() || p.onSince.IsZero() {
+		return 0
+	}
+	return time.Since(p.onSince)
+}
+
+// StateDuration возвращает, сколько реле находится в текущем состоянии (вкл или выкл).
+// До первого переключения отсчет идет от создания обертки.
+func (p *ProtectedRelay) StateDuration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Since(p.stateSince)
+}
+
+// MaxOnTimeExceeded сообщает, что реле работает дольше MaxOnTime.
+func (p *ProtectedRelay) MaxOnTimeExceeded() bool {
+	return p.limits.MaxOnTime > 0 && p.OnDuration() >= p.limits.MaxOnTime
+}
+
+func (p *ProtectedRelay) off(now time.Time) error {
+	if err := p.inner.Off(); err != nil {
+		return err
+	}
+	p.lastChange = now
+	p.onSince = time.Time{}
+	p.stateSince = now
+	return nil
+}
+
+// pruneActivations удаляет включения старше одного часа. Вызывается под мьютексом.
+func (p *ProtectedRelay) pruneActivations(now time.Time) {
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(p.activation) && p.activation[i].Before(cutoff) {
+		i++
+	}
+	p.activation = p.activation[i:]
+}
+
+// ForceOff выключает реле в обход защиты, если реле ее поддерживает, иначе обычным Off().
+func ForceOff(r RelayController) error {
+	if f, ok := r.(interface{ ForceOff() error }); ok {
+		return f.ForceOff()
+	}
+	return r.Off()
+}
+
+// ReadState читает фактическое состояние реле, если оно это поддерживает, иначе возвращает IsOn().
+func ReadState(r RelayController) (bool, error) {
+	if sr, ok := r.(StateReader); ok {
+		return sr.ReadState()
+	}
+	return r.IsOn(), nil
+}
+
+// Reapply повторно применяет желаемое состояние реле (см. ProtectedRelay.Reapply).
+func Reapply(r RelayController) error {
+	if a, ok := r.(interface{ Reapply() error }); ok {
+		return a.Reapply()
+	}
+	if r.IsOn() {
+		return r.On()
+	}
+	return r.Off()
+}
+
+// ParseRelayLimits разбирает JSON-строку вида
+// {"heat_mat": {"min_on_sec": 60, "min_off_sec": 60, "max_switches_per_hour": 20, "max_on_min": 0}}
+// и накладывает указанные значения поверх defaults.
+func ParseRelayLimits(raw string, defaults map[string]RelayLimits) (map[string]RelayLimits, error) {
+	result := make(map[string]RelayLimits, len(defaults))
+	for name, l := range defaults {
+		result[name] = l
+	}
+	if raw == "" {
+		return result, nil
+	}
+
+	var parsed map[string]struct {
+		MinOnSec           *int `json:"min_on_sec"`
+		MinOffSec          *int `json:"min_off_sec"`
+		MaxSwitchesPerHour *int `json:"max_switches_per_hour"`
+		MaxOnMin           *int `json:"max_on_min"`
+	}
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("ошибка разбора RELAY_PROTECTION: %w", err)
+	}
+
+	for name, p := range parsed {
+		l := result[name]
+		if p.MinOnSec != nil {
+			l.MinOnTime = time.Duration(*p.MinOnSec) * time.Second
+		}
+		if p.MinOffSec != nil {
+			l.MinOffTime = time.Duration(*p.MinOffSec) * time.Second
+		}
+		if p.MaxSwitchesPerHour != nil {
+			l.MaxSwitchesPerHour = *p.MaxSwitchesPerHour
+		}
+		if p.MaxOnMin != nil {
+			l.MaxOnTime = time.Duration(*p.MaxOnMin) * time.Minute
+		}
+		result[name] = l
+		logger.Info("Защита реле", logging.KeyRelay, name, "min_on", l.MinOnTime, "min_off", l.MinOffTime,
+			"max_switches_per_hour", l.MaxSwitchesPerHour, "max_on", l.MaxOnTime)
+	}
+	return result, nil
+}
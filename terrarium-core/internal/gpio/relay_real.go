@@ -2,16 +2,18 @@ package gpio
 
 import (
 	"fmt"
-	"log"
+
+	"terrarium-core/internal/logging"
 
 	"github.com/stianeikeland/go-rpio/v4"
 )
 
 // RealRelay управляет настоящим реле через пины Raspberry Pi (используя /dev/gpiomem)
 type RealRelay struct {
-	name  string
-	pin   rpio.Pin
-	state bool // Кэшируем состояние
+	name      string
+	pinNumber int
+	pin       rpio.Pin
+	state     bool // Желаемое состояние (последняя подтвержденная команда)
 }
 
 // NewRealRelay инициализирует физический пин как Output.
@@ -26,35 +28,58 @@ func NewRealRelay(name string, pinNumber int) (*RealRelay, error) {
 	pin.Output()
 	pin.High() // Защита для инвертированных реле: HIGH отключает цепь (Нормально Разомкнуто)
 
-	log.Printf("[GPIO INIT] Аппаратное Реле '%s' инициализировано на пине BCM %d (HIGH/OFF)\n", name, pinNumber)
+	logger.Info("Аппаратное реле инициализировано (HIGH/OFF)", logging.KeyRelay, name, "pin", pinNumber)
 
 	return &RealRelay{
-		name:  name,
-		pin:   pin,
-		state: false,
+		name:      name,
+		pinNumber: pinNumber,
+		pin:       pin,
+		state:     false,
 	}, nil
 }
 
 func (r *RealRelay) Name() string { return r.name }
 
+// On и Off всегда заново выставляют пин (это позволяет восстановить уровень, измененный
+// другим процессом) и проверяют его обратным чтением.
 func (r *RealRelay) On() error {
-	if !r.state {
-		r.pin.Low() // LOW включает инвертированное реле (замыкает цепь)
-		r.state = true
-		log.Printf("[GPIO EVENT] Реле '%s' -> ВКЛЮЧЕНО (LOW)\n", r.name)
-	}
-	return nil
+	return r.drive(true)
 }
 
 func (r *RealRelay) Off() error {
-	if r.state {
-		r.pin.High() // HIGH выключает инвертированное реле (размыкает цепь)
-		r.state = false
-		log.Printf("[GPIO EVENT] Реле '%s' -> ВЫКЛЮЧЕНО (HIGH)\n", r.name)
-	}
-	return nil
+	return r.drive(false)
 }
 
 func (r *RealRelay) IsOn() bool {
 	return r.state
 }
+
+// ReadState читает фактический уровень пина: LOW — реле включено (инвертированная логика).
+func (r *RealRelay) ReadState() (bool, error) {
+	return r.pin.Read() == rpio.Low, nil
+}
+
+func (r *RealRelay) drive(on bool) error {
+	level := rpio.High // HIGH выключает инвертированное реле (размыкает цепь)
+	if on {
+		level = rpio.Low // LOW включает инвертированное реле (замыкает цепь)
+	}
+	r.pin.Output() // пин мог быть переведен во вход другим процессом
+	r.pin.Write(level)
+	if got := r.pin.Read(); got != level {
+		return fmt.Errorf("реле %s: на пине BCM %d после записи %s читается %s", r.name, r.pinNumber, levelName(level), levelName(got))
+	}
+
+	if r.state != on {
+		r.state = on
+		logger.Info("Реле переключено", logging.KeyRelay, r.name, "on", on)
+	}
+	return nil
+}
+
+func levelName(s rpio.State) string {
+	if s == rpio.Low {
+		return "LOW"
+	}
+	return "HIGH"
+}
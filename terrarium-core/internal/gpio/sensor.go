@@ -0,0 +1,42 @@
+package gpio
+
+// Измеряемые величины (ключи Measurement)
+const (
+	QuantityTemperature = "temperature" // °C
+	QuantityHumidity    = "humidity"    // % (0-100)
+)
+
+// Measurement — показания датчика за один опрос: величина -> значение.
+type Measurement map[string]float64
+
+// Sensor описывает датчик с произвольным набором измеряемых величин
+// (температура, влажность, давление, ...). Используется реестром датчиков движка.
+type Sensor interface {
+	// Name возвращает имя датчика
+	Name() string
+	// Quantities возвращает величины, которые измеряет датчик
+	Quantities() []string
+	// Measure опрашивает датчик. Может вернуть ошибку, если сенсор отключен.
+	Measure() (Measurement, error)
+}
+
+// FromReader адаптирует датчик температуры и влажности (DHT22) к интерфейсу Sensor.
+func FromReader(r SensorReader) Sensor {
+	return readerSensor{r}
+}
+
+type readerSensor struct {
+	SensorReader
+}
+
+func (s readerSensor) Quantities() []string {
+	return []string{QuantityTemperature, QuantityHumidity}
+}
+
+func (s readerSensor) Measure() (Measurement, error) {
+	data, err := s.Read()
+	if err != nil {
+		return nil, err
+	}
+	return Measurement{QuantityTemperature: data.Temperature, QuantityHumidity: data.Humidity}, nil
+}
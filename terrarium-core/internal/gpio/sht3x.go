@@ -0,0 +1,82 @@
+package gpio
+
+import (
+	"fmt"
+	"time"
+)
+
+// Адреса SHT3x на шине (вывод ADDR на GND / на VDD)
+const (
+	SHT3xAddrPrimary   = 0x44
+	SHT3xAddrSecondary = 0x45
+)
+
+// Однократное измерение, высокая повторяемость, без растягивания такта (datasheet SHT3x-DIS, табл. 9)
+var sht3xMeasureHigh = []byte{0x24, 0x00}
+
+// sht3xMeasureTime — максимальная длительность измерения с высокой повторяемостью (15.5 мс)
+const sht3xMeasureTime = 16 * time.Millisecond
+
+// SHT3x — датчик температуры и влажности Sensirion SHT30/31/35 на шине I2C.
+type SHT3x struct {
+	name string
+	bus  I2CBus
+	addr uint16
+}
+
+// NewSHT3x создает датчик SHT3x.
+func NewSHT3x(name string, bus I2CBus, addr uint16) *SHT3x {
+	return &SHT3x{name: name, bus: bus, addr: addr}
+}
+
+func (d *SHT3x) Name() string { return d.name }
+
+func (d *SHT3x) Quantities() []string {
+	return []string{QuantityTemperature, QuantityHumidity}
+}
+
+func (d *SHT3x) Measure() (Measurement, error) {
+	if err := d.bus.Tx(d.addr, sht3xMeasureHigh, nil); err != nil {
+		return nil, err
+	}
+	time.Sleep(sht3xMeasureTime)
+
+	data := make([]byte, 6)
+	if err := d.bus.Tx(d.addr, nil, data); err != nil {
+		return nil, err
+	}
+	temp, hum, err := parseSHT3x(data)
+	if err != nil {
+		return nil, fmt.Errorf("sht3x 0x%02x: %w", d.addr, err)
+	}
+	return Measurement{QuantityTemperature: temp, QuantityHumidity: hum}, nil
+}
+
+// parseSHT3x проверяет CRC обоих слов ответа [T msb, T lsb, CRC, RH msb, RH lsb, CRC]
+// и пересчитывает их в °C и % (datasheet SHT3x-DIS, раздел 4.13).
+func parseSHT3x(data []byte) (float64, float64, error) {
+	for _, word := range [][]byte{data[0:3], data[3:6]} {
+		if crc := crc8Sensirion(word[:2]); crc != word[2] {
+			return 0, 0, fmt.Errorf("%w: вычислено %02x, получено %02x", ErrCRC, crc, word[2])
+		}
+	}
+	rawT := uint16(data[0])<<8 | uint16(data[1])
+	rawRH := uint16(data[3])<<8 | uint16(data[4])
+	return -45 + 175*float64(rawT)/65535, 100 * float64(rawRH) / 65535, nil
+}
+
+// crc8Sensirion вычисляет CRC-8 Sensirion (полином 0x31, начальное значение 0xFF).
+func crc8Sensirion(data []byte) byte {
+	crc := byte(0xFF)
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
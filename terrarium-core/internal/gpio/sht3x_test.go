@@ -0,0 +1,83 @@
+package gpio
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// Пример CRC из datasheet SHT3x-DIS (раздел 4.12): CRC(0xBEEF) = 0x92.
+func TestCRC8SensirionDatasheet(t *testing.T) {
+	if crc := crc8Sensirion([]byte{0xBE, 0xEF}); crc != 0x92 {
+		t.Fatalf("CRC(0xBEEF) = %#02x, want 0x92", crc)
+	}
+}
+
+// sht3xWord возвращает слово ответа с правильной CRC.
+func sht3xWord(raw uint16) []byte {
+	w := []byte{byte(raw >> 8), byte(raw)}
+	return append(w, crc8Sensirion(w))
+}
+
+func TestParseSHT3x(t *testing.T) {
+	tests := []struct {
+		rawT, rawRH uint16
+		temp, hum   float64
+	}{
+		// Границы шкалы из формул раздела 4.13: T = -45 + 175·S/(2^16-1), RH = 100·S/(2^16-1)
+		{0x0000, 0x0000, -45, 0},
+		{0xFFFF, 0xFFFF, 130, 100},
+		{0x6666, 0x8000, 24.9996, 50.0008},
+	}
+	for _, tt := range tests {
+		data := append(sht3xWord(tt.rawT), sht3xWord(tt.rawRH)...)
+		temp, hum, err := parseSHT3x(data)
+		if err != nil {
+			t.Fatalf("%x: %v", data, err)
+		}
+		if math.Abs(temp-tt.temp) > 0.001 || math.Abs(hum-tt.hum) > 0.001 {
+			t.Errorf("%x: T = %.4f, RH = %.4f; want %.4f, %.4f", data, temp, hum, tt.temp, tt.hum)
+		}
+	}
+}
+
+func TestParseSHT3xCRCMismatch(t *testing.T) {
+	good := append(sht3xWord(0x6666), sht3xWord(0x8000)...)
+	for _, i := range []int{2, 5} {
+		data := bytes.Clone(good)
+		data[i] ^= 0xFF
+		if _, _, err := parseSHT3x(data); !errors.Is(err, ErrCRC) {
+			t.Errorf("испорчена CRC в байте %d: err = %v, want ErrCRC", i, err)
+		}
+	}
+}
+
+func TestSHT3xMeasureOverFakeBus(t *testing.T) {
+	var command []byte
+	response := append(sht3xWord(0x6666), sht3xWord(0x8000)...)
+	bus := NewFakeI2C()
+	bus.Attach(SHT3xAddrPrimary, FakeI2CFunc(func(w, r []byte) error {
+		if len(w) > 0 {
+			command = bytes.Clone(w)
+		}
+		copy(r, response)
+		return nil
+	}))
+
+	m, err := NewSHT3x("cold", bus, SHT3xAddrPrimary).Measure()
+	if err != nil {
+		t.Fatalf("Measure: %v", err)
+	}
+	if !bytes.Equal(command, sht3xMeasureHigh) {
+		t.Errorf("команда измерения = %x, want %x", command, sht3xMeasureHigh)
+	}
+	if math.Abs(m[QuantityTemperature]-24.9996) > 0.001 || math.Abs(m[QuantityHumidity]-50.0008) > 0.001 {
+		t.Errorf("показания = %v", m)
+	}
+
+	response[5] ^= 0x01
+	if _, err := NewSHT3x("cold", bus, SHT3xAddrPrimary).Measure(); !errors.Is(err, ErrCRC) {
+		t.Errorf("испорченная CRC: err = %v, want ErrCRC", err)
+	}
+}
@@ -0,0 +1,183 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Форматы вывода журнала (LOG_FORMAT)
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// DefaultBufferSize — сколько последних записей журнала хранится в памяти для /api/v1/system/logs
+const DefaultBufferSize = 2000
+
+// root — общее состояние всех логгеров: уровень, вывод и кольцевой буфер.
+// Логгеры, созданные до Setup (например, на уровне пакета), подхватывают настройки Setup.
+var root = func() *rootState {
+	st := &rootState{ring: NewRing(DefaultBufferSize)}
+	st.setOutput(os.Stderr, FormatText)
+	return st
+}()
+
+type rootState struct {
+	level slog.LevelVar
+	out   atomic.Pointer[slog.Handler] // вывод в stderr (text или json)
+	mu    sync.RWMutex
+	ring  *Ring
+}
+
+func (st *rootState) setOutput(w io.Writer, format string) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug} // уровень проверяет handler.Enabled
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+	if format == FormatJSON {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	st.out.Store(&h)
+}
+
+func (st *rootState) buffer() *Ring {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.ring
+}
+
+func init() {
+	slog.SetDefault(slog.New(&handler{st: root}))
+}
+
+// Setup задает уровень (debug, info, warn, error), формат вывода (FormatText или FormatJSON)
+// и размер кольцевого буфера (<= 0 — DefaultBufferSize).
+func Setup(level, format string, bufferSize int) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	format = strings.ToLower(format)
+	if format == "" {
+		format = FormatText
+	}
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("неизвестный формат журнала %q (допустимо: text, json)", format)
+	}
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	root.level.Set(lvl)
+	root.setOutput(os.Stderr, format)
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if root.ring.Cap() != bufferSize {
+		root.ring = NewRing(bufferSize)
+	}
+	return nil
+}
+
+// ParseLevel разбирает уровень журнала (debug, info, warn, error; пусто — info).
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("неизвестный уровень журнала %q (допустимо: debug, info, warn, error)", s)
+}
+
+// Component возвращает логгер компонента: все записи получают поле component.
+func Component(name string) *slog.Logger {
+	return slog.New(&handler{st: root}).With(KeyComponent, name)
+}
+
+// Buffer возвращает кольцевой буфер последних записей журнала.
+func Buffer() *Ring {
+	return root.buffer()
+}
+
+// Ключи полей записей
+const (
+	KeyComponent = "component"
+	KeyEnclosure = "enclosure"
+	KeySensor    = "sensor"
+	KeyRelay     = "relay"
+	KeyError     = "err"
+)
+
+// Err возвращает поле ошибки записи.
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// handler пишет записи в текущий вывод и в кольцевой буфер.
+type handler struct {
+	st     *rootState
+	attrs  []slog.Attr // поля, добавленные через With (с префиксом групп)
+	prefix string      // префикс групп WithGroup ("group.")
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.st.level.Level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+r.NumAttrs())
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, flatten(h.prefix, a)...)
+		return true
+	})
+
+	h.st.buffer().add(r.Time, r.Level, r.Message, attrs)
+
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	out.AddAttrs(attrs...)
+	return (*h.st.out.Load()).Handle(ctx, out)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := &handler{st: h.st, prefix: h.prefix, attrs: append([]slog.Attr(nil), h.attrs...)}
+	for _, a := range attrs {
+		child.attrs = append(child.attrs, flatten(h.prefix, a)...)
+	}
+	return child
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &handler{st: h.st, attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// flatten разворачивает группы полей в ключи "group.key".
+func flatten(prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Key == "" {
+			return nil
+		}
+		a.Key = prefix + a.Key
+		return []slog.Attr{a}
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	var result []slog.Attr
+	for _, ga := range a.Value.Group() {
+		result = append(result, flatten(prefix, ga)...)
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/models"
+)
+
+// subscriberBuffer — сколько записей может накопиться у медленного подписчика, прежде чем новые будут пропущены
+const subscriberBuffer = 256
+
+// Ring — кольцевой буфер последних записей журнала с подпиской на новые записи.
+type Ring struct {
+	mu   sync.RWMutex
+	seq  uint64
+	buf  []ringEntry
+	next int
+	subs map[chan models.LogEntry]struct{}
+}
+
+type ringEntry struct {
+	level slog.Level
+	entry models.LogEntry
+}
+
+// NewRing создает буфер на size записей.
+func NewRing(size int) *Ring {
+	return &Ring{buf: make([]ringEntry, 0, size), subs: make(map[chan models.LogEntry]struct{})}
+}
+
+// Cap возвращает емкость буфера.
+func (r *Ring) Cap() int {
+	return cap(r.buf)
+}
+
+// add записывает запись в буфер и рассылает ее подписчикам. Медленные подписчики теряют записи,
+// но не задерживают логирование.
+func (r *Ring) add(t time.Time, level slog.Level, msg string, attrs []slog.Attr) {
+	e := models.LogEntry{Time: t, Level: level.String(), Message: msg}
+	for _, a := range attrs {
+		switch a.Key {
+		case KeyComponent:
+			e.Component = a.Value.String()
+		case KeyEnclosure:
+			e.Enclosure = a.Value.String()
+		default:
+			if e.Fields == nil {
+				e.Fields = make(map[string]string, len(attrs))
+			}
+			e.Fields[a.Key] = fieldString(a.Value)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	e.ID = r.seq
+	if len(r.buf) < cap(r.buf) {
+		r.buf = append(r.buf, ringEntry{level, e})
+	} else {
+		r.buf[r.next] = ringEntry{level, e}
+		r.next = (r.next + 1) % len(r.buf)
+	}
+	for ch := range r.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func fieldString(v slog.Value) string {
+	if v.Kind() == slog.KindAny {
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return fmt.Sprint(v.Any())
+	}
+	return v.String()
+}
+
+// Filter отбирает записи журнала.
+type Filter struct {
+	MinLevel  slog.Level // записи ниже уровня отбрасываются
+	Component string     // пусто — любой компонент
+	Enclosure string     // пусто — любой террариум (и записи без террариума)
+	AfterID   uint64     // только записи новее указанной
+}
+
+// Match сообщает, подходит ли запись под фильтр.
+func (f Filter) Match(e models.LogEntry) bool {
+	lvl, err := ParseLevel(e.Level)
+	if err != nil {
+		lvl = slog.LevelInfo
+	}
+	return f.match(lvl, e)
+}
+
+func (f Filter) match(level slog.Level, e models.LogEntry) bool {
+	return level >= f.MinLevel &&
+		(f.Component == "" || e.Component == f.Component) &&
+		(f.Enclosure == "" || e.Enclosure == f.Enclosure) &&
+		e.ID > f.AfterID
+}
+
+// Query возвращает до limit последних записей, подходящих под фильтр (от новых к старым).
+func (r *Ring) Query(f Filter, limit int) []models.LogEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]models.LogEntry, 0, min(limit, len(r.buf)))
+	n := len(r.buf)
+	newest := n - 1 // буфер еще не заполнен: новейшая запись в конце
+	if n == cap(r.buf) {
+		newest = r.next - 1 + n
+	}
+	for i := 0; i < n && len(result) < limit; i++ {
+		re := r.buf[(newest-i+n)%n]
+		if f.match(re.level, re.entry) {
+			result = append(result, re.entry)
+		}
+	}
+	return result
+}
+
+// Subscribe подписывается на новые записи журнала. Вызывающий обязан вызвать cancel.
+func (r *Ring) Subscribe() (entries <-chan models.LogEntry, cancel func()) {
+	ch := make(chan models.LogEntry, subscriberBuffer)
+	r.mu.Lock()
+	r.subs[ch] = struct{}{}
+	r.mu.Unlock()
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subs, ch)
+	}
+}
@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace — префикс всех метрик сервиса
+const namespace = "terrarium"
+
+// registry — реестр метрик сервиса (вместе со стандартными метриками Go-рантайма и процесса)
+var registry = prometheus.NewRegistry()
+
+// ==========================================
+// ДАТЧИКИ
+// ==========================================
+
+var (
+	sensorTemperature = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "sensor_temperature_celsius",
+		Help: "Последняя температура датчика, °C.",
+	}, []string{"enclosure", "sensor", "zone"})
+	sensorHumidity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "sensor_humidity_percent",
+		Help: "Последняя относительная влажность датчика, %.",
+	}, []string{"enclosure", "sensor", "zone"})
+	sensorPressure = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "sensor_pressure_hpa",
+		Help: "Последнее атмосферное давление датчика, гПа.",
+	}, []string{"enclosure", "sensor", "zone"})
+	sensorReadDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "sensor_read_duration_seconds",
+		Help:    "Время опроса датчика (включая неудачные попытки).",
+		Buckets: []float64{.005, .01, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"enclosure", "sensor"})
+	sensorReadErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "sensor_read_errors_total",
+		Help: "Количество неудачных опросов датчика.",
+	}, []string{"enclosure", "sensor"})
+)
+
+// sensorGauges — метрики показаний по измеряемой величине (величины без метрики не экспортируются)
+var sensorGauges = map[string]*prometheus.GaugeVec{
+	gpio.QuantityTemperature: sensorTemperature,
+	gpio.QuantityHumidity:    sensorHumidity,
+	gpio.QuantityPressure:    sensorPressure,
+}
+
+// ObserveSensorRead учитывает опрос датчика: время опроса, ошибку и показания.
+// Если датчик не ответил, его показания убираются из экспорта, чтобы не отдавать устаревшие значения.
+func ObserveSensorRead(enclosure string, info models.SensorInfo, m gpio.Measurement, d time.Duration, err error) {
+	sensorReadDuration.WithLabelValues(enclosure, info.ID).Observe(d.Seconds())
+	if err != nil {
+		sensorReadErrors.WithLabelValues(enclosure, info.ID).Inc()
+		for _, g := range sensorGauges {
+			g.DeletePartialMatch(prometheus.Labels{"enclosure": enclosure, "sensor": info.ID})
+		}
+		return
+	}
+	for q, v := range m {
+		if g, ok := sensorGauges[q]; ok {
+			g.WithLabelValues(enclosure, info.ID, info.Zone).Set(v)
+		}
+	}
+}
+
+// ==========================================
+// РЕЛЕ
+// ==========================================
+
+var (
+	relaySwitches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "relay_switches_total",
+		Help: "Количество переключений реле (state: on, off).",
+	}, []string{"enclosure", "relay", "state"})
+	relayErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "relay_errors_total",
+		Help: "Количество ошибок управления реле (без отказов защиты от дребезга).",
+	}, []string{"enclosure", "relay"})
+)
+
+// RegisterRelays экспортирует состояние реле террариума (1 — включено) и яркость диммеров.
+// Значения читаются у реле в момент опроса /metrics.
+func RegisterRelays(enclosure string, relays []gpio.RelayController) {
+	for _, relay := range relays {
+		labels := prometheus.Labels{"enclosure": enclosure, "relay": relay.Name()}
+		register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace, Name: "relay_state",
+			Help:        "Состояние реле (1 — включено, 0 — выключено).",
+			ConstLabels: labels,
+		}, func() float64 {
+			if relay.IsOn() {
+				return 1
+			}
+			return 0
+		}))
+		if dimmer, ok := gpio.AsDimmer(relay); ok {
+			register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace, Name: "relay_brightness_percent",
+				Help:        "Яркость диммируемого выхода, %.",
+				ConstLabels: labels,
+			}, dimmer.Brightness))
+		}
+	}
+}
+
+// RelaySwitched учитывает переключение реле.
+func RelaySwitched(enclosure, relay string, state bool) {
+	label := "off"
+	if state {
+		label = "on"
+	}
+	relaySwitches.WithLabelValues(enclosure, relay, label).Inc()
+}
+
+// RelayFailed учитывает ошибку управления реле.
+func RelayFailed(enclosure, relay string) {
+	relayErrors.WithLabelValues(enclosure, relay).Inc()
+}
+
+// ==========================================
+// ДВИЖОК АВТОМАТИЗАЦИИ
+// ==========================================
+
+var (
+	cycleDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "engine_cycle_duration_seconds",
+		Help:    "Длительность цикла движка автоматизации.",
+		Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"enclosure"})
+	setpoints = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "setpoint",
+		Help: "Уставки климата, по которым работал последний цикл движка (°C или %).",
+	}, []string{"enclosure", "setpoint"})
+)
+
+// ObserveCycle учитывает длительность цикла движка.
+func ObserveCycle(enclosure string, d time.Duration) {
+	cycleDuration.WithLabelValues(enclosure).Observe(d.Seconds())
+}
+
+// SetSetpoints экспортирует уставки климата, действующие в цикле движка.
+func SetSetpoints(enclosure string, cfg *models.ConfigPayload) {
+	for name, v := range map[string]float64{
+		"warm_target_min":         cfg.WarmTargetMin,
+		"warm_target_max":         cfg.WarmTargetMax,
+		"cold_max_threshold":      cfg.ColdMaxThreshold,
+		"emergency_max_threshold": cfg.EmergencyMaxThreshold,
+		"humidity_min":            cfg.HumidityMin,
+		"humidity_max":            cfg.HumidityMax,
+		"hysteresis_temp":         cfg.HysteresisTemp,
+		"hysteresis_hum":          cfg.HysteresisHum,
+	} {
+		setpoints.WithLabelValues(enclosure, name).Set(v)
+	}
+}
+
+// ==========================================
+// БАЗА ДАННЫХ
+// ==========================================
+
+var dbWriteErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace, Name: "db_write_errors_total",
+	Help: "Количество неудачных записей в БД по таблицам.",
+}, []string{"enclosure", "table"})
+
+// DBWriteFailed учитывает неудачную запись в таблицу БД.
+func DBWriteFailed(enclosure, table string) {
+	dbWriteErrors.WithLabelValues(enclosure, table).Inc()
+}
+
+// ==========================================
+// HTTP API
+// ==========================================
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Name: "http_requests_total",
+		Help: "Количество HTTP-запросов по маршрутам и кодам ответа.",
+	}, []string{"method", "route", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Name: "http_request_duration_seconds",
+		Help:    "Время обработки HTTP-запроса.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// ObserveHTTP учитывает обработанный HTTP-запрос. route — шаблон маршрута (например, /api/v1/relays/:id/toggle).
+func ObserveHTTP(method, route string, code int, d time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// Handler возвращает обработчик /metrics в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// register добавляет метрику в реестр. Повторная регистрация (тот же террариум и реле) пропускается.
+func register(c prometheus.Collector) {
+	if err := registry.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			logging.Component("metrics").Error("Не удалось зарегистрировать метрику", logging.Err(err))
+		}
+	}
+}
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		sensorTemperature, sensorHumidity, sensorPressure, sensorReadDuration, sensorReadErrors,
+		relaySwitches, relayErrors,
+		cycleDuration, setpoints,
+		dbWriteErrors,
+		httpRequests, httpDuration,
+	)
+}
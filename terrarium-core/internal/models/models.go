@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // HTTPError представляет стандартную структуру ошибки API.
 // Используется для возврата детальной информации в случае проблем (например, 400 Bad Request или 500 Internal Server Error).
@@ -52,6 +55,10 @@ type ModeRequest struct {
 	// Целевой режим работы системы. Допускается 'AUTO' (автоматика) или 'MANUAL' (ручное управление).
 	// Example: MANUAL
 	Mode string `json:"mode" binding:"required,oneof=AUTO MANUAL" example:"MANUAL"`
+	// Срок аренды режима MANUAL в минутах (1..1440). По истечении система сама вернется в AUTO.
+	// Если не указан, MANUAL действует до ручного переключения.
+	// Example: 30
+	DurationMin int `json:"duration_min,omitempty" binding:"omitempty,min=1,max=1440" example:"30"`
 }
 
 // SystemStatus представляет текущее операционное состояние Backend'а.
@@ -60,12 +67,180 @@ type SystemStatus struct {
 	// Время работы сервиса с момента старта (в секундах).
 	// Example: 3600
 	Uptime int64 `json:"uptime" example:"3600"`
+	// Время запуска сервиса
+	// Example: "2026-02-26T12:30:00Z"
+	StartedAt time.Time `json:"started_at" example:"2026-02-26T12:30:00Z"`
+	// Версия сборки
+	// Example: 1.4.0
+	Version string `json:"version" example:"1.4.0"`
 	// Текущий активный режим автоматизации (AUTO или MANUAL).
 	// Example: AUTO
 	Mode string `json:"mode" example:"AUTO"`
+	// Время автоматического возврата из MANUAL в AUTO (если задана аренда)
+	// Example: "2026-02-26T16:00:00Z"
+	ManualUntil *time.Time `json:"manual_until,omitempty" example:"2026-02-26T16:00:00Z"`
+	// Осталось секунд до автоматического возврата в AUTO
+	// Example: 1200
+	ManualRemainingSec *int64 `json:"manual_remaining_sec,omitempty" example:"1200"`
 	// Статус соединения с базой данных PostgreSQL (OK или ERROR).
 	// Example: OK
 	DBStatus string `json:"db_status" example:"OK"`
+	// Диагностика оборудования (нагреватель, датчики)
+	Health []ComponentHealth `json:"health"`
+	// Время включения аварийного стопа (кнопкой или через API), если он активен
+	// Example: "2026-02-26T14:05:00Z"
+	PanicStopSince *time.Time `json:"panic_stop_since,omitempty" example:"2026-02-26T14:05:00Z"`
+	// Состояние цикла движка автоматизации
+	Engine EngineStatus `json:"engine"`
+	// Последний успешный опрос каждого датчика
+	Sensors []SensorHealth `json:"sensors"`
+	// Связь с драйвером каждого реле
+	Relays []RelayHealth `json:"relays"`
+	// Задержка и загрузка пула соединений PostgreSQL (отсутствует, если БД не ответила)
+	Storage *StorageStatus `json:"storage,omitempty"`
+}
+
+// EngineStatus описывает работу цикла движка автоматизации.
+// @Description Последний цикл движка. Цикл выполняется каждые 5 секунд; если он не завершался дольше 15 секунд, движок считается зависшим.
+type EngineStatus struct {
+	// Запущен ли движок
+	// Example: true
+	Running bool `json:"running" example:"true"`
+	// Время начала последнего завершенного цикла
+	// Example: "2026-02-26T15:30:00Z"
+	LastCycleAt *time.Time `json:"last_cycle_at,omitempty" example:"2026-02-26T15:30:00Z"`
+	// Длительность последнего цикла (мс)
+	// Example: 215.4
+	LastCycleMs float64 `json:"last_cycle_ms" example:"215.4"`
+	// Причина прерывания последнего цикла (SENSOR_ERROR, CONFIG_ERROR)
+	// Example: ""
+	LastSkipped string `json:"last_skipped,omitempty" example:""`
+	// Движок завис: цикл давно не завершался
+	// Example: false
+	Stale bool `json:"stale" example:"false"`
+}
+
+// SensorHealth описывает доступность датчика.
+// @Description Последний успешный опрос датчика и ошибка последнего опроса.
+type SensorHealth struct {
+	// ID датчика
+	// Example: warm
+	ID string `json:"id" example:"warm"`
+	// Драйвер датчика
+	// Example: dht22
+	Driver string `json:"driver" example:"dht22"`
+	// Время последнего успешного опроса (отсутствует, если датчик еще ни разу не ответил)
+	// Example: "2026-02-26T15:30:00Z"
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty" example:"2026-02-26T15:30:00Z"`
+	// Ошибка последнего опроса (пусто — датчик отвечает)
+	// Example: ""
+	Error string `json:"error,omitempty" example:""`
+}
+
+// RelayHealth описывает связь с драйвером реле.
+// @Description Состояние драйвера реле по данным сверки желаемого и фактического состояния.
+type RelayHealth struct {
+	// ID реле
+	// Example: light
+	ID string `json:"id" example:"light"`
+	// Драйвер реле (gpio, pwm, shelly, shelly_rpc, shelly_dimmer, tasmota, tasmota_dimmer, mqtt)
+	// Example: shelly
+	Driver string `json:"driver" example:"shelly"`
+	// OK, DESYNC (фактическое состояние расходится с желаемым) или UNREACHABLE (состояние не читается)
+	// Example: OK
+	Status string `json:"status" example:"OK"`
+	// Последняя ошибка чтения или переключения реле
+	// Example: реле light недоступно: connection refused
+	LastError string `json:"last_error,omitempty" example:"реле light недоступно: connection refused"`
+	// Время последней ошибки
+	// Example: "2026-02-26T13:29:30Z"
+	LastErrorAt *time.Time `json:"last_error_at,omitempty" example:"2026-02-26T13:29:30Z"`
+}
+
+// StorageStatus описывает задержку и загрузку хранилища.
+// @Description Задержка PostgreSQL и пул соединений. Записи выполняются синхронно, поэтому очередь хранилища — это запросы, занявшие соединения пула.
+type StorageStatus struct {
+	// Задержка ping базы данных (мс)
+	// Example: 1.8
+	LatencyMs float64 `json:"latency_ms" example:"1.8"`
+	// Занятые соединения пула (запросы в работе)
+	// Example: 1
+	AcquiredConns int32 `json:"acquired_conns" example:"1"`
+	// Свободные соединения пула
+	// Example: 2
+	IdleConns int32 `json:"idle_conns" example:"2"`
+	// Открытые соединения пула (занятые, свободные и устанавливаемые)
+	// Example: 3
+	TotalConns int32 `json:"total_conns" example:"3"`
+	// Максимальный размер пула
+	// Example: 10
+	MaxConns int32 `json:"max_conns" example:"10"`
+}
+
+// HealthCheck описывает результат одной проверки готовности.
+// @Description Проверка готовности сервиса.
+type HealthCheck struct {
+	// Что проверялось ("database", "engine:default", ...)
+	// Example: database
+	Name string `json:"name" example:"database"`
+	// Пройдена ли проверка
+	// Example: true
+	OK bool `json:"ok" example:"true"`
+	// Подробности
+	// Example: ping 1.8 мс
+	Detail string `json:"detail,omitempty" example:"ping 1.8 мс"`
+}
+
+// HealthStatus — ответ /healthz и /readyz.
+// @Description Живость (liveness) или готовность (readiness) сервиса.
+type HealthStatus struct {
+	// ok или fail
+	// Example: ok
+	Status string `json:"status" example:"ok"`
+	// Время работы сервиса (в секундах)
+	// Example: 3600
+	Uptime int64 `json:"uptime" example:"3600"`
+	// Версия сборки
+	// Example: 1.4.0
+	Version string `json:"version" example:"1.4.0"`
+	// Результаты проверок
+	Checks []HealthCheck `json:"checks"`
+}
+
+// ComponentHealth описывает результат самодиагностики одного компонента.
+// @Description Здоровье компонента по данным диагностики теплового отклика и показаний датчиков.
+type ComponentHealth struct {
+	// Компонент ("heater", "sensor:WarmZone", ...)
+	// Example: heater
+	Component string `json:"component" example:"heater"`
+	// Статус: OK, LEARNING (набор статистики) или FAULT
+	// Example: OK
+	Status string `json:"status" example:"OK"`
+	// Выученная скорость нагрева при включенном нагревателе (°C/мин)
+	// Example: 0.12
+	OnRateCpm float64 `json:"on_rate_c_per_min,omitempty" example:"0.12"`
+	// Выученная скорость изменения температуры при выключенном нагревателе (°C/мин)
+	// Example: -0.05
+	OffRateCpm float64 `json:"off_rate_c_per_min,omitempty" example:"-0.05"`
+	// Количество завершенных фаз, учтенных при обучении
+	// Example: 12
+	LearnedRuns int `json:"learned_runs,omitempty" example:"12"`
+	// Активные неисправности
+	Faults []FaultInfo `json:"faults"`
+}
+
+// FaultInfo описывает активную неисправность компонента.
+// @Description Активная неисправность, выявленная диагностикой.
+type FaultInfo struct {
+	// Тип неисправности (HEATER_NOT_HEATING, TEMP_RISING_WHILE_OFF, SENSOR_FROZEN)
+	// Example: HEATER_NOT_HEATING
+	Fault string `json:"fault" example:"HEATER_NOT_HEATING"`
+	// Подробности
+	// Example: "нагрев включен 20m0s, прирост 0.05°C при ожидаемых ≥0.30°C"
+	Message string `json:"message" example:"нагрев включен 20m0s, прирост 0.05°C при ожидаемых ≥0.30°C"`
+	// Время обнаружения
+	// Example: "2026-02-26T15:30:00Z"
+	Since time.Time `json:"since" example:"2026-02-26T15:30:00Z"`
 }
 
 // RelayState описывает текущее состояние аппаратных реле, подключенных к Raspberry Pi.
@@ -83,6 +258,37 @@ type RelayState struct {
 	// Состояние запасной розетки
 	// Example: false
 	Spare bool `json:"spare" example:"false"`
+	// Яркость диммируемых выходов, % (ID реле -> яркость; обычные реле не включаются)
+	Brightness map[string]float64 `json:"brightness"`
+	// Сверка желаемого и фактического состояния каждого реле (ID реле -> сверка)
+	Sync map[string]RelaySync `json:"sync"`
+}
+
+// RelaySync описывает расхождение между состоянием, которое задала система, и фактическим
+// состоянием оборудования (уровень пина GPIO или ответ сетевой розетки).
+// @Description Желаемое и фактическое состояние реле. Движок сверяет их каждые 30 секунд и восстанавливает желаемое при расхождении.
+type RelaySync struct {
+	// Желаемое состояние (последняя подтвержденная команда)
+	// Example: true
+	Desired bool `json:"desired" example:"true"`
+	// Фактическое состояние при последней сверке (отсутствует, если прочитать не удалось или сверки еще не было)
+	// Example: true
+	Actual *bool `json:"actual,omitempty" example:"true"`
+	// Совпадало ли фактическое состояние с желаемым при последней сверке
+	// Example: true
+	InSync bool `json:"in_sync" example:"true"`
+	// Время последней сверки
+	// Example: "2026-02-26T13:30:00Z"
+	CheckedAt *time.Time `json:"checked_at,omitempty" example:"2026-02-26T13:30:00Z"`
+	// Сколько раз с момента запуска обнаруживалось расхождение
+	// Example: 0
+	Desyncs int `json:"desyncs" example:"0"`
+	// Последняя ошибка чтения или переключения реле
+	// Example: реле light недоступно: connection refused
+	LastError string `json:"last_error,omitempty" example:"реле light недоступно: connection refused"`
+	// Время последней ошибки
+	// Example: "2026-02-26T13:29:30Z"
+	LastErrorAt *time.Time `json:"last_error_at,omitempty" example:"2026-02-26T13:29:30Z"`
 }
 
 // RelayToggleRequest представляет запрос пользователя на изменение состояния конкретного реле в ручном режиме.
@@ -91,6 +297,9 @@ type RelayToggleRequest struct {
 	// Желаемое состояние реле (true для включения, false для выключения)
 	// Example: true
 	State bool `json:"state" example:"true"`
+	// Яркость диммируемого выхода при включении, % (по умолчанию — последняя ненулевая)
+	// Example: 60
+	Brightness *float64 `json:"brightness,omitempty" binding:"omitempty,gt=0,lte=100" example:"60"`
 }
 
 // SensorDataHistory содержит агрегированные данные датчиков за указанный период времени.
@@ -136,8 +345,8 @@ type EnergyReport struct {
 	TotalKwh float64 `json:"total_kwh" example:"0.4200"`
 }
 
-// SensorCurrent представляет актуальные (последние) показания с обоих датчиков DHT22.
-// Каждый датчик измеряет температуру и влажность одновременно.
+// SensorCurrent представляет актуальные (последние) показания теплой и холодной зон
+// (первые датчики зон warm и cold в реестре). Показания всех датчиков — в SensorReadings.
 // @Description Текущие (live) показания температуры и влажности с двух зон террариума.
 type SensorCurrent struct {
 	// Температура (°C) в тёплой зоне
@@ -160,6 +369,101 @@ type SensorCurrent struct {
 	Mode string `json:"mode" example:"AUTO"`
 }
 
+// SensorInfo описывает датчик из реестра террариума.
+// @Description Датчик террариума: ID, зона и измеряемые величины.
+type SensorInfo struct {
+	// Уникальный (в пределах террариума) ID датчика
+	// Example: basking
+	ID string `json:"id" example:"basking"`
+	// Отображаемое имя
+	// Example: "Точка прогрева"
+	Name string `json:"name" example:"Точка прогрева"`
+	// Зона террариума (warm, cold, basking, ...)
+	// Example: warm
+	Zone string `json:"zone" example:"warm"`
+	// Драйвер датчика (dht22, ds18b20, bme280, bmp280, sht3x)
+	// Example: dht22
+	Driver string `json:"driver" example:"dht22"`
+	// Адрес устройства на шине (ID 1-wire для ds18b20, "<шина>@<адрес>" для датчиков I2C)
+	// Example: 28-0316a2799cff
+	Device string `json:"device,omitempty" example:"28-0316a2799cff"`
+	// Измеряемые величины (temperature, humidity, pressure)
+	// Example: ["temperature","humidity"]
+	Quantities []string `json:"quantities" example:"temperature,humidity"`
+}
+
+// DiscoveredProbe описывает датчик, найденный на шине оборудования.
+// @Description Датчик на шине 1-wire и его текущее показание.
+type DiscoveredProbe struct {
+	// ID устройства на шине
+	// Example: 28-0316a2799cff
+	Device string `json:"device" example:"28-0316a2799cff"`
+	// Драйвер
+	// Example: ds18b20
+	Driver string `json:"driver" example:"ds18b20"`
+	// Текущая температура (°C), если датчик ответил
+	// Example: 23.125
+	Temperature *float64 `json:"temperature,omitempty" example:"23.125"`
+	// Ошибка опроса
+	// Example: "ds18b20 28-0316a2799cff: ошибка CRC: вычислено 57, получено 58"
+	Error string `json:"error,omitempty" example:"ds18b20 28-0316a2799cff: ошибка CRC: вычислено 57, получено 58"`
+	// Датчик реестра, к которому привязано устройство ("<террариум>/<датчик>"; пусто — не используется)
+	// Example: default/basking
+	AssignedTo string `json:"assigned_to,omitempty" example:"default/basking"`
+}
+
+// SensorSnapshot содержит последние показания одного датчика.
+// @Description Последние показания датчика из реестра.
+type SensorSnapshot struct {
+	SensorInfo
+	// Значения по величинам (пусто, если датчик еще не опрашивался или не ответил)
+	// Example: {"temperature": 34.8, "humidity": 41.2}
+	Values map[string]float64 `json:"values"`
+	// Время последнего успешного опроса
+	// Example: "2026-02-26T15:30:00Z"
+	ReadAt *time.Time `json:"read_at,omitempty" example:"2026-02-26T15:30:00Z"`
+	// Ошибка последнего опроса
+	// Example: "dht error: checksum did not validate"
+	Error string `json:"error,omitempty" example:"dht error: checksum did not validate"`
+}
+
+// SensorReadings содержит текущие показания всех датчиков террариума, ключ — ID датчика.
+// @Description Текущие (live) показания всех датчиков террариума.
+type SensorReadings struct {
+	// Показания по ID датчика
+	Sensors map[string]SensorSnapshot `json:"sensors"`
+	// Время последнего цикла опроса
+	// Example: "2026-02-26T15:30:00Z"
+	Timestamp time.Time `json:"timestamp" example:"2026-02-26T15:30:00Z"`
+	// Текущий режим системы (AUTO / MANUAL)
+	// Example: AUTO
+	Mode string `json:"mode" example:"AUTO"`
+}
+
+// SensorReading — одно значение величины, измеренное датчиком (строка sensor_readings).
+// @Description Показание датчика в длинном формате.
+type SensorReading struct {
+	// ID датчика
+	// Example: basking
+	SensorID string `json:"sensor_id" example:"basking"`
+	// Величина
+	// Example: temperature
+	Quantity string `json:"quantity" example:"temperature"`
+	// Значение
+	// Example: 34.8
+	Value float64 `json:"value" example:"34.8"`
+	// Время измерения
+	// Example: "2026-02-26T13:30:00Z"
+	Timestamp time.Time `json:"timestamp" example:"2026-02-26T13:30:00Z"`
+}
+
+// ReadingSample — показания всех датчиков одного цикла (ключ — "<датчик>.<величина>").
+// Используется для воспроизведения истории в симуляции.
+type ReadingSample struct {
+	Timestamp time.Time
+	Values    map[string]float64
+}
+
 // Schedule представляет запись расписания включения/выключения реле (например, освещение по таймеру).
 // @Description Расписание автоматического управления реле по времени суток.
 type Schedule struct {
@@ -175,6 +479,12 @@ type Schedule struct {
 	// Время выключения (формат HH:MM)
 	// Example: "20:00"
 	EndTime string `json:"end_time" example:"20:00"`
+	// Яркость диммируемого выхода, %
+	// Example: 80
+	Brightness float64 `json:"brightness" example:"80"`
+	// Длительность рассвета после start_time и заката перед end_time, мин (0 — без плавного перехода)
+	// Example: 30
+	RampMin int `json:"ramp_min" example:"30"`
 	// Активно ли расписание
 	// Example: true
 	IsActive bool `json:"is_active" example:"true"`
@@ -195,6 +505,12 @@ type ScheduleRequest struct {
 	// Время выключения (формат HH:MM)
 	// Example: "20:00"
 	EndTime string `json:"end_time" binding:"required" example:"20:00"`
+	// Яркость диммируемого выхода, % (по умолчанию 100)
+	// Example: 80
+	Brightness *float64 `json:"brightness" binding:"omitempty,gt=0,lte=100" example:"80"`
+	// Длительность рассвета и заката, мин (по умолчанию 0, не более 720)
+	// Example: 30
+	RampMin int `json:"ramp_min" binding:"min=0,max=720" example:"30"`
 	// Активно ли расписание (по умолчанию true)
 	// Example: true
 	IsActive *bool `json:"is_active" example:"true"`
@@ -215,7 +531,899 @@ type RelayLogEntry struct {
 	// Причина переключения (AUTO_TEMP_TRIGGER, MANUAL_OVERRIDE, EMERGENCY_CUTOFF и т.д.)
 	// Example: "AUTO_TEMP_TRIGGER"
 	Reason string `json:"reason" example:"AUTO_TEMP_TRIGGER"`
+	// Яркость диммируемого выхода после события, % (только для диммеров)
+	// Example: 80
+	Brightness *float64 `json:"brightness,omitempty" example:"80"`
 	// Время события
 	// Example: "2026-02-26T14:05:00Z"
 	RecordedAt time.Time `json:"recorded_at" example:"2026-02-26T14:05:00Z"`
 }
+
+// MistSettings представляет настройки импульсного режима туманообразования (misting).
+// @Description В режиме misting фоггер включается короткими импульсами с паузой между ними и суточным лимитом вместо термостатного управления.
+type MistSettings struct {
+	// Включен ли импульсный режим (false = классический гистерезис по влажности)
+	// Example: true
+	Enabled bool `json:"enabled" example:"true"`
+	// Длительность одного импульса (секунды)
+	// Example: 30
+	PulseSec int `json:"pulse_sec" binding:"required,min=10,max=600" example:"30"`
+	// Минимальная пауза между импульсами по влажности (секунды)
+	// Example: 900
+	CooldownSec int `json:"cooldown_sec" binding:"required,min=30,max=86400" example:"900"`
+	// Суточный лимит суммарной работы фоггера (минуты)
+	// Example: 20
+	DailyMaxMin int `json:"daily_max_min" binding:"required,min=1,max=720" example:"20"`
+}
+
+// MistStatus представляет текущее состояние импульсного режима фоггера.
+// @Description Состояние импульсов тумана и расход суточного лимита.
+type MistStatus struct {
+	// Включен ли импульсный режим
+	// Example: true
+	Enabled bool `json:"enabled" example:"true"`
+	// Идет ли импульс прямо сейчас
+	// Example: false
+	PulseActive bool `json:"pulse_active" example:"false"`
+	// Время окончания текущего импульса (если идет)
+	// Example: "2026-02-26T15:30:30Z"
+	PulseEndsAt *time.Time `json:"pulse_ends_at,omitempty" example:"2026-02-26T15:30:30Z"`
+	// Время окончания последнего импульса
+	// Example: "2026-02-26T15:00:30Z"
+	LastPulseAt *time.Time `json:"last_pulse_at,omitempty" example:"2026-02-26T15:00:30Z"`
+	// Израсходовано работы фоггера за сутки (секунды)
+	// Example: 360
+	UsedTodaySec int `json:"used_today_sec" example:"360"`
+	// Суточный лимит работы фоггера (секунды)
+	// Example: 1200
+	DailyBudgetSec int `json:"daily_budget_sec" example:"1200"`
+}
+
+// RainEvent представляет запланированный "дождь" — импульс тумана в заданное время суток.
+// @Description Запланированное событие дождя (принудительный импульс фоггера по времени).
+type RainEvent struct {
+	// Уникальный идентификатор события (UUID)
+	// Example: "b2c3d4e5-f6a7-8901-bcde-f12345678901"
+	ID string `json:"id" example:"b2c3d4e5-f6a7-8901-bcde-f12345678901"`
+	// Время начала дождя (формат HH:MM)
+	// Example: "07:30"
+	StartTime string `json:"start_time" example:"07:30"`
+	// Длительность дождя (секунды)
+	// Example: 120
+	DurationSec int `json:"duration_sec" example:"120"`
+	// Активно ли событие
+	// Example: true
+	IsActive bool `json:"is_active" example:"true"`
+	// Дата создания записи
+	// Example: "2026-02-26T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2026-02-26T12:00:00Z"`
+}
+
+// RainEventRequest представляет запрос на создание или обновление события дождя.
+// @Description Payload для создания/обновления запланированного дождя.
+type RainEventRequest struct {
+	// Время начала дождя (формат HH:MM)
+	// Example: "07:30"
+	StartTime string `json:"start_time" binding:"required" example:"07:30"`
+	// Длительность дождя (секунды, от 10 до 1800)
+	// Example: 120
+	DurationSec int `json:"duration_sec" binding:"required,min=10,max=1800" example:"120"`
+	// Активно ли событие (по умолчанию true)
+	// Example: true
+	IsActive *bool `json:"is_active" example:"true"`
+}
+
+// ReservoirStatus описывает уровень воды в резервуаре фоггера по поплавковому выключателю.
+// @Description Состояние резервуара фоггера. При пустом резервуаре (или недоступном датчике) фоггер заблокирован в любом режиме.
+type ReservoirStatus struct {
+	// Установлен ли поплавковый выключатель
+	// Example: true
+	Configured bool `json:"configured" example:"true"`
+	// Уровень: OK, EMPTY или UNKNOWN (датчик не отвечает)
+	// Example: OK
+	Level string `json:"level" example:"OK"`
+	// Заблокировано ли включение фоггера
+	// Example: false
+	FoggerBlocked bool `json:"fogger_blocked" example:"false"`
+	// С какого момента резервуар в текущем состоянии
+	// Example: "2026-02-26T09:15:00Z"
+	Since *time.Time `json:"since,omitempty" example:"2026-02-26T09:15:00Z"`
+	// Когда резервуар последний раз опустел
+	// Example: "2026-02-25T21:40:00Z"
+	LastEmptyAt *time.Time `json:"last_empty_at,omitempty" example:"2026-02-25T21:40:00Z"`
+	// Когда резервуар последний раз пополнили
+	// Example: "2026-02-26T09:15:00Z"
+	LastRefillAt *time.Time `json:"last_refill_at,omitempty" example:"2026-02-26T09:15:00Z"`
+	// Ошибка чтения датчика (если есть)
+	// Example: ""
+	Error string `json:"error,omitempty" example:""`
+}
+
+// ReservoirEvent — событие журнала резервуара фоггера.
+// @Description Опустошение или пополнение резервуара фоггера (по фронту поплавкового выключателя).
+type ReservoirEvent struct {
+	// Уникальный идентификатор события (UUID)
+	// Example: "c3d4e5f6-a7b8-9012-cdef-123456789012"
+	ID string `json:"id" example:"c3d4e5f6-a7b8-9012-cdef-123456789012"`
+	// Событие: EMPTY (резервуар опустел) или REFILL (пополнен)
+	// Example: REFILL
+	Event string `json:"event" example:"REFILL"`
+	// Сколько резервуар простоял пустым до пополнения (секунды, только для REFILL)
+	// Example: 41100
+	EmptySec *int `json:"empty_sec,omitempty" example:"41100"`
+	// Время события
+	// Example: "2026-02-26T09:15:00Z"
+	OccurredAt time.Time `json:"occurred_at" example:"2026-02-26T09:15:00Z"`
+}
+
+// InputStatus описывает цифровой вход террариума (концевик крышки, кнопка аварийного стопа).
+// @Description Состояние цифрового входа после подавления дребезга.
+type InputStatus struct {
+	// Идентификатор входа
+	// Example: lid
+	ID string `json:"id" example:"lid"`
+	// Назначение: lid (крышка — пока открыта, фоггер на паузе) или panic (кнопка аварийного стопа)
+	// Example: lid
+	Role string `json:"role" example:"lid"`
+	// Активен ли вход (крышка открыта, кнопка нажата)
+	// Example: false
+	Active bool `json:"active" example:"false"`
+	// С какого момента вход в текущем состоянии
+	// Example: "2026-02-26T14:02:11Z"
+	Since *time.Time `json:"since,omitempty" example:"2026-02-26T14:02:11Z"`
+	// Ошибка чтения входа (если есть)
+	// Example: ""
+	Error string `json:"error,omitempty" example:""`
+}
+
+// InputEvent — событие журнала цифровых входов.
+// @Description Открытие/закрытие крышки, включение или сброс аварийного стопа.
+type InputEvent struct {
+	// Уникальный идентификатор события (UUID)
+	// Example: "d4e5f6a7-b8c9-0123-def0-234567890123"
+	ID string `json:"id" example:"d4e5f6a7-b8c9-0123-def0-234567890123"`
+	// Вход-источник (ID входа или "api" для команд через API)
+	// Example: lid
+	InputID string `json:"input_id" example:"lid"`
+	// Событие: OPEN, CLOSE, PANIC_STOP или PANIC_RESET
+	// Example: OPEN
+	Event string `json:"event" example:"OPEN"`
+	// Время события
+	// Example: "2026-02-26T14:02:11Z"
+	OccurredAt time.Time `json:"occurred_at" example:"2026-02-26T14:02:11Z"`
+}
+
+// PanicStopRequest включает или сбрасывает аварийный стоп.
+// @Description Payload аварийного стопа (то же, что нажатие физической кнопки).
+type PanicStopRequest struct {
+	// true — выключить все реле и остановить автоматику, false — сбросить стоп
+	// Example: false
+	Active *bool `json:"active" binding:"required" example:"false"`
+}
+
+// RelayOverrideRequest представляет запрос на временное ручное переопределение реле в режиме AUTO.
+// Нужно указать либо duration_min, либо until.
+// @Description Временное переопределение реле ("туман на 10 минут", "свет выключен до 18:00").
+type RelayOverrideRequest struct {
+	// Желаемое состояние реле на время переопределения
+	// Example: true
+	State bool `json:"state" example:"true"`
+	// Длительность переопределения в минутах (1..1440)
+	// Example: 10
+	DurationMin int `json:"duration_min" binding:"omitempty,min=1,max=1440" example:"10"`
+	// Время окончания переопределения (формат HH:MM, ближайшее наступление)
+	// Example: "18:00"
+	Until string `json:"until" example:"18:00"`
+}
+
+// RelayOverride описывает активное временное переопределение реле.
+// @Description Активное переопределение: пока оно действует, автоматика не управляет реле.
+type RelayOverride struct {
+	// ID реле
+	// Example: "fogger"
+	RelayID string `json:"relay_id" example:"fogger"`
+	// Удерживаемое состояние реле
+	// Example: true
+	State bool `json:"state" example:"true"`
+	// Состояние реле до переопределения (восстанавливается по окончании)
+	// Example: false
+	PrevState bool `json:"prev_state" example:"false"`
+	// Время создания
+	// Example: "2026-02-26T15:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2026-02-26T15:00:00Z"`
+	// Время окончания, после которого автоматика возобновит управление
+	// Example: "2026-02-26T15:10:00Z"
+	ExpiresAt time.Time `json:"expires_at" example:"2026-02-26T15:10:00Z"`
+	// Осталось секунд до окончания
+	// Example: 540
+	RemainingSec int `json:"remaining_sec" example:"540"`
+}
+
+// DecisionRecord представляет трассировку одного цикла движка автоматизации.
+// @Description Структурированная запись решения: входные данные, эффективная конфигурация, оценка каждого правила и итоговые действия с реле.
+type DecisionRecord struct {
+	// Порядковый номер цикла с момента запуска
+	// Example: 1542
+	CycleID uint64 `json:"cycle_id" example:"1542"`
+	// Время начала цикла
+	// Example: "2026-02-26T15:30:00Z"
+	StartedAt time.Time `json:"started_at" example:"2026-02-26T15:30:00Z"`
+	// Длительность цикла (мс)
+	// Example: 215.4
+	DurationMs float64 `json:"duration_ms" example:"215.4"`
+	// Режим системы в момент цикла (AUTO / MANUAL)
+	// Example: AUTO
+	Mode string `json:"mode" example:"AUTO"`
+	// Причина досрочного прерывания цикла (SENSOR_ERROR, CONFIG_ERROR)
+	// Example: ""
+	Skipped string `json:"skipped,omitempty" example:""`
+	// Входные показания датчиков
+	Inputs *DecisionInputs `json:"inputs,omitempty"`
+	// Эффективная конфигурация климата
+	Config *ConfigPayload `json:"config,omitempty"`
+	// Эффективные настройки импульсного режима фоггера
+	Mist *MistSettings `json:"mist,omitempty"`
+	// Результаты оценки правил в порядке приоритета
+	Rules []RuleEvaluation `json:"rules"`
+	// Команды реле, выданные в цикле
+	Actions []RelayAction `json:"actions"`
+}
+
+// DecisionInputs содержит показания датчиков, на основе которых принималось решение.
+// @Description Входные данные цикла движка.
+type DecisionInputs struct {
+	// Example: 32.3
+	WarmTemp float64 `json:"warm_temp" example:"32.3"`
+	// Example: 58.5
+	WarmHum float64 `json:"warm_hum" example:"58.5"`
+	// Example: 24.8
+	ColdTemp float64 `json:"cold_temp" example:"24.8"`
+	// Example: 65.2
+	ColdHum float64 `json:"cold_hum" example:"65.2"`
+	// Все показания цикла ("<датчик>.<величина>" -> значение)
+	// Example: {"warm.temperature": 32.3, "basking.temperature": 41.5}
+	Readings map[string]float64 `json:"readings,omitempty"`
+}
+
+// RuleEvaluation описывает результат оценки одного правила движка в цикле.
+// @Description Оценка правила: сработало ли оно, почему, и какое решение приняло.
+type RuleEvaluation struct {
+	// Имя правила автоматизации (EMERGENCY_CUTOFF, HEAT_HYSTERESIS_ON, пользовательские) или служебного контура (MIST_PULSE, OVERRIDE, MAX_ON_TIME, ...)
+	// Example: HEAT_HYSTERESIS_ON
+	Rule string `json:"rule" example:"HEAT_HYSTERESIS_ON"`
+	// Реле, к которому относится правило ("*" — ко всем)
+	// Example: heat_mat
+	Relay string `json:"relay" example:"heat_mat"`
+	// Выполнилось ли условие правила
+	// Example: true
+	Matched bool `json:"matched" example:"true"`
+	// Решение правила: ON, OFF, HOLD (оставить как есть) или SKIP (правило не применялось)
+	// Example: ON
+	Decision string `json:"decision" example:"ON"`
+	// Человекочитаемое объяснение с фактическими значениями
+	// Example: "warm_temp 30.2 <= 30.5 (warm_target_min-hysteresis_temp)"
+	Detail string `json:"detail" example:"warm_temp 30.2 <= 30.5 (warm_target_min-hysteresis_temp)"`
+}
+
+// RelayAction описывает команду реле, выданную движком.
+// @Description Команда реле и результат ее выполнения.
+type RelayAction struct {
+	// ID реле
+	// Example: heat_mat
+	Relay string `json:"relay" example:"heat_mat"`
+	// Запрошенное состояние
+	// Example: true
+	State bool `json:"state" example:"true"`
+	// Запрошенная яркость диммируемого выхода, %
+	// Example: 42.5
+	Brightness *float64 `json:"brightness,omitempty" example:"42.5"`
+	// Причина (как в relay_logs)
+	// Example: AUTO_TEMP_TRIGGER
+	Reason string `json:"reason" example:"AUTO_TEMP_TRIGGER"`
+	// Выполнена ли команда (false — отклонена защитой реле или аппаратной ошибкой)
+	// Example: true
+	Applied bool `json:"applied" example:"true"`
+	// Ошибка выполнения (если команда не выполнена)
+	// Example: ""
+	Error string `json:"error,omitempty" example:""`
+	// Время команды
+	// Example: "2026-02-26T15:30:00Z"
+	At time.Time `json:"at" example:"2026-02-26T15:30:00Z"`
+}
+
+// RelayExplanation объясняет текущее состояние реле ("почему это реле включено").
+// @Description Объяснение состояния реле на основе трассировки решений движка.
+type RelayExplanation struct {
+	// ID реле
+	// Example: heat_mat
+	RelayID string `json:"relay_id" example:"heat_mat"`
+	// Текущее состояние реле
+	// Example: true
+	State bool `json:"state" example:"true"`
+	// Режим системы
+	// Example: AUTO
+	Mode string `json:"mode" example:"AUTO"`
+	// Время непрерывной работы (секунды, если реле включено и известно)
+	// Example: 320
+	OnForSec int64 `json:"on_for_sec,omitempty" example:"320"`
+	// Активное ручное переопределение (если есть)
+	Override *RelayOverride `json:"override,omitempty"`
+	// Последнее переключение реле
+	LastChange *RelayAction `json:"last_change,omitempty"`
+	// Правила, которые привели к последнему переключению
+	LastChangeRules []RuleEvaluation `json:"last_change_rules"`
+	// Оценка правил для реле в последнем цикле
+	CurrentRules []RuleEvaluation `json:"current_rules"`
+	// Итоговое объяснение
+	// Example: "Реле heat_mat ВКЛЮЧЕНО. Включено в 15:03:10 (AUTO_TEMP_TRIGGER): warm_temp 30.2 <= 30.5."
+	Summary string `json:"summary" example:"Реле heat_mat ВКЛЮЧЕНО. Включено в 15:03:10 (AUTO_TEMP_TRIGGER): warm_temp 30.2 <= 30.5."`
+}
+
+// DryRunRequest представляет запрос на "сухой прогон" кандидатной конфигурации по истории датчиков.
+// @Description Кандидатная конфигурация и интервал истории sensor_readings для симуляции (не более 7 суток).
+type DryRunRequest struct {
+	// Начало интервала (RFC3339)
+	// Example: "2026-02-25T00:00:00Z"
+	From time.Time `json:"from" binding:"required" example:"2026-02-25T00:00:00Z"`
+	// Конец интервала (RFC3339)
+	// Example: "2026-02-26T00:00:00Z"
+	To time.Time `json:"to" binding:"required" example:"2026-02-26T00:00:00Z"`
+	// Кандидатная конфигурация климата
+	Config ConfigPayload `json:"config" binding:"required"`
+}
+
+// DryRunResult представляет результат симуляции в сравнении с фактической работой реле.
+// @Description Смоделированные переключения реле, скважность и энергопотребление против фактических.
+type DryRunResult struct {
+	// Начало интервала
+	// Example: "2026-02-25T00:00:00Z"
+	From time.Time `json:"from" example:"2026-02-25T00:00:00Z"`
+	// Конец интервала
+	// Example: "2026-02-26T00:00:00Z"
+	To time.Time `json:"to" example:"2026-02-26T00:00:00Z"`
+	// Количество воспроизведенных показаний
+	// Example: 17280
+	Samples int `json:"samples" example:"17280"`
+	// Сводка по каждому реле
+	Relays []DryRunRelaySummary `json:"relays"`
+	// Смоделированные переключения в хронологическом порядке
+	Transitions []SimulatedTransition `json:"transitions"`
+}
+
+// DryRunRelaySummary сравнивает смоделированную и фактическую работу одного реле.
+// @Description Сводка симуляции по реле.
+type DryRunRelaySummary struct {
+	// Example: heat_mat
+	RelayID string `json:"relay_id" example:"heat_mat"`
+	// Количество переключений в симуляции
+	// Example: 14
+	SimulatedSwitches int `json:"simulated_switches" example:"14"`
+	// Фактическое количество переключений по relay_logs
+	// Example: 22
+	ActualSwitches int `json:"actual_switches" example:"22"`
+	// Время работы в симуляции (секунды)
+	// Example: 28800
+	SimulatedOnSec int64 `json:"simulated_on_sec" example:"28800"`
+	// Фактическое время работы (секунды)
+	// Example: 31200
+	ActualOnSec int64 `json:"actual_on_sec" example:"31200"`
+	// Скважность в симуляции (% времени во включенном состоянии)
+	// Example: 33.3
+	SimulatedDutyPct float64 `json:"simulated_duty_pct" example:"33.3"`
+	// Фактическая скважность (%)
+	// Example: 36.1
+	ActualDutyPct float64 `json:"actual_duty_pct" example:"36.1"`
+	// Оценка энергопотребления в симуляции (кВт⋅ч, по WATTAGE_MAPPING)
+	// Example: 0.36
+	SimulatedKwh float64 `json:"simulated_kwh" example:"0.36"`
+	// Фактическое энергопотребление (кВт⋅ч)
+	// Example: 0.39
+	ActualKwh float64 `json:"actual_kwh" example:"0.39"`
+}
+
+// SimulatedTransition описывает переключение реле в симуляции.
+// @Description Смоделированное переключение реле и правило, которое его вызвало.
+type SimulatedTransition struct {
+	// Example: "2026-02-25T03:15:00Z"
+	At time.Time `json:"at" example:"2026-02-25T03:15:00Z"`
+	// Example: heat_mat
+	Relay string `json:"relay" example:"heat_mat"`
+	// Example: true
+	State bool `json:"state" example:"true"`
+	// Example: HEAT_HYSTERESIS_ON
+	Rule string `json:"rule" example:"HEAT_HYSTERESIS_ON"`
+	// Example: "warm_temp 30.4 <= 30.5 (warm_target_min-hysteresis_temp)"
+	Detail string `json:"detail" example:"warm_temp 30.4 <= 30.5 (warm_target_min-hysteresis_temp)"`
+}
+
+// AutomationRule представляет правило автоматизации: набор условий (И) и действие над реле.
+// Встроенные правила (аварийное отключение, защита холодной зоны, гистерезис нагрева и тумана)
+// хранятся в той же таблице и помечены builtin.
+// @Description Правило автоматизации с приоритетом, условиями и действием.
+type AutomationRule struct {
+	// Идентификатор правила (UUID или "builtin-..." для встроенных)
+	// Example: "builtin-heat-on"
+	ID string `json:"id" example:"builtin-heat-on"`
+	// Уникальное имя правила (отображается в трассировке решений)
+	// Example: HEAT_HYSTERESIS_ON
+	Name string `json:"name" example:"HEAT_HYSTERESIS_ON"`
+	// Описание правила
+	// Example: "Включить обогрев, когда теплая зона остыла ниже нижней границы гистерезиса"
+	Description string `json:"description" example:"Включить обогрев, когда теплая зона остыла ниже нижней границы гистерезиса"`
+	// Приоритет: меньшее значение оценивается раньше. Правила безопасности всегда оцениваются до остальных.
+	// Example: 100
+	Priority int `json:"priority" example:"100"`
+	// Активно ли правило
+	// Example: true
+	IsActive bool `json:"is_active" example:"true"`
+	// Правило безопасности: работает и в режиме MANUAL, выключает реле в обход защиты от дребезга
+	// и снимает ручные переопределения. Допускает только действие "выключить".
+	// Example: false
+	Safety bool `json:"safety" example:"false"`
+	// Встроенное правило (нельзя удалить или изменить условия)
+	// Example: true
+	Builtin bool `json:"builtin" example:"true"`
+	// Условия правила (все должны выполниться)
+	Conditions []RuleCondition `json:"conditions"`
+	// Действие при выполнении условий
+	Action RuleAction `json:"action"`
+	// Example: "2026-02-26T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2026-02-26T12:00:00Z"`
+	// Example: "2026-02-26T12:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" example:"2026-02-26T12:00:00Z"`
+}
+
+// RuleCondition описывает одно условие правила.
+// Тип sensor: сравнение показания (input) с числом (value) или параметром конфигурации (ref,
+// допускается выражение вида "warm_target_min-hysteresis_temp").
+// Тип time: текущее время в окне from..to (HH:MM, окно может переходить через полночь).
+// Тип relay: реле relay находится в состоянии state не менее for_sec секунд.
+// @Description Условие правила автоматизации.
+type RuleCondition struct {
+	// Тип условия
+	// Example: sensor
+	Type string `json:"type" binding:"required" enums:"sensor,time,relay" example:"sensor"`
+	// Показание датчика — для sensor: "<датчик>.<величина>" (например basking.temperature)
+	// или показание зоны "<зона>_temp" / "<зона>_hum" (warm_temp, cold_hum, ...)
+	// Example: warm_temp
+	Input string `json:"input,omitempty" example:"warm_temp"`
+	// Оператор сравнения (<, <=, >, >=) — для sensor
+	// Example: "<="
+	Op string `json:"op,omitempty" enums:"<,<=,>,>=" example:"<="`
+	// Порог-число — для sensor (взаимоисключающе с ref)
+	// Example: 30.5
+	Value *float64 `json:"value,omitempty" example:"30.5"`
+	// Порог из конфигурации — для sensor (взаимоисключающе с value)
+	// Example: "warm_target_min-hysteresis_temp"
+	Ref string `json:"ref,omitempty" example:"warm_target_min-hysteresis_temp"`
+	// Начало окна (HH:MM) — для time
+	// Example: "08:00"
+	From string `json:"from,omitempty" example:"08:00"`
+	// Конец окна (HH:MM, не включительно) — для time
+	// Example: "20:00"
+	To string `json:"to,omitempty" example:"20:00"`
+	// ID реле — для relay
+	// Example: light
+	Relay string `json:"relay,omitempty" example:"light"`
+	// Ожидаемое состояние реле — для relay
+	// Example: true
+	State *bool `json:"state,omitempty" example:"true"`
+	// Минимальное время в этом состоянии (секунды) — для relay
+	// Example: 600
+	ForSec int `json:"for_sec,omitempty" example:"600"`
+}
+
+// RuleAction описывает действие правила.
+// @Description Действие правила: перевести реле в заданное состояние.
+type RuleAction struct {
+	// ID реле ("*" — все реле, только для правил безопасности)
+	// Example: heat_mat
+	Relay string `json:"relay" binding:"required" example:"heat_mat"`
+	// Требуемое состояние реле
+	// Example: true
+	State bool `json:"state" example:"true"`
+	// Причина для журнала relay_logs (по умолчанию RULE:<имя правила>)
+	// Example: AUTO_TEMP_TRIGGER
+	Reason string `json:"reason,omitempty" example:"AUTO_TEMP_TRIGGER"`
+}
+
+// AutomationRuleRequest представляет запрос на создание или обновление правила автоматизации.
+// Для встроенных правил учитывается только is_active (правила безопасности отключить нельзя).
+// @Description Payload для создания/обновления правила автоматизации.
+type AutomationRuleRequest struct {
+	// Example: SPARE_FAN_HOT
+	Name string `json:"name" binding:"required" example:"SPARE_FAN_HOT"`
+	// Example: "Включить вентилятор, если холодная зона перегрелась днем"
+	Description string `json:"description" example:"Включить вентилятор, если холодная зона перегрелась днем"`
+	// Example: 50
+	Priority int `json:"priority" example:"50"`
+	// Активно ли правило (по умолчанию true)
+	// Example: true
+	IsActive *bool `json:"is_active" example:"true"`
+	// Правило безопасности (только выключение реле, работает и в MANUAL)
+	// Example: false
+	Safety bool `json:"safety" example:"false"`
+	// Условия правила (от 1 до 10, все должны выполниться)
+	Conditions []RuleCondition `json:"conditions" binding:"required,min=1,max=10,dive"`
+	// Действие при выполнении условий
+	Action RuleAction `json:"action" binding:"required"`
+}
+
+// EnclosureInfo описывает террариум, обслуживаемый экземпляром ядра.
+// @Description Террариум: собственные датчики, реле, конфигурация, расписания и журналы. Маршруты террариума: /api/v1/enclosures/{id}/...
+type EnclosureInfo struct {
+	// Идентификатор террариума ("default" — террариум маршрутов /api/v1/... без префикса)
+	// Example: default
+	ID string `json:"id" example:"default"`
+	// Название террариума
+	// Example: "Королевский питон"
+	Name string `json:"name" example:"Королевский питон"`
+	// Текущий режим автоматики (AUTO / MANUAL)
+	// Example: AUTO
+	Mode string `json:"mode" example:"AUTO"`
+	// Датчики террариума
+	// Example: ["warm","cold","basking"]
+	Sensors []string `json:"sensors" example:"warm,cold,basking"`
+	// Реле террариума
+	// Example: ["fogger","heat_mat","light","spare"]
+	Relays []string `json:"relays" example:"fogger,heat_mat,light,spare"`
+	// Префикс маршрутов API террариума
+	// Example: "/api/v1/enclosures/default"
+	BasePath string `json:"base_path" example:"/api/v1/enclosures/default"`
+}
+
+// LogEntry — запись журнала сервиса из кольцевого буфера.
+// @Description Структурированная запись журнала (slog) с уровнем, компонентом и полями.
+type LogEntry struct {
+	// Порядковый номер записи с момента запуска (для продолжения хвоста: after_id)
+	// Example: 10542
+	ID uint64 `json:"id" example:"10542"`
+	// Время записи
+	// Example: "2026-02-26T15:30:00Z"
+	Time time.Time `json:"time" example:"2026-02-26T15:30:00Z"`
+	// Уровень: DEBUG, INFO, WARN, ERROR
+	// Example: WARN
+	Level string `json:"level" example:"WARN"`
+	// Компонент (engine, sensors, gpio, storage, ...)
+	// Example: sensors
+	Component string `json:"component,omitempty" example:"sensors"`
+	// Террариум, к которому относится запись
+	// Example: default
+	Enclosure string `json:"enclosure,omitempty" example:"default"`
+	// Сообщение
+	// Example: Ошибка опроса датчика
+	Message string `json:"message" example:"Ошибка опроса датчика"`
+	// Поля записи (sensor, relay, err, ...)
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// AlertRule — правило оповещения: условие, которое должно держаться for_sec секунд, чтобы алерт сработал.
+// @Description Правило оповещения над показаниями, временем и реле (condition) или доступностью датчика (sensor_offline).
+type AlertRule struct {
+	// Идентификатор правила (UUID)
+	// Example: "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+	ID string `json:"id" example:"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"`
+	// Уникальное имя правила (заголовок уведомления)
+	// Example: COLD_ZONE_TOO_COLD
+	Name string `json:"name" example:"COLD_ZONE_TOO_COLD"`
+	// Описание правила
+	// Example: "Холодная зона ниже 22 °C дольше 15 минут"
+	Description string `json:"description" example:"Холодная зона ниже 22 °C дольше 15 минут"`
+	// Тип правила: condition — условия (И) как у правил автоматизации, sensor_offline — датчик не отвечает
+	// Example: condition
+	Type string `json:"type" enums:"condition,sensor_offline" example:"condition"`
+	// Условия — для condition (все должны выполниться)
+	Conditions []RuleCondition `json:"conditions,omitempty"`
+	// ID датчика — для sensor_offline ("*" — любой датчик террариума)
+	// Example: basking
+	Sensor string `json:"sensor,omitempty" example:"basking"`
+	// Сколько секунд условие должно держаться непрерывно, прежде чем алерт сработает
+	// Example: 900
+	ForSec int `json:"for_sec" example:"900"`
+	// Важность: INFO, WARNING, CRITICAL
+	// Example: WARNING
+	Severity string `json:"severity" enums:"INFO,WARNING,CRITICAL" example:"WARNING"`
+	// Повторять уведомление, пока алерт активен и не подтвержден, каждые N минут (0 — не повторять)
+	// Example: 60
+	RepeatMin int `json:"repeat_min" example:"60"`
+	// Каналы доставки (GET /alerts/channels); пусто — все каналы
+	// Example: ["telegram"]
+	Channels []string `json:"channels" example:"telegram"`
+	// Активно ли правило
+	// Example: true
+	IsActive bool `json:"is_active" example:"true"`
+	// Example: "2026-02-26T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2026-02-26T12:00:00Z"`
+	// Example: "2026-02-26T12:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" example:"2026-02-26T12:00:00Z"`
+}
+
+// AlertRuleRequest представляет запрос на создание или обновление правила оповещения.
+// @Description Payload для создания/обновления правила оповещения.
+type AlertRuleRequest struct {
+	// Example: COLD_ZONE_TOO_COLD
+	Name string `json:"name" binding:"required" example:"COLD_ZONE_TOO_COLD"`
+	// Example: "Холодная зона ниже 22 °C дольше 15 минут"
+	Description string `json:"description" example:"Холодная зона ниже 22 °C дольше 15 минут"`
+	// Тип правила: condition или sensor_offline
+	// Example: condition
+	Type string `json:"type" binding:"required" enums:"condition,sensor_offline" example:"condition"`
+	// Условия — для condition (от 1 до 10)
+	Conditions []RuleCondition `json:"conditions" binding:"max=10,dive"`
+	// ID датчика или "*" — для sensor_offline
+	// Example: basking
+	Sensor string `json:"sensor" example:"basking"`
+	// Example: 900
+	ForSec int `json:"for_sec" example:"900"`
+	// Важность (по умолчанию WARNING)
+	// Example: WARNING
+	Severity string `json:"severity" enums:"INFO,WARNING,CRITICAL" example:"WARNING"`
+	// Example: 60
+	RepeatMin int `json:"repeat_min" example:"60"`
+	// Example: ["telegram"]
+	Channels []string `json:"channels" example:"telegram"`
+	// Активно ли правило (по умолчанию true)
+	// Example: true
+	IsActive *bool `json:"is_active" example:"true"`
+}
+
+// Alert — сработавший алерт (активный или решенный).
+// @Description Алерт: срабатывание правила оповещения с подтверждением оператором.
+type Alert struct {
+	// Идентификатор алерта (UUID)
+	// Example: "6f1d2e3c-4b5a-4978-8c6d-5e4f3a2b1c0d"
+	ID string `json:"id" example:"6f1d2e3c-4b5a-4978-8c6d-5e4f3a2b1c0d"`
+	// Правило оповещения
+	// Example: "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+	RuleID string `json:"rule_id" example:"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"`
+	// Имя правила на момент срабатывания
+	// Example: COLD_ZONE_TOO_COLD
+	RuleName string `json:"rule_name" example:"COLD_ZONE_TOO_COLD"`
+	// Важность
+	// Example: WARNING
+	Severity string `json:"severity" example:"WARNING"`
+	// Состояние: FIRING (активен) или RESOLVED (условие больше не выполняется)
+	// Example: FIRING
+	State string `json:"state" enums:"FIRING,RESOLVED" example:"FIRING"`
+	// Объяснение: выполненные условия на момент срабатывания
+	// Example: "cold_temp 21.4 < 22.0"
+	Message string `json:"message" example:"cold_temp 21.4 < 22.0"`
+	// Когда условие начало выполняться
+	// Example: "2026-02-26T14:00:00Z"
+	StartedAt time.Time `json:"started_at" example:"2026-02-26T14:00:00Z"`
+	// Когда алерт сработал (started_at + for_sec)
+	// Example: "2026-02-26T14:15:00Z"
+	FiredAt time.Time `json:"fired_at" example:"2026-02-26T14:15:00Z"`
+	// Когда алерт решен (null — активен)
+	// Example: "2026-02-26T15:02:00Z"
+	ResolvedAt *time.Time `json:"resolved_at" example:"2026-02-26T15:02:00Z"`
+	// Когда алерт подтвержден оператором (null — не подтвержден)
+	// Example: "2026-02-26T14:20:00Z"
+	AcknowledgedAt *time.Time `json:"acknowledged_at" example:"2026-02-26T14:20:00Z"`
+	// Кто подтвердил алерт
+	// Example: anna
+	AcknowledgedBy string `json:"acknowledged_by,omitempty" example:"anna"`
+}
+
+// AlertAckRequest подтверждает алерт: повторные уведомления о нем прекращаются.
+// @Description Payload подтверждения алерта.
+type AlertAckRequest struct {
+	// Кто подтверждает
+	// Example: anna
+	By string `json:"by" example:"anna"`
+}
+
+// AlertSilence — окно тишины: алерты срабатывают и сохраняются, но уведомления не рассылаются.
+// @Description Окно тишины для одного правила оповещения или для всех правил террариума.
+type AlertSilence struct {
+	// Идентификатор окна (UUID)
+	// Example: "9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"
+	ID string `json:"id" example:"9a8b7c6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"`
+	// Правило оповещения (пусто — все правила террариума)
+	// Example: "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+	RuleID string `json:"rule_id,omitempty" example:"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"`
+	// Начало окна
+	// Example: "2026-02-26T09:00:00Z"
+	StartsAt time.Time `json:"starts_at" example:"2026-02-26T09:00:00Z"`
+	// Конец окна
+	// Example: "2026-02-26T11:00:00Z"
+	EndsAt time.Time `json:"ends_at" example:"2026-02-26T11:00:00Z"`
+	// Комментарий (например, "чистка террариума")
+	// Example: "Чистка террариума"
+	Comment string `json:"comment" example:"Чистка террариума"`
+	// Example: "2026-02-26T08:55:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2026-02-26T08:55:00Z"`
+}
+
+// AlertSilenceRequest создает окно тишины.
+// @Description Payload окна тишины: ends_at или duration_min.
+type AlertSilenceRequest struct {
+	// Правило оповещения (пусто — все правила террариума)
+	// Example: "0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"
+	RuleID string `json:"rule_id" example:"0b7e3c1a-5d2f-4e8b-9a61-2c4d7f9e1b30"`
+	// Начало окна (по умолчанию — сейчас)
+	// Example: "2026-02-26T09:00:00Z"
+	StartsAt *time.Time `json:"starts_at" example:"2026-02-26T09:00:00Z"`
+	// Конец окна (взаимоисключающе с duration_min)
+	// Example: "2026-02-26T11:00:00Z"
+	EndsAt *time.Time `json:"ends_at" example:"2026-02-26T11:00:00Z"`
+	// Длительность окна в минутах от starts_at (1..10080)
+	// Example: 120
+	DurationMin int `json:"duration_min" example:"120"`
+	// Example: "Чистка террариума"
+	Comment string `json:"comment" example:"Чистка террариума"`
+}
+
+// Webhook — подписка внешней системы на события террариума.
+// @Description Исходящий webhook: URL, типы событий и секрет подписи HMAC-SHA256.
+type Webhook struct {
+	// Идентификатор подписки (UUID)
+	// Example: "3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4"
+	ID string `json:"id" example:"3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4"`
+	// URL получателя (http или https)
+	// Example: "http://192.168.0.20:9000/terrarium"
+	URL string `json:"url" example:"http://192.168.0.20:9000/terrarium"`
+	// Типы событий ("*" — все): relay.switched, mode.changed, alert.fired, alert.resolved, alert.acknowledged
+	// Example: ["relay.switched","alert.fired"]
+	Events []string `json:"events" example:"relay.switched,alert.fired"`
+	// Секрет подписи (возвращается только при создании)
+	// Example: "4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a"
+	Secret string `json:"secret,omitempty" example:"4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a"`
+	// Описание подписки
+	// Example: "Home Assistant"
+	Description string `json:"description" example:"Home Assistant"`
+	// Активна ли подписка (неактивной события не ставятся в очередь)
+	// Example: true
+	IsActive bool `json:"is_active" example:"true"`
+	// Example: "2026-02-26T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2026-02-26T12:00:00Z"`
+	// Example: "2026-02-26T12:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" example:"2026-02-26T12:00:00Z"`
+}
+
+// WebhookRequest представляет запрос на создание или обновление подписки webhook.
+// @Description Payload подписки webhook. Пустой secret: при создании генерируется, при обновлении сохраняется прежний.
+type WebhookRequest struct {
+	// Example: "http://192.168.0.20:9000/terrarium"
+	URL string `json:"url" binding:"required" example:"http://192.168.0.20:9000/terrarium"`
+	// Example: ["relay.switched","alert.fired"]
+	Events []string `json:"events" binding:"required,min=1" example:"relay.switched,alert.fired"`
+	// Example: "4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a"
+	Secret string `json:"secret" example:"4f9c0e1d7a2b3c8e5f6a9b0c1d2e3f4a"`
+	// Example: "Home Assistant"
+	Description string `json:"description" example:"Home Assistant"`
+	// Активна ли подписка (по умолчанию true)
+	// Example: true
+	IsActive *bool `json:"is_active" example:"true"`
+}
+
+// WebhookEvent — тело запроса webhook. Подпись тела передается в заголовке
+// X-Terrarium-Signature: sha256=<hex HMAC-SHA256(secret, тело)>.
+// @Description Событие, отправляемое подписчикам webhook.
+type WebhookEvent struct {
+	// Тип события
+	// Example: relay.switched
+	Type string `json:"type" example:"relay.switched"`
+	// Террариум-источник
+	// Example: default
+	Enclosure string `json:"enclosure" example:"default"`
+	// Время события
+	// Example: "2026-02-26T14:02:11Z"
+	OccurredAt time.Time `json:"occurred_at" example:"2026-02-26T14:02:11Z"`
+	// Данные события: реле (relay, state, brightness, reason), режим (mode, previous, manual_until) или алерт (models.Alert)
+	Data any `json:"data" swaggertype:"object"`
+}
+
+// WebhookDelivery — доставка события подписчику (запись журнала доставок и элемент очереди).
+// @Description Доставка webhook: состояние, число попыток и результат последней попытки.
+type WebhookDelivery struct {
+	// Идентификатор доставки (UUID, заголовок X-Terrarium-Delivery)
+	// Example: "7e6d5c4b-3a29-4180-9f8e-7d6c5b4a3928"
+	ID string `json:"id" example:"7e6d5c4b-3a29-4180-9f8e-7d6c5b4a3928"`
+	// Подписка
+	// Example: "3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4"
+	WebhookID string `json:"webhook_id" example:"3c2b1a09-8f7e-4d6c-b5a4-93827160f5e4"`
+	// Тип события
+	// Example: relay.switched
+	Event string `json:"event" example:"relay.switched"`
+	// Тело запроса (models.WebhookEvent)
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// Состояние: PENDING (в очереди), DELIVERED (доставлено), DEAD (попытки исчерпаны)
+	// Example: PENDING
+	Status string `json:"status" enums:"PENDING,DELIVERED,DEAD" example:"PENDING"`
+	// Сделано попыток
+	// Example: 2
+	Attempts int `json:"attempts" example:"2"`
+	// Время следующей попытки (для PENDING)
+	// Example: "2026-02-26T14:02:51Z"
+	NextAttemptAt time.Time `json:"next_attempt_at" example:"2026-02-26T14:02:51Z"`
+	// HTTP-код последней попытки (null — ответа не было)
+	// Example: 503
+	LastStatusCode *int `json:"last_status_code" example:"503"`
+	// Ошибка последней попытки
+	// Example: "получатель ответил 503"
+	LastError string `json:"last_error,omitempty" example:"получатель ответил 503"`
+	// Example: "2026-02-26T14:02:11Z"
+	CreatedAt time.Time `json:"created_at" example:"2026-02-26T14:02:11Z"`
+	// Когда доставлено (null — не доставлено)
+	// Example: "2026-02-26T14:02:12Z"
+	DeliveredAt *time.Time `json:"delivered_at" example:"2026-02-26T14:02:12Z"`
+}
+
+// DailyDigest — сводка работы террариума за сутки (для ежедневного письма).
+// @Description Суточная сводка: климат по зонам, работа реле, энергопотребление и события безопасности.
+type DailyDigest struct {
+	// Террариум
+	// Example: default
+	Enclosure string `json:"enclosure" example:"default"`
+	// Сутки сводки (местное время сервера)
+	// Example: "2026-02-25"
+	Date string `json:"date" example:"2026-02-25"`
+	// Начало суток
+	// Example: "2026-02-25T00:00:00+03:00"
+	From time.Time `json:"from" example:"2026-02-25T00:00:00+03:00"`
+	// Конец суток
+	// Example: "2026-02-26T00:00:00+03:00"
+	To time.Time `json:"to" example:"2026-02-26T00:00:00+03:00"`
+	// Показания по зонам и величинам
+	Zones []DigestZoneStat `json:"zones"`
+	// Работа реле
+	Relays []DigestRelay `json:"relays"`
+	// Суммарное энергопотребление (кВт⋅ч, по WATTAGE_MAPPING)
+	// Example: 0.41
+	TotalKwh float64 `json:"total_kwh" example:"0.41"`
+	// События безопасности (в хронологическом порядке)
+	Events []DigestEvent `json:"events"`
+}
+
+// DigestZoneStat — статистика одной величины в зоне за сутки (по всем датчикам зоны).
+// @Description Минимум, максимум и среднее величины в зоне.
+type DigestZoneStat struct {
+	// Example: warm
+	Zone string `json:"zone" example:"warm"`
+	// Example: temperature
+	Quantity string `json:"quantity" example:"temperature"`
+	// Example: 26.1
+	Min float64 `json:"min" example:"26.1"`
+	// Example: 31.2
+	Max float64 `json:"max" example:"31.2"`
+	// Example: 29.04
+	Avg float64 `json:"avg" example:"29.04"`
+	// Количество измерений
+	// Example: 8640
+	Samples int `json:"samples" example:"8640"`
+}
+
+// DigestRelay — работа реле за сутки по журналу relay_logs.
+// @Description Время работы, скважность, переключения и энергопотребление реле.
+type DigestRelay struct {
+	// Example: heat_mat
+	RelayID string `json:"relay_id" example:"heat_mat"`
+	// Время во включенном состоянии (секунды)
+	// Example: 22320
+	OnSec int64 `json:"on_sec" example:"22320"`
+	// Скважность (% времени суток во включенном состоянии; для текущих суток — по текущий момент)
+	// Example: 25.8
+	DutyPct float64 `json:"duty_pct" example:"25.8"`
+	// Example: 14
+	Switches int `json:"switches" example:"14"`
+	// Энергопотребление (кВт⋅ч)
+	// Example: 0.279
+	Kwh float64 `json:"kwh" example:"0.279"`
+}
+
+// DigestEvent — событие безопасности за сутки.
+// @Description Срабатывание алерта, аварийное отключение по правилу безопасности, аварийный стоп или опустошение резервуара.
+type DigestEvent struct {
+	// Example: "2026-02-25T14:02:11+03:00"
+	Time time.Time `json:"time" example:"2026-02-25T14:02:11+03:00"`
+	// Источник: alert, safety, input, reservoir
+	// Example: safety
+	Source string `json:"source" example:"safety"`
+	// Событие: имя правила оповещения, причина отключения, PANIC_STOP, EMPTY, ...
+	// Example: EMERGENCY_CUTOFF
+	Event string `json:"event" example:"EMERGENCY_CUTOFF"`
+	// Подробности: сообщение алерта, отключенные реле, вход
+	// Example: "fogger, heat_mat, light"
+	Detail string `json:"detail" example:"fogger, heat_mat, light"`
+}
@@ -0,0 +1,367 @@
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"terrarium-core/internal/events"
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+// DefaultPrefix — префикс топиков по умолчанию (MQTT_TOPIC_PREFIX)
+const DefaultPrefix = "terrarium"
+
+// Значения топиков состояния
+const (
+	StatusOnline  = "online"
+	StatusOffline = "offline"
+	PayloadOn     = "ON"
+	PayloadOff    = "OFF"
+)
+
+const (
+	// commandTimeout — предельное время выполнения одной команды из топика .../set
+	commandTimeout = 10 * time.Second
+	// commandQueue — сколько команд может ждать выполнения; лишние отбрасываются
+	commandQueue = 32
+)
+
+var logger = logging.Component("mqtt-bridge")
+
+// Controller — управление террариумом через топики команд (automation.Engine). Команды подчиняются
+// тем же правилам, что и REST API: реле переключаются только в режиме MANUAL и не включаются
+// во время аварийного отключения.
+type Controller interface {
+	Mode(ctx context.Context) (string, error)
+	SetMode(ctx context.Context, mode string, durationMin int) error
+	ToggleRelay(ctx context.Context, relayID string, state bool, brightness *float64) error
+	Emergency() bool
+}
+
+// Enclosure — террариум, публикуемый мостом.
+type Enclosure struct {
+	ID      string
+	Name    string
+	Relays  map[string]gpio.RelayController
+	Sensors []models.SensorInfo // для discovery Home Assistant
+	Control Controller
+}
+
+// Bridge публикует показания, переключения реле, режим и аварийное состояние террариумов в MQTT
+// и принимает команды из топиков .../set. Схема топиков (<p> — префикс, <enc> — ID террариума):
+//
+//	<p>/status                               online/offline (retained, offline — завещание)
+//	<p>/<enc>/sensors/<датчик>/<величина>    последнее значение (retained)
+//	<p>/<enc>/relays/<реле>/state            ON/OFF (retained), .../brightness — яркость диммера
+//	<p>/<enc>/mode/state                     AUTO/MANUAL (retained)
+//	<p>/<enc>/emergency/state                ON/OFF (retained): аварийное отключение или аварийный стоп
+//	<p>/<enc>/readings                       JSON показаний цикла
+//	<p>/<enc>/events/<relay|mode|emergency|alert|command>   JSON событий
+//	<p>/<enc>/relays/<реле>/set              команда: ON/OFF или {"state": true, "brightness": 40}
+//	<p>/<enc>/mode/set                       команда: AUTO/MANUAL или {"mode": "MANUAL", "duration_min": 30}
+type Bridge struct {
+	broker     *gpio.MQTTBroker
+	prefix     string
+	enclosures []Enclosure
+	byID       map[string]Enclosure
+	commands   chan command
+	onConnect  []func()
+}
+
+// command — сообщение из топика команд, ожидающее выполнения.
+type command struct {
+	enclosure Enclosure
+	topic     string
+	payload   []byte
+	run       func(ctx context.Context, enc Enclosure, payload []byte) error
+}
+
+// Connect подключается к брокеру rawURL отдельным клиентом с завещанием <prefix>/status = offline.
+// Недоступный брокер не считается ошибкой: подключение продолжает попытки в фоне.
+func Connect(rawURL, prefix string, enclosures []Enclosure) (*Bridge, error) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	broker, err := gpio.ConnectMQTT(rawURL, "bridge", &gpio.MQTTWill{
+		Topic:    prefix + "/status",
+		Payload:  StatusOffline,
+		QoS:      1,
+		Retained: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b := &Bridge{
+		broker:     broker,
+		prefix:     prefix,
+		enclosures: enclosures,
+		byID:       make(map[string]Enclosure, len(enclosures)),
+		commands:   make(chan command, commandQueue),
+	}
+	for _, enc := range enclosures {
+		b.byID[enc.ID] = enc
+	}
+	return b, nil
+}
+
+// Prefix возвращает префикс топиков моста.
+func (b *Bridge) Prefix() string { return b.prefix }
+
+// StatusTopic возвращает топик доступности моста (online/offline).
+func (b *Bridge) StatusTopic() string { return b.prefix + "/status" }
+
+// Topic возвращает топик террариума: <prefix>/<enclosure>/<parts...>.
+func (b *Bridge) Topic(enclosure string, parts ...string) string {
+	return strings.Join(append([]string{b.prefix, enclosure}, parts...), "/")
+}
+
+// Broker возвращает подключение моста (для дополнительных публикаций, например discovery).
+func (b *Bridge) Broker() *gpio.MQTTBroker { return b.broker }
+
+// OnConnect регистрирует обработчик, вызываемый при каждом (пере)подключении перед публикацией
+// снимка состояния. Регистрировать нужно до Start.
+func (b *Bridge) OnConnect(fn func()) { b.onConnect = append(b.onConnect, fn) }
+
+// Start подписывается на события и топики команд и работает в фоне до отмены ctx.
+// При каждом (пере)подключении публикуется online и снимок состояния всех террариумов.
+func (b *Bridge) Start(ctx context.Context) {
+	stream, cancel := events.Subscribe()
+
+	for _, enc := range b.enclosures {
+		b.listen(enc, b.Topic(enc.ID, "mode", "set"), setMode)
+		for id := range enc.Relays {
+			b.listen(enc, b.Topic(enc.ID, "relays", id, "set"), toggleRelay(id))
+		}
+	}
+	b.broker.OnConnect(func() {
+		for _, fn := range b.onConnect {
+			fn()
+		}
+		b.publishSnapshot(ctx)
+	})
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case cmd := <-b.commands:
+				b.execute(ctx, cmd)
+			}
+		}
+	}()
+
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				// Штатное отключение: завещание брокер не опубликует, поэтому offline отправляем сами
+				b.publish(b.StatusTopic(), 1, true, []byte(StatusOffline))
+				b.broker.Close()
+				logger.Info("Мост MQTT остановлен")
+				return
+			case ev := <-stream:
+				if enc, ok := b.byID[ev.Enclosure]; ok {
+					b.handleEvent(enc, ev)
+				}
+			}
+		}
+	}()
+	logger.Info("Мост MQTT запущен", "broker", b.broker.URL(), "prefix", b.prefix, "enclosures", len(b.enclosures))
+}
+
+// ==========================================
+// СОСТОЯНИЕ И СОБЫТИЯ
+// ==========================================
+
+// publishSnapshot публикует доступность и текущее состояние всех террариумов (retained).
+func (b *Bridge) publishSnapshot(ctx context.Context) {
+	b.publish(b.StatusTopic(), 1, true, []byte(StatusOnline))
+	for _, enc := range b.enclosures {
+		if mode, err := enc.Control.Mode(ctx); err == nil {
+			b.publish(b.Topic(enc.ID, "mode", "state"), 1, true, []byte(mode))
+		} else {
+			logger.Warn("Не удалось прочитать режим для публикации", logging.KeyEnclosure, enc.ID, logging.Err(err))
+		}
+		b.publish(b.Topic(enc.ID, "emergency", "state"), 1, true, onOff(enc.Control.Emergency()))
+		for id := range enc.Relays {
+			b.publishRelay(enc, id)
+		}
+	}
+}
+
+// publishRelay публикует фактическое состояние реле (и яркость диммера).
+func (b *Bridge) publishRelay(enc Enclosure, relayID string) {
+	relay, ok := enc.Relays[relayID]
+	if !ok {
+		return
+	}
+	b.publish(b.Topic(enc.ID, "relays", relayID, "state"), 1, true, onOff(relay.IsOn()))
+	if dimmer, ok := gpio.AsDimmer(relay); ok {
+		b.publish(b.Topic(enc.ID, "relays", relayID, "brightness"), 1, true, []byte(formatValue(dimmer.Brightness())))
+	}
+}
+
+// eventMessage — JSON события в топиках .../events/*.
+type eventMessage struct {
+	Type      string    `json:"type"`
+	Enclosure string    `json:"enclosure"`
+	Time      time.Time `json:"time"`
+	Data      any       `json:"data"`
+}
+
+// handleEvent публикует событие террариума в топики состояния и событий.
+func (b *Bridge) handleEvent(enc Enclosure, ev events.Event) {
+	switch data := ev.Data.(type) {
+	case []models.SensorReading:
+		values := make(map[string]map[string]float64)
+		for _, r := range data {
+			b.publish(b.Topic(enc.ID, "sensors", r.SensorID, r.Quantity), 0, true, []byte(formatValue(r.Value)))
+			if values[r.SensorID] == nil {
+				values[r.SensorID] = make(map[string]float64)
+			}
+			values[r.SensorID][r.Quantity] = r.Value
+		}
+		b.publishJSON(b.Topic(enc.ID, "readings"), 0, map[string]any{"time": ev.Time, "sensors": values})
+		return
+	case events.RelayChange:
+		b.publishRelay(enc, data.Relay)
+		b.publishJSON(b.Topic(enc.ID, "events", "relay"), 1, eventMessage{ev.Type, enc.ID, ev.Time, data})
+	case events.ModeChange:
+		b.publish(b.Topic(enc.ID, "mode", "state"), 1, true, []byte(data.Mode))
+		b.publishJSON(b.Topic(enc.ID, "events", "mode"), 1, eventMessage{ev.Type, enc.ID, ev.Time, data})
+	case events.Emergency:
+		b.publish(b.Topic(enc.ID, "emergency", "state"), 1, true, onOff(data.Active))
+		b.publishJSON(b.Topic(enc.ID, "events", "emergency"), 1, eventMessage{ev.Type, enc.ID, ev.Time, data})
+	default:
+		if strings.HasPrefix(ev.Type, "alert.") {
+			b.publishJSON(b.Topic(enc.ID, "events", "alert"), 1, eventMessage{ev.Type, enc.ID, ev.Time, data})
+		}
+	}
+}
+
+func (b *Bridge) publishJSON(topic string, qos byte, v any) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		logger.Error("Ошибка сериализации сообщения", "topic", topic, logging.Err(err))
+		return
+	}
+	b.publish(topic, qos, false, payload)
+}
+
+// publish публикует сообщение; без связи с брокером сообщение пропускается —
+// состояние будет опубликовано заново после переподключения.
+func (b *Bridge) publish(topic string, qos byte, retained bool, payload []byte) {
+	if err := b.broker.Publish(topic, qos, retained, payload); err != nil {
+		logger.Debug("Сообщение не опубликовано", "topic", topic, logging.Err(err))
+	}
+}
+
+// ==========================================
+// КОМАНДЫ
+// ==========================================
+
+// listen подписывается на топик команд. Команды выполняются по очереди в отдельной горутине:
+// обработчик подписки не должен блокировать клиента MQTT.
+func (b *Bridge) listen(enc Enclosure, topic string, run func(ctx context.Context, enc Enclosure, payload []byte) error) {
+	b.broker.Subscribe(topic, 1, func(payload []byte) {
+		select {
+		case b.commands <- command{enclosure: enc, topic: topic, payload: payload, run: run}:
+		default:
+			logger.Warn("Очередь команд переполнена, команда отброшена", logging.KeyEnclosure, enc.ID, "topic", topic)
+		}
+	})
+}
+
+// commandResult — JSON результата команды в топике .../events/command.
+type commandResult struct {
+	Topic   string    `json:"topic"`
+	Payload string    `json:"payload"`
+	OK      bool      `json:"ok"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func (b *Bridge) execute(ctx context.Context, cmd command) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	err := cmd.run(ctx, cmd.enclosure, cmd.payload)
+	result := commandResult{Topic: cmd.topic, Payload: string(cmd.payload), OK: err == nil, Time: time.Now()}
+	if err != nil {
+		result.Error = err.Error()
+		logger.Warn("Команда MQTT отклонена", logging.KeyEnclosure, cmd.enclosure.ID, "topic", cmd.topic, "payload", string(cmd.payload), logging.Err(err))
+		// Возвращаем фактическое состояние: клиенты с оптимистичным интерфейсом (Home Assistant) откатят переключатель
+		b.publishSnapshotOf(ctx, cmd.enclosure)
+	} else {
+		logger.Info("Команда MQTT выполнена", logging.KeyEnclosure, cmd.enclosure.ID, "topic", cmd.topic, "payload", string(cmd.payload))
+	}
+	b.publishJSON(b.Topic(cmd.enclosure.ID, "events", "command"), 1, result)
+}
+
+// publishSnapshotOf публикует режим и состояние реле одного террариума.
+func (b *Bridge) publishSnapshotOf(ctx context.Context, enc Enclosure) {
+	if mode, err := enc.Control.Mode(ctx); err == nil {
+		b.publish(b.Topic(enc.ID, "mode", "state"), 1, true, []byte(mode))
+	}
+	for id := range enc.Relays {
+		b.publishRelay(enc, id)
+	}
+}
+
+// setMode выполняет команду mode/set: AUTO, MANUAL или {"mode": "MANUAL", "duration_min": 30}.
+func setMode(ctx context.Context, enc Enclosure, payload []byte) error {
+	var req models.ModeRequest
+	text := strings.TrimSpace(string(payload))
+	if strings.HasPrefix(text, "{") {
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return fmt.Errorf("неверный JSON команды: %w", err)
+		}
+	} else {
+		req.Mode = text
+	}
+	return enc.Control.SetMode(ctx, strings.ToUpper(req.Mode), req.DurationMin)
+}
+
+// toggleRelay возвращает обработчик команды relays/<реле>/set: ON/OFF или {"state": true, "brightness": 40}.
+func toggleRelay(relayID string) func(ctx context.Context, enc Enclosure, payload []byte) error {
+	return func(ctx context.Context, enc Enclosure, payload []byte) error {
+		var req models.RelayToggleRequest
+		text := strings.TrimSpace(string(payload))
+		switch {
+		case strings.HasPrefix(text, "{"):
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return fmt.Errorf("неверный JSON команды: %w", err)
+			}
+		case strings.EqualFold(text, PayloadOn):
+			req.State = true
+		case strings.EqualFold(text, PayloadOff):
+			req.State = false
+		default:
+			return fmt.Errorf("ожидается ON, OFF или JSON {\"state\": ..., \"brightness\": ...}, получено %q", text)
+		}
+		if req.Brightness != nil && (*req.Brightness <= 0 || *req.Brightness > 100) {
+			return fmt.Errorf("яркость должна быть больше 0 и не больше 100")
+		}
+		return enc.Control.ToggleRelay(ctx, relayID, req.State, req.Brightness)
+	}
+}
+
+func onOff(on bool) []byte {
+	if on {
+		return []byte(PayloadOn)
+	}
+	return []byte(PayloadOff)
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
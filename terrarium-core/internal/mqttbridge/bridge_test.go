@@ -0,0 +1,368 @@
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+
+	"terrarium-core/internal/events"
+	"terrarium-core/internal/gpio"
+)
+
+// fakeController запоминает последнюю команду движку.
+type fakeController struct {
+	mode        string
+	durationMin int
+	relay       string
+	state       bool
+	brightness  *float64
+	calls       int
+	err         error
+}
+
+func (f *fakeController) Mode(context.Context) (string, error) { return f.mode, nil }
+
+func (f *fakeController) SetMode(_ context.Context, mode string, durationMin int) error {
+	f.calls++
+	f.mode, f.durationMin = mode, durationMin
+	return f.err
+}
+
+func (f *fakeController) ToggleRelay(_ context.Context, relayID string, state bool, brightness *float64) error {
+	f.calls++
+	f.relay, f.state, f.brightness = relayID, state, brightness
+	return f.err
+}
+
+func (f *fakeController) Emergency() bool { return false }
+
+func TestSetModePayload(t *testing.T) {
+	tests := []struct {
+		payload  string
+		mode     string
+		duration int
+	}{
+		{"AUTO", "AUTO", 0},
+		{" manual\n", "MANUAL", 0},
+		{`{"mode": "MANUAL", "duration_min": 30}`, "MANUAL", 30},
+		{`{"mode": "auto"}`, "AUTO", 0},
+	}
+	for _, tt := range tests {
+		ctrl := &fakeController{}
+		if err := setMode(context.Background(), Enclosure{ID: "default", Control: ctrl}, []byte(tt.payload)); err != nil {
+			t.Errorf("setMode(%q): %v", tt.payload, err)
+			continue
+		}
+		if ctrl.mode != tt.mode || ctrl.durationMin != tt.duration {
+			t.Errorf("setMode(%q) -> %s/%d, want %s/%d", tt.payload, ctrl.mode, ctrl.durationMin, tt.mode, tt.duration)
+		}
+	}
+}
+
+func TestSetModeRejected(t *testing.T) {
+	ctrl := &fakeController{}
+	enc := Enclosure{ID: "default", Control: ctrl}
+	if err := setMode(context.Background(), enc, []byte(`{"mode": `)); err == nil {
+		t.Error("ожидалась ошибка неверного JSON")
+	}
+	if ctrl.calls != 0 {
+		t.Error("неверный JSON не должен доходить до движка")
+	}
+
+	// Ошибка движка (неизвестный режим) возвращается мосту для публикации в events/command
+	ctrl.err = errors.New("режим должен быть AUTO или MANUAL")
+	if err := setMode(context.Background(), enc, []byte("SLEEP")); !errors.Is(err, ctrl.err) {
+		t.Errorf("setMode(SLEEP) = %v, want ошибку движка", err)
+	}
+}
+
+func TestToggleRelayPayload(t *testing.T) {
+	tests := []struct {
+		payload    string
+		state      bool
+		brightness float64 // 0 — яркость не задана
+	}{
+		{"ON", true, 0},
+		{"off", false, 0},
+		{" On ", true, 0},
+		{`{"state": true}`, true, 0},
+		{`{"state": true, "brightness": 40}`, true, 40},
+		{`{"state": false, "brightness": 100}`, false, 100},
+	}
+	for _, tt := range tests {
+		ctrl := &fakeController{}
+		run := toggleRelay("light")
+		if err := run(context.Background(), Enclosure{ID: "default", Control: ctrl}, []byte(tt.payload)); err != nil {
+			t.Errorf("toggleRelay(%q): %v", tt.payload, err)
+			continue
+		}
+		if ctrl.relay != "light" || ctrl.state != tt.state {
+			t.Errorf("toggleRelay(%q) -> %s=%v, want light=%v", tt.payload, ctrl.relay, ctrl.state, tt.state)
+		}
+		switch {
+		case tt.brightness == 0 && ctrl.brightness != nil:
+			t.Errorf("toggleRelay(%q): яркость %v, want не задана", tt.payload, *ctrl.brightness)
+		case tt.brightness != 0 && (ctrl.brightness == nil || *ctrl.brightness != tt.brightness):
+			t.Errorf("toggleRelay(%q): яркость %v, want %v", tt.payload, ctrl.brightness, tt.brightness)
+		}
+	}
+}
+
+func TestToggleRelayRejected(t *testing.T) {
+	for _, payload := range []string{
+		"",
+		"1",
+		"TOGGLE",
+		`{"state": `,
+		`{"state": true, "brightness": 0}`,
+		`{"state": true, "brightness": -5}`,
+		`{"state": true, "brightness": 100.5}`,
+	} {
+		ctrl := &fakeController{}
+		if err := toggleRelay("light")(context.Background(), Enclosure{ID: "default", Control: ctrl}, []byte(payload)); err == nil {
+			t.Errorf("toggleRelay(%q): ожидалась ошибка", payload)
+		}
+		if ctrl.calls != 0 {
+			t.Errorf("toggleRelay(%q): неверная команда не должна доходить до движка", payload)
+		}
+	}
+}
+
+// startTestBroker запускает встроенный MQTT-брокер на addr ("" — свободный порт) и возвращает его адрес
+// и функцию остановки (вызывается и при завершении теста).
+func startTestBroker(t *testing.T, addr string) (*mochi.Server, string, func()) {
+	t.Helper()
+	if addr == "" {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("свободный порт: %v", err)
+		}
+		addr = l.Addr().String()
+		l.Close()
+	}
+
+	srv := mochi.New(&mochi.Options{InlineClient: true, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	_ = srv.AddHook(new(auth.AllowHook), nil)
+	if err := srv.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: addr})); err != nil {
+		t.Fatalf("listener: %v", err)
+	}
+	if err := srv.Serve(); err != nil {
+		t.Fatalf("брокер: %v", err)
+	}
+	stop := sync.OnceFunc(func() { _ = srv.Close() })
+	t.Cleanup(stop)
+	return srv, addr, stop
+}
+
+// engineController повторяет правила движка для команд: реле переключаются только в MANUAL
+// и не включаются во время аварийного отключения. Переключение публикуется в шину событий.
+type engineController struct {
+	enclosure string
+	relays    map[string]gpio.RelayController
+
+	mu        sync.Mutex
+	mode      string
+	emergency bool
+}
+
+func (c *engineController) Mode(context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mode, nil
+}
+
+func (c *engineController) SetMode(_ context.Context, mode string, _ int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mode = mode
+	return nil
+}
+
+func (c *engineController) ToggleRelay(_ context.Context, relayID string, state bool, _ *float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.mode != "MANUAL":
+		return errors.New("ручное переключение разрешено только в режиме MANUAL")
+	case state && c.emergency:
+		return errors.New("активно аварийное отключение: включение реле запрещено")
+	}
+	relay := c.relays[relayID]
+	if state {
+		_ = relay.On()
+	} else {
+		_ = relay.Off()
+	}
+	events.Publish(events.Event{Type: events.RelaySwitched, Enclosure: c.enclosure, Time: time.Now(), Data: events.RelayChange{Relay: relayID, State: state}})
+	return nil
+}
+
+func (c *engineController) Emergency() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.emergency
+}
+
+// commandResults подписывается на результаты команд моста.
+func commandResults(t *testing.T, srv *mochi.Server, topic string) <-chan commandResult {
+	t.Helper()
+	results := make(chan commandResult, 16)
+	err := srv.Subscribe(topic, 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		var res commandResult
+		if err := json.Unmarshal(pk.Payload, &res); err != nil {
+			t.Errorf("результат команды %s: %v", pk.Payload, err)
+			return
+		}
+		results <- res
+	})
+	if err != nil {
+		t.Fatalf("подписка на результаты: %v", err)
+	}
+	return results
+}
+
+// waitRetained ждет, пока в топике не окажется retained-сообщение want.
+func waitRetained(t *testing.T, srv *mochi.Server, topic, want string) {
+	t.Helper()
+	var got []string
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		got = got[:0]
+		for _, pk := range srv.Topics.Messages(topic) {
+			got = append(got, string(pk.Payload))
+		}
+		if len(got) == 1 && got[0] == want {
+			return
+		}
+	}
+	t.Fatalf("retained %s = %v, want %s", topic, got, want)
+}
+
+// sendCommand публикует команду и возвращает ответ моста из events/command.
+func sendCommand(t *testing.T, srv *mochi.Server, results <-chan commandResult, topic, payload string) commandResult {
+	t.Helper()
+	if err := srv.Publish(topic, []byte(payload), false, 1); err != nil {
+		t.Fatalf("публикация %s: %v", topic, err)
+	}
+	select {
+	case res := <-results:
+		if res.Topic != topic || res.Payload != payload {
+			t.Fatalf("результат относится к %s %q, want %s %q", res.Topic, res.Payload, topic, payload)
+		}
+		return res
+	case <-time.After(10 * time.Second):
+		t.Fatalf("нет результата команды %s %q", topic, payload)
+		return commandResult{}
+	}
+}
+
+func TestBridgeWithBroker(t *testing.T) {
+	srv, addr, stop := startTestBroker(t, "")
+	light := gpio.NewMockRelay("light")
+	ctrl := &engineController{enclosure: "main", relays: map[string]gpio.RelayController{"light": light}, mode: "AUTO"}
+
+	b, err := Connect("mqtt://"+addr, "/home/terra/", []Enclosure{{ID: "main", Relays: ctrl.relays, Control: ctrl}})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b.Start(ctx)
+
+	// Снимок состояния при подключении — retained-топики под настроенным префиксом
+	waitRetained(t, srv, "home/terra/status", StatusOnline)
+	waitRetained(t, srv, "home/terra/main/mode/state", "AUTO")
+	waitRetained(t, srv, "home/terra/main/emergency/state", PayloadOff)
+	waitRetained(t, srv, "home/terra/main/relays/light/state", PayloadOff)
+
+	results := commandResults(t, srv, "home/terra/main/events/command")
+	states := make(chan string, 16)
+	_ = srv.Subscribe("home/terra/main/relays/light/state", 2, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		states <- string(pk.Payload)
+	})
+	<-states // retained-состояние при подписке
+
+	// В AUTO команда отклоняется, реле не переключается, фактическое состояние публикуется заново
+	if res := sendCommand(t, srv, results, "home/terra/main/relays/light/set", "ON"); res.OK || res.Error == "" {
+		t.Errorf("команда в AUTO: %+v, want отказ", res)
+	}
+	if light.IsOn() {
+		t.Error("команда в AUTO не должна включать реле")
+	}
+	select {
+	case state := <-states:
+		if state != PayloadOff {
+			t.Errorf("состояние после отказа = %s, want %s", state, PayloadOff)
+		}
+	case <-time.After(10 * time.Second):
+		t.Error("состояние реле не опубликовано после отказа")
+	}
+
+	// Во время аварийного отключения включение запрещено и в MANUAL
+	if res := sendCommand(t, srv, results, "home/terra/main/mode/set", "manual"); !res.OK {
+		t.Fatalf("mode/set MANUAL: %+v", res)
+	}
+	ctrl.mu.Lock()
+	ctrl.emergency = true
+	ctrl.mu.Unlock()
+	if res := sendCommand(t, srv, results, "home/terra/main/relays/light/set", `{"state": true}`); res.OK {
+		t.Errorf("команда во время аварии: %+v, want отказ", res)
+	}
+	if light.IsOn() {
+		t.Error("команда во время аварии не должна включать реле")
+	}
+
+	// После сброса аварии команда выполняется, а переключение попадает в state и events/relay
+	ctrl.mu.Lock()
+	ctrl.emergency = false
+	ctrl.mu.Unlock()
+	relayEvents := make(chan []byte, 4)
+	_ = srv.Subscribe("home/terra/main/events/relay", 3, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		relayEvents <- pk.Payload
+	})
+	if res := sendCommand(t, srv, results, "home/terra/main/relays/light/set", "ON"); !res.OK {
+		t.Fatalf("команда в MANUAL: %+v", res)
+	}
+	waitRetained(t, srv, "home/terra/main/relays/light/state", PayloadOn)
+	select {
+	case payload := <-relayEvents:
+		var msg struct {
+			Type      string             `json:"type"`
+			Enclosure string             `json:"enclosure"`
+			Data      events.RelayChange `json:"data"`
+		}
+		if err := json.Unmarshal(payload, &msg); err != nil || msg.Type != events.RelaySwitched || msg.Enclosure != "main" || !msg.Data.State {
+			t.Errorf("events/relay = %s (%v)", payload, err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Error("нет события в events/relay")
+	}
+
+	// Брокер перезапущен без сохраненных сообщений: мост переподключается, публикует снимок заново
+	// и восстанавливает подписки на команды
+	stop()
+	srv, _, _ = startTestBroker(t, addr)
+	waitRetained(t, srv, "home/terra/status", StatusOnline)
+	waitRetained(t, srv, "home/terra/main/relays/light/state", PayloadOn)
+	results = commandResults(t, srv, "home/terra/main/events/command")
+	if res := sendCommand(t, srv, results, "home/terra/main/relays/light/set", "OFF"); !res.OK {
+		t.Fatalf("команда после переподключения: %+v", res)
+	}
+	if light.IsOn() {
+		t.Error("команда после переподключения не выключила реле")
+	}
+
+	// Штатная остановка моста публикует offline
+	cancel()
+	waitRetained(t, srv, "home/terra/status", StatusOffline)
+}
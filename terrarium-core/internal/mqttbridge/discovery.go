@@ -0,0 +1,189 @@
+package mqttbridge
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"terrarium-core/internal/gpio"
+	"terrarium-core/internal/logging"
+)
+
+// DefaultDiscoveryPrefix — префикс discovery Home Assistant по умолчанию (MQTT_HA_DISCOVERY_PREFIX)
+const DefaultDiscoveryPrefix = "homeassistant"
+
+// haStatusOnline — сообщение, которое Home Assistant публикует в <discovery>/status после запуска
+const haStatusOnline = "online"
+
+// Классы устройств и единицы измерения величин датчиков в Home Assistant
+var haSensorClasses = map[string]struct{ class, unit string }{
+	gpio.QuantityTemperature: {"temperature", "°C"},
+	gpio.QuantityHumidity:    {"humidity", "%"},
+	gpio.QuantityPressure:    {"atmospheric_pressure", "hPa"},
+}
+
+// Названия величин в именах сущностей
+var haQuantityNames = map[string]string{
+	gpio.QuantityTemperature: "температура",
+	gpio.QuantityHumidity:    "влажность",
+	gpio.QuantityPressure:    "давление",
+}
+
+// Названия стандартных реле в именах сущностей (прочие называются по ID)
+var haRelayNames = map[string]string{
+	"heat_mat": "Термоковрик",
+	"fogger":   "Фоггер",
+	"light":    "Освещение",
+	"spare":    "Резервное реле",
+}
+
+// haObjectID — допустимые символы node_id/object_id в топиках discovery
+var haObjectID = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// haDevice — устройство Home Assistant (одно на террариум).
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+}
+
+// haAvailability — топик доступности сущности (завещание моста).
+type haAvailability struct {
+	Topic               string `json:"topic"`
+	PayloadAvailable    string `json:"payload_available"`
+	PayloadNotAvailable string `json:"payload_not_available"`
+}
+
+// haEntity — конфигурация сущности MQTT discovery. Поля, не нужные платформе, не передаются.
+type haEntity struct {
+	Name              string           `json:"name"`
+	UniqueID          string           `json:"unique_id"`
+	Device            haDevice         `json:"device"`
+	Availability      []haAvailability `json:"availability"`
+	StateTopic        string           `json:"state_topic"`
+	CommandTopic      string           `json:"command_topic,omitempty"`
+	DeviceClass       string           `json:"device_class,omitempty"`
+	StateClass        string           `json:"state_class,omitempty"`
+	UnitOfMeasurement string           `json:"unit_of_measurement,omitempty"`
+	PayloadOn         string           `json:"payload_on,omitempty"`
+	PayloadOff        string           `json:"payload_off,omitempty"`
+	StateOn           string           `json:"state_on,omitempty"`
+	StateOff          string           `json:"state_off,omitempty"`
+	Options           []string         `json:"options,omitempty"`
+	Icon              string           `json:"icon,omitempty"`
+}
+
+// haConfig — сообщение discovery: топик конфигурации и сущность.
+type haConfig struct {
+	Topic  string
+	Entity haEntity
+}
+
+// EnableDiscovery включает публикацию конфигураций MQTT discovery Home Assistant с префиксом prefix:
+// датчики (sensor), реле (switch), режим (select) и аварийное отключение (binary_sensor) каждого
+// террариума как отдельного устройства. Доступность сущностей привязана к <prefix моста>/status.
+// Конфигурации публикуются при каждом подключении и после перезапуска Home Assistant
+// (сообщение online в <prefix>/status). Вызывать до Start.
+func (b *Bridge) EnableDiscovery(prefix, swVersion string) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		prefix = DefaultDiscoveryPrefix
+	}
+	b.OnConnect(func() { b.publishDiscovery(prefix, swVersion) })
+	b.broker.Subscribe(prefix+"/status", 1, func(payload []byte) {
+		if string(payload) == haStatusOnline {
+			// Обработчик подписки не должен ждать подтверждений брокера
+			go b.publishDiscovery(prefix, swVersion)
+		}
+	})
+	logger.Info("Discovery Home Assistant включен", "prefix", prefix)
+}
+
+// publishDiscovery публикует конфигурации сущностей всех террариумов (retained).
+func (b *Bridge) publishDiscovery(prefix, swVersion string) {
+	for _, enc := range b.enclosures {
+		for _, cfg := range b.discoveryConfigs(prefix, swVersion, enc) {
+			payload, err := json.Marshal(cfg.Entity)
+			if err != nil {
+				logger.Error("Ошибка сериализации конфигурации discovery", "topic", cfg.Topic, logging.Err(err))
+				continue
+			}
+			b.publish(cfg.Topic, 1, true, payload)
+		}
+	}
+}
+
+// discoveryConfigs собирает конфигурации сущностей террариума.
+func (b *Bridge) discoveryConfigs(prefix, swVersion string, enc Enclosure) []haConfig {
+	node := haObjectID.ReplaceAllString(b.prefix+"_"+enc.ID, "_")
+	name := enc.Name
+	if name == "" {
+		name = enc.ID
+	}
+	device := haDevice{
+		Identifiers:  []string{node},
+		Name:         name,
+		Manufacturer: "Terrarium Climate",
+		Model:        "terrarium-core",
+		SWVersion:    swVersion,
+	}
+	availability := []haAvailability{{Topic: b.StatusTopic(), PayloadAvailable: StatusOnline, PayloadNotAvailable: StatusOffline}}
+	entity := func(component, object, entityName string) haConfig {
+		object = haObjectID.ReplaceAllString(object, "_")
+		return haConfig{
+			Topic: strings.Join([]string{prefix, component, node, object, "config"}, "/"),
+			Entity: haEntity{
+				Name:         entityName,
+				UniqueID:     node + "_" + object,
+				Device:       device,
+				Availability: availability,
+			},
+		}
+	}
+
+	var configs []haConfig
+	for _, s := range enc.Sensors {
+		sensorName := s.Name
+		if sensorName == "" {
+			sensorName = s.ID
+		}
+		for _, q := range s.Quantities {
+			cfg := entity("sensor", s.ID+"_"+q, sensorName+" — "+cmp.Or(haQuantityNames[q], q))
+			cfg.Entity.StateTopic = b.Topic(enc.ID, "sensors", s.ID, q)
+			cfg.Entity.StateClass = "measurement"
+			if class, ok := haSensorClasses[q]; ok {
+				cfg.Entity.DeviceClass, cfg.Entity.UnitOfMeasurement = class.class, class.unit
+			}
+			configs = append(configs, cfg)
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(enc.Relays)) {
+		cfg := entity("switch", "relay_"+id, cmp.Or(haRelayNames[id], id))
+		cfg.Entity.StateTopic = b.Topic(enc.ID, "relays", id, "state")
+		cfg.Entity.CommandTopic = b.Topic(enc.ID, "relays", id, "set")
+		cfg.Entity.PayloadOn, cfg.Entity.PayloadOff = PayloadOn, PayloadOff
+		cfg.Entity.StateOn, cfg.Entity.StateOff = PayloadOn, PayloadOff
+		configs = append(configs, cfg)
+	}
+
+	mode := entity("select", "mode", "Режим")
+	mode.Entity.StateTopic = b.Topic(enc.ID, "mode", "state")
+	mode.Entity.CommandTopic = b.Topic(enc.ID, "mode", "set")
+	mode.Entity.Options = []string{"AUTO", "MANUAL"}
+	mode.Entity.Icon = "mdi:hand-back-right"
+	configs = append(configs, mode)
+
+	emergency := entity("binary_sensor", "emergency", "Аварийное отключение")
+	emergency.Entity.StateTopic = b.Topic(enc.ID, "emergency", "state")
+	emergency.Entity.PayloadOn, emergency.Entity.PayloadOff = PayloadOn, PayloadOff
+	emergency.Entity.DeviceClass = "problem"
+	configs = append(configs, emergency)
+
+	return configs
+}
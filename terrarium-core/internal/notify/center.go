@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	"terrarium-core/internal/logging"
+)
+
+// queueSize — сколько уведомлений может ждать доставки в одном канале; лишние отбрасываются
+const queueSize = 64
+
+// Center рассылает уведомления по именованным каналам доставки (log, telegram, ...).
+// Каналы регистрируются при старте; уведомление с пустым Channels уходит во все каналы.
+// У каждого канала своя очередь и горутина доставки: Notify не ждет сети, а медленный или
+// недоступный канал (таймаут Telegram, SMTP) не задерживает вызывающего и остальные каналы.
+type Center struct {
+	mu       sync.RWMutex
+	channels map[string]*channel
+}
+
+// channel — канал доставки с очередью уведомлений.
+type channel struct {
+	notifier Notifier
+	queue    chan delivery
+}
+
+// delivery — уведомление в очереди канала.
+type delivery struct {
+	ctx context.Context
+	n   Notification
+}
+
+// NewCenter создает центр уведомлений без каналов.
+func NewCenter() *Center {
+	return &Center{channels: make(map[string]*channel)}
+}
+
+// Register добавляет (или заменяет) канал доставки и запускает его горутину доставки.
+// Очередь замененного канала дорабатывает уже принятые уведомления.
+func (c *Center) Register(name string, n Notifier) {
+	ch := &channel{notifier: n, queue: make(chan delivery, queueSize)}
+	go ch.run(name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.channels[name]; ok {
+		close(old.queue)
+	}
+	c.channels[name] = ch
+}
+
+// run доставляет уведомления из очереди канала до ее закрытия.
+func (ch *channel) run(name string) {
+	for d := range ch.queue {
+		if err := ch.notifier.Notify(d.ctx, d.n); err != nil {
+			alertLog.Error("Ошибка доставки уведомления", "channel", name, logging.KeyEnclosure, d.n.Enclosure, logging.Err(err))
+		}
+	}
+}
+
+// Channels возвращает имена зарегистрированных каналов (по алфавиту).
+func (c *Center) Channels() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.channels))
+	for name := range c.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Notify ставит уведомление в очереди выбранных каналов и сразу возвращается; неизвестные каналы
+// пропускаются. Ошибки доставки пишутся в журнал, ошибка возвращается только при переполнении очереди.
+func (c *Center) Notify(ctx context.Context, n Notification) error {
+	// Доставка переживает отмену контекста вызывающего (например, завершенный HTTP-запрос)
+	ctx = context.WithoutCancel(ctx)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var errs []error
+	for name, ch := range c.channels {
+		if len(n.Channels) > 0 && !slices.Contains(n.Channels, name) {
+			continue
+		}
+		select {
+		case ch.queue <- delivery{ctx: ctx, n: n}:
+		default:
+			alertLog.Error("Очередь канала уведомлений переполнена, уведомление отброшено", "channel", name, logging.KeyEnclosure, n.Enclosure, "title", n.Title)
+			errs = append(errs, fmt.Errorf("канал %s: очередь переполнена", name))
+		}
+	}
+	return errors.Join(errs...)
+}
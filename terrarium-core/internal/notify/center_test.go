@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingNotifier имитирует недоступный канал: доставка ждет, пока тест не отпустит release.
+type blockingNotifier struct {
+	release   chan struct{}
+	delivered chan Notification
+}
+
+func (b *blockingNotifier) Notify(_ context.Context, n Notification) error {
+	<-b.release
+	b.delivered <- n
+	return nil
+}
+
+type recordNotifier chan Notification
+
+func (r recordNotifier) Notify(_ context.Context, n Notification) error {
+	r <- n
+	return nil
+}
+
+func TestCenterNotifyDoesNotWaitForSlowChannel(t *testing.T) {
+	slow := &blockingNotifier{release: make(chan struct{}), delivered: make(chan Notification, 1)}
+	fast := make(recordNotifier, 1)
+	c := NewCenter()
+	c.Register("slow", slow)
+	c.Register("fast", fast)
+
+	start := time.Now()
+	if err := c.Notify(context.Background(), Notification{Title: "перегрев"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("Notify ждал доставки %v", d)
+	}
+
+	select {
+	case n := <-fast:
+		if n.Title != "перегрев" {
+			t.Fatalf("fast получил %q", n.Title)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("быстрый канал не получил уведомление, пока медленный занят")
+	}
+
+	close(slow.release)
+	select {
+	case <-slow.delivered:
+	case <-time.After(time.Second):
+		t.Fatal("медленный канал не доставил уведомление")
+	}
+}
+
+func TestCenterNotifySelectedChannels(t *testing.T) {
+	a, b := make(recordNotifier, 1), make(recordNotifier, 1)
+	c := NewCenter()
+	c.Register("a", a)
+	c.Register("b", b)
+
+	if err := c.Notify(context.Background(), Notification{Title: "x", Channels: []string{"b", "unknown"}}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	select {
+	case <-b:
+	case <-time.After(time.Second):
+		t.Fatal("канал b не получил уведомление")
+	}
+	select {
+	case <-a:
+		t.Fatal("канал a не выбран, но получил уведомление")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCenterNotifyQueueOverflow(t *testing.T) {
+	slow := &blockingNotifier{release: make(chan struct{}), delivered: make(chan Notification, queueSize+1)}
+	c := NewCenter()
+	c.Register("slow", slow)
+	defer close(slow.release)
+
+	// Одно уведомление занимает горутину доставки, queueSize ждут в очереди
+	var err error
+	for i := 0; i < queueSize+2 && err == nil; i++ {
+		err = c.Notify(context.Background(), Notification{Title: "x"})
+	}
+	if err == nil {
+		t.Fatal("ожидалась ошибка переполнения очереди")
+	}
+}
@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Режимы шифрования SMTP (SMTP_TLS)
+const (
+	// SMTPStartTLS — STARTTLS, если сервер его поддерживает (по умолчанию)
+	SMTPStartTLS = "starttls"
+	// SMTPTLS — TLS с момента подключения (обычно порт 465)
+	SMTPTLS = "tls"
+	// SMTPNone — без шифрования (локальные тестовые серверы вроде MailHog)
+	SMTPNone = "none"
+)
+
+// smtpTimeout — предельное время одной отправки письма
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig — параметры SMTP-сервера и получателей (SMTP_HOST, SMTP_PORT, ...).
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // пусто — без авторизации
+	Password string
+	From     string
+	To       []string
+	TLS      string // starttls | tls | none
+}
+
+// Email отправляет уведомления письмами через SMTP.
+type Email struct {
+	cfg SMTPConfig
+}
+
+// NewEmail создает канал email. Неизвестный режим TLS считается starttls, пустой отправитель — первым получателем.
+func NewEmail(cfg SMTPConfig) *Email {
+	if cfg.TLS != SMTPTLS && cfg.TLS != SMTPNone {
+		cfg.TLS = SMTPStartTLS
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.TLS == SMTPTLS {
+			cfg.Port = 465
+		}
+	}
+	if cfg.From == "" && len(cfg.To) > 0 {
+		cfg.From = cfg.To[0]
+	}
+	return &Email{cfg: cfg}
+}
+
+func (m *Email) Notify(ctx context.Context, n Notification) error {
+	subject := fmt.Sprintf("[%s] %s", n.Level, n.Title)
+	if n.Enclosure != "" {
+		subject += " [" + n.Enclosure + "]"
+	}
+
+	var body strings.Builder
+	body.WriteString(n.Title + "\n")
+	if n.Message != "" {
+		body.WriteString("\n" + n.Message + "\n")
+	}
+	fmt.Fprintf(&body, "\nТеррариум: %s\nИсточник: %s\nВремя: %s\n", n.Enclosure, n.Source, n.Time.Local().Format("02.01.2006 15:04:05"))
+	if n.Alert != nil && n.Alert.ID != "" {
+		fmt.Fprintf(&body, "Алерт: %s\n", n.Alert.ID)
+	}
+	return m.Send(ctx, subject, body.String())
+}
+
+// Send отправляет текстовое письмо всем получателям.
+func (m *Email) Send(ctx context.Context, subject, body string) error {
+	msg, err := m.message(subject, body)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("подключение к SMTP %s: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	if m.cfg.TLS == SMTPTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.cfg.Host})
+	}
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if m.cfg.TLS == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+				return fmt.Errorf("SMTP STARTTLS: %w", err)
+			}
+		}
+	}
+	if m.cfg.Username != "" {
+		// PlainAuth отказывается передавать пароль без TLS (кроме localhost)
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP авторизация: %w", err)
+		}
+	}
+	if err := c.Mail(m.cfg.From); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM: %w", err)
+	}
+	for _, to := range m.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	return c.Quit()
+}
+
+// message собирает письмо: заголовки (тема в кодировке RFC 2047) и тело в quoted-printable.
+func (m *Email) message(subject, body string) ([]byte, error) {
+	var msg bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&msg, "%s: %s\r\n", k, v) }
+	header("From", m.cfg.From)
+	header("To", strings.Join(m.cfg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	msg.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,203 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession — то, что получил тестовый SMTP-сервер за одну сессию.
+type smtpSession struct {
+	commands []string
+	data     string
+}
+
+// startSMTPStub запускает минимальный SMTP-сервер на 127.0.0.1 для одной сессии.
+// rejectRcpt — отвечать 550 на RCPT TO.
+func startSMTPStub(t *testing.T, rejectRcpt bool) (port int, result <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	out := make(chan smtpSession, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var s smtpSession
+		defer func() { out <- s }()
+
+		_ = tp.PrintfLine("220 stub ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			s.commands = append(s.commands, line)
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch cmd {
+			case "EHLO":
+				_ = tp.PrintfLine("250-stub")
+				_ = tp.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				_ = tp.PrintfLine("235 2.7.0 Authentication successful")
+			case "MAIL":
+				_ = tp.PrintfLine("250 OK")
+			case "RCPT":
+				if rejectRcpt {
+					_ = tp.PrintfLine("550 5.1.1 No such user")
+					continue
+				}
+				_ = tp.PrintfLine("250 OK")
+			case "DATA":
+				_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := io.ReadAll(tp.DotReader())
+				if err != nil {
+					return
+				}
+				s.data = string(data)
+				_ = tp.PrintfLine("250 OK queued")
+			case "QUIT":
+				_ = tp.PrintfLine("221 Bye")
+				return
+			default:
+				_ = tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, out
+}
+
+func waitSession(t *testing.T, ch <-chan smtpSession) smtpSession {
+	t.Helper()
+	select {
+	case s := <-ch:
+		return s
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP-сервер не завершил сессию")
+		return smtpSession{}
+	}
+}
+
+func TestEmailSend(t *testing.T) {
+	port, result := startSMTPStub(t, false)
+	m := NewEmail(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     port,
+		Username: "core",
+		Password: "secret",
+		From:     "core@terrarium.local",
+		To:       []string{"keeper@example.com", "vet@example.com"},
+		TLS:      SMTPNone,
+	})
+
+	body := "Сводка за сутки\nТемпература: 31.5°C"
+	if err := m.Send(context.Background(), "Террариум: сводка", body); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	s := waitSession(t, result)
+
+	wantAuth := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00core\x00secret"))
+	for _, want := range []string{wantAuth, "MAIL FROM:<core@terrarium.local>", "RCPT TO:<keeper@example.com>", "RCPT TO:<vet@example.com>", "QUIT"} {
+		if !containsLine(s.commands, want) {
+			t.Errorf("нет команды %q в %q", want, s.commands)
+		}
+	}
+
+	msg, err := textprotoMessage(s.data)
+	if err != nil {
+		t.Fatalf("разбор письма: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.header.Get("Subject"))
+	if err != nil || subject != "Террариум: сводка" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	if got := msg.header.Get("To"); got != "keeper@example.com, vet@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if got := msg.header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q", got)
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(msg.body)))
+	if err != nil {
+		t.Fatalf("quoted-printable: %v", err)
+	}
+	// DotReader завершает данные переводом строки
+	if got := strings.TrimSuffix(strings.ReplaceAll(string(decoded), "\r\n", "\n"), "\n"); got != body {
+		t.Errorf("тело письма = %q, want %q", got, body)
+	}
+}
+
+func TestEmailSendRejectedRecipient(t *testing.T) {
+	port, result := startSMTPStub(t, true)
+	m := NewEmail(SMTPConfig{Host: "127.0.0.1", Port: port, To: []string{"nobody@example.com"}, TLS: SMTPNone})
+
+	err := m.Send(context.Background(), "x", "y")
+	if err == nil || !strings.Contains(err.Error(), "RCPT TO nobody@example.com") {
+		t.Fatalf("Send: ожидалась ошибка RCPT TO, получено %v", err)
+	}
+	waitSession(t, result)
+}
+
+func TestEmailSendUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	m := NewEmail(SMTPConfig{Host: "127.0.0.1", Port: port, To: []string{"keeper@example.com"}, TLS: SMTPNone})
+	if err := m.Send(context.Background(), "x", "y"); err == nil || !strings.Contains(err.Error(), "подключение к SMTP") {
+		t.Fatalf("Send: ожидалась ошибка подключения, получено %v", err)
+	}
+}
+
+func TestNewEmailDefaults(t *testing.T) {
+	m := NewEmail(SMTPConfig{Host: "smtp.example.com", To: []string{"keeper@example.com"}, TLS: "bogus"})
+	if m.cfg.TLS != SMTPStartTLS || m.cfg.Port != 587 || m.cfg.From != "keeper@example.com" {
+		t.Errorf("cfg = %+v", m.cfg)
+	}
+	if m := NewEmail(SMTPConfig{TLS: SMTPTLS}); m.cfg.Port != 465 {
+		t.Errorf("порт tls = %d, want 465", m.cfg.Port)
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
+
+// parsedMessage — заголовки и тело письма.
+type parsedMessage struct {
+	header textproto.MIMEHeader
+	body   string
+}
+
+func textprotoMessage(data string) (parsedMessage, error) {
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(data)))
+	h, err := r.ReadMIMEHeader()
+	if err != nil {
+		return parsedMessage{}, err
+	}
+	body, err := io.ReadAll(r.R)
+	return parsedMessage{header: h, body: string(body)}, err
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+// Уровни важности уведомлений
+const (
+	LevelInfo     = "INFO"
+	LevelWarning  = "WARNING"
+	LevelCritical = "CRITICAL"
+)
+
+// Notification — одно уведомление для оператора (авария, неисправность, смена режима).
+type Notification struct {
+	Level     string
+	Source    string // подсистема-источник: "diagnostics", "engine", "alerts", ...
+	Title     string
+	Message   string
+	Time      time.Time
+	Enclosure string        // террариум-источник (заполняет движок)
+	Channels  []string      // каналы доставки (пусто — все каналы Center)
+	Alert     *models.Alert // алерт, если уведомление о нем (nil — системное уведомление)
+}
+
+// Notifier описывает канал доставки уведомлений (лог, Telegram, email, ...).
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier пишет уведомления в системный лог. Используется по умолчанию.
+type LogNotifier struct{}
+
+// alertLog — журнал уведомлений
+var alertLog = logging.Component("alerts")
+
+// logLevels — уровень записи журнала для уровня уведомления
+var logLevels = map[string]slog.Level{
+	LevelInfo:     slog.LevelInfo,
+	LevelWarning:  slog.LevelWarn,
+	LevelCritical: slog.LevelError,
+}
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	alertLog.Log(ctx, logLevels[n.Level], n.Title, logging.KeyEnclosure, n.Enclosure,
+		"source", n.Source, "alert_level", n.Level, "message", n.Message)
+	return nil
+}
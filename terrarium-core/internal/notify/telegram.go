@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// telegramAPI — адрес Bot API Telegram
+const telegramAPI = "https://api.telegram.org"
+
+// levelIcons — значок уровня в тексте сообщения Telegram
+var levelIcons = map[string]string{
+	LevelInfo:     "ℹ️",
+	LevelWarning:  "⚠️",
+	LevelCritical: "🚨",
+}
+
+// Telegram отправляет уведомления в чат через Bot API (TELEGRAM_TOKEN, TELEGRAM_CHAT_ID).
+type Telegram struct {
+	token  string
+	chatID string
+	client *http.Client
+}
+
+// NewTelegram создает канал Telegram для бота token и чата chatID.
+func NewTelegram(token, chatID string) *Telegram {
+	return &Telegram{token: token, chatID: chatID, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (t *Telegram) Notify(ctx context.Context, n Notification) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s %s", levelIcons[n.Level], n.Title)
+	if n.Enclosure != "" {
+		fmt.Fprintf(&text, " [%s]", n.Enclosure)
+	}
+	if n.Message != "" {
+		text.WriteString("\n" + n.Message)
+	}
+
+	form := url.Values{"chat_id": {t.chatID}, "text": {text.String()}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPI+"/bot"+t.token+"/sendMessage", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// Ошибка содержит URL с токеном бота
+		return fmt.Errorf("запрос к Telegram Bot API не выполнен: %w", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Description string `json:"description"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("telegram ответил %d: %s", resp.StatusCode, body.Description)
+	}
+	return nil
+}
+
+// unwrapURLError убирает URL запроса из ошибки *url.Error.
+func unwrapURLError(err error) error {
+	if ue, ok := err.(*url.Error); ok {
+		return ue.Err
+	}
+	return err
+}
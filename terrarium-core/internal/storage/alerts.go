@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"terrarium-core/internal/metrics"
+	"terrarium-core/internal/models"
+)
+
+// Состояния алертов
+const (
+	AlertFiring   = "FIRING"
+	AlertResolved = "RESOLVED"
+)
+
+const alertRuleColumns = `id, name, description, type, conditions, sensor, for_sec, severity, repeat_min, channels, is_active, created_at, updated_at`
+
+const alertColumns = `id, rule_id, rule_name, severity, state, message, started_at, fired_at, resolved_at, acknowledged_at, acknowledged_by`
+
+// ==========================================
+// ПРАВИЛА ОПОВЕЩЕНИЯ
+// ==========================================
+
+// GetAlertRules возвращает все правила оповещения террариума (по имени).
+func (r *Repository) GetAlertRules(ctx context.Context) ([]models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE enclosure_id = $1 ORDER BY name`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки правил оповещения: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *rule)
+	}
+	return result, rows.Err()
+}
+
+// GetAlertRule возвращает правило оповещения по ID (ErrNotFound, если его нет).
+func (r *Repository) GetAlertRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	query := `SELECT ` + alertRuleColumns + ` FROM alert_rules WHERE id = $1 AND enclosure_id = $2`
+	rule, err := scanAlertRule(r.db.Pool.QueryRow(ctx, query, id, r.enclosure))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("правило оповещения с id=%s: %w", id, ErrNotFound)
+	}
+	return rule, err
+}
+
+// CreateAlertRule создает правило оповещения и возвращает созданную запись.
+func (r *Repository) CreateAlertRule(ctx context.Context, req models.AlertRuleRequest) (*models.AlertRule, error) {
+	conditions, err := json.Marshal(req.Conditions)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации условий правила оповещения: %w", err)
+	}
+
+	query := `
+		INSERT INTO alert_rules (name, description, type, conditions, sensor, for_sec, severity, repeat_min, channels, is_active, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + alertRuleColumns
+	rule, err := scanAlertRule(r.db.Pool.QueryRow(ctx, query,
+		req.Name, req.Description, req.Type, conditions, req.Sensor, req.ForSec, req.Severity, req.RepeatMin,
+		alertChannels(req.Channels), alertRuleActive(req), r.enclosure))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания правила оповещения: %w", uniqueViolation(err))
+	}
+	return rule, nil
+}
+
+// UpdateAlertRule полностью обновляет правило оповещения по ID.
+func (r *Repository) UpdateAlertRule(ctx context.Context, id string, req models.AlertRuleRequest) error {
+	conditions, err := json.Marshal(req.Conditions)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации условий правила оповещения: %w", err)
+	}
+
+	query := `
+		UPDATE alert_rules
+		SET name = $1, description = $2, type = $3, conditions = $4, sensor = $5, for_sec = $6,
+		    severity = $7, repeat_min = $8, channels = $9, is_active = $10, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $11 AND enclosure_id = $12
+	`
+	ct, err := r.exec(ctx, query, req.Name, req.Description, req.Type, conditions, req.Sensor, req.ForSec,
+		req.Severity, req.RepeatMin, alertChannels(req.Channels), alertRuleActive(req), id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления правила оповещения: %w", uniqueViolation(err))
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("правило оповещения с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteAlertRule удаляет правило оповещения по ID. История алертов правила сохраняется.
+func (r *Repository) DeleteAlertRule(ctx context.Context, id string) error {
+	ct, err := r.exec(ctx, `DELETE FROM alert_rules WHERE id = $1 AND enclosure_id = $2`, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления правила оповещения: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("правило оповещения с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func scanAlertRule(row pgx.Row) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	var conditions []byte
+	if err := row.Scan(&rule.ID, &rule.Name, &rule.Description, &rule.Type, &conditions, &rule.Sensor,
+		&rule.ForSec, &rule.Severity, &rule.RepeatMin, &rule.Channels, &rule.IsActive,
+		&rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка чтения правила оповещения: %w", err)
+	}
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("ошибка разбора условий правила оповещения %s: %w", rule.Name, err)
+	}
+	return &rule, nil
+}
+
+func alertRuleActive(req models.AlertRuleRequest) bool {
+	if req.IsActive != nil {
+		return *req.IsActive
+	}
+	return true
+}
+
+// alertChannels заменяет nil пустым списком (колонка channels NOT NULL).
+func alertChannels(channels []string) []string {
+	if channels == nil {
+		return []string{}
+	}
+	return channels
+}
+
+// ==========================================
+// АЛЕРТЫ
+// ==========================================
+
+// InsertAlert записывает сработавший алерт, оповещает подписчиков webhook и возвращает ID алерта.
+func (r *Repository) InsertAlert(ctx context.Context, a models.Alert) (string, error) {
+	query := `
+		INSERT INTO alerts (rule_id, rule_name, severity, state, message, started_at, fired_at, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	var id string
+	if err := r.db.Pool.QueryRow(ctx, query, a.RuleID, a.RuleName, a.Severity, AlertFiring,
+		a.Message, a.StartedAt, a.FiredAt, r.enclosure).Scan(&id); err != nil {
+		return "", fmt.Errorf("ошибка записи алерта: %w", err)
+	}
+	a.ID, a.State = id, AlertFiring
+	r.enqueueEvent(ctx, EventAlertFired, a)
+	return id, nil
+}
+
+// ResolveAlert переводит активный алерт в RESOLVED и оповещает подписчиков webhook.
+func (r *Repository) ResolveAlert(ctx context.Context, id string, at time.Time) error {
+	query := `
+		UPDATE alerts SET state = $1, resolved_at = $2
+		WHERE id::text = $3 AND enclosure_id = $4 AND state = $5
+		RETURNING ` + alertColumns
+	a, err := scanAlert(r.db.Pool.QueryRow(ctx, query, AlertResolved, at, id, r.enclosure, AlertFiring))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // алерт уже решен
+	}
+	if err != nil {
+		metrics.DBWriteFailed(r.enclosure, "alerts")
+		return fmt.Errorf("ошибка обновления алерта: %w", err)
+	}
+	r.enqueueEvent(ctx, EventAlertResolved, a)
+	return nil
+}
+
+// AcknowledgeAlert подтверждает алерт. Повторное подтверждение сохраняет первое время и автора
+// и не оповещает подписчиков webhook повторно.
+func (r *Repository) AcknowledgeAlert(ctx context.Context, id, by string, at time.Time) (*models.Alert, error) {
+	query := `
+		WITH prev AS (SELECT acknowledged_at FROM alerts WHERE id::text = $3 AND enclosure_id = $4)
+		UPDATE alerts
+		SET acknowledged_at = COALESCE(acknowledged_at, $1),
+		    acknowledged_by = CASE WHEN acknowledged_at IS NULL THEN $2 ELSE acknowledged_by END
+		WHERE id::text = $3 AND enclosure_id = $4
+		RETURNING ` + alertColumns + `, (SELECT acknowledged_at IS NULL FROM prev)`
+	var a models.Alert
+	var first bool
+	err := r.db.Pool.QueryRow(ctx, query, at, by, id, r.enclosure).Scan(&a.ID, &a.RuleID, &a.RuleName, &a.Severity,
+		&a.State, &a.Message, &a.StartedAt, &a.FiredAt, &a.ResolvedAt, &a.AcknowledgedAt, &a.AcknowledgedBy, &first)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("алерт с id=%s: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения алерта: %w", err)
+	}
+	if first {
+		r.enqueueEvent(ctx, EventAlertAcknowledged, &a)
+	}
+	return &a, nil
+}
+
+// GetAlerts возвращает алерты, начиная с последних. state — FIRING, RESOLVED или пусто (все).
+func (r *Repository) GetAlerts(ctx context.Context, state string, limit int) ([]models.Alert, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts
+		WHERE enclosure_id = $1 AND ($2 = '' OR state = $2)
+		ORDER BY fired_at DESC
+		LIMIT $3
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure, state, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки алертов: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.Alert
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *a)
+	}
+	return result, rows.Err()
+}
+
+func scanAlert(row pgx.Row) (*models.Alert, error) {
+	var a models.Alert
+	if err := row.Scan(&a.ID, &a.RuleID, &a.RuleName, &a.Severity, &a.State, &a.Message, &a.StartedAt,
+		&a.FiredAt, &a.ResolvedAt, &a.AcknowledgedAt, &a.AcknowledgedBy); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка чтения алерта: %w", err)
+	}
+	return &a, nil
+}
+
+// ==========================================
+// ОКНА ТИШИНЫ
+// ==========================================
+
+// GetAlertSilences возвращает окна тишины, которые еще не закончились к моменту at (по началу окна).
+func (r *Repository) GetAlertSilences(ctx context.Context, at time.Time) ([]models.AlertSilence, error) {
+	query := `
+		SELECT id, rule_id, starts_at, ends_at, comment, created_at
+		FROM alert_silences
+		WHERE enclosure_id = $1 AND ends_at > $2
+		ORDER BY starts_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure, at)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки окон тишины: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.AlertSilence
+	for rows.Next() {
+		var s models.AlertSilence
+		if err := rows.Scan(&s.ID, &s.RuleID, &s.StartsAt, &s.EndsAt, &s.Comment, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения окна тишины: %w", err)
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// CreateAlertSilence создает окно тишины и возвращает созданную запись.
+func (r *Repository) CreateAlertSilence(ctx context.Context, s models.AlertSilence) (*models.AlertSilence, error) {
+	query := `
+		INSERT INTO alert_silences (rule_id, starts_at, ends_at, comment, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	if err := r.db.Pool.QueryRow(ctx, query, s.RuleID, s.StartsAt, s.EndsAt, s.Comment, r.enclosure).
+		Scan(&s.ID, &s.CreatedAt); err != nil {
+		return nil, fmt.Errorf("ошибка создания окна тишины: %w", err)
+	}
+	return &s, nil
+}
+
+// DeleteAlertSilence удаляет окно тишины по ID.
+func (r *Repository) DeleteAlertSilence(ctx context.Context, id string) error {
+	ct, err := r.exec(ctx, `DELETE FROM alert_silences WHERE id::text = $1 AND enclosure_id = $2`, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления окна тишины: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("окно тишины с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
@@ -3,13 +3,18 @@ package storage
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"time"
 
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// logger — журнал хранилища
+var logger = logging.Component("storage")
+
 // DB содержит пул соединений к PostgreSQL
 type DB struct {
 	Pool *pgxpool.Pool
@@ -53,7 +58,7 @@ func Connect(ctx context.Context) (*DB, error) {
 	config.MaxConnLifetime = time.Hour
 	config.MaxConnIdleTime = 30 * time.Minute
 
-	log.Printf("Подключение к PostgreSQL на %s:%s...", host, port)
+	logger.Info("Подключение к PostgreSQL...", "host", host, "port", port)
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -65,15 +70,33 @@ func Connect(ctx context.Context) (*DB, error) {
 		return nil, fmt.Errorf("база данных не отвечает на ping: %w", err)
 	}
 
-	log.Println("Успешное подключение к PostgreSQL!")
+	logger.Info("Успешное подключение к PostgreSQL")
 
 	return &DB{Pool: pool}, nil
 }
 
+// Status проверяет, что база отвечает, и возвращает задержку ping и загрузку пула соединений.
+func (db *DB) Status(ctx context.Context) (*models.StorageStatus, error) {
+	start := time.Now()
+	if err := db.Pool.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("база данных не отвечает на ping: %w", err)
+	}
+	latency := time.Since(start)
+
+	stat := db.Pool.Stat()
+	return &models.StorageStatus{
+		LatencyMs:     float64(latency.Microseconds()) / 1000,
+		AcquiredConns: stat.AcquiredConns(),
+		IdleConns:     stat.IdleConns(),
+		TotalConns:    stat.TotalConns(),
+		MaxConns:      stat.MaxConns(),
+	}, nil
+}
+
 // Close закрывает все открытые соединения с базой
 func (db *DB) Close() {
 	if db.Pool != nil {
 		db.Pool.Close()
-		log.Println("Соединение с PostgreSQL закрыто.")
+		logger.Info("Соединение с PostgreSQL закрыто")
 	}
 }
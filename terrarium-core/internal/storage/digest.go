@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"terrarium-core/internal/models"
+)
+
+// GetZoneStats возвращает минимум, максимум и среднее каждой величины по зонам за интервал [from, to).
+// Показания датчиков одной зоны объединяются.
+func (r *Repository) GetZoneStats(ctx context.Context, from, to time.Time) ([]models.DigestZoneStat, error) {
+	query := `
+		SELECT s.zone, r.quantity, MIN(r.value), MAX(r.value), ROUND(AVG(r.value), 2), COUNT(*)
+		FROM sensor_readings r
+		JOIN sensors s ON s.enclosure_id = r.enclosure_id AND s.id = r.sensor_id
+		WHERE r.enclosure_id = $1 AND r.recorded_at >= $2 AND r.recorded_at < $3
+		GROUP BY s.zone, r.quantity
+		ORDER BY s.zone, r.quantity
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки статистики по зонам: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.DigestZoneStat
+	for rows.Next() {
+		var s models.DigestZoneStat
+		if err := rows.Scan(&s.Zone, &s.Quantity, &s.Min, &s.Max, &s.Avg, &s.Samples); err != nil {
+			return nil, fmt.Errorf("ошибка чтения статистики по зонам: %w", err)
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// GetSafetyEvents возвращает события безопасности за интервал [from, to) в хронологическом порядке:
+// сработавшие алерты, отключения реле с причинами safetyReasons (одно событие на отключение всех реле),
+// аварийные стопы и опустошение резервуара.
+func (r *Repository) GetSafetyEvents(ctx context.Context, from, to time.Time, safetyReasons []string) ([]models.DigestEvent, error) {
+	query := `
+		SELECT fired_at, 'alert', rule_name, message
+		FROM alerts
+		WHERE enclosure_id = $1 AND fired_at >= $2 AND fired_at < $3
+		UNION ALL
+		SELECT date_trunc('second', recorded_at), 'safety', reason, string_agg(DISTINCT relay_id, ', ')
+		FROM relay_logs
+		WHERE enclosure_id = $1 AND recorded_at >= $2 AND recorded_at < $3 AND NOT state AND reason = ANY($4)
+		GROUP BY date_trunc('second', recorded_at), reason
+		UNION ALL
+		SELECT occurred_at, 'input', event, input_id
+		FROM input_events
+		WHERE enclosure_id = $1 AND occurred_at >= $2 AND occurred_at < $3 AND event = 'PANIC_STOP'
+		UNION ALL
+		SELECT occurred_at, 'reservoir', event, ''
+		FROM reservoir_events
+		WHERE enclosure_id = $1 AND occurred_at >= $2 AND occurred_at < $3 AND event = 'EMPTY'
+		ORDER BY 1
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure, from, to, safetyReasons)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки событий безопасности: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.DigestEvent
+	for rows.Next() {
+		var e models.DigestEvent
+		if err := rows.Scan(&e.Time, &e.Source, &e.Event, &e.Detail); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события безопасности: %w", err)
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// EnsureEnclosure регистрирует террариум репозитория и создает для него строки настроек
+// климата и тумана по умолчанию, если их еще нет. Вызывается при старте ядра.
+func (r *Repository) EnsureEnclosure(ctx context.Context, name string) error {
+	_, err := r.exec(ctx, `
+		INSERT INTO enclosures (id, name) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name
+	`, r.enclosure, name)
+	if err != nil {
+		return fmt.Errorf("ошибка регистрации террариума %s: %w", r.enclosure, err)
+	}
+
+	for _, table := range []string{"automation_settings", "mist_settings"} {
+		query := `INSERT INTO ` + table + ` (enclosure_id) VALUES ($1) ON CONFLICT (enclosure_id) DO NOTHING`
+		if _, err := r.exec(ctx, query, r.enclosure); err != nil {
+			return fmt.Errorf("ошибка создания настроек %s для террариума %s: %w", table, r.enclosure, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"terrarium-core/internal/models"
+)
+
+// InsertReservoirEvent записывает опустошение (EMPTY) или пополнение (REFILL) резервуара фоггера.
+// emptySec — сколько резервуар простоял пустым (только для REFILL, иначе nil).
+func (r *Repository) InsertReservoirEvent(ctx context.Context, event string, emptySec *int, at time.Time) error {
+	query := `
+		INSERT INTO reservoir_events (event, empty_sec, occurred_at, enclosure_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := r.exec(ctx, query, event, emptySec, at, r.enclosure); err != nil {
+		return fmt.Errorf("ошибка записи события резервуара: %w", err)
+	}
+	return nil
+}
+
+// GetReservoirEvents возвращает журнал резервуара фоггера, начиная с последних событий.
+func (r *Repository) GetReservoirEvents(ctx context.Context, limit int) ([]models.ReservoirEvent, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, event, empty_sec, occurred_at
+		FROM reservoir_events
+		WHERE enclosure_id = $2
+		ORDER BY occurred_at DESC
+		LIMIT $1
+	`
+	rows, err := r.db.Pool.Query(ctx, query, limit, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки событий резервуара: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.ReservoirEvent
+	for rows.Next() {
+		var ev models.ReservoirEvent
+		if err := rows.Scan(&ev.ID, &ev.Event, &ev.EmptySec, &ev.OccurredAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события резервуара: %w", err)
+		}
+		result = append(result, ev)
+	}
+	return result, nil
+}
+
+// GetLastReservoirEvents возвращает время последнего события каждого типа (EMPTY, REFILL).
+func (r *Repository) GetLastReservoirEvents(ctx context.Context) (map[string]time.Time, error) {
+	query := `
+		SELECT event, MAX(occurred_at)
+		FROM reservoir_events
+		WHERE enclosure_id = $1
+		GROUP BY event
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки событий резервуара: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]time.Time)
+	for rows.Next() {
+		var event string
+		var at time.Time
+		if err := rows.Scan(&event, &at); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события резервуара: %w", err)
+		}
+		result[event] = at
+	}
+	return result, nil
+}
+
+// InsertInputEvent записывает событие цифрового входа (крышка, аварийный стоп).
+func (r *Repository) InsertInputEvent(ctx context.Context, inputID, event string, at time.Time) error {
+	query := `
+		INSERT INTO input_events (input_id, event, occurred_at, enclosure_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := r.exec(ctx, query, inputID, event, at, r.enclosure); err != nil {
+		return fmt.Errorf("ошибка записи события входа: %w", err)
+	}
+	return nil
+}
+
+// GetInputEvents возвращает журнал цифровых входов, начиная с последних событий.
+func (r *Repository) GetInputEvents(ctx context.Context, limit int) ([]models.InputEvent, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, input_id, event, occurred_at
+		FROM input_events
+		WHERE enclosure_id = $2
+		ORDER BY occurred_at DESC
+		LIMIT $1
+	`
+	rows, err := r.db.Pool.Query(ctx, query, limit, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки событий входов: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.InputEvent
+	for rows.Next() {
+		var ev models.InputEvent
+		if err := rows.Scan(&ev.ID, &ev.InputID, &ev.Event, &ev.OccurredAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события входа: %w", err)
+		}
+		result = append(result, ev)
+	}
+	return result, nil
+}
+
+// SetPanicStop сохраняет время включения аварийного стопа (nil — стоп сброшен),
+// чтобы стоп пережил перезапуск сервиса.
+func (r *Repository) SetPanicStop(ctx context.Context, since *time.Time) error {
+	query := `
+		UPDATE automation_settings SET panic_stop_since = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE enclosure_id = $2
+	`
+	if _, err := r.exec(ctx, query, since, r.enclosure); err != nil {
+		return fmt.Errorf("ошибка сохранения аварийного стопа: %w", err)
+	}
+	return nil
+}
+
+// GetPanicStop возвращает время включения аварийного стопа (nil — стоп не активен).
+func (r *Repository) GetPanicStop(ctx context.Context) (*time.Time, error) {
+	var since *time.Time
+	err := r.db.Pool.QueryRow(ctx, `SELECT panic_stop_since FROM automation_settings WHERE enclosure_id = $1`, r.enclosure).Scan(&since)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil // настроек террариума еще нет
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения аварийного стопа: %w", err)
+	}
+	return since, nil
+}
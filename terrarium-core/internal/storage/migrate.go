@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+)
+
+// schema — схема БД. Тот же файл монтируется в docker-entrypoint-initdb.d контейнера PostgreSQL,
+// но скрипты инициализации выполняются только на пустом томе данных.
+//
+//go:embed schema.sql
+var schema string
+
+// Migrate применяет схему к базе: создает недостающие таблицы, столбцы и индексы
+// (в т.ч. привязку к террариуму в базах, созданных до поддержки нескольких террариумов).
+// Схема идемпотентна и выполняется одной транзакцией при каждом запуске до NewRepository.
+func (db *DB) Migrate(ctx context.Context) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала миграции схемы: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, schema); err != nil {
+		return fmt.Errorf("ошибка применения схемы БД: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка фиксации миграции схемы: %w", err)
+	}
+	logger.Info("Схема БД применена")
+	return nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"terrarium-core/internal/models"
+)
+
+// GetMistSettings возвращает настройки импульсного режима фоггера.
+func (r *Repository) GetMistSettings(ctx context.Context) (*models.MistSettings, error) {
+	query := `
+		SELECT enabled, pulse_sec, cooldown_sec, daily_max_min
+		FROM mist_settings
+		WHERE enclosure_id = $1
+	`
+	var s models.MistSettings
+	err := r.db.Pool.QueryRow(ctx, query, r.enclosure).Scan(&s.Enabled, &s.PulseSec, &s.CooldownSec, &s.DailyMaxMin)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения настроек тумана из БД: %w", err)
+	}
+	return &s, nil
+}
+
+// UpdateMistSettings обновляет настройки импульсного режима фоггера.
+func (r *Repository) UpdateMistSettings(ctx context.Context, s models.MistSettings) error {
+	query := `
+		UPDATE mist_settings
+		SET enabled = $1, pulse_sec = $2, cooldown_sec = $3, daily_max_min = $4,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE enclosure_id = $5
+	`
+	_, err := r.exec(ctx, query, s.Enabled, s.PulseSec, s.CooldownSec, s.DailyMaxMin, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления настроек тумана: %w", err)
+	}
+	return nil
+}
+
+// GetRainEvents возвращает все запланированные события дождя, отсортированные по времени суток.
+func (r *Repository) GetRainEvents(ctx context.Context) ([]models.RainEvent, error) {
+	query := `
+		SELECT id, start_time, duration_sec, is_active, created_at
+		FROM rain_events
+		WHERE enclosure_id = $1
+		ORDER BY start_time
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки событий дождя: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.RainEvent
+	for rows.Next() {
+		var ev models.RainEvent
+		var startTime time.Time
+		if err := rows.Scan(&ev.ID, &startTime, &ev.DurationSec, &ev.IsActive, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события дождя: %w", err)
+		}
+		ev.StartTime = startTime.Format("15:04")
+		result = append(result, ev)
+	}
+	return result, nil
+}
+
+// CreateRainEvent создаёт новое событие дождя и возвращает созданную запись.
+func (r *Repository) CreateRainEvent(ctx context.Context, req models.RainEventRequest) (*models.RainEvent, error) {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	query := `
+		INSERT INTO rain_events (start_time, duration_sec, is_active, enclosure_id)
+		VALUES ($1::time, $2, $3, $4)
+		RETURNING id, start_time, duration_sec, is_active, created_at
+	`
+	var ev models.RainEvent
+	var startTime time.Time
+	err := r.db.Pool.QueryRow(ctx, query, req.StartTime, req.DurationSec, isActive, r.enclosure).
+		Scan(&ev.ID, &startTime, &ev.DurationSec, &ev.IsActive, &ev.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания события дождя: %w", err)
+	}
+	ev.StartTime = startTime.Format("15:04")
+	return &ev, nil
+}
+
+// UpdateRainEvent обновляет событие дождя по ID.
+func (r *Repository) UpdateRainEvent(ctx context.Context, id string, req models.RainEventRequest) error {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	query := `
+		UPDATE rain_events
+		SET start_time = $1::time, duration_sec = $2, is_active = $3
+		WHERE id = $4 AND enclosure_id = $5
+	`
+	ct, err := r.exec(ctx, query, req.StartTime, req.DurationSec, isActive, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления события дождя: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("событие дождя с id=%s не найдено", id)
+	}
+	return nil
+}
+
+// DeleteRainEvent удаляет событие дождя по ID.
+func (r *Repository) DeleteRainEvent(ctx context.Context, id string) error {
+	ct, err := r.exec(ctx, `DELETE FROM rain_events WHERE id = $1 AND enclosure_id = $2`, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления события дождя: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("событие дождя с id=%s не найдено", id)
+	}
+	return nil
+}
@@ -2,21 +2,65 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"regexp"
 	"time"
 
+	"terrarium-core/internal/events"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/metrics"
 	"terrarium-core/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
-// Repository обеспечивает слой абстракции над SQL-запросами к PostgreSQL
+// DefaultEnclosure — террариум, к которому относятся данные установки с одним террариумом
+// и маршруты API без префикса /enclosures/:id.
+const DefaultEnclosure = "default"
+
+// Repository обеспечивает слой абстракции над SQL-запросами к PostgreSQL.
+// Все запросы ограничены одним террариумом (enclosure_id).
 type Repository struct {
-	db *DB
+	db        *DB
+	enclosure string
 }
 
-// NewRepository создает новый инстанс репозитория
+// NewRepository создает новый инстанс репозитория для террариума по умолчанию
 func NewRepository(db *DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: db, enclosure: DefaultEnclosure}
+}
+
+// ForEnclosure возвращает репозиторий с тем же пулом соединений, ограниченный указанным террариумом.
+func (r *Repository) ForEnclosure(id string) *Repository {
+	return &Repository{db: r.db, enclosure: id}
+}
+
+// Enclosure возвращает ID террариума, которым ограничен репозиторий.
+func (r *Repository) Enclosure() string {
+	return r.enclosure
+}
+
+// StorageStatus проверяет, что база отвечает, и возвращает задержку и загрузку пула соединений.
+func (r *Repository) StorageStatus(ctx context.Context) (*models.StorageStatus, error) {
+	return r.db.Status(ctx)
+}
+
+// writeTablePattern выделяет таблицу из запроса INSERT, UPDATE или DELETE
+var writeTablePattern = regexp.MustCompile(`(?i)^\s*(?:INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+(\w+)`)
+
+// exec выполняет запрос записи и учитывает неудачные записи в метрике terrarium_db_write_errors_total.
+func (r *Repository) exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	ct, err := r.db.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		table := "unknown"
+		if m := writeTablePattern.FindStringSubmatch(sql); m != nil {
+			table = m[1]
+		}
+		metrics.DBWriteFailed(r.enclosure, table)
+	}
+	return ct, err
 }
 
 // GetConfig извлекает единственную активную конфигурацию климата из БД.
@@ -26,10 +70,10 @@ func (r *Repository) GetConfig(ctx context.Context) (*models.ConfigPayload, erro
 			warm_target_min, warm_target_max, cold_max_threshold, emergency_max_threshold,
 			humidity_min, humidity_max, hysteresis_temp, hysteresis_hum
 		FROM automation_settings 
-		WHERE id = 1
+		WHERE enclosure_id = $1
 	`
 	var cfg models.ConfigPayload
-	err := r.db.Pool.QueryRow(ctx, query).Scan(
+	err := r.db.Pool.QueryRow(ctx, query, r.enclosure).Scan(
 		&cfg.WarmTargetMin, &cfg.WarmTargetMax, &cfg.ColdMaxThreshold, &cfg.EmergencyMaxThreshold,
 		&cfg.HumidityMin, &cfg.HumidityMax, &cfg.HysteresisTemp, &cfg.HysteresisHum,
 	)
@@ -49,13 +93,14 @@ func (r *Repository) UpdateConfig(ctx context.Context, cfg models.ConfigPayload)
 			humidity_min = $5, humidity_max = $6, 
 			hysteresis_temp = $7, hysteresis_hum = $8,
 			updated_at = CURRENT_TIMESTAMP
-		WHERE id = 1
+		WHERE enclosure_id = $9
 	`
-	_, err := r.db.Pool.Exec(ctx, query,
+	_, err := r.exec(ctx, query,
 		cfg.WarmTargetMin, cfg.WarmTargetMax,
 		cfg.ColdMaxThreshold, cfg.EmergencyMaxThreshold,
 		cfg.HumidityMin, cfg.HumidityMax,
 		cfg.HysteresisTemp, cfg.HysteresisHum,
+		r.enclosure,
 	)
 	if err != nil {
 		return fmt.Errorf("ошибка обновления конфигурации: %w", err)
@@ -66,7 +111,7 @@ func (r *Repository) UpdateConfig(ctx context.Context, cfg models.ConfigPayload)
 // GetSystemMode возвращает текущий режим работы автоматики (AUTO / MANUAL).
 func (r *Repository) GetSystemMode(ctx context.Context) (string, error) {
 	var mode string
-	err := r.db.Pool.QueryRow(ctx, `SELECT mode FROM automation_settings WHERE id = 1`).Scan(&mode)
+	err := r.db.Pool.QueryRow(ctx, `SELECT mode FROM automation_settings WHERE enclosure_id = $1`, r.enclosure).Scan(&mode)
 	if err != nil {
 		return "AUTO", err // По умолчанию всегда AUTO в случае сбоя чтения
 	}
@@ -74,67 +119,100 @@ func (r *Repository) GetSystemMode(ctx context.Context) (string, error) {
 }
 
 // SetSystemMode переключает режим работы между AUTO и MANUAL.
-func (r *Repository) SetSystemMode(ctx context.Context, mode string) error {
-	_, err := r.db.Pool.Exec(ctx, `UPDATE automation_settings SET mode = $1, updated_at = CURRENT_TIMESTAMP WHERE id = 1`, mode)
-	return err
+// manualUntil задает срок аренды MANUAL (nil — бессрочно); для AUTO всегда сбрасывается.
+// Подписчики webhook оповещаются, только если режим действительно сменился.
+func (r *Repository) SetSystemMode(ctx context.Context, mode string, manualUntil *time.Time) error {
+	if mode != "MANUAL" {
+		manualUntil = nil
+	}
+	query := `
+		WITH prev AS (SELECT mode FROM automation_settings WHERE enclosure_id = $3)
+		UPDATE automation_settings SET mode = $1, manual_until = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE enclosure_id = $3
+		RETURNING (SELECT mode FROM prev)
+	`
+	var previous string
+	err := r.db.Pool.QueryRow(ctx, query, mode, manualUntil, r.enclosure).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // настроек террариума еще нет — менять нечего
+	}
+	if err != nil {
+		metrics.DBWriteFailed(r.enclosure, "automation_settings")
+		return err
+	}
+	if previous != mode {
+		r.enqueueEvent(ctx, EventModeChanged, events.ModeChange{Mode: mode, Previous: previous, ManualUntil: manualUntil})
+	}
+	return nil
+}
+
+// GetManualUntil возвращает срок аренды режима MANUAL (nil, если аренда не задана).
+func (r *Repository) GetManualUntil(ctx context.Context) (*time.Time, error) {
+	var until *time.Time
+	err := r.db.Pool.QueryRow(ctx, `SELECT manual_until FROM automation_settings WHERE enclosure_id = $1`, r.enclosure).Scan(&until)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения срока режима MANUAL: %w", err)
+	}
+	return until, nil
 }
 
-// InsertSensorLog сохраняет показания обоих датчиков в Timeseries таблицу.
-func (r *Repository) InsertSensorLog(ctx context.Context, warmTemp, warmHum, coldTemp, coldHum float64) error {
+// InsertRelayLog записывает в аудит событие переключения релейного аппарата и оповещает подписчиков webhook.
+// Событие relay.switched публикуется, только если запись в аудит удалась.
+func (r *Repository) InsertRelayLog(ctx context.Context, relayID string, state bool, reason string) error {
 	query := `
-		INSERT INTO sensor_logs (warm_zone_temp, warm_zone_hum, cold_zone_temp, cold_zone_hum)
+		INSERT INTO relay_logs (relay_id, state, reason, enclosure_id)
 		VALUES ($1, $2, $3, $4)
 	`
-	_, err := r.db.Pool.Exec(ctx, query, warmTemp, warmHum, coldTemp, coldHum)
+	_, err := r.exec(ctx, query, relayID, state, reason, r.enclosure)
 	if err != nil {
-		log.Printf("Ошибка сохранения лога датчиков: %v\n", err)
+		logger.Error("Ошибка сохранения лога реле", logging.KeyEnclosure, r.enclosure, logging.KeyRelay, relayID, logging.Err(err))
+		return err
 	}
-	return err
+	r.enqueueEvent(ctx, EventRelaySwitched, events.RelayChange{Relay: relayID, State: state, Reason: reason})
+	return nil
 }
 
-// InsertRelayLog записывает в аудит событие переключения релейного аппарата.
-func (r *Repository) InsertRelayLog(ctx context.Context, relayID string, state bool, reason string) error {
+// InsertBrightnessLog записывает в аудит изменение яркости диммируемого выхода (state = яркость > 0).
+func (r *Repository) InsertBrightnessLog(ctx context.Context, relayID string, brightness float64, reason string) error {
 	query := `
-		INSERT INTO relay_logs (relay_id, state, reason)
-		VALUES ($1, $2, $3)
+		INSERT INTO relay_logs (relay_id, state, reason, brightness, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5)
 	`
-	_, err := r.db.Pool.Exec(ctx, query, relayID, state, reason)
+	_, err := r.exec(ctx, query, relayID, brightness > 0, reason, brightness, r.enclosure)
 	if err != nil {
-		log.Printf("Ошибка сохранения лога реле: %v\n", err)
+		logger.Error("Ошибка сохранения лога реле", logging.KeyEnclosure, r.enclosure, logging.KeyRelay, relayID, logging.Err(err))
+		return err
 	}
-	return err
+	r.enqueueEvent(ctx, EventRelaySwitched, events.RelayChange{Relay: relayID, State: brightness > 0, Brightness: &brightness, Reason: reason})
+	return nil
 }
 
-// GetSensorHistory возвращает исторические показания датчиков за указанный период.
-// Если from/to не заданы (zero), возвращает последние записи с учётом limit.
+// GetSensorHistory возвращает историю показаний теплой и холодной зон (датчики warm и cold)
+// в широком формате за указанный период. Если from/to не заданы (zero), возвращает последние записи с учётом limit.
 func (r *Repository) GetSensorHistory(ctx context.Context, from, to time.Time, limit int) ([]models.SensorDataHistory, error) {
 	if limit <= 0 || limit > 1000 {
 		limit = 100
 	}
 
-	var query string
-	var args []interface{}
-
+	query := `
+		SELECT recorded_at,
+		       COALESCE(MAX(value) FILTER (WHERE sensor_id = 'warm' AND quantity = 'temperature'), 0),
+		       COALESCE(MAX(value) FILTER (WHERE sensor_id = 'warm' AND quantity = 'humidity'), 0),
+		       COALESCE(MAX(value) FILTER (WHERE sensor_id = 'cold' AND quantity = 'temperature'), 0),
+		       COALESCE(MAX(value) FILTER (WHERE sensor_id = 'cold' AND quantity = 'humidity'), 0)
+		FROM sensor_readings
+		WHERE enclosure_id = $1 AND sensor_id IN ('warm', 'cold')
+		  AND ($2::timestamptz IS NULL OR recorded_at BETWEEN $2 AND $3)
+		GROUP BY recorded_at
+		ORDER BY recorded_at DESC
+		LIMIT $4
+	`
+	var fromArg, toArg *time.Time
 	if !from.IsZero() && !to.IsZero() {
-		query = `
-			SELECT recorded_at, warm_zone_temp, warm_zone_hum, cold_zone_temp, cold_zone_hum
-			FROM sensor_logs
-			WHERE recorded_at BETWEEN $1 AND $2
-			ORDER BY recorded_at DESC
-			LIMIT $3
-		`
-		args = []interface{}{from, to, limit}
-	} else {
-		query = `
-			SELECT recorded_at, warm_zone_temp, warm_zone_hum, cold_zone_temp, cold_zone_hum
-			FROM sensor_logs
-			ORDER BY recorded_at DESC
-			LIMIT $1
-		`
-		args = []interface{}{limit}
+		fromArg, toArg = &from, &to
 	}
 
-	rows, err := r.db.Pool.Query(ctx, query, args...)
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure, fromArg, toArg, limit)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выборки истории датчиков: %w", err)
 	}
@@ -144,7 +222,7 @@ func (r *Repository) GetSensorHistory(ctx context.Context, from, to time.Time, l
 	for rows.Next() {
 		var entry models.SensorDataHistory
 		if err := rows.Scan(&entry.Timestamp, &entry.WarmTemp, &entry.WarmHum, &entry.ColdTemp, &entry.ColdHum); err != nil {
-			return nil, fmt.Errorf("ошибка чтения строки sensor_logs: %w", err)
+			return nil, fmt.Errorf("ошибка чтения строки sensor_readings: %w", err)
 		}
 		result = append(result, entry)
 	}
@@ -160,17 +238,19 @@ func (r *Repository) GetEnergyReports(ctx context.Context, from, to string) ([]m
 		query = `
 			SELECT report_date, heat_mat_kwh, light_kwh, fogger_kwh, spare_kwh, total_kwh
 			FROM energy_reports
-			WHERE report_date BETWEEN $1 AND $2
+			WHERE report_date BETWEEN $1 AND $2 AND enclosure_id = $3
 			ORDER BY report_date DESC
 		`
-		args = []interface{}{from, to}
+		args = []interface{}{from, to, r.enclosure}
 	} else {
 		query = `
 			SELECT report_date, heat_mat_kwh, light_kwh, fogger_kwh, spare_kwh, total_kwh
 			FROM energy_reports
+			WHERE enclosure_id = $1
 			ORDER BY report_date DESC
 			LIMIT 30
 		`
+		args = []interface{}{r.enclosure}
 	}
 
 	rows, err := r.db.Pool.Query(ctx, query, args...)
@@ -193,11 +273,12 @@ func (r *Repository) GetEnergyReports(ctx context.Context, from, to string) ([]m
 // GetSchedules возвращает все расписания реле.
 func (r *Repository) GetSchedules(ctx context.Context) ([]models.Schedule, error) {
 	query := `
-		SELECT id, relay_id, start_time, end_time, is_active, created_at
+		SELECT id, relay_id, start_time, end_time, brightness::float8, ramp_min, is_active, created_at
 		FROM schedules
+		WHERE enclosure_id = $1
 		ORDER BY created_at DESC
 	`
-	rows, err := r.db.Pool.Query(ctx, query)
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выборки расписаний: %w", err)
 	}
@@ -207,7 +288,7 @@ func (r *Repository) GetSchedules(ctx context.Context) ([]models.Schedule, error
 	for rows.Next() {
 		var s models.Schedule
 		var startTime, endTime time.Time
-		if err := rows.Scan(&s.ID, &s.RelayID, &startTime, &endTime, &s.IsActive, &s.CreatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.RelayID, &startTime, &endTime, &s.Brightness, &s.RampMin, &s.IsActive, &s.CreatedAt); err != nil {
 			return nil, fmt.Errorf("ошибка чтения расписания: %w", err)
 		}
 		s.StartTime = startTime.Format("15:04")
@@ -219,20 +300,17 @@ func (r *Repository) GetSchedules(ctx context.Context) ([]models.Schedule, error
 
 // CreateSchedule создаёт новое расписание реле и возвращает созданную запись.
 func (r *Repository) CreateSchedule(ctx context.Context, req models.ScheduleRequest) (*models.Schedule, error) {
-	isActive := true
-	if req.IsActive != nil {
-		isActive = *req.IsActive
-	}
+	isActive, brightness := scheduleDefaults(req)
 
 	query := `
-		INSERT INTO schedules (relay_id, start_time, end_time, is_active)
-		VALUES ($1, $2::time, $3::time, $4)
-		RETURNING id, relay_id, start_time, end_time, is_active, created_at
+		INSERT INTO schedules (relay_id, start_time, end_time, brightness, ramp_min, is_active, enclosure_id)
+		VALUES ($1, $2::time, $3::time, $4, $5, $6, $7)
+		RETURNING id, relay_id, start_time, end_time, brightness::float8, ramp_min, is_active, created_at
 	`
 	var s models.Schedule
 	var startTime, endTime time.Time
-	err := r.db.Pool.QueryRow(ctx, query, req.RelayID, req.StartTime, req.EndTime, isActive).
-		Scan(&s.ID, &s.RelayID, &startTime, &endTime, &s.IsActive, &s.CreatedAt)
+	err := r.db.Pool.QueryRow(ctx, query, req.RelayID, req.StartTime, req.EndTime, brightness, req.RampMin, isActive, r.enclosure).
+		Scan(&s.ID, &s.RelayID, &startTime, &endTime, &s.Brightness, &s.RampMin, &s.IsActive, &s.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания расписания: %w", err)
 	}
@@ -243,17 +321,14 @@ func (r *Repository) CreateSchedule(ctx context.Context, req models.ScheduleRequ
 
 // UpdateSchedule обновляет существующее расписание по ID.
 func (r *Repository) UpdateSchedule(ctx context.Context, id string, req models.ScheduleRequest) error {
-	isActive := true
-	if req.IsActive != nil {
-		isActive = *req.IsActive
-	}
+	isActive, brightness := scheduleDefaults(req)
 
 	query := `
 		UPDATE schedules
-		SET relay_id = $1, start_time = $2::time, end_time = $3::time, is_active = $4
-		WHERE id = $5
+		SET relay_id = $1, start_time = $2::time, end_time = $3::time, brightness = $4, ramp_min = $5, is_active = $6
+		WHERE id = $7 AND enclosure_id = $8
 	`
-	ct, err := r.db.Pool.Exec(ctx, query, req.RelayID, req.StartTime, req.EndTime, isActive, id)
+	ct, err := r.exec(ctx, query, req.RelayID, req.StartTime, req.EndTime, brightness, req.RampMin, isActive, id, r.enclosure)
 	if err != nil {
 		return fmt.Errorf("ошибка обновления расписания: %w", err)
 	}
@@ -263,9 +338,21 @@ func (r *Repository) UpdateSchedule(ctx context.Context, id string, req models.S
 	return nil
 }
 
+// scheduleDefaults возвращает активность (по умолчанию true) и яркость (по умолчанию 100%) расписания.
+func scheduleDefaults(req models.ScheduleRequest) (bool, float64) {
+	isActive, brightness := true, 100.0
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	if req.Brightness != nil {
+		brightness = *req.Brightness
+	}
+	return isActive, brightness
+}
+
 // DeleteSchedule удаляет расписание по ID.
 func (r *Repository) DeleteSchedule(ctx context.Context, id string) error {
-	ct, err := r.db.Pool.Exec(ctx, `DELETE FROM schedules WHERE id = $1`, id)
+	ct, err := r.exec(ctx, `DELETE FROM schedules WHERE id = $1 AND enclosure_id = $2`, id, r.enclosure)
 	if err != nil {
 		return fmt.Errorf("ошибка удаления расписания: %w", err)
 	}
@@ -285,12 +372,13 @@ func (r *Repository) GetRelayLogs(ctx context.Context, limit, offset int) ([]mod
 	}
 
 	query := `
-		SELECT id, relay_id, state, reason, recorded_at
+		SELECT id, relay_id, state, reason, brightness::float8, recorded_at
 		FROM relay_logs
+		WHERE enclosure_id = $3
 		ORDER BY recorded_at DESC
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := r.db.Pool.Query(ctx, query, limit, offset)
+	rows, err := r.db.Pool.Query(ctx, query, limit, offset, r.enclosure)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выборки логов реле: %w", err)
 	}
@@ -299,10 +387,78 @@ func (r *Repository) GetRelayLogs(ctx context.Context, limit, offset int) ([]mod
 	var result []models.RelayLogEntry
 	for rows.Next() {
 		var entry models.RelayLogEntry
-		if err := rows.Scan(&entry.ID, &entry.RelayID, &entry.State, &entry.Reason, &entry.RecordedAt); err != nil {
+		if err := rows.Scan(&entry.ID, &entry.RelayID, &entry.State, &entry.Reason, &entry.Brightness, &entry.RecordedAt); err != nil {
 			return nil, fmt.Errorf("ошибка чтения строки relay_logs: %w", err)
 		}
 		result = append(result, entry)
 	}
 	return result, nil
 }
+
+// GetRelayOnTime вычисляет суммарное время работы реле начиная с since по журналу relay_logs.
+// Если реле было включено до since, учитывается состояние из последней записи перед since.
+func (r *Repository) GetRelayOnTime(ctx context.Context, relayID string, since time.Time) (time.Duration, error) {
+	activity, err := r.GetRelayActivity(ctx, relayID, since, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return activity.OnTime, nil
+}
+
+// RelayActivity — сводка работы реле за интервал по журналу relay_logs.
+type RelayActivity struct {
+	InitialState bool          // состояние реле на начало интервала
+	OnTime       time.Duration // суммарное время во включенном состоянии
+	Switches     int           // количество фактических переключений
+}
+
+// GetRelayActivity восстанавливает работу реле в интервале [from, to] по журналу relay_logs.
+func (r *Repository) GetRelayActivity(ctx context.Context, relayID string, from, to time.Time) (*RelayActivity, error) {
+	var wasOn bool
+	err := r.db.Pool.QueryRow(ctx, `
+		SELECT COALESCE((
+			SELECT state FROM relay_logs
+			WHERE relay_id = $1 AND recorded_at < $2 AND enclosure_id = $3
+			ORDER BY recorded_at DESC
+			LIMIT 1
+		), false)
+	`, relayID, from, r.enclosure).Scan(&wasOn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения состояния реле: %w", err)
+	}
+
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT state, recorded_at
+		FROM relay_logs
+		WHERE relay_id = $1 AND recorded_at >= $2 AND recorded_at <= $3 AND enclosure_id = $4
+		ORDER BY recorded_at
+	`, relayID, from, to, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки журнала реле: %w", err)
+	}
+	defer rows.Close()
+
+	activity := &RelayActivity{InitialState: wasOn}
+	onSince := from
+	for rows.Next() {
+		var state bool
+		var at time.Time
+		if err := rows.Scan(&state, &at); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки relay_logs: %w", err)
+		}
+		if state == wasOn {
+			continue // Повторная запись того же состояния (например, MANUAL_OVERRIDE без смены)
+		}
+		if state {
+			onSince = at
+		} else {
+			activity.OnTime += at.Sub(onSince)
+		}
+		activity.Switches++
+		wasOn = state
+	}
+	if wasOn {
+		activity.OnTime += to.Sub(onSince)
+	}
+	return activity, nil
+}
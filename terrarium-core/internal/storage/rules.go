@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"terrarium-core/internal/models"
+)
+
+// ErrNotFound возвращается, если запись с указанным ID не существует.
+var ErrNotFound = errors.New("запись не найдена")
+
+// ErrDuplicate возвращается при нарушении уникальности (например, имени правила).
+var ErrDuplicate = errors.New("запись с таким именем уже существует")
+
+const ruleColumns = `id, name, description, priority, is_active, safety, builtin, conditions, action, created_at, updated_at`
+
+// GetRules возвращает все правила автоматизации в порядке оценки (приоритет, затем имя).
+func (r *Repository) GetRules(ctx context.Context) ([]models.AutomationRule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM automation_rules WHERE enclosure_id = $1 ORDER BY priority, name`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки правил: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.AutomationRule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *rule)
+	}
+	return result, rows.Err()
+}
+
+// GetRule возвращает правило по ID (ErrNotFound, если его нет).
+func (r *Repository) GetRule(ctx context.Context, id string) (*models.AutomationRule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM automation_rules WHERE id = $1 AND enclosure_id = $2`
+	rule, err := scanRule(r.db.Pool.QueryRow(ctx, query, id, r.enclosure))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("правило с id=%s: %w", id, ErrNotFound)
+	}
+	return rule, err
+}
+
+// CreateRule создает пользовательское правило и возвращает созданную запись.
+func (r *Repository) CreateRule(ctx context.Context, req models.AutomationRuleRequest) (*models.AutomationRule, error) {
+	conditions, action, err := marshalRule(req.Conditions, req.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO automation_rules (name, description, priority, is_active, safety, conditions, action, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + ruleColumns
+	rule, err := scanRule(r.db.Pool.QueryRow(ctx, query,
+		req.Name, req.Description, req.Priority, ruleActive(req), req.Safety, conditions, action, r.enclosure))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания правила: %w", uniqueViolation(err))
+	}
+	return rule, nil
+}
+
+// UpdateRule полностью обновляет пользовательское правило по ID.
+func (r *Repository) UpdateRule(ctx context.Context, id string, req models.AutomationRuleRequest) error {
+	conditions, action, err := marshalRule(req.Conditions, req.Action)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE automation_rules
+		SET name = $1, description = $2, priority = $3, is_active = $4, safety = $5,
+		    conditions = $6, action = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8 AND enclosure_id = $9 AND NOT builtin
+	`
+	ct, err := r.exec(ctx, query, req.Name, req.Description, req.Priority, ruleActive(req), req.Safety, conditions, action, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления правила: %w", uniqueViolation(err))
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("правило с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// SetRuleActive включает или отключает правило (используется для встроенных правил).
+func (r *Repository) SetRuleActive(ctx context.Context, id string, active bool) error {
+	ct, err := r.exec(ctx, `UPDATE automation_rules SET is_active = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND enclosure_id = $3`, active, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления правила: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("правило с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteRule удаляет пользовательское правило по ID. Встроенные правила не удаляются.
+func (r *Repository) DeleteRule(ctx context.Context, id string) error {
+	ct, err := r.exec(ctx, `DELETE FROM automation_rules WHERE id = $1 AND enclosure_id = $2 AND NOT builtin`, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления правила: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("правило с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// SeedRules добавляет недостающие встроенные правила, а у существующих обновляет приоритет, условия
+// и действие до текущей версии кода. Флаг is_active, выставленный пользователем, сохраняется.
+func (r *Repository) SeedRules(ctx context.Context, rules []models.AutomationRule) error {
+	query := `
+		INSERT INTO automation_rules (id, name, description, priority, is_active, safety, builtin, conditions, action, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5, $6, true, $7, $8, $9)
+		ON CONFLICT (enclosure_id, id) DO UPDATE
+		SET name = EXCLUDED.name, description = EXCLUDED.description, priority = EXCLUDED.priority,
+		    safety = EXCLUDED.safety, conditions = EXCLUDED.conditions, action = EXCLUDED.action
+		WHERE automation_rules.builtin
+	`
+	for _, rule := range rules {
+		conditions, action, err := marshalRule(rule.Conditions, rule.Action)
+		if err != nil {
+			return err
+		}
+		if _, err := r.exec(ctx, query, rule.ID, rule.Name, rule.Description, rule.Priority,
+			rule.IsActive, rule.Safety, conditions, action, r.enclosure); err != nil {
+			return fmt.Errorf("ошибка записи встроенного правила %s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func scanRule(row pgx.Row) (*models.AutomationRule, error) {
+	var rule models.AutomationRule
+	var conditions, action []byte
+	if err := row.Scan(&rule.ID, &rule.Name, &rule.Description, &rule.Priority, &rule.IsActive,
+		&rule.Safety, &rule.Builtin, &conditions, &action, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка чтения правила: %w", err)
+	}
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("ошибка разбора условий правила %s: %w", rule.Name, err)
+	}
+	if err := json.Unmarshal(action, &rule.Action); err != nil {
+		return nil, fmt.Errorf("ошибка разбора действия правила %s: %w", rule.Name, err)
+	}
+	return &rule, nil
+}
+
+func marshalRule(conditions []models.RuleCondition, action models.RuleAction) ([]byte, []byte, error) {
+	c, err := json.Marshal(conditions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка сериализации условий правила: %w", err)
+	}
+	a, err := json.Marshal(action)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка сериализации действия правила: %w", err)
+	}
+	return c, a, nil
+}
+
+// uniqueViolation заменяет ошибку нарушения уникального индекса на ErrDuplicate.
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func ruleActive(req models.AutomationRuleRequest) bool {
+	if req.IsActive != nil {
+		return *req.IsActive
+	}
+	return true
+}
@@ -0,0 +1,359 @@
+-- ==========================================================
+-- ПЛАТФОРМА КЛИМАТ-КОНТРОЛЯ ТЕРРАРИУМА - СХЕМА POSTGRESQL
+-- ==========================================================
+-- Ядро применяет схему при каждом запуске (storage.Migrate), поэтому все операторы
+-- идемпотентны: новые таблицы и столбцы появляются и в уже существующих базах.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+-- Террариумы, обслуживаемые одним экземпляром ядра. Все данные ниже привязаны к террариуму
+-- через enclosure_id ('default' — террариум установки с одним террариумом).
+CREATE TABLE IF NOT EXISTS enclosures (
+    id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO enclosures (id, name) VALUES ('default', 'Террариум') ON CONFLICT DO NOTHING;
+
+-- Таблица для настроек автоматизации и климата
+CREATE TABLE IF NOT EXISTS automation_settings (
+    id SERIAL PRIMARY KEY,
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    warm_target_min NUMERIC(5, 2) NOT NULL DEFAULT 31.0,
+    warm_target_max NUMERIC(5, 2) NOT NULL DEFAULT 33.0,
+    cold_max_threshold NUMERIC(5, 2) NOT NULL DEFAULT 26.0,
+    emergency_max_threshold NUMERIC(5, 2) NOT NULL DEFAULT 35.0,
+    humidity_min NUMERIC(5, 2) NOT NULL DEFAULT 50.0,
+    humidity_max NUMERIC(5, 2) NOT NULL DEFAULT 65.0,
+    hysteresis_temp NUMERIC(4, 2) NOT NULL DEFAULT 0.5,
+    hysteresis_hum NUMERIC(4, 2) NOT NULL DEFAULT 2.0,
+    mode VARCHAR(20) NOT NULL DEFAULT 'AUTO', -- 'AUTO' или 'MANUAL'
+    manual_until TIMESTAMP WITH TIME ZONE, -- срок аренды MANUAL (NULL = бессрочно)
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Таблица для расписания освещения и других задач
+CREATE TABLE IF NOT EXISTS schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    relay_id VARCHAR(50) NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Показания датчиков в широком формате (устарело: только зоны warm/cold, ядро больше не пишет сюда —
+-- см. sensor_readings; история переносится в sensor_readings в конце скрипта)
+CREATE TABLE IF NOT EXISTS sensor_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    warm_zone_temp NUMERIC(5, 2),
+    warm_zone_hum NUMERIC(5, 2),
+    cold_zone_temp NUMERIC(5, 2),
+    cold_zone_hum NUMERIC(5, 2)
+);
+
+-- Отслеживание включения/выключения реле для восстановления состояния и расчета потребления
+CREATE TABLE IF NOT EXISTS relay_logs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    relay_id VARCHAR(50) NOT NULL,
+    state BOOLEAN NOT NULL,
+    reason VARCHAR(100), -- 'AUTO_TEMP_TRIGGER', 'MANUAL_OVERRIDE', 'EMERGENCY_CUTOFF', 'MIST_PULSE', 'RULE:<имя>'
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Агрегированные отчеты об энергопотреблении (например, генерируемые ежедневно)
+CREATE TABLE IF NOT EXISTS energy_reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    report_date DATE NOT NULL,
+    heat_mat_kwh NUMERIC(8, 4) DEFAULT 0,
+    light_kwh NUMERIC(8, 4) DEFAULT 0,
+    fogger_kwh NUMERIC(8, 4) DEFAULT 0,
+    spare_kwh NUMERIC(8, 4) DEFAULT 0,
+    total_kwh NUMERIC(8, 4) DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Минимальное системное состояние для восстановления (Полезно вместе с JSON для быстрого восстановления после сбоя)
+CREATE TABLE IF NOT EXISTS system_state (
+    id SERIAL PRIMARY KEY,
+    heat_mat BOOLEAN DEFAULT false,
+    fogger BOOLEAN DEFAULT false,
+    light BOOLEAN DEFAULT false,
+    spare BOOLEAN DEFAULT false,
+    last_updated TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS single_state_idx ON system_state((1));
+INSERT INTO system_state (id) VALUES (1) ON CONFLICT DO NOTHING;
+
+-- Настройки импульсного режима фоггера (misting)
+CREATE TABLE IF NOT EXISTS mist_settings (
+    id SERIAL PRIMARY KEY,
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    pulse_sec INTEGER NOT NULL DEFAULT 30,
+    cooldown_sec INTEGER NOT NULL DEFAULT 900,
+    daily_max_min INTEGER NOT NULL DEFAULT 20,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Запланированные события дождя (импульсы фоггера по времени суток)
+CREATE TABLE IF NOT EXISTS rain_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    start_time TIME NOT NULL,
+    duration_sec INTEGER NOT NULL,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Трассировка решений движка (опционально, DECISION_TRACE_PERSIST=actions|all)
+CREATE TABLE IF NOT EXISTS decision_traces (
+    id BIGSERIAL PRIMARY KEY,
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    cycle_id BIGINT NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    record JSONB NOT NULL
+);
+
+-- Правила автоматизации (условия над датчиками, временем и реле -> действие над реле).
+-- Встроенные правила (builtin) записываются движком при старте.
+CREATE TABLE IF NOT EXISTS automation_rules (
+    id VARCHAR(64) NOT NULL DEFAULT uuid_generate_v4()::text,
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    priority INTEGER NOT NULL DEFAULT 100, -- меньше = оценивается раньше
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    safety BOOLEAN NOT NULL DEFAULT false,
+    builtin BOOLEAN NOT NULL DEFAULT false,
+    conditions JSONB NOT NULL,
+    action JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (enclosure_id, id),
+    UNIQUE (enclosure_id, name)
+);
+
+-- ==========================================================
+-- ПРИВЯЗКА К ТЕРРАРИУМУ (в т.ч. миграция установок с одним террариумом)
+-- ==========================================================
+
+ALTER TABLE automation_settings ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE sensor_logs ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE relay_logs ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE energy_reports ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE mist_settings ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE rain_events ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE decision_traces ADD COLUMN IF NOT EXISTS enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- Одна строка настроек на террариум (раньше — одна строка на всю систему)
+DROP INDEX IF EXISTS single_config_idx;
+DROP INDEX IF EXISTS single_mist_idx;
+ALTER TABLE energy_reports DROP CONSTRAINT IF EXISTS energy_reports_report_date_key;
+CREATE UNIQUE INDEX IF NOT EXISTS config_enclosure_idx ON automation_settings(enclosure_id);
+CREATE UNIQUE INDEX IF NOT EXISTS mist_enclosure_idx ON mist_settings(enclosure_id);
+CREATE UNIQUE INDEX IF NOT EXISTS energy_reports_enclosure_date_idx ON energy_reports(enclosure_id, report_date);
+
+-- Индексы для запросов временных рядов
+DROP INDEX IF EXISTS idx_sensor_logs_time;
+DROP INDEX IF EXISTS idx_relay_logs_time;
+DROP INDEX IF EXISTS idx_decision_traces_time;
+CREATE INDEX IF NOT EXISTS idx_sensor_logs_enclosure_time ON sensor_logs(enclosure_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_relay_logs_enclosure_time ON relay_logs(enclosure_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_decision_traces_enclosure_time ON decision_traces(enclosure_id, started_at DESC);
+
+-- Строки настроек террариума по умолчанию (для остальных террариумов создаются ядром при старте)
+INSERT INTO automation_settings (enclosure_id) VALUES ('default') ON CONFLICT DO NOTHING;
+INSERT INTO mist_settings (enclosure_id) VALUES ('default') ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('automation_settings', 'id'), (SELECT MAX(id) FROM automation_settings));
+SELECT setval(pg_get_serial_sequence('mist_settings', 'id'), (SELECT MAX(id) FROM mist_settings));
+
+-- ==========================================================
+-- РЕЕСТР ДАТЧИКОВ И ПОКАЗАНИЯ В ДЛИННОМ ФОРМАТЕ
+-- ==========================================================
+
+-- Реестр датчиков террариума (записывается ядром при старте из ENCLOSURES).
+-- Датчики warm и cold создаются всегда, дополнительные добавляются без изменения схемы.
+CREATE TABLE IF NOT EXISTS sensors (
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    id VARCHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    zone VARCHAR(64) NOT NULL, -- warm, cold, basking, ...
+    driver VARCHAR(32) NOT NULL, -- dht22, ...
+    quantities TEXT[] NOT NULL, -- temperature, humidity, ...
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (enclosure_id, id)
+);
+
+-- Временные ряды большого объема: одна строка на величину, измеренную датчиком в цикле опроса
+-- (все строки цикла имеют одинаковое recorded_at)
+CREATE TABLE IF NOT EXISTS sensor_readings (
+    id BIGSERIAL PRIMARY KEY,
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    sensor_id VARCHAR(64) NOT NULL,
+    quantity VARCHAR(32) NOT NULL,
+    value NUMERIC(10, 3) NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sensor_readings_enclosure_time ON sensor_readings(enclosure_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sensor_readings_sensor_time ON sensor_readings(enclosure_id, sensor_id, recorded_at DESC);
+
+-- Перенос истории sensor_logs (однократно, пока sensor_readings пуста)
+INSERT INTO sensor_readings (enclosure_id, sensor_id, quantity, value, recorded_at)
+SELECT l.enclosure_id, v.sensor_id, v.quantity, v.value, l.recorded_at
+FROM sensor_logs l
+CROSS JOIN LATERAL (VALUES
+    ('warm', 'temperature', l.warm_zone_temp),
+    ('warm', 'humidity', l.warm_zone_hum),
+    ('cold', 'temperature', l.cold_zone_temp),
+    ('cold', 'humidity', l.cold_zone_hum)
+) AS v(sensor_id, quantity, value)
+WHERE v.value IS NOT NULL AND NOT EXISTS (SELECT 1 FROM sensor_readings);
+
+-- ==========================================================
+-- ДИММИРУЕМОЕ ОСВЕЩЕНИЕ (РАССВЕТ/ЗАКАТ)
+-- ==========================================================
+
+-- Яркость и длительность плавного перехода для расписаний диммируемых выходов
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS brightness NUMERIC(4, 1) NOT NULL DEFAULT 100;
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS ramp_min INTEGER NOT NULL DEFAULT 0;
+
+-- Яркость диммируемого выхода после события (NULL — обычное реле)
+ALTER TABLE relay_logs ADD COLUMN IF NOT EXISTS brightness NUMERIC(4, 1);
+
+-- ==========================================================
+-- РЕЗЕРВУАР ФОГГЕРА (ПОПЛАВКОВЫЙ ВЫКЛЮЧАТЕЛЬ)
+-- ==========================================================
+
+-- Журнал опустошения и пополнения резервуара (по фронтам поплавкового выключателя)
+CREATE TABLE IF NOT EXISTS reservoir_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    event VARCHAR(16) NOT NULL CHECK (event IN ('EMPTY', 'REFILL')),
+    empty_sec INTEGER, -- для REFILL: сколько резервуар простоял пустым
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservoir_events_enclosure_time ON reservoir_events(enclosure_id, occurred_at DESC);
+
+-- ==========================================================
+-- ЦИФРОВЫЕ ВХОДЫ (КРЫШКА, КНОПКА АВАРИЙНОГО СТОПА)
+-- ==========================================================
+
+-- Журнал цифровых входов: открытие/закрытие крышки, включение и сброс аварийного стопа
+CREATE TABLE IF NOT EXISTS input_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    input_id VARCHAR(64) NOT NULL, -- ID входа или 'api'
+    event VARCHAR(16) NOT NULL,    -- 'OPEN', 'CLOSE', 'PANIC_STOP', 'PANIC_RESET'
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_input_events_enclosure_time ON input_events(enclosure_id, occurred_at DESC);
+
+-- Время включения аварийного стопа (NULL — стоп не активен); переживает перезапуск сервиса
+ALTER TABLE automation_settings ADD COLUMN IF NOT EXISTS panic_stop_since TIMESTAMP WITH TIME ZONE;
+
+-- ==========================================================
+-- ОПОВЕЩЕНИЯ (ПРАВИЛА, АЛЕРТЫ, ОКНА ТИШИНЫ)
+-- ==========================================================
+
+-- Правила оповещения: условие должно держаться for_sec секунд, чтобы алерт сработал
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id VARCHAR(64) NOT NULL DEFAULT uuid_generate_v4()::text,
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    type VARCHAR(16) NOT NULL CHECK (type IN ('condition', 'sensor_offline')),
+    conditions JSONB NOT NULL DEFAULT '[]',
+    sensor VARCHAR(64) NOT NULL DEFAULT '', -- для sensor_offline: ID датчика или '*'
+    for_sec INTEGER NOT NULL DEFAULT 0,
+    severity VARCHAR(16) NOT NULL DEFAULT 'WARNING' CHECK (severity IN ('INFO', 'WARNING', 'CRITICAL')),
+    repeat_min INTEGER NOT NULL DEFAULT 0, -- 0 = не повторять уведомление
+    channels TEXT[] NOT NULL DEFAULT '{}', -- пусто = все каналы доставки
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (enclosure_id, id),
+    UNIQUE (enclosure_id, name)
+);
+
+-- Сработавшие алерты: активные (FIRING) и история (RESOLVED)
+CREATE TABLE IF NOT EXISTS alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    rule_id VARCHAR(64) NOT NULL,
+    rule_name VARCHAR(100) NOT NULL,
+    severity VARCHAR(16) NOT NULL,
+    state VARCHAR(16) NOT NULL CHECK (state IN ('FIRING', 'RESOLVED')),
+    message TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    fired_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    acknowledged_by VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_enclosure_time ON alerts(enclosure_id, fired_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alerts_firing ON alerts(enclosure_id) WHERE state = 'FIRING';
+
+-- Окна тишины: алерты сохраняются, но уведомления не рассылаются
+CREATE TABLE IF NOT EXISTS alert_silences (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    rule_id VARCHAR(64) NOT NULL DEFAULT '', -- пусто = все правила террариума
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL CHECK (ends_at > starts_at),
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_silences_enclosure_end ON alert_silences(enclosure_id, ends_at DESC);
+
+-- ==========================================================
+-- ИСХОДЯЩИЕ WEBHOOKS
+-- ==========================================================
+
+-- Подписки внешних систем на события террариума
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    url TEXT NOT NULL,
+    events TEXT[] NOT NULL, -- типы событий, '*' = все
+    secret VARCHAR(200) NOT NULL, -- ключ подписи HMAC-SHA256
+    description TEXT NOT NULL DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_enclosure ON webhooks(enclosure_id);
+
+-- Очередь и журнал доставок: PENDING ждет попытки, DEAD — попытки исчерпаны (dead letter)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    enclosure_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    event_type VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INTEGER,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_enclosure_time ON webhook_deliveries(enclosure_id, created_at DESC);
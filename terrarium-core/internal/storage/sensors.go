@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+// RegisterSensors записывает реестр датчиков террариума (при старте ядра). Датчики, удаленные
+// из конфигурации, остаются в таблице, чтобы их история сохраняла имя и зону.
+func (r *Repository) RegisterSensors(ctx context.Context, sensors []models.SensorInfo) error {
+	query := `
+		INSERT INTO sensors (id, name, zone, driver, quantities, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (enclosure_id, id) DO UPDATE
+		SET name = EXCLUDED.name, zone = EXCLUDED.zone, driver = EXCLUDED.driver,
+		    quantities = EXCLUDED.quantities, updated_at = CURRENT_TIMESTAMP
+	`
+	for _, s := range sensors {
+		if _, err := r.exec(ctx, query, s.ID, s.Name, s.Zone, s.Driver, s.Quantities, r.enclosure); err != nil {
+			return fmt.Errorf("ошибка регистрации датчика %s: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// InsertReadings сохраняет показания цикла опроса в длинном формате (одна строка на величину).
+// Все строки цикла получают одно время recorded_at.
+func (r *Repository) InsertReadings(ctx context.Context, at time.Time, readings []models.SensorReading) error {
+	if len(readings) == 0 {
+		return nil
+	}
+	sensorIDs := make([]string, len(readings))
+	quantities := make([]string, len(readings))
+	values := make([]float64, len(readings))
+	for i, rd := range readings {
+		sensorIDs[i], quantities[i], values[i] = rd.SensorID, rd.Quantity, rd.Value
+	}
+
+	query := `
+		INSERT INTO sensor_readings (sensor_id, quantity, value, recorded_at, enclosure_id)
+		SELECT s, q, v, $4, $5 FROM unnest($1::text[], $2::text[], $3::float8[]) AS t(s, q, v)
+	`
+	_, err := r.exec(ctx, query, sensorIDs, quantities, values, at, r.enclosure)
+	if err != nil {
+		logger.Error("Ошибка сохранения показаний датчиков", logging.KeyEnclosure, r.enclosure, logging.Err(err))
+	}
+	return err
+}
+
+// GetReadings возвращает историю показаний в порядке от новых к старым. Пустой sensorIDs — все датчики,
+// пустой quantity — все величины; from/to (zero — без ограничения) задают интервал.
+func (r *Repository) GetReadings(ctx context.Context, sensorIDs []string, quantity string, from, to time.Time, limit int) ([]models.SensorReading, error) {
+	if limit <= 0 || limit > 10000 {
+		limit = 1000
+	}
+	if sensorIDs == nil {
+		sensorIDs = []string{}
+	}
+
+	query := `
+		SELECT sensor_id, quantity, value, recorded_at
+		FROM sensor_readings
+		WHERE enclosure_id = $1
+		  AND (cardinality($2::text[]) = 0 OR sensor_id = ANY($2))
+		  AND ($3 = '' OR quantity = $3)
+		  AND ($4::timestamptz IS NULL OR recorded_at >= $4)
+		  AND ($5::timestamptz IS NULL OR recorded_at <= $5)
+		ORDER BY recorded_at DESC, sensor_id, quantity
+		LIMIT $6
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure, sensorIDs, quantity, nullTime(from), nullTime(to), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки показаний датчиков: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.SensorReading
+	for rows.Next() {
+		var rd models.SensorReading
+		if err := rows.Scan(&rd.SensorID, &rd.Quantity, &rd.Value, &rd.Timestamp); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки sensor_readings: %w", err)
+		}
+		result = append(result, rd)
+	}
+	return result, rows.Err()
+}
+
+// GetReadingSamples возвращает показания за интервал, сгруппированные по циклам опроса,
+// в хронологическом порядке (для воспроизведения истории в симуляции).
+func (r *Repository) GetReadingSamples(ctx context.Context, from, to time.Time) ([]models.ReadingSample, error) {
+	query := `
+		SELECT recorded_at, sensor_id, quantity, value
+		FROM sensor_readings
+		WHERE recorded_at BETWEEN $1 AND $2 AND enclosure_id = $3
+		ORDER BY recorded_at
+	`
+	rows, err := r.db.Pool.Query(ctx, query, from, to, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки истории датчиков: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.ReadingSample
+	for rows.Next() {
+		var at time.Time
+		var sensorID, quantity string
+		var value float64
+		if err := rows.Scan(&at, &sensorID, &quantity, &value); err != nil {
+			return nil, fmt.Errorf("ошибка чтения строки sensor_readings: %w", err)
+		}
+		if n := len(result); n == 0 || !result[n-1].Timestamp.Equal(at) {
+			result = append(result, models.ReadingSample{Timestamp: at, Values: make(map[string]float64)})
+		}
+		result[len(result)-1].Values[sensorID+"."+quantity] = value
+	}
+	return result, rows.Err()
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"terrarium-core/internal/models"
+)
+
+// InsertDecisionTrace сохраняет трассировку цикла движка (JSONB) для последующего разбора.
+func (r *Repository) InsertDecisionTrace(ctx context.Context, rec *models.DecisionRecord) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации трассировки: %w", err)
+	}
+
+	query := `
+		INSERT INTO decision_traces (cycle_id, started_at, record, enclosure_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := r.exec(ctx, query, int64(rec.CycleID), rec.StartedAt, payload, r.enclosure); err != nil {
+		return fmt.Errorf("ошибка сохранения трассировки: %w", err)
+	}
+	return nil
+}
+
+// GetLastRelayLog возвращает последнюю запись журнала переключений реле (nil, если записей нет).
+func (r *Repository) GetLastRelayLog(ctx context.Context, relayID string) (*models.RelayLogEntry, error) {
+	query := `
+		SELECT id, relay_id, state, reason, brightness::float8, recorded_at
+		FROM relay_logs
+		WHERE relay_id = $1 AND enclosure_id = $2
+		ORDER BY recorded_at DESC
+		LIMIT 1
+	`
+	var entry models.RelayLogEntry
+	err := r.db.Pool.QueryRow(ctx, query, relayID, r.enclosure).
+		Scan(&entry.ID, &entry.RelayID, &entry.State, &entry.Reason, &entry.Brightness, &entry.RecordedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала реле: %w", err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"terrarium-core/internal/events"
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/models"
+)
+
+// Типы событий webhook
+const (
+	EventRelaySwitched     = events.RelaySwitched
+	EventModeChanged       = events.ModeChanged
+	EventAlertFired        = events.AlertFired
+	EventAlertResolved     = events.AlertResolved
+	EventAlertAcknowledged = events.AlertAcknowledged
+	// EventPing отправляется только кнопкой проверки подписки
+	EventPing = "ping"
+	// EventAll подписывает на все типы событий
+	EventAll = "*"
+)
+
+// EventTypes — типы событий, на которые можно подписаться.
+var EventTypes = []string{EventRelaySwitched, EventModeChanged, EventAlertFired, EventAlertResolved, EventAlertAcknowledged}
+
+// Состояния доставок webhook
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryDead      = "DEAD"
+)
+
+const webhookColumns = `id, url, events, description, is_active, created_at, updated_at`
+
+const deliveryColumns = `id, webhook_id, event_type, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+// ==========================================
+// ПОДПИСКИ
+// ==========================================
+
+// GetWebhooks возвращает подписки террариума (секреты не возвращаются).
+func (r *Repository) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE enclosure_id = $1 ORDER BY created_at`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *w)
+	}
+	return result, rows.Err()
+}
+
+// GetWebhook возвращает подписку по ID без секрета (ErrNotFound, если ее нет).
+func (r *Repository) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id::text = $1 AND enclosure_id = $2`
+	w, err := scanWebhook(r.db.Pool.QueryRow(ctx, query, id, r.enclosure))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("webhook с id=%s: %w", id, ErrNotFound)
+	}
+	return w, err
+}
+
+// CreateWebhook создает подписку и возвращает ее вместе с секретом.
+func (r *Repository) CreateWebhook(ctx context.Context, req models.WebhookRequest) (*models.Webhook, error) {
+	query := `
+		INSERT INTO webhooks (url, events, secret, description, is_active, enclosure_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + webhookColumns
+	w, err := scanWebhook(r.db.Pool.QueryRow(ctx, query,
+		req.URL, req.Events, req.Secret, req.Description, webhookActive(req), r.enclosure))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания webhook: %w", err)
+	}
+	w.Secret = req.Secret
+	return w, nil
+}
+
+// UpdateWebhook полностью обновляет подписку по ID. Пустой секрет сохраняет прежний.
+func (r *Repository) UpdateWebhook(ctx context.Context, id string, req models.WebhookRequest) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, events = $2, secret = COALESCE(NULLIF($3, ''), secret), description = $4,
+		    is_active = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id::text = $6 AND enclosure_id = $7
+	`
+	ct, err := r.exec(ctx, query, req.URL, req.Events, req.Secret, req.Description, webhookActive(req), id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления webhook: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("webhook с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// DeleteWebhook удаляет подписку по ID вместе с ее доставками.
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) error {
+	ct, err := r.exec(ctx, `DELETE FROM webhooks WHERE id::text = $1 AND enclosure_id = $2`, id, r.enclosure)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления webhook: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("webhook с id=%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+func scanWebhook(row pgx.Row) (*models.Webhook, error) {
+	var w models.Webhook
+	if err := row.Scan(&w.ID, &w.URL, &w.Events, &w.Description, &w.IsActive, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка чтения webhook: %w", err)
+	}
+	return &w, nil
+}
+
+func webhookActive(req models.WebhookRequest) bool {
+	if req.IsActive != nil {
+		return *req.IsActive
+	}
+	return true
+}
+
+// ==========================================
+// СОБЫТИЯ
+// ==========================================
+
+// enqueueEvent рассылает событие подписчикам внутри процесса и ставит его в очередь доставки всем
+// активным подпискам webhook террариума на этот тип. Ошибка только записывается в журнал:
+// недоставленный webhook не должен мешать основной операции.
+func (r *Repository) enqueueEvent(ctx context.Context, eventType string, data any) {
+	events.Publish(events.Event{Type: eventType, Enclosure: r.enclosure, Data: data})
+
+	payload, err := r.eventPayload(eventType, data)
+	if err != nil {
+		logger.Error("Ошибка сериализации события webhook", logging.KeyEnclosure, r.enclosure, "event", eventType, logging.Err(err))
+		return
+	}
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, enclosure_id, event_type, payload)
+		SELECT id, enclosure_id, $1::text, $2::jsonb
+		FROM webhooks
+		WHERE enclosure_id = $3 AND is_active AND ($1::text = ANY(events) OR '*' = ANY(events))
+	`
+	if _, err := r.exec(ctx, query, eventType, payload, r.enclosure); err != nil {
+		logger.Error("Ошибка постановки события webhook в очередь", logging.KeyEnclosure, r.enclosure, "event", eventType, logging.Err(err))
+	}
+}
+
+// EnqueuePing ставит проверочное событие ping в очередь одной подписки и возвращает ID доставки.
+func (r *Repository) EnqueuePing(ctx context.Context, webhookID string) (string, error) {
+	payload, err := r.eventPayload(EventPing, map[string]string{"webhook_id": webhookID})
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации события: %w", err)
+	}
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, enclosure_id, event_type, payload)
+		SELECT id, enclosure_id, $1::text, $2::jsonb FROM webhooks WHERE id::text = $3 AND enclosure_id = $4
+		RETURNING id
+	`
+	var id string
+	err = r.db.Pool.QueryRow(ctx, query, EventPing, payload, webhookID, r.enclosure).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("webhook с id=%s: %w", webhookID, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка постановки события в очередь: %w", err)
+	}
+	return id, nil
+}
+
+func (r *Repository) eventPayload(eventType string, data any) ([]byte, error) {
+	return json.Marshal(models.WebhookEvent{Type: eventType, Enclosure: r.enclosure, OccurredAt: time.Now(), Data: data})
+}
+
+// ==========================================
+// ДОСТАВКИ
+// ==========================================
+
+// PendingDelivery — доставка из очереди вместе с адресом и секретом подписки.
+type PendingDelivery struct {
+	models.WebhookDelivery
+	Enclosure string
+	URL       string
+	Secret    string
+}
+
+// DueDeliveries возвращает доставки всех террариумов, время попытки которых наступило (старые первыми).
+// Доставки отключенных подписок ждут их включения.
+func (r *Repository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]PendingDelivery, error) {
+	query := `
+		SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+		       d.last_status_code, d.last_error, d.created_at, d.delivered_at, d.enclosure_id, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = $1 AND d.next_attempt_at <= $2 AND w.is_active
+		ORDER BY d.next_attempt_at
+		LIMIT $3
+	`
+	rows, err := r.db.Pool.Query(ctx, query, DeliveryPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки очереди webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var result []PendingDelivery
+	for rows.Next() {
+		var p PendingDelivery
+		d := &p.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt, &p.Enclosure, &p.URL, &p.Secret); err != nil {
+			return nil, fmt.Errorf("ошибка чтения доставки webhook: %w", err)
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+// MarkDeliveryDelivered отмечает доставку успешной.
+func (r *Repository) MarkDeliveryDelivered(ctx context.Context, id string, statusCode int, at time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = '', delivered_at = $3
+		WHERE id::text = $4
+	`
+	if _, err := r.exec(ctx, query, DeliveryDelivered, statusCode, at, id); err != nil {
+		return fmt.Errorf("ошибка обновления доставки webhook: %w", err)
+	}
+	return nil
+}
+
+// MarkDeliveryFailed учитывает неудачную попытку: доставка ждет next или, если next == nil, становится DEAD.
+// statusCode — код ответа получателя (nil, если ответа не было).
+func (r *Repository) MarkDeliveryFailed(ctx context.Context, id string, statusCode *int, errMsg string, next *time.Time) error {
+	status, nextAt := DeliveryPending, time.Now()
+	if next == nil {
+		status = DeliveryDead
+	} else {
+		nextAt = *next
+	}
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, next_attempt_at = $2, last_status_code = $3, last_error = $4
+		WHERE id::text = $5
+	`
+	if _, err := r.exec(ctx, query, status, nextAt, statusCode, errMsg, id); err != nil {
+		return fmt.Errorf("ошибка обновления доставки webhook: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries возвращает журнал доставок террариума, начиная с последних.
+// webhookID и status (PENDING, DELIVERED, DEAD) необязательны.
+func (r *Repository) GetDeliveries(ctx context.Context, webhookID, status string, limit int) ([]models.WebhookDelivery, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE enclosure_id = $1 AND ($2 = '' OR webhook_id::text = $2) AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC
+		LIMIT $4
+	`
+	rows, err := r.db.Pool.Query(ctx, query, r.enclosure, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки доставок webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *d)
+	}
+	return result, rows.Err()
+}
+
+// RetryDelivery возвращает доставку из DEAD в очередь с обнулением счетчика попыток.
+func (r *Repository) RetryDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE id::text = $2 AND enclosure_id = $3 AND status = $4
+		RETURNING ` + deliveryColumns
+	d, err := scanDelivery(r.db.Pool.QueryRow(ctx, query, DeliveryPending, id, r.enclosure, DeliveryDead))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("доставка с id=%s в состоянии DEAD: %w", id, ErrNotFound)
+	}
+	return d, err
+}
+
+// PurgeDeliveries удаляет доставленные записи журнала всех террариумов старше before.
+func (r *Repository) PurgeDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	ct, err := r.exec(ctx, `DELETE FROM webhook_deliveries WHERE status = $1 AND created_at < $2`, DeliveryDelivered, before)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки журнала доставок webhooks: %w", err)
+	}
+	return ct.RowsAffected(), nil
+}
+
+func scanDelivery(row pgx.Row) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	if err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка чтения доставки webhook: %w", err)
+	}
+	return &d, nil
+}
@@ -0,0 +1,206 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"terrarium-core/internal/logging"
+	"terrarium-core/internal/storage"
+)
+
+const (
+	// pollInterval — период опроса очереди доставок
+	pollInterval = 2 * time.Second
+	// batchSize — сколько доставок берется из очереди за один опрос
+	batchSize = 50
+	// workers — сколько получателей опрашивается параллельно
+	workers = 4
+	// requestTimeout — время ожидания ответа получателя
+	requestTimeout = 10 * time.Second
+
+	// MaxAttempts — после стольких неудачных попыток доставка становится DEAD
+	MaxAttempts = 10
+	// backoffBase и backoffMax — задержка перед повторной попыткой удваивается от base до max
+	backoffBase = 10 * time.Second
+	backoffMax  = time.Hour
+
+	// retention — сколько хранятся записи журнала об успешных доставках
+	retention = 7 * 24 * time.Hour
+	// purgeInterval — период очистки журнала доставок
+	purgeInterval = time.Hour
+)
+
+// Заголовки запроса webhook
+const (
+	HeaderEvent     = "X-Terrarium-Event"
+	HeaderDelivery  = "X-Terrarium-Delivery"
+	HeaderSignature = "X-Terrarium-Signature"
+)
+
+var logger = logging.Component("webhooks")
+
+// Store — очередь доставок webhook (storage.Repository; террариум репозитория не важен).
+type Store interface {
+	// DueDeliveries возвращает до limit доставок всех террариумов, время попытки которых наступило
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]storage.PendingDelivery, error)
+	// MarkDeliveryDelivered отмечает успешную доставку
+	MarkDeliveryDelivered(ctx context.Context, id string, statusCode int, at time.Time) error
+	// MarkDeliveryFailed учитывает неудачную попытку: следующая в next или, если next == nil, доставка DEAD
+	MarkDeliveryFailed(ctx context.Context, id string, statusCode *int, errMsg string, next *time.Time) error
+	// PurgeDeliveries удаляет записи об успешных доставках, созданные раньше before
+	PurgeDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Dispatcher доставляет события из очереди webhook_deliveries подписчикам всех террариумов:
+// POST с JSON-телом, подписанным HMAC-SHA256 секретом подписки. Ответ 2xx — доставлено,
+// иначе попытка повторяется с экспоненциальной задержкой, пока не будет исчерпан MaxAttempts.
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+}
+
+// NewDispatcher создает диспетчер доставок поверх очереди store.
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{store: store, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Start запускает опрос очереди в фоне до отмены ctx.
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		poll := time.NewTicker(pollInterval)
+		defer poll.Stop()
+		purge := time.NewTicker(purgeInterval)
+		defer purge.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Доставка webhooks остановлена")
+				return
+			case <-poll.C:
+				d.deliverDue(ctx)
+			case <-purge.C:
+				if n, err := d.store.PurgeDeliveries(ctx, time.Now().Add(-retention)); err != nil {
+					logger.Error("Не удалось очистить журнал доставок", logging.Err(err))
+				} else if n > 0 {
+					logger.Debug("Журнал доставок очищен", "deleted", n)
+				}
+			}
+		}
+	}()
+}
+
+// deliverDue отправляет доставки, время которых наступило. Следующий опрос начинается только после
+// завершения всех попыток, поэтому одна доставка не отправляется дважды одновременно.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	due, err := d.store.DueDeliveries(ctx, time.Now(), batchSize)
+	if err != nil {
+		logger.Error("Не удалось прочитать очередь доставок", logging.Err(err))
+		return
+	}
+
+	queue := make(chan storage.PendingDelivery)
+	var wg sync.WaitGroup
+	for range min(workers, len(due)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range queue {
+				d.deliver(ctx, p)
+			}
+		}()
+	}
+	for _, p := range due {
+		queue <- p
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// deliver выполняет одну попытку доставки и записывает ее результат.
+func (d *Dispatcher) deliver(ctx context.Context, p storage.PendingDelivery) {
+	log := logger.With(logging.KeyEnclosure, p.Enclosure, "webhook", p.WebhookID, "delivery", p.ID, "event", p.Event)
+
+	code, err := d.post(ctx, p)
+	if err == nil {
+		if err := d.store.MarkDeliveryDelivered(ctx, p.ID, code, time.Now()); err != nil {
+			log.Error("Не удалось отметить доставку", logging.Err(err))
+		}
+		log.Debug("Webhook доставлен", "status", code, "attempt", p.Attempts+1)
+		return
+	}
+
+	var status *int
+	if code != 0 {
+		status = &code
+	}
+	attempt := p.Attempts + 1
+	var next *time.Time
+	if attempt < MaxAttempts {
+		at := time.Now().Add(Backoff(attempt))
+		next = &at
+		log.Warn("Webhook не доставлен, попытка будет повторена", "attempt", attempt, "next_attempt_at", at, logging.Err(err))
+	} else {
+		log.Error("Webhook не доставлен, попытки исчерпаны", "attempt", attempt, logging.Err(err))
+	}
+	if err := d.store.MarkDeliveryFailed(ctx, p.ID, status, err.Error(), next); err != nil {
+		log.Error("Не удалось отметить доставку", logging.Err(err))
+	}
+}
+
+// post отправляет тело доставки получателю. Возвращает код ответа (0, если ответа не было)
+// и ошибку, если ответ не 2xx.
+func (d *Dispatcher) post(ctx context.Context, p storage.PendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "terrarium-core-webhooks")
+	req.Header.Set(HeaderEvent, p.Event)
+	req.Header.Set(HeaderDelivery, p.ID)
+	req.Header.Set(HeaderSignature, Sign(p.Secret, p.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		return 0, fmt.Errorf("запрос не выполнен: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // соединение можно переиспользовать
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("получатель ответил %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign возвращает значение заголовка подписи: "sha256=" и hex HMAC-SHA256 тела на ключе secret.
+// Получатель проверяет подпись тем же вычислением над сырым телом запроса.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff возвращает задержку перед попыткой после attempt неудачных: backoffBase·2^(attempt-1),
+// не больше backoffMax, со случайным разбросом ±20%, чтобы повторы разных доставок не совпадали.
+func Backoff(attempt int) time.Duration {
+	delay := backoffMax
+	if attempt < 20 {
+		delay = min(backoffBase<<(attempt-1), backoffMax)
+	}
+	jitter := 0.8 + 0.4*rand.Float64()
+	return time.Duration(float64(delay) * jitter)
+}
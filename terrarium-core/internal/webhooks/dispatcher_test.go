@@ -0,0 +1,211 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/storage"
+)
+
+// fakeStore — очередь из одной доставки; результат попытки меняет ее так же, как storage.Repository.
+type fakeStore struct {
+	mu       sync.Mutex
+	delivery storage.PendingDelivery
+	code     *int
+	errMsg   string
+}
+
+func (f *fakeStore) DueDeliveries(context.Context, time.Time, int) ([]storage.PendingDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.delivery.Status != storage.DeliveryPending {
+		return nil, nil
+	}
+	return []storage.PendingDelivery{f.delivery}, nil
+}
+
+func (f *fakeStore) MarkDeliveryDelivered(_ context.Context, _ string, statusCode int, _ time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delivery.Attempts++
+	f.delivery.Status, f.code = storage.DeliveryDelivered, &statusCode
+	return nil
+}
+
+func (f *fakeStore) MarkDeliveryFailed(_ context.Context, _ string, statusCode *int, errMsg string, next *time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delivery.Attempts++
+	f.code, f.errMsg = statusCode, errMsg
+	if next == nil {
+		f.delivery.Status = storage.DeliveryDead
+	} else {
+		f.delivery.NextAttemptAt = *next
+	}
+	return nil
+}
+
+func (f *fakeStore) PurgeDeliveries(context.Context, time.Time) (int64, error) { return 0, nil }
+
+// newTestDispatcher поднимает получателя с ответом status и ставит в очередь доставку после attempts попыток.
+func newTestDispatcher(t *testing.T, status, attempts int) (*Dispatcher, *fakeStore, <-chan received) {
+	t.Helper()
+	requests := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &fakeStore{delivery: storage.PendingDelivery{
+		WebhookDelivery: models.WebhookDelivery{
+			ID:       "d-1",
+			Event:    "relay.switched",
+			Payload:  []byte(`{"event":"relay.switched"}`),
+			Status:   storage.DeliveryPending,
+			Attempts: attempts,
+		},
+		Enclosure: "default",
+		URL:       srv.URL,
+		Secret:    "s3cr3t",
+	}}
+	return NewDispatcher(store), store, requests
+}
+
+// received — запрос, принятый тестовым получателем.
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	d, store, requests := newTestDispatcher(t, http.StatusOK, 0)
+	d.deliverDue(context.Background())
+
+	r := <-requests
+	body := r.body
+	if string(body) != string(store.delivery.Payload) {
+		t.Errorf("тело = %s, want %s", body, store.delivery.Payload)
+	}
+	if got, want := r.header.Get(HeaderSignature), Sign("s3cr3t", body); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if got := r.header.Get(HeaderEvent); got != "relay.switched" {
+		t.Errorf("%s = %q", HeaderEvent, got)
+	}
+	if got := r.header.Get(HeaderDelivery); got != "d-1" {
+		t.Errorf("%s = %q", HeaderDelivery, got)
+	}
+}
+
+func TestDeliverSuccess(t *testing.T) {
+	d, store, _ := newTestDispatcher(t, http.StatusNoContent, 2)
+	d.deliverDue(context.Background())
+
+	if store.delivery.Status != storage.DeliveryDelivered {
+		t.Fatalf("статус = %s, want %s", store.delivery.Status, storage.DeliveryDelivered)
+	}
+	if store.code == nil || *store.code != http.StatusNoContent || store.delivery.Attempts != 3 {
+		t.Errorf("код = %v, попыток = %d", store.code, store.delivery.Attempts)
+	}
+
+	// Доставленное больше не отправляется
+	d.deliverDue(context.Background())
+	if store.delivery.Attempts != 3 {
+		t.Errorf("доставленное отправлено повторно: попыток = %d", store.delivery.Attempts)
+	}
+}
+
+func TestDeliverFailureIsRescheduled(t *testing.T) {
+	d, store, _ := newTestDispatcher(t, http.StatusServiceUnavailable, 2)
+	before := time.Now()
+	d.deliverDue(context.Background())
+	after := time.Now()
+
+	if store.delivery.Status != storage.DeliveryPending {
+		t.Fatalf("статус = %s, want %s", store.delivery.Status, storage.DeliveryPending)
+	}
+	if store.code == nil || *store.code != http.StatusServiceUnavailable || store.errMsg == "" {
+		t.Errorf("код = %v, ошибка = %q", store.code, store.errMsg)
+	}
+	// Третья неудачная попытка: задержка backoffBase·4 ±20%
+	base := backoffBase << 2
+	lo, hi := before.Add(base*8/10), after.Add(base*12/10)
+	if next := store.delivery.NextAttemptAt; next.Before(lo) || next.After(hi) {
+		t.Errorf("следующая попытка %s, want между %s и %s", next, lo, hi)
+	}
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	d, store, _ := newTestDispatcher(t, http.StatusInternalServerError, MaxAttempts-1)
+	d.deliverDue(context.Background())
+
+	if store.delivery.Status != storage.DeliveryDead {
+		t.Errorf("статус = %s, want %s", store.delivery.Status, storage.DeliveryDead)
+	}
+	if store.delivery.Attempts != MaxAttempts {
+		t.Errorf("попыток = %d, want %d", store.delivery.Attempts, MaxAttempts)
+	}
+}
+
+func TestDeliverUnreachable(t *testing.T) {
+	d, store, _ := newTestDispatcher(t, http.StatusOK, 0)
+	store.delivery.URL = "http://127.0.0.1:1/hook"
+	d.deliverDue(context.Background())
+
+	if store.delivery.Status != storage.DeliveryPending || store.code != nil || store.errMsg == "" {
+		t.Errorf("статус = %s, код = %v, ошибка = %q: want PENDING без кода с ошибкой",
+			store.delivery.Status, store.code, store.errMsg)
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret string
+		body   string
+		want   string
+	}{
+		{"", "", "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad"},
+		{"key", "The quick brown fox jumps over the lazy dog", "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+	}
+	for _, tt := range tests {
+		if got := Sign(tt.secret, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q) = %s, want %s", tt.secret, tt.body, got, tt.want)
+		}
+	}
+	if Sign("a", []byte("body")) == Sign("b", []byte("body")) {
+		t.Error("подпись не зависит от секрета")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{8, 1280 * time.Second},
+		{9, 2560 * time.Second},
+		{MaxAttempts, time.Hour},
+		{20, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tt := range tests {
+		lo, hi := tt.base*8/10, tt.base*12/10
+		for range 50 {
+			if got := Backoff(tt.attempt); got < lo || got > hi {
+				t.Errorf("Backoff(%d) = %s, want от %s до %s", tt.attempt, got, lo, hi)
+				break
+			}
+		}
+	}
+}
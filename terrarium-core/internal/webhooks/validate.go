@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"terrarium-core/internal/models"
+	"terrarium-core/internal/storage"
+)
+
+// ErrInvalidWebhook возвращается, если подписка не прошла проверку при сохранении.
+var ErrInvalidWebhook = errors.New("некорректная подписка webhook")
+
+// maxSecretLength — ограничение длины секрета подписи (колонка secret)
+const maxSecretLength = 200
+
+// Validate проверяет подписку: абсолютный http(s) URL и известные типы событий.
+// Повторяющиеся типы событий убираются.
+func Validate(req *models.WebhookRequest) error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url должен быть абсолютным адресом http или https", ErrInvalidWebhook)
+	}
+
+	events := make([]string, 0, len(req.Events))
+	for _, e := range req.Events {
+		if e != storage.EventAll && !slices.Contains(storage.EventTypes, e) {
+			return fmt.Errorf("%w: неизвестный тип события %q (допустимы %v или \"*\")", ErrInvalidWebhook, e, storage.EventTypes)
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	req.Events = events
+
+	if len(req.Secret) > maxSecretLength {
+		return fmt.Errorf("%w: secret длиннее %d символов", ErrInvalidWebhook, maxSecretLength)
+	}
+	return nil
+}
+
+// NewSecret генерирует случайный секрет подписи (32 байта в hex).
+func NewSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b) // crypto/rand.Read не возвращает ошибок
+	return hex.EncodeToString(b)
+}
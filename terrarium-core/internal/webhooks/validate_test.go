@@ -0,0 +1,51 @@
+package webhooks
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"terrarium-core/internal/models"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    models.WebhookRequest
+		wantOK bool
+	}{
+		{"https", models.WebhookRequest{URL: "https://example.com/hook"}, true},
+		{"http с портом", models.WebhookRequest{URL: "http://192.168.1.10:8080/hook?x=1"}, true},
+		{"известные события", models.WebhookRequest{URL: "https://example.com", Events: []string{"relay.switched", "*"}}, true},
+		{"пустой url", models.WebhookRequest{URL: ""}, false},
+		{"относительный url", models.WebhookRequest{URL: "/hook"}, false},
+		{"без схемы", models.WebhookRequest{URL: "example.com/hook"}, false},
+		{"другая схема", models.WebhookRequest{URL: "ftp://example.com/hook"}, false},
+		{"без хоста", models.WebhookRequest{URL: "http:///hook"}, false},
+		{"неразбираемый url", models.WebhookRequest{URL: "http://[::1"}, false},
+		{"неизвестное событие", models.WebhookRequest{URL: "https://example.com", Events: []string{"relay.exploded"}}, false},
+		{"длинный секрет", models.WebhookRequest{URL: "https://example.com", Secret: strings.Repeat("s", maxSecretLength+1)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.req)
+			if tt.wantOK && err != nil {
+				t.Errorf("Validate = %v, want nil", err)
+			}
+			if !tt.wantOK && !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("Validate = %v, want ErrInvalidWebhook", err)
+			}
+		})
+	}
+}
+
+func TestValidateDedupsEvents(t *testing.T) {
+	req := models.WebhookRequest{URL: "https://example.com", Events: []string{"relay.switched", "*", "relay.switched"}}
+	if err := Validate(&req); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if want := []string{"relay.switched", "*"}; !slices.Equal(req.Events, want) {
+		t.Errorf("события = %v, want %v", req.Events, want)
+	}
+}